@@ -113,7 +113,10 @@ func (self *CpuAgent) blockproducer(work *Work, stop <-chan struct{}) {
 
 		logger.Infof("Successfully sealed new block number: %d  hash:0x%x\n" , result.Number() , result.Hash())
 		//fmt.Println("ProduceBlock: num:" , result.Number().String(),"  Hash:",result.Hash().String())
-		time.Sleep(time.Duration(rand.Intn(20))*time.Second)
+		// the slot schedule, a dev chain and the lottery time the blocks themselves
+		if config := self.chain.Config(); config.Dev == nil && config.Slot == nil && config.Lottery == nil {
+			time.Sleep(time.Duration(rand.Intn(20))*time.Second)
+		}
 		self.returnCh <- &Result{work, result}
 		//fmt.Printf("!!!!!Return Produce work......")
 
@@ -82,6 +82,7 @@ type Work struct {
 	ancestors *set.Set       // ancestor set
 	family    *set.Set       // family set
 	tcount    int            // tx count in cycle
+	size      uint64         // bytes of the txs in cycle
 	Block *block.Block // the new block
 	header   *block.Header
 	txs      []*transaction.Transaction
@@ -129,6 +130,7 @@ type producer struct {
 	current   *Work
 
 	unconfirmed *unconfirmedBlocks // set of locally produced blocks pending canonicalness confirmations
+	slots       *slotScheduler     // times the blocks by the slot schedule of the chain
 
 	// atomic status counters
 	producing int32
@@ -152,6 +154,7 @@ func newProducer(config *params.ChainConfig, engine consensus.Engine, coinbase t
 		coinbase:       coinbase,
 		agents:         make(map[Agent]struct{}),
 		unconfirmed:    newUnconfirmedBlocks(mjoy.BlockChain(), producingLogAtDepth),
+		slots:          newSlotScheduler(config.Slot),
 	}
 	// Subscribe TxPreEvent for tx pool
 	producer.txSub = mjoy.TxPool().SubscribeTxPreEvent(producer.txCh)
@@ -230,6 +233,7 @@ func (self *producer) stop() {
 	}
 	atomic.StoreInt32(&self.producing, 0)
 	atomic.StoreInt32(&self.atWork, 0)
+	self.slots.stop()
 }
 
 func (self *producer) register(agent Agent) {
@@ -258,9 +262,22 @@ func (self *producer) update() {
 		case <-self.chainHeadCh:
 			self.commitNewWork()
 
+		// Handle a block put off by the slot schedule
+		case <-self.slots.wakeCh:
+			if atomic.LoadInt32(&self.producing) == 1 {
+				self.commitNewWork()
+			}
+
 		// Handle TxPreEvent
 		case ev := <-self.txCh:
 			_ = ev
+			// A block waiting for transactions is made at once, so is the one of a dev
+			// chain sealing on transactions, unless a block is being sealed: the next
+			// block after it takes the transaction
+			waiting := self.slots.waitingForTxs() || (self.config.Dev != nil && self.config.Dev.Period == 0)
+			if waiting && atomic.LoadInt32(&self.producing) == 1 && atomic.LoadInt32(&self.atWork) == 0 {
+				self.commitNewWork()
+			}
 			// Apply transaction to the pending state if we're not producing
 			//if atomic.LoadInt32(&self.producing) == 0 {
 			//	self.currentMu.Lock()
@@ -402,7 +419,7 @@ func (self *producer) addTestTransactions(num *big.Int){
 	w0 := wallets[0]
 	w1 := wallets[1]
 	//make params
-	param := balancetransfer.MakaBalanceTransferParam(w0.Accounts()[0].Address , w1.Accounts()[0].Address , 1000)
+	param := balancetransfer.MakaBalanceTransferParam(w1.Accounts()[0].Address , 1000)
 	//make action
 	action := transaction.MakeAction(balancetransfer.BalanceTransferAddress , param)
 	actions := transaction.ActionSlice{}
@@ -424,6 +441,12 @@ func (self *producer) addTestTransactions(num *big.Int){
 
 }
 
+// hasPending reports whether the pool has transactions to produce a block for
+func (self *producer) hasPending() bool {
+	pending, _ := self.mjoy.TxPool().Stats()
+	return pending > 0
+}
+
 func (self *producer) commitNewWork() {
 
 	self.mu.Lock()
@@ -434,6 +457,12 @@ func (self *producer) commitNewWork() {
 	tstart := time.Now()
 	parent := self.chain.CurrentBlock()
 
+	// The slot schedule may put the block off
+	if atomic.LoadInt32(&self.producing) == 1 && !self.slots.schedule(parent.Header(), tstart.Unix(), self.hasPending()) {
+		logger.Debug("Block put off by the slot schedule", "number", parent.NumberU64()+1)
+		return
+	}
+
 	tstamp := tstart.Unix()
 	if parent.Time().Cmp(new(big.Int).SetInt64(tstamp)) >= 0 {
 		tstamp = parent.Time().Int64() + 1
@@ -459,6 +488,10 @@ func (self *producer) commitNewWork() {
 	}
 
 	if err := self.engine.Prepare(self.chain, header); err != nil {
+		if err == consensus.ErrNotInTurn {
+			logger.Debug("Waiting for the producer in turn", "number", header.Number.IntVal.Uint64())
+			return
+		}
 		logger.Error("Failed to prepare header for producing", "err", err)
 		return
 	}
@@ -484,31 +517,31 @@ func (self *producer) commitNewWork() {
 
 
 	//txs := transaction.NewTransactionsForProducing(self.current.signer, pending)
-	actions := transaction.ActionSlice{}
-	action := transaction.Action{&types.Address{},balancetransfer.MakeActionParamsReword(header.BlockProducer)}
-	actions = append(actions, action)
-
-	tx := transaction.NewTransaction(num.Uint64() - 1 , actions)
-
-	txReword, err := transaction.SignTx(tx,self.current.signer, params.RewordPrikey)
-	if err != nil {
-		logger.Error("Failed to make reword transaction", "err", err)
-		return
-	}
-	txReword.Priority = big.NewInt(10)
-	txs := transaction.NewTransactionsByPriorityAndNonce(self.current.signer , pending, txReword)
+	txs := transaction.NewTransactionsByPriorityAndNonce(self.current.signer , pending)
 
 	sdkHandler := sdk.NewTmpStatusManager(self.chain.GetDb(), work.state,self.coinbase)
 	vmHandler := interpreter.NewVm()
 	sysparam := intertypes.MakeSystemParams(sdkHandler,vmHandler )
 	work.commitTransactions(self.mux, txs, self.chain, self.coinbase , sysparam)
 
+	if err := stateprocessor.UpgradeBalances(self.config, header, work.state, self.chain.GetDb(), work.dbCache); err != nil {
+		logger.Error("Failed to upgrade balances", "err", err)
+		return
+	}
 
-	// Create the new block to seal with the consensus engine
-	if work.Block, err = self.engine.Finalize(self.chain, header, work.state, work.txs, work.receipts, true); err != nil {
+	// Create the new block to seal with the consensus engine,the engine pays the block rewards
+	rewards := stateprocessor.BlockRewards(self.config, header, sysparam, work.dbCache)
+	if work.Block, err = self.engine.Finalize(self.chain, header, work.state, work.txs, work.receipts, rewards, true); err != nil {
 		logger.Error("Failed to finalize block for sealing", "err", err)
 		return
 	}
+	// A chain skipping empty blocks rejects the block if all the pending transactions
+	// failed, it is only sealed as a heartbeat and the schedule waits for new ones
+	if slot := self.config.Slot; slot != nil && slot.SkipEmpty && work.tcount == 0 && atomic.LoadInt32(&self.producing) == 1 &&
+		!self.slots.schedule(parent.Header(), header.Time.IntVal.Int64(), false) {
+		logger.Debug("Empty block put off by the slot schedule", "number", header.Number.IntVal.Uint64())
+		return
+	}
 	// We only care about logging if we're actually producing.
 	if atomic.LoadInt32(&self.producing) == 1 {
 		logger.Info("Commit new producing work", "number", work.Block.Number(), "txs", work.tcount, "elapsed", common.PrettyDuration(time.Since(tstart)))
@@ -531,6 +564,18 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *transaction.Transac
 		if tx == nil {
 			break
 		}
+		// Abort if the block is full, skip the account if the transaction does not fit
+		if slot := env.config.Slot; slot != nil {
+			if slot.MaxTxs > 0 && uint64(env.tcount) >= slot.MaxTxs {
+				logger.Trace("Block is full", "txs", env.tcount)
+				break
+			}
+			if slot.MaxBytes > 0 && env.size+uint64(tx.Size()) > slot.MaxBytes {
+				logger.Trace("Skipping transaction over the block size", "hash", tx.Hash().String(), "size", tx.Size())
+				txs.Pop()
+				continue
+			}
+		}
 		// Error may be ignored here. The error has already been checked
 		// during transaction acceptance is the transaction pool.
 		//
@@ -568,10 +613,21 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *transaction.Transac
 			logger.Info("Skipping account with hight nonce", "sender", from, "nonce", tx.Nonce())
 			txs.Pop()
 
+		case core.ErrInsufficientFundsForFee:
+			// The sender can not pay for its transactions, skip account
+			logger.Info("Skipping account which can not pay the resource fee", "sender", from)
+			txs.Pop()
+
+		case core.ErrTxNotYetValid, core.ErrTxExpired:
+			// The block is out of the validity bounds of the transaction, skip account
+			logger.Info("Skipping transaction out of its validity bounds", "sender", from, "nonce", tx.Nonce(), "err", err)
+			txs.Pop()
+
 		case nil:
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
 			env.tcount++
+			env.size += uint64(tx.Size())
 			txs.Shift()
 
 		default:
@@ -603,11 +659,11 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *transaction.Transac
 }
 
 func (env *Work) commitTransaction(tx *transaction.Transaction, bc *blockchain.BlockChain, coinbase types.Address, cache *stateprocessor.DbCache , sysparam *intertypes.SystemParams) (error, []*transaction.Log) {
-	snap := env.state.Snapshot()
+	snap := sysparam.SdkHandler.Snapshot()
 	//                                ApplyTransaction(this.config,&coinbase,this.state ,header,tx)
 	receipt, err := stateprocessor.ApplyTransaction(env.config, &coinbase,  env.state, env.header, tx, cache , sysparam)
 	if err != nil {
-		env.state.RevertToSnapshot(snap)
+		sysparam.SdkHandler.RevertToSnapshot(snap)
 		return err, nil
 	}
 	env.txs = append(env.txs, tx)
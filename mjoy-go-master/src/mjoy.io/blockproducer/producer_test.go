@@ -0,0 +1,142 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: producer_test.go
+// @Date: 2018/07/30 16:41:52
+////////////////////////////////////////////////////////////////////////////////
+
+package blockproducer
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"mjoy.io/accounts"
+	"mjoy.io/consensus"
+	"mjoy.io/core/blockchain"
+	"mjoy.io/core/genesis"
+	"mjoy.io/core/transaction"
+	"mjoy.io/core/txprocessor"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/database"
+	"mjoy.io/utils/event"
+)
+
+type testBackend struct {
+	db    database.IDatabase
+	chain *blockchain.BlockChain
+	pool  *txprocessor.TxPool
+}
+
+func (b *testBackend) AccountManager() *accounts.Manager  { return nil }
+func (b *testBackend) BlockChain() *blockchain.BlockChain { return b.chain }
+func (b *testBackend) TxPool() *txprocessor.TxPool        { return b.pool }
+func (b *testBackend) ChainDb() database.IDatabase        { return b.db }
+
+// testAgent takes the works pushed to it without sealing them
+type testAgent struct {
+	workCh chan *Work
+}
+
+func (a *testAgent) Work() chan<- *Work         { return a.workCh }
+func (a *testAgent) SetReturnCh(chan<- *Result) {}
+func (a *testAgent) Start()                     {}
+func (a *testAgent) Stop()                      {}
+func (a *testAgent) GetHashRate() int64         { return 0 }
+
+// newTestProducer starts producing on a chain skipping empty blocks for an hour,
+// whose genesis is of genesisTime
+func newTestProducer(t *testing.T, genesisTime int64) (*producer, *testBackend, *testAgent) {
+	config := &params.ChainConfig{
+		ChainId: big.NewInt(101),
+		Slot:    &params.SlotConfig{Interval: 1, SkipEmpty: true, MaxIdle: 3600},
+	}
+	db, _ := database.OpenMemDB()
+	(&genesis.Genesis{Config: config, Timestamp: uint64(genesisTime)}).MustCommit(db)
+
+	key, _ := crypto.GenerateKey()
+	engine := consensus.NewBasicEngine(key)
+	chain, err := blockchain.NewBlockChain(db, config, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poolConfig := txprocessor.DefaultTxPoolConfig
+	poolConfig.Journal = ""
+	backend := &testBackend{db: db, chain: chain, pool: txprocessor.NewTxPool(poolConfig, config, chain)}
+
+	producer := newProducer(config, engine, crypto.PubkeyToAddress(key.PublicKey), backend, nil, new(event.TypeMux))
+	agent := &testAgent{workCh: make(chan *Work, 1)}
+	producer.register(agent)
+	producer.start()
+	return producer, backend, agent
+}
+
+func (b *testBackend) stop() {
+	b.pool.Stop()
+	b.chain.Stop()
+}
+
+func TestSkipFailedTransactions(t *testing.T) {
+	producer, backend, agent := newTestProducer(t, time.Now().Unix()-1)
+	defer backend.stop()
+
+	// the sender can not pay the fee of any of its transactions
+	key, _ := crypto.GenerateKey()
+	signer := transaction.NewMSigner(producer.config.ChainId)
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx, _ := transaction.SignTx(transaction.NewTransaction(nonce, nil), signer, key)
+		if err := backend.pool.AddRemote(tx); err != nil {
+			t.Fatalf("add transaction %d: %v", nonce, err)
+		}
+	}
+	if pending, _ := backend.pool.Stats(); pending != 2 {
+		t.Fatalf("%d pending transactions, want 2", pending)
+	}
+
+	producer.commitNewWork()
+	select {
+	case work := <-agent.workCh:
+		t.Fatalf("empty block %d pushed to seal", work.Block.NumberU64())
+	default:
+	}
+	if !producer.slots.waitingForTxs() {
+		t.Error("the schedule does not wait for transactions")
+	}
+}
+
+func TestHeartbeatAfterFailedTransactions(t *testing.T) {
+	producer, backend, agent := newTestProducer(t, time.Now().Unix()-3600)
+	defer backend.stop()
+
+	key, _ := crypto.GenerateKey()
+	tx, _ := transaction.SignTx(transaction.NewTransaction(0, nil), transaction.NewMSigner(producer.config.ChainId), key)
+	if err := backend.pool.AddRemote(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the max idle time passed, the empty block is a heartbeat
+	producer.commitNewWork()
+	select {
+	case work := <-agent.workCh:
+		if len(work.Block.Transactions()) != 0 {
+			t.Errorf("heartbeat block holds %d transactions", len(work.Block.Transactions()))
+		}
+	default:
+		t.Fatal("heartbeat block not pushed to seal")
+	}
+}
@@ -0,0 +1,107 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: slot.go
+// @Date: 2018/07/27 10:05:31
+////////////////////////////////////////////////////////////////////////////////
+
+package blockproducer
+
+import (
+	"sync"
+	"time"
+
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+)
+
+// slotScheduler times the blocks of the producer by the slot schedule of the chain: a
+// block is put off until its interval since the parent passed, and on a chain skipping
+// empty blocks until there are transactions or the max idle time passed.
+type slotScheduler struct {
+	config *params.SlotConfig
+
+	mu      sync.Mutex
+	timer   *time.Timer   // wakes the producer when a put off block is due
+	waitTxs bool          // the next block waits for transactions
+	wakeCh  chan struct{} // receives when a put off block is due
+}
+
+func newSlotScheduler(config *params.SlotConfig) *slotScheduler {
+	return &slotScheduler{
+		config: config,
+		wakeCh: make(chan struct{}, 1),
+	}
+}
+
+// schedule reports whether the block after parent can be made at now, pending tells
+// whether there are transactions for it. If not the scheduler wakes the producer when
+// the block is due, a block waiting for transactions only without a max idle time.
+func (s *slotScheduler) schedule(parent *block.Header, now int64, pending bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cancel()
+	if s.config == nil {
+		return true
+	}
+	parentTime := parent.Time.IntVal.Int64()
+	due := parentTime + int64(s.config.Interval)
+	if !pending && s.config.SkipEmpty {
+		s.waitTxs = true
+		if s.config.MaxIdle == 0 {
+			return false
+		}
+		if idle := parentTime + int64(s.config.MaxIdle); idle > due {
+			due = idle
+		}
+	}
+	if now < due {
+		s.timer = time.AfterFunc(time.Unix(due, 0).Sub(time.Now()), s.wake)
+		return false
+	}
+	s.waitTxs = false
+	return true
+}
+
+// waitingForTxs reports whether the next block waits for transactions
+func (s *slotScheduler) waitingForTxs() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waitTxs
+}
+
+// stop drops the put off block
+func (s *slotScheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel()
+}
+
+func (s *slotScheduler) cancel() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.waitTxs = false
+}
+
+func (s *slotScheduler) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
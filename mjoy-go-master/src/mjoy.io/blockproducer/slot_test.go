@@ -0,0 +1,111 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: slot_test.go
+// @Date: 2018/07/27 10:42:17
+////////////////////////////////////////////////////////////////////////////////
+
+package blockproducer
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+)
+
+func slotParent(t int64) *block.Header {
+	return &block.Header{Time: &types.BigInt{IntVal: *big.NewInt(t)}}
+}
+
+// Tests that blocks are put off until their interval passed.
+func TestSlotScheduleInterval(t *testing.T) {
+	s := newSlotScheduler(&params.SlotConfig{Interval: 5})
+	defer s.stop()
+
+	if s.schedule(slotParent(100), 103, true) {
+		t.Fatalf("block scheduled before the end of the interval")
+	}
+	if s.timer == nil {
+		t.Fatalf("no wake up set for the put off block")
+	}
+	if !s.schedule(slotParent(100), 105, false) {
+		t.Fatalf("block not scheduled at the end of the interval")
+	}
+	if s.timer != nil {
+		t.Fatalf("wake up left for a scheduled block")
+	}
+}
+
+// Tests that a chain skipping empty blocks waits for transactions, and makes a
+// heartbeat block after the max idle time.
+func TestSlotScheduleSkipEmpty(t *testing.T) {
+	s := newSlotScheduler(&params.SlotConfig{Interval: 1, SkipEmpty: true})
+	defer s.stop()
+
+	if s.schedule(slotParent(100), 200, false) {
+		t.Fatalf("empty block scheduled without a max idle time")
+	}
+	if !s.waitingForTxs() || s.timer != nil {
+		t.Fatalf("waiting: have %v/%v, want true/no wake up", s.waitingForTxs(), s.timer != nil)
+	}
+	if !s.schedule(slotParent(100), 200, true) {
+		t.Fatalf("block with transactions not scheduled")
+	}
+	if s.waitingForTxs() {
+		t.Fatalf("still waiting for transactions")
+	}
+
+	s = newSlotScheduler(&params.SlotConfig{Interval: 1, SkipEmpty: true, MaxIdle: 30})
+	defer s.stop()
+
+	if s.schedule(slotParent(100), 110, false) {
+		t.Fatalf("empty block scheduled before the max idle time")
+	}
+	if !s.waitingForTxs() || s.timer == nil {
+		t.Fatalf("waiting: have %v/%v, want true/wake up", s.waitingForTxs(), s.timer != nil)
+	}
+	if !s.schedule(slotParent(100), 130, false) {
+		t.Fatalf("heartbeat block not scheduled after the max idle time")
+	}
+}
+
+// Tests that a put off block wakes the producer when due.
+func TestSlotScheduleWake(t *testing.T) {
+	s := newSlotScheduler(&params.SlotConfig{Interval: 1})
+	defer s.stop()
+
+	now := time.Now().Unix()
+	if s.schedule(slotParent(now), now, true) {
+		t.Fatalf("block scheduled before the end of the interval")
+	}
+	select {
+	case <-s.wakeCh:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("producer not woken up")
+	}
+}
+
+// Tests that a chain without a slot schedule makes blocks at once.
+func TestSlotScheduleNone(t *testing.T) {
+	s := newSlotScheduler(nil)
+	if !s.schedule(slotParent(100), 100, false) {
+		t.Fatalf("block put off without a slot schedule")
+	}
+}
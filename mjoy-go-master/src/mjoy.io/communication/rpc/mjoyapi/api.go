@@ -23,6 +23,7 @@ package mjoyapi
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -41,7 +42,10 @@ import (
 	"mjoy.io/core/blockchain"
 	"mjoy.io/core/sdk"
 	"mjoy.io/core/interpreter"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/interpreter/balancetransfer"
 	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/params"
 )
 
 
@@ -453,12 +457,17 @@ func (s *PublicBlockChainAPI) GetNum() int {
 // GetStoragePara returns the storage parameters of certain contract
 // The detail parameter is defined by contact interpreter
 func (s *PublicBlockChainAPI) GetStorageParameter(ctx context.Context, actionArg SendTxAction,blockNr rpc.BlockNumber) (hex.Bytes, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
 	if state == nil || err != nil {
 		return nil, err
 	}
 	fmt.Println("=====================================>")
+	if err := actionArg.packParams(); err != nil {
+		return nil, err
+	}
 	sdkHandler := sdk.NewTmpStatusManager(s.b.ChainDb(), state, types.Address{})
+	// a query runs in the block it reads, but for no sender
+	sdkHandler.SetBlockContext(sdk.NewBlockContext(s.b.ChainConfig(), header))
 	vmHandler := interpreter.NewVm()
 	sysparam := intertypes.MakeSystemParams(sdkHandler, vmHandler)
 	//package param
@@ -482,6 +491,21 @@ func (s *PublicBlockChainAPI) GetStorageParameter(ctx context.Context, actionArg
 	//return nil, state.Error()
 }
 
+// GetBalance returns the balance of address at the given block as a decimal string
+func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address types.Address, blockNr rpc.BlockNumber) (string, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return "", err
+	}
+	sdkHandler := sdk.NewTmpStatusManager(s.b.ChainDb(), state, types.Address{})
+	sysparam := intertypes.MakeSystemParams(sdkHandler, interpreter.NewVm())
+	balance, err := balancetransfer.BalanceOf(sysparam, address)
+	if err != nil {
+		return "", err
+	}
+	return balance.String(), nil
+}
+
 // GetBlockByNumber returns the requested block. When blockNr is -1 the chain head is returned. When fullTx is true all
 // transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
@@ -519,6 +543,35 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address types.Address
 	return code, state.Error()
 }
 
+// GetContractAbi returns the abi of an inner contract
+func (s *PublicBlockChainAPI) GetContractAbi(address types.Address) (*abi.ABI, error) {
+	contractAbi := interpreter.NewVm().GetAbi(address)
+	if contractAbi == nil {
+		return nil, fmt.Errorf("no abi for contract %x", address)
+	}
+	return contractAbi, nil
+}
+
+// CallContract runs a constant method of a contract and returns its results by name,
+// the method and arguments are given as in a transaction action
+func (s *PublicBlockChainAPI) CallContract(ctx context.Context, actionArg SendTxAction, blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	if actionArg.Method == "" {
+		return nil, errors.New("no method in call")
+	}
+	method, err := actionArg.contractMethod()
+	if err != nil {
+		return nil, err
+	}
+	if !method.Constant {
+		return nil, fmt.Errorf("method %s is not constant", method.Name)
+	}
+	result, err := s.GetStorageParameter(ctx, actionArg, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return method.OutputsToJSON(result)
+}
+
 // GetStorageAt returns the storage from the state at the given address, key and
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
@@ -611,6 +664,9 @@ type RPCTransaction struct {
 	From             types.Address  			`json:"from"`
 	Hash             types.Hash     			`json:"hash"`
 	Nonce            hex.Uint64     			`json:"nonce"`
+	ResourceLimit    hex.Uint64     			`json:"resourceLimit"`
+	ValidAfter       hex.Uint64     			`json:"validAfter"`
+	ValidUntil       hex.Uint64     			`json:"validUntil"`
 	TransactionIndex hex.Uint       			`json:"transactionIndex"`
 	Actions          []*SendTxAction			`json:"actions"`
 	V                *hex.Big       			`json:"v"`
@@ -631,7 +687,7 @@ func newRPCTransaction(tx *transaction.Transaction, blockHash types.Hash, blockN
 	for _, action := range tx.Data.Actions {
 		hexbyte := make(hex.Bytes, len(action.Params))
 		copy(hexbyte, action.Params)
-		actionSend := &SendTxAction{action.Address, &hexbyte}
+		actionSend := &SendTxAction{Address:action.Address, Params:&hexbyte}
 		actions = append(actions, actionSend)
 	}
 
@@ -639,6 +695,9 @@ func newRPCTransaction(tx *transaction.Transaction, blockHash types.Hash, blockN
 		From:     from,
 		Hash:     tx.Hash(),
 		Nonce:    hex.Uint64(tx.Nonce()),
+		ResourceLimit: hex.Uint64(tx.ResourceLimit()),
+		ValidAfter: hex.Uint64(tx.ValidAfter()),
+		ValidUntil: hex.Uint64(tx.ValidUntil()),
 		V:        (*hex.Big)(v),
 		R:        (*hex.Big)(r),
 		S:        (*hex.Big)(s),
@@ -852,15 +911,59 @@ func (s *PublicTransactionPoolAPI) sign(addr types.Address, tx *transaction.Tran
 	return wallet.SignTx(account, tx, chainID)
 }
 
+// SendTxAction is an action of a transaction.The params are given either encoded,or as the name of a
+// method of the contract and its json arguments which are encoded with the abi of the contract.
 type SendTxAction struct {
 	Address		*types.Address    `json:"address"`
 	Params 		*hex.Bytes       `json:"params"`
+	Method		string            `json:"method,omitempty"`
+	Args		[]json.RawMessage `json:"args,omitempty"`
+}
+
+// contractMethod returns the abi method the action calls
+func (a *SendTxAction) contractMethod() (*abi.Method, error) {
+	if a.Address == nil {
+		return nil, errors.New("contract method call without address")
+	}
+	contractAbi := interpreter.NewVm().GetAbi(*a.Address)
+	if contractAbi == nil {
+		return nil, fmt.Errorf("no abi for contract %x", *a.Address)
+	}
+	return contractAbi.Method(a.Method)
+}
+
+// packParams encodes the method call of the action into its params
+func (a *SendTxAction) packParams() error {
+	if a.Method == "" {
+		if a.Params == nil {
+			return errors.New("action without params or method")
+		}
+		return nil
+	}
+	if a.Params != nil {
+		return errors.New("both params and method specified in action")
+	}
+	method, err := a.contractMethod()
+	if err != nil {
+		return err
+	}
+	data, err := method.PackJSON(a.Args)
+	if err != nil {
+		return err
+	}
+	params := hex.Bytes(data)
+	a.Params = &params
+	return nil
 }
 
 // SendTxArgs represents the arguments to sumbit a new transaction into the transaction pool.
 type SendTxArgs struct {
 	From     types.Address  `json:"from"`
 	Nonce    *hex.Uint64    `json:"nonce"`
+	ResourceLimit *hex.Uint64 `json:"resourceLimit"`
+	// Optional validity bounds, block numbers or unix timestamps
+	ValidAfter *hex.Uint64 `json:"validAfter"`
+	ValidUntil *hex.Uint64 `json:"validUntil"`
 
 	Actions  []SendTxAction    `json:"actions"`
 }
@@ -874,9 +977,24 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 		}
 		args.Nonce = (*hex.Uint64)(&nonce)
 	}
+	if args.ResourceLimit == nil {
+		limit := params.TxResourceLimit
+		args.ResourceLimit = (*hex.Uint64)(&limit)
+	}
+	if args.ValidAfter == nil {
+		args.ValidAfter = new(hex.Uint64)
+	}
+	if args.ValidUntil == nil {
+		args.ValidUntil = new(hex.Uint64)
+	}
 	if len(args.Actions) == 0 {
 		return errors.New("no actions in transaction !!")
 	}
+	for i := range args.Actions {
+		if err := args.Actions[i].packParams(); err != nil {
+			return fmt.Errorf("action %d: %v", i, err)
+		}
+	}
 
 	return nil
 }
@@ -887,7 +1005,7 @@ func (args *SendTxArgs) toTransaction() *transaction.Transaction {
 		action := transaction.Action{argAction.Address, *argAction.Params}
 		actions = append(actions, action)
 	}
-	return transaction.NewTransaction(uint64(*args.Nonce), actions)
+	return transaction.NewBoundedTransaction(uint64(*args.Nonce), uint64(*args.ResourceLimit), uint64(*args.ValidAfter), uint64(*args.ValidUntil), actions)
 
 	return nil
 }
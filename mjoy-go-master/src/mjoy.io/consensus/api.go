@@ -0,0 +1,56 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: api.go
+// @Date: 2018/07/31 09:48:25
+////////////////////////////////////////////////////////////////////////////////
+
+package consensus
+
+import (
+	"errors"
+
+	"mjoy.io/communication/rpc"
+	"mjoy.io/core/blockchain/block"
+)
+
+// MaxScheduleLength is the max number of slots of a proposer schedule returned by
+// the engine APIs.
+const MaxScheduleLength = 1024
+
+var (
+	// ErrUnknownBlock is returned by the engine APIs for a block not in the chain.
+	ErrUnknownBlock = errors.New("unknown block")
+
+	// ErrScheduleTooLong is returned by the engine APIs for a proposer schedule of
+	// more than MaxScheduleLength slots.
+	ErrScheduleTooLong = errors.New("proposer schedule too long")
+)
+
+// HeaderByNumber returns the header of the canonical block number for the engine
+// APIs, the head one if number is nil, latest or pending.
+func HeaderByNumber(chain ChainReader, number *rpc.BlockNumber) (*block.Header, error) {
+	var header *block.Header
+	if number == nil || *number == rpc.LatestBlockNumber || *number == rpc.PendingBlockNumber {
+		header = chain.CurrentHeader()
+	} else {
+		header = chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, ErrUnknownBlock
+	}
+	return header, nil
+}
@@ -8,8 +8,8 @@ import (
 	"mjoy.io/common/types"
 	"errors"
 	"mjoy.io/common"
-	"runtime"
 	"crypto/ecdsa"
+	"mjoy.io/communication/rpc"
 )
 
 
@@ -61,6 +61,9 @@ func (basic *Engine_basic) VerifyHeader(chain ChainReader, header *block.Header,
 	if header.Time.IntVal.Cmp(&parent.Time.IntVal) <= 0 {
 		return ErrBlockTime
 	}
+	if err := VerifySlotTime(chain.Config(), header, parent); err != nil {
+		return err
+	}
 
 	//verify ConsensusData
 	if seal {
@@ -94,6 +97,9 @@ func (basic *Engine_basic) verifyHeader(chain ChainReader, header, parent *block
 	if header.Time.IntVal.Cmp(&parent.Time.IntVal) <= 0 {
 		return ErrBlockTime
 	}
+	if err := VerifySlotTime(chain.Config(), header, parent); err != nil {
+		return err
+	}
 
 	//verify signature
 	singner := block.NewBlockSigner(chain.Config().ChainId)
@@ -104,74 +110,13 @@ func (basic *Engine_basic) verifyHeader(chain ChainReader, header, parent *block
 	return nil
 }
 
-func (basic *Engine_basic) verifyHeaderWorker(chain ChainReader, headers []*block.Header, seals []bool, index int) error {
-	var parent *block.Header
-	if index == 0 {
-		parent = chain.GetHeader(headers[0].ParentHash, headers[0].Number.IntVal.Uint64()-1)
-	} else if headers[index-1].Hash() == headers[index].ParentHash {
-		parent = headers[index-1]
-	}
-	if parent == nil {
-		return ErrUnknownAncestor
-	}
-	if chain.GetHeader(headers[index].Hash(), headers[index].Number.IntVal.Uint64()) != nil {
-		return nil // known block
-	}
-	return basic.verifyHeader(chain, headers[index], parent,seals[index])
-}
-
 // VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
 // concurrently. The method returns a quit channel to abort the operations and
 // a results channel to retrieve the async verifications.
 func (basic *Engine_basic) VerifyHeaders(chain ChainReader, headers []*block.Header, seals []bool) (chan<- struct{}, <-chan error) {
-	workers := runtime.GOMAXPROCS(0)
-	if len(headers) < workers {
-		workers = len(headers)
-	}
-	// Create a task channel and spawn the verifiers
-	var (
-		inputs = make(chan int)
-		done   = make(chan int, workers)
-		errors = make([]error, len(headers))
-		abort  = make(chan struct{})
-	)
-	for i := 0; i < workers; i++ {
-		go func() {
-			for index := range inputs {
-				errors[index] = basic.verifyHeaderWorker(chain, headers, seals, index)
-				done <- index
-			}
-		}()
-	}
-
-	errorsOut := make(chan error, len(headers))
-	go func() {
-		defer close(inputs)
-		var (
-			in, out = 0, 0
-			checked = make([]bool, len(headers))
-			inputs  = inputs
-		)
-		for {
-			select {
-			case inputs <- in:
-				if in++; in == len(headers) {
-					// Reached end of headers. Stop sending to workers.
-					inputs = nil
-				}
-			case index := <-done:
-				for checked[index] = true; checked[out]; out++ {
-					errorsOut <- errors[out]
-					if out == len(headers)-1 {
-						return
-					}
-				}
-			case <-abort:
-				return
-			}
-		}
-	}()
-	return abort, errorsOut
+	return VerifyHeaders(chain, headers, seals, func(header, parent *block.Header, seal bool) error {
+		return basic.verifyHeader(chain, header, parent, seal)
+	})
 }
 
 
@@ -187,9 +132,12 @@ func (basic *Engine_basic) Prepare(chain ChainReader, header *block.Header) erro
 
 //todo this need interpreter process
 //interpreter need change state
-func (basic *Engine_basic) Finalize(chain ChainReader, header *block.Header, state *state.StateDB, txs []*transaction.Transaction, receipts []*transaction.Receipt, sign bool) (*block.Block, error) {
-	//reward := big.NewInt(5e+18)
-	//state.AddBalance(header.BlockProducer, reward)
+func (basic *Engine_basic) Finalize(chain ChainReader, header *block.Header, state *state.StateDB, txs []*transaction.Transaction, receipts []*transaction.Receipt, rewards *RewardState, sign bool) (*block.Block, error) {
+	if rewards != nil {
+		if err := AccumulateRewards(chain.Config(), header, state, receipts, rewards); err != nil {
+			return nil, err
+		}
+	}
 	header.StateRootHash = state.IntermediateRoot()
 
 	//sign header
@@ -215,3 +163,8 @@ func (basic *Engine_basic) Seal(chain ChainReader, block *block.Block, stop <-ch
 	header := block.Header()
 	return block.WithSeal(header), nil
 }
+
+// APIs implements Engine, the engine has no APIs.
+func (basic *Engine_basic) APIs(chain ChainReader) []rpc.API {
+	return nil
+}
@@ -0,0 +1,262 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: aggregate.go
+// @Date: 2018/07/30 14:12:58
+////////////////////////////////////////////////////////////////////////////////
+
+package bft
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/crypto/bls"
+)
+
+// AggregateConsensusId is the Id of the ConsensusData carrying an aggregate commit
+const AggregateConsensusId = "bft-bls"
+
+// aggregateHeaderLength is the size of the encoded commit without the signer bitmap:
+// the height, the round, the block hash and the aggregate signature.
+const aggregateHeaderLength = 8 + 8 + types.HashLength + bls.SignatureLength
+
+var (
+	// ErrInvalidAggregateCommit is returned if an aggregate commit can not be decoded,
+	// or is not signed by more than 2/3 of the validators.
+	ErrInvalidAggregateCommit = errors.New("invalid aggregate commit")
+
+	// ErrInvalidBlsKey is returned if a bls key of the config can not be decoded or
+	// its proof of possession is invalid.
+	ErrInvalidBlsKey = errors.New("invalid bls key")
+
+	// ErrMissingBlsKey is returned if a validator of a chain aggregating the commits
+	// has no bls key.
+	ErrMissingBlsKey = errors.New("validator without bls key")
+
+	errDuplicateSigner = errors.New("validator signing an aggregate commit twice")
+	errUnknownSigner   = errors.New("aggregate commit signer out of the validator set")
+)
+
+// blsKeyDomain separates the bls key of a validator from its signing key
+var blsKeyDomain = []byte("mjoy-bft-bls-key")
+
+// BlsKey returns the bls key of the validator of the signing key prv, so a node signs
+// its precommits without another key to keep.
+func BlsKey(prv *ecdsa.PrivateKey) *bls.PrivateKey {
+	seed := crypto.FromECDSA(prv)
+	for {
+		seed = crypto.Keccak256(blsKeyDomain, seed)
+		if key, err := bls.ToPrivateKey(seed); err == nil {
+			return key
+		}
+	}
+}
+
+// ConfigBlsKey returns the entry of the validator of the signing key prv in the bls
+// keys of a config, with the proof of possession of its key.
+func ConfigBlsKey(prv *ecdsa.PrivateKey) params.BftBlsKey {
+	key := BlsKey(prv)
+	return params.BftBlsKey{
+		Address:   crypto.PubkeyToAddress(prv.PublicKey),
+		PublicKey: key.Public().Marshal(),
+		Proof:     key.ProvePossession().Marshal(),
+	}
+}
+
+// ValidatorBlsKeys decodes the bls keys of config by validator, an error is returned
+// if a key is invalid, is not proven to be owned by its validator or is given twice.
+func ValidatorBlsKeys(config *params.BftConfig) (map[types.Address]*bls.PublicKey, error) {
+	keys := make(map[types.Address]*bls.PublicKey, len(config.BlsKeys))
+	for _, entry := range config.BlsKeys {
+		if _, ok := keys[entry.Address]; ok {
+			return nil, ErrInvalidBlsKey
+		}
+		pub, err := bls.ToPublicKey(entry.PublicKey)
+		if err != nil {
+			return nil, ErrInvalidBlsKey
+		}
+		proof, err := bls.ToSignature(entry.Proof)
+		if err != nil || !pub.VerifyPossession(proof) {
+			return nil, ErrInvalidBlsKey
+		}
+		keys[entry.Address] = pub
+	}
+	return keys, nil
+}
+
+// AggregateCommit is the compact form of a commit: the bls precommit signatures of the
+// validators aggregated into one, and a bitmap of the signers by their order in the
+// validator set. Its size only depends on the number of validators, and it verifies
+// with a single pairing check against the aggregate of the signer public keys.
+type AggregateCommit struct {
+	Height    uint64
+	Round     uint64
+	BlockHash types.Hash
+	Signers   []byte
+	Signature *bls.Signature
+}
+
+// NewAggregateCommit returns an aggregate commit without signers for a block of a set of
+// n validators.
+func NewAggregateCommit(height, round uint64, hash types.Hash, n int) *AggregateCommit {
+	return &AggregateCommit{
+		Height:    height,
+		Round:     round,
+		BlockHash: hash,
+		Signers:   make([]byte, (n+7)/8),
+	}
+}
+
+// SigHash is the hash the validators sign with their bls keys, the one of the
+// precommits of the block.
+func (c *AggregateCommit) SigHash() types.Hash {
+	return (&Vote{Type: Precommit, Height: c.Height, Round: c.Round, BlockHash: c.BlockHash}).SigHash()
+}
+
+// SignPrecommit signs the precommit of a block with the bls key of a validator.
+func SignPrecommit(key *bls.PrivateKey, height, round uint64, hash types.Hash) *bls.Signature {
+	sigHash := (&Vote{Type: Precommit, Height: height, Round: round, BlockHash: hash}).SigHash()
+	return key.Sign(sigHash[:])
+}
+
+// Add aggregates the precommit signature of the validator at index into the commit.
+func (c *AggregateCommit) Add(index int, sig *bls.Signature) error {
+	if index < 0 || index >= len(c.Signers)*8 {
+		return errUnknownSigner
+	}
+	if c.Signed(index) {
+		return errDuplicateSigner
+	}
+	if c.Signature == nil {
+		c.Signature = sig
+	} else {
+		agg, err := bls.AggregateSignatures([]*bls.Signature{c.Signature, sig})
+		if err != nil {
+			return err
+		}
+		c.Signature = agg
+	}
+	c.Signers[index/8] |= 1 << uint(index%8)
+	return nil
+}
+
+// Signed reports whether the validator at index signs the commit, an index out of the
+// bitmap never does.
+func (c *AggregateCommit) Signed(index int) bool {
+	if index < 0 || index >= len(c.Signers)*8 {
+		return false
+	}
+	return c.Signers[index/8]&(1<<uint(index%8)) != 0
+}
+
+// Verify checks that the commit is signed by more than 2/3 of validators, the bls
+// public keys of the validator set in its order.
+func (c *AggregateCommit) Verify(validators []*bls.PublicKey) error {
+	if len(c.Signers) != (len(validators)+7)/8 || c.Signature == nil {
+		return ErrInvalidAggregateCommit
+	}
+	// No signer bits past the validator set
+	for i := len(validators); i < len(c.Signers)*8; i++ {
+		if c.Signed(i) {
+			return ErrInvalidAggregateCommit
+		}
+	}
+	signers := make([]*bls.PublicKey, 0, len(validators))
+	for i, pub := range validators {
+		if c.Signed(i) {
+			signers = append(signers, pub)
+		}
+	}
+	if !quorum(len(signers), len(validators)) {
+		return ErrInvalidAggregateCommit
+	}
+	hash := c.SigHash()
+	if !bls.VerifyAggregate(signers, hash[:], c.Signature) {
+		return ErrInvalidAggregateCommit
+	}
+	return nil
+}
+
+// Encode encodes the commit for a header: the height, the round, the block hash, the
+// aggregate signature and the signer bitmap.
+func (c *AggregateCommit) Encode() (block.ConsensusData, error) {
+	if c.Signature == nil {
+		return block.ConsensusData{}, ErrInvalidAggregateCommit
+	}
+	para := make([]byte, aggregateHeaderLength, aggregateHeaderLength+len(c.Signers))
+	binary.BigEndian.PutUint64(para[0:], c.Height)
+	binary.BigEndian.PutUint64(para[8:], c.Round)
+	copy(para[16:], c.BlockHash[:])
+	copy(para[16+types.HashLength:], c.Signature.Marshal())
+	para = append(para, c.Signers...)
+	return block.ConsensusData{Id: AggregateConsensusId, Para: para}, nil
+}
+
+// Commit returns the commit in the form kept by the engine.
+func (c *AggregateCommit) Commit() *Commit {
+	return &Commit{
+		Height:    c.Height,
+		Round:     c.Round,
+		BlockHash: c.BlockHash,
+		Bitmap:    c.Signers,
+		Aggregate: c.Signature.Marshal(),
+	}
+}
+
+// AggregateCommit returns the aggregate form of a commit of a chain aggregating the
+// commits.
+func (c *Commit) AggregateCommit() (*AggregateCommit, error) {
+	if len(c.Signatures) != 0 {
+		return nil, ErrInvalidAggregateCommit
+	}
+	sig, err := bls.ToSignature(c.Aggregate)
+	if err != nil {
+		return nil, ErrInvalidAggregateCommit
+	}
+	return &AggregateCommit{
+		Height:    c.Height,
+		Round:     c.Round,
+		BlockHash: c.BlockHash,
+		Signers:   c.Bitmap,
+		Signature: sig,
+	}, nil
+}
+
+// DecodeAggregateCommit decodes the aggregate commit of a header.
+func DecodeAggregateCommit(data *block.ConsensusData) (*AggregateCommit, error) {
+	if data.Id != AggregateConsensusId || len(data.Para) < aggregateHeaderLength {
+		return nil, ErrInvalidAggregateCommit
+	}
+	para := data.Para
+	sig, err := bls.ToSignature(para[16+types.HashLength : aggregateHeaderLength])
+	if err != nil {
+		return nil, ErrInvalidAggregateCommit
+	}
+	c := &AggregateCommit{
+		Height:    binary.BigEndian.Uint64(para[0:]),
+		Round:     binary.BigEndian.Uint64(para[8:]),
+		Signers:   append([]byte{}, para[aggregateHeaderLength:]...),
+		Signature: sig,
+	}
+	copy(c.BlockHash[:], para[16:16+types.HashLength])
+	return c, nil
+}
@@ -0,0 +1,120 @@
+package bft
+
+import (
+	"bytes"
+	"testing"
+
+	"mjoy.io/common/types"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/crypto/bls"
+)
+
+func newBLSValidators(t *testing.T, n int) ([]*bls.PrivateKey, []*bls.PublicKey) {
+	keys := make([]*bls.PrivateKey, n)
+	pubs := make([]*bls.PublicKey, n)
+	for i := range keys {
+		key, err := bls.GenerateKey(nil)
+		if err != nil {
+			t.Fatalf("failed to generate bls key: %v", err)
+		}
+		keys[i], pubs[i] = key, key.Public()
+	}
+	return keys, pubs
+}
+
+func TestAggregateCommit(t *testing.T) {
+	keys, pubs := newBLSValidators(t, 4)
+	hash := types.Hash{1}
+
+	commit := NewAggregateCommit(7, 1, hash, len(keys))
+	for i := 0; i < 2; i++ {
+		if err := commit.Add(i, SignPrecommit(keys[i], 7, 1, hash)); err != nil {
+			t.Fatalf("failed to add signature %d: %v", i, err)
+		}
+	}
+	if err := commit.Verify(pubs); err != ErrInvalidAggregateCommit {
+		t.Fatalf("commit without quorum: have %v, want %v", err, ErrInvalidAggregateCommit)
+	}
+	if err := commit.Add(1, SignPrecommit(keys[1], 7, 1, hash)); err == nil {
+		t.Fatalf("validator added twice")
+	}
+	if err := commit.Add(3, SignPrecommit(keys[3], 7, 1, hash)); err != nil {
+		t.Fatalf("failed to add signature: %v", err)
+	}
+	if err := commit.Verify(pubs); err != nil {
+		t.Fatalf("valid commit rejected: %v", err)
+	}
+
+	// The encoded commit is the same size whatever the signers, and decodes back
+	data, err := commit.Encode()
+	if err != nil {
+		t.Fatalf("failed to encode commit: %v", err)
+	}
+	if len(data.Para) != aggregateHeaderLength+1 {
+		t.Fatalf("encoded size mismatch: have %d, want %d", len(data.Para), aggregateHeaderLength+1)
+	}
+	dec, err := DecodeAggregateCommit(&data)
+	if err != nil {
+		t.Fatalf("failed to decode commit: %v", err)
+	}
+	if dec.Height != 7 || dec.Round != 1 || dec.BlockHash != hash || !dec.Signed(3) || dec.Signed(2) {
+		t.Fatalf("decoded commit mismatch: %+v", dec)
+	}
+	if err := dec.Verify(pubs); err != nil {
+		t.Fatalf("decoded commit rejected: %v", err)
+	}
+
+	// A commit of another block or validator set is rejected
+	dec.BlockHash = types.Hash{2}
+	if err := dec.Verify(pubs); err != ErrInvalidAggregateCommit {
+		t.Errorf("commit of another block: have %v, want %v", err, ErrInvalidAggregateCommit)
+	}
+	if err := commit.Verify(pubs[:3]); err != ErrInvalidAggregateCommit {
+		t.Errorf("commit of another validator set: have %v, want %v", err, ErrInvalidAggregateCommit)
+	}
+	data.Id = ConsensusId
+	if _, err := DecodeAggregateCommit(&data); err != ErrInvalidAggregateCommit {
+		t.Errorf("bft consensus data decoded as aggregate commit: %v", err)
+	}
+}
+
+func TestSignedOutOfRange(t *testing.T) {
+	commit := NewAggregateCommit(7, 1, types.Hash{1}, 4)
+	commit.Signers[0] = 0xff
+	for _, index := range []int{-1, 8, 9, 64} {
+		if commit.Signed(index) {
+			t.Errorf("validator %d out of the bitmap signs", index)
+		}
+	}
+	if !commit.Signed(7) {
+		t.Errorf("validator 7 of the bitmap does not sign")
+	}
+}
+
+func TestValidatorBlsKeys(t *testing.T) {
+	prv, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	entry, otherEntry := ConfigBlsKey(prv), ConfigBlsKey(other)
+
+	keys, err := ValidatorBlsKeys(&params.BftConfig{BlsKeys: []params.BftBlsKey{entry, otherEntry}})
+	if err != nil {
+		t.Fatalf("valid keys rejected: %v", err)
+	}
+	if pub := keys[entry.Address]; pub == nil || !bytes.Equal(pub.Marshal(), BlsKey(prv).Public().Marshal()) {
+		t.Errorf("key of %x mismatch", entry.Address)
+	}
+
+	// The proof of another key, a key without proof and an undecodable key are rejected
+	stolen := entry
+	stolen.Proof = otherEntry.Proof
+	unproven := entry
+	unproven.Proof = nil
+	invalid := entry
+	invalid.PublicKey = make([]byte, bls.PublicKeyLength)
+	for i, test := range [][]params.BftBlsKey{{stolen}, {unproven}, {invalid}, {entry, entry}} {
+		if _, err := ValidatorBlsKeys(&params.BftConfig{BlsKeys: test}); err != ErrInvalidBlsKey {
+			t.Errorf("test %d: have %v, want %v", i, err, ErrInvalidBlsKey)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: api.go
+// @Date: 2018/07/31 11:04:37
+////////////////////////////////////////////////////////////////////////////////
+
+package bft
+
+import (
+	"mjoy.io/common/types"
+	"mjoy.io/communication/rpc"
+	"mjoy.io/consensus"
+)
+
+// VoteTally is the validators voting for a block in a round, a zero BlockHash is
+// the vote for no block.
+type VoteTally struct {
+	BlockHash types.Hash      `json:"blockHash"`
+	Voters    []types.Address `json:"voters"`
+}
+
+// RoundStatus is the state of the current round of a validating node.
+type RoundStatus struct {
+	Height     uint64      `json:"height"`
+	Round      uint64      `json:"round"`
+	Step       string      `json:"step"`
+	Validators int         `json:"validators"`
+	Proposal   *types.Hash `json:"proposal"`
+	Locked     *types.Hash `json:"locked"`
+	Prevotes   []VoteTally `json:"prevotes"`
+	Precommits []VoteTally `json:"precommits"`
+}
+
+// Status returns the state of the current round, an error is returned if the
+// engine is not started.
+func (b *Bft) Status() (*RoundStatus, error) {
+	c := b.getCore()
+	if c == nil {
+		return nil, errNotStarted
+	}
+	status := c.status()
+	if status == nil {
+		return nil, errNotStarted
+	}
+	return status, nil
+}
+
+// API is the RPC API of the bft engine, the namespace operators monitor the
+// validators and the rounds with.
+type API struct {
+	chain consensus.ChainReader
+	bft   *Bft
+}
+
+// APIs implements consensus.Engine, publishing the bft namespace.
+func (b *Bft) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "bft",
+		Version:   "1.0",
+		Service:   &API{chain: chain, bft: b},
+		Public:    true,
+	}}
+}
+
+// GetValidators returns the validators of block number, the ones of the block after
+// the head if number is nil.
+func (api *API) GetValidators(number *rpc.BlockNumber) ([]types.Address, error) {
+	if number == nil || *number == rpc.LatestBlockNumber || *number == rpc.PendingBlockNumber {
+		return api.bft.Validators(api.chain, api.chain.CurrentHeader().Number.IntVal.Uint64()+1)
+	}
+	header, err := consensus.HeaderByNumber(api.chain, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.bft.Validators(api.chain, header.Number.IntVal.Uint64())
+}
+
+// GetSigner returns the validator proposing block number.
+func (api *API) GetSigner(number *rpc.BlockNumber) (types.Address, error) {
+	header, err := consensus.HeaderByNumber(api.chain, number)
+	if err != nil {
+		return types.Address{}, err
+	}
+	return api.bft.Author(api.chain, header)
+}
+
+// GetProposers returns the proposers of the first round of the count blocks after
+// the head, as long as the validator set does not change.
+func (api *API) GetProposers(count uint64) ([]types.Address, error) {
+	if count > consensus.MaxScheduleLength {
+		return nil, consensus.ErrScheduleTooLong
+	}
+	number := api.chain.CurrentHeader().Number.IntVal.Uint64()
+	validators, err := api.bft.Validators(api.chain, number+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		return nil, ErrNoValidators
+	}
+	proposers := make([]types.Address, count)
+	for i := range proposers {
+		next := number + uint64(i) + 1
+		proposers[i] = validators[next%uint64(len(validators))]
+	}
+	return proposers, nil
+}
+
+// GetCommit returns the commit of block number, nil if it is not known.
+func (api *API) GetCommit(number *rpc.BlockNumber) (*Commit, error) {
+	header, err := consensus.HeaderByNumber(api.chain, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.bft.GetCommit(header.Number.IntVal.Uint64()), nil
+}
+
+// GetVotes returns the state of the current round with its pending votes.
+func (api *API) GetVotes() (*RoundStatus, error) {
+	return api.bft.Status()
+}
@@ -0,0 +1,552 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: bft.go
+// @Date: 2018/07/16 10:12:40
+////////////////////////////////////////////////////////////////////////////////
+
+// Package bft implements a BFT finality engine. The validators agree on every
+// block in rounds of proposal, prevotes and precommits exchanged over their own
+// p2p protocol, and a block precommitted by more than 2/3 of them is final.
+package bft
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"mjoy.io/common"
+	"mjoy.io/common/types"
+	"mjoy.io/consensus"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/crypto/bls"
+	"mjoy.io/utils/database"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+const (
+	defaultTimeoutPropose   = 3000 * time.Millisecond
+	defaultTimeoutPrevote   = 1000 * time.Millisecond
+	defaultTimeoutPrecommit = 1000 * time.Millisecond
+	defaultTimeoutDelta     = 500 * time.Millisecond
+
+	// number of commits kept in memory for the headers of the next blocks
+	commitCacheSize = 16
+)
+
+var (
+	commitPrefix = []byte("bft-commit-")   // commitPrefix + num (uint64 big endian) -> commit
+	finalizedKey = []byte("bft-finalized") // number of the last finalized block
+)
+
+var (
+	errMissingKey = errors.New("no key found for signing header")
+	errNoCommit   = errors.New("no commit known for the parent block")
+	errNotStarted = errors.New("bft engine is not started")
+)
+
+// BlockHandler is called by the engine with a proposed block, to check it before
+// voting for it, or with a committed block, to insert it into the chain.
+type BlockHandler func(blk *block.Block) error
+
+// Bft is the BFT finality engine.
+type Bft struct {
+	config *params.BftConfig
+	db     database.IDatabase // database keeping the commits
+
+	blsKeys map[types.Address]*bls.PublicKey // bls keys of the validators, on a chain aggregating the commits
+	blsErr  error                            // error of an invalid bls key of the config
+
+	lock      sync.RWMutex
+	prv       *ecdsa.PrivateKey  // key for sign header and votes
+	blsPrv    *bls.PrivateKey    // key for sign the precommits to aggregate
+	commits   map[uint64]*Commit // recent commits by height
+	finalized *Commit            // the commit of the last finalized block
+
+	validate BlockHandler
+	commit   BlockHandler
+
+	peers *peerSet
+	core  *core
+}
+
+// New creates a bft engine keeping the commits in db, the key for a validating node
+// is set by SetKey. With an invalid bls key in config no commit verifies.
+func New(config *params.BftConfig, db database.IDatabase) *Bft {
+	b := &Bft{
+		config:  config,
+		db:      db,
+		commits: make(map[uint64]*Commit),
+		peers:   newPeerSet(),
+	}
+	if b.aggregating() {
+		if b.blsKeys, b.blsErr = ValidatorBlsKeys(config); b.blsErr != nil {
+			logger.Error("Invalid bls keys of the validators", "err", b.blsErr)
+		}
+	}
+	if data, err := db.Get(finalizedKey); err == nil && len(data) == 8 {
+		b.finalized = b.readCommit(binary.BigEndian.Uint64(data))
+	}
+	return b
+}
+
+// SetKey sets the signing key of the node, its bls key is the one derived by BlsKey.
+func (b *Bft) SetKey(prv *ecdsa.PrivateKey) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.prv, b.blsPrv = prv, nil
+	if prv != nil {
+		b.blsPrv = BlsKey(prv)
+	}
+}
+
+func (b *Bft) key() *ecdsa.PrivateKey {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.prv
+}
+
+func (b *Bft) blsKey() *bls.PrivateKey {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.blsPrv
+}
+
+// aggregating reports whether the commits of the chain are aggregate ones.
+func (b *Bft) aggregating() bool {
+	return len(b.config.BlsKeys) > 0
+}
+
+// validatorBlsKeys returns the bls keys of validators in their order.
+func (b *Bft) validatorBlsKeys(validators []types.Address) ([]*bls.PublicKey, error) {
+	if b.blsErr != nil {
+		return nil, b.blsErr
+	}
+	keys := make([]*bls.PublicKey, len(validators))
+	for i, validator := range validators {
+		if keys[i] = b.blsKeys[validator]; keys[i] == nil {
+			return nil, ErrMissingBlsKey
+		}
+	}
+	return keys, nil
+}
+
+// Start runs the consensus rounds from the head of chain. validate checks a proposed
+// block against the state, it may be nil; commit inserts a committed block.
+func (b *Bft) Start(chain consensus.ChainReader, validate, commit BlockHandler) {
+	b.lock.Lock()
+	if b.core != nil {
+		b.lock.Unlock()
+		return
+	}
+	b.validate, b.commit = validate, commit
+	b.core = newCore(b, chain)
+	b.lock.Unlock()
+
+	b.core.start(chain.CurrentHeader())
+}
+
+// Stop ends the consensus rounds.
+func (b *Bft) Stop() {
+	b.lock.Lock()
+	c := b.core
+	b.core = nil
+	b.lock.Unlock()
+	if c != nil {
+		c.stop()
+	}
+}
+
+func (b *Bft) getCore() *core {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.core
+}
+
+// Validators returns the validators of the block number in proposer order: the ones
+// elected by stake, or the configured ones as long as no candidate is elected. An
+// error is returned if the election can not be read, e.g. without the state of the
+// election block, the configured validators may not be the ones of the block then.
+func (b *Bft) Validators(chain consensus.ChainReader, number uint64) ([]types.Address, error) {
+	elected, err := chain.ElectedValidators(number)
+	if err != nil {
+		return nil, err
+	}
+	if len(elected) > 0 {
+		return elected, nil
+	}
+	return b.config.Validators, nil
+}
+
+// Proposer returns the proposer of the round of the block number.
+func (b *Bft) Proposer(chain consensus.ChainReader, number, round uint64) (types.Address, error) {
+	validators, err := b.Validators(chain, number)
+	if err != nil {
+		return types.Address{}, err
+	}
+	if len(validators) == 0 {
+		return types.Address{}, ErrNoValidators
+	}
+	return validators[(number+round)%uint64(len(validators))], nil
+}
+
+func isValidator(validators []types.Address, address types.Address) bool {
+	for _, v := range validators {
+		if v == address {
+			return true
+		}
+	}
+	return false
+}
+
+// quorum returns whether count votes are more than 2/3 of n validators.
+func quorum(count, n int) bool {
+	return 3*count > 2*n
+}
+
+func commitKey(number uint64) []byte {
+	key := make([]byte, len(commitPrefix)+8)
+	copy(key, commitPrefix)
+	binary.BigEndian.PutUint64(key[len(commitPrefix):], number)
+	return key
+}
+
+func (b *Bft) readCommit(number uint64) *Commit {
+	data, err := b.db.Get(commitKey(number))
+	if err != nil {
+		return nil
+	}
+	commit := &Commit{}
+	if err := msgp.Decode(bytes.NewReader(data), commit); err != nil {
+		logger.Error("Invalid commit in database", "number", number, "err", err)
+		return nil
+	}
+	return commit
+}
+
+// addCommit keeps a verified commit, the commits are written to the database so a
+// restarted validator can still propose the child of its head. The commits of the
+// rounds are added once reached, the ones carried by headers once their block is
+// imported.
+func (b *Bft) addCommit(commit *Commit) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if known, ok := b.commits[commit.Height]; ok && known.BlockHash == commit.BlockHash {
+		return
+	}
+	b.commits[commit.Height] = commit
+	delete(b.commits, commit.Height-commitCacheSize)
+
+	var buf bytes.Buffer
+	if err := msgp.Encode(&buf, commit); err != nil {
+		logger.Error("Failed to encode commit", "err", err)
+		return
+	}
+	if err := b.db.Put(commitKey(commit.Height), buf.Bytes()); err != nil {
+		logger.Error("Failed to store commit", "err", err)
+		return
+	}
+	if b.finalized == nil || commit.Height > b.finalized.Height {
+		b.finalized = commit
+		number := make([]byte, 8)
+		binary.BigEndian.PutUint64(number, commit.Height)
+		if err := b.db.Put(finalizedKey, number); err != nil {
+			logger.Error("Failed to store finalized number", "err", err)
+		}
+	}
+}
+
+// GetCommit returns the commit of the block number if it is known.
+func (b *Bft) GetCommit(number uint64) *Commit {
+	b.lock.RLock()
+	commit := b.commits[number]
+	b.lock.RUnlock()
+	if commit == nil {
+		commit = b.readCommit(number)
+	}
+	return commit
+}
+
+// Finalized implements consensus.Finalizer.
+func (b *Bft) Finalized() (uint64, types.Hash, bool) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.finalized == nil {
+		return 0, types.Hash{}, false
+	}
+	return b.finalized.Height, b.finalized.BlockHash, true
+}
+
+// verifyBlsPrecommit reports whether the bls signature of the precommit v of signer
+// is valid.
+func (b *Bft) verifyBlsPrecommit(signer types.Address, v *Vote) bool {
+	pub := b.blsKeys[signer]
+	if pub == nil {
+		return false
+	}
+	sig, err := bls.ToSignature(v.BlsSignature)
+	if err != nil {
+		return false
+	}
+	hash := v.SigHash()
+	return pub.Verify(hash[:], sig)
+}
+
+// VerifyCommit checks that commit is signed by more than 2/3 of the validators of
+// the block number with hash. On a chain aggregating the commits, the commit has to
+// be an aggregate one verified against the bls keys of the validators.
+func (b *Bft) VerifyCommit(chain consensus.ChainReader, commit *Commit, number uint64, hash types.Hash) error {
+	if commit == nil || commit.Height != number || commit.BlockHash != hash {
+		return ErrInvalidCommit
+	}
+	validators, err := b.Validators(chain, number)
+	if err != nil {
+		return err
+	}
+	if b.aggregating() {
+		keys, err := b.validatorBlsKeys(validators)
+		if err != nil {
+			return err
+		}
+		aggregate, err := commit.AggregateCommit()
+		if err != nil {
+			return err
+		}
+		return aggregate.Verify(keys)
+	}
+	if len(commit.Bitmap) != 0 || len(commit.Aggregate) != 0 {
+		return ErrInvalidCommit
+	}
+	signers, err := commit.Signers()
+	if err != nil {
+		return err
+	}
+	for _, signer := range signers {
+		if !isValidator(validators, signer) {
+			return ErrInvalidCommit
+		}
+	}
+	if !quorum(len(signers), len(validators)) {
+		return ErrInvalidCommit
+	}
+	return nil
+}
+
+func (b *Bft) Author(chain consensus.ChainReader, header *block.Header) (types.Address, error) {
+	signer := block.NewBlockSigner(chain.Config().ChainId)
+	return signer.Sender(header)
+}
+
+func (b *Bft) VerifyHeader(chain consensus.ChainReader, header *block.Header, seal bool) error {
+	//if the header is known, verify success
+	number := header.Number.IntVal.Uint64()
+	if chain.GetHeader(header.Hash(), number) != nil {
+		return nil
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return b.verifyHeader(chain, header, parent, seal)
+}
+
+// verifyHeader checks the header against its parent and the commit of the parent
+// it carries. The commit of the header itself comes with its child or with the votes.
+func (b *Bft) verifyHeader(chain consensus.ChainReader, header, parent *block.Header, seal bool) error {
+	// Verify that the block number is parent's +1
+	if diff := new(big.Int).Sub(&header.Number.IntVal, &parent.Number.IntVal); diff.Cmp(common.Big1) != 0 {
+		return consensus.ErrInvalidNumber
+	}
+
+	//verify time
+	if header.Time.IntVal.Cmp(&parent.Time.IntVal) <= 0 {
+		return consensus.ErrBlockTime
+	}
+	if err := consensus.VerifySlotTime(chain.Config(), header, parent); err != nil {
+		return err
+	}
+
+	if seal {
+		return b.VerifySeal(chain, header)
+	}
+	if err := b.verifyLastCommit(chain, header); err != nil {
+		return err
+	}
+	signer := block.NewBlockSigner(chain.Config().ChainId)
+	if _, err := signer.Sender(header); err != nil {
+		return consensus.ErrSignature
+	}
+	return nil
+}
+
+// verifyLastCommit checks the commit of the parent carried by the header, a child of
+// the genesis carries none.
+func (b *Bft) verifyLastCommit(chain consensus.ChainReader, header *block.Header) error {
+	commit, err := DecodeCommit(&header.ConsensusData)
+	if err != nil {
+		return err
+	}
+	number := header.Number.IntVal.Uint64()
+	if number <= 1 {
+		if commit != nil {
+			return ErrInvalidCommit
+		}
+		return nil
+	}
+	return b.VerifyCommit(chain, commit, number-1, header.ParentHash)
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
+// concurrently. The method returns a quit channel to abort the operations and
+// a results channel to retrieve the async verifications.
+func (b *Bft) VerifyHeaders(chain consensus.ChainReader, headers []*block.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	return consensus.VerifyHeaders(chain, headers, seals, func(header, parent *block.Header, seal bool) error {
+		return b.verifyHeader(chain, header, parent, seal)
+	})
+}
+
+// Imported implements consensus.Importer, keeping the commit of the parent carried
+// by the imported header. The header is verified, so is the commit.
+func (b *Bft) Imported(chain consensus.ChainReader, header *block.Header) {
+	commit, err := DecodeCommit(&header.ConsensusData)
+	if err != nil || commit == nil {
+		return
+	}
+	b.addCommit(commit)
+}
+
+// VerifySeal checks the commit of the parent carried by the header, and that the
+// header is signed by a validator. The proposer of the round may propose a block
+// signed by another one, so the signer is not checked against the proposer.
+func (b *Bft) VerifySeal(chain consensus.ChainReader, header *block.Header) error {
+	signer, err := b.Author(chain, header)
+	if err != nil {
+		return consensus.ErrSignature
+	}
+	validators, err := b.Validators(chain, header.Number.IntVal.Uint64())
+	if err != nil {
+		return err
+	}
+	if !isValidator(validators, signer) {
+		return ErrUnauthorized
+	}
+	return b.verifyLastCommit(chain, header)
+}
+
+// Prepare puts the commit of the parent into the header, in its aggregate encoding on
+// a chain aggregating the commits.
+func (b *Bft) Prepare(chain consensus.ChainReader, header *block.Header) error {
+	prv := b.key()
+	if prv == nil {
+		return errMissingKey
+	}
+	signer := crypto.PubkeyToAddress(prv.PublicKey)
+	number := header.Number.IntVal.Uint64()
+	validators, err := b.Validators(chain, number)
+	if err != nil {
+		return err
+	}
+	if !isValidator(validators, signer) {
+		return ErrUnauthorized
+	}
+
+	data := &ConsensusData{}
+	if number > 1 {
+		commit := b.GetCommit(number - 1)
+		if commit == nil || commit.BlockHash != header.ParentHash {
+			return errNoCommit
+		}
+		data.LastCommit = commit
+	}
+	if data.LastCommit != nil && b.aggregating() {
+		aggregate, err := data.LastCommit.AggregateCommit()
+		if err != nil {
+			return err
+		}
+		header.ConsensusData, err = aggregate.Encode()
+		if err != nil {
+			return err
+		}
+	} else if header.ConsensusData, err = data.Encode(); err != nil {
+		return err
+	}
+	header.BlockProducer = signer
+	return nil
+}
+
+func (b *Bft) Finalize(chain consensus.ChainReader, header *block.Header, state *state.StateDB, txs []*transaction.Transaction, receipts []*transaction.Receipt, rewards *consensus.RewardState, sign bool) (*block.Block, error) {
+	if rewards != nil {
+		if err := consensus.AccumulateRewards(chain.Config(), header, state, receipts, rewards); err != nil {
+			return nil, err
+		}
+	}
+	header.StateRootHash = state.IntermediateRoot()
+	blk := block.NewBlock(header, txs, receipts)
+	if !sign {
+		return blk, nil
+	}
+
+	prv := b.key()
+	if prv == nil {
+		return nil, errMissingKey
+	}
+	if err := block.SignHeaderInner(blk.B_header, block.NewBlockSigner(chain.Config().ChainId), prv); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+// Seal gives the block to the consensus rounds as the local candidate for its height.
+// The block is inserted by the commit handler once it is committed, so Seal returns
+// no block.
+func (b *Bft) Seal(chain consensus.ChainReader, block *block.Block, stop <-chan struct{}) (*block.Block, error) {
+	c := b.getCore()
+	if c == nil {
+		return nil, errNotStarted
+	}
+	c.setCandidate(block)
+	return nil, nil
+}
+
+func (b *Bft) timeout(step step, round uint64) time.Duration {
+	timeout, base := time.Duration(0), time.Duration(0)
+	switch step {
+	case stepPropose:
+		timeout, base = time.Duration(b.config.TimeoutPropose)*time.Millisecond, defaultTimeoutPropose
+	case stepPrevote:
+		timeout, base = time.Duration(b.config.TimeoutPrevote)*time.Millisecond, defaultTimeoutPrevote
+	case stepPrecommit:
+		timeout, base = time.Duration(b.config.TimeoutPrecommit)*time.Millisecond, defaultTimeoutPrecommit
+	}
+	if timeout == 0 {
+		timeout = base
+	}
+	delta := time.Duration(b.config.TimeoutDelta) * time.Millisecond
+	if delta == 0 {
+		delta = defaultTimeoutDelta
+	}
+	return timeout + time.Duration(round)*delta
+}
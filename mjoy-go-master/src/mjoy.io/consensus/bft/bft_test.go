@@ -0,0 +1,464 @@
+package bft
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"mjoy.io/common/types"
+	"mjoy.io/communication/p2p"
+	"mjoy.io/communication/p2p/discover"
+	"mjoy.io/communication/rpc"
+	"mjoy.io/consensus"
+	"mjoy.io/consensus/consensustest"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/database"
+)
+
+// testNode is a validator with its chain, it builds a candidate on every new head
+type testNode struct {
+	t      *testing.T
+	engine *Bft
+	chain  *consensustest.Chain
+}
+
+func (n *testNode) produce() {
+	blk, err := n.chain.Produce(n.engine, nil)
+	if err != nil {
+		n.t.Errorf("produce block %d: %v", n.chain.Height()+1, err)
+		return
+	}
+	n.engine.Seal(n.chain, blk, nil)
+}
+
+// insert is the commit handler of the node
+func (n *testNode) insert(blk *block.Block) error {
+	if err := n.engine.VerifyHeader(n.chain, blk.Header(), true); err != nil {
+		return err
+	}
+	if err := n.chain.Insert(blk); err != nil {
+		return err
+	}
+
+	n.produce()
+	return nil
+}
+
+type testNetwork struct {
+	nodes []*testNode
+	pipes []*p2p.MsgPipeRW
+}
+
+// newTestNetwork starts the validators of the config except the offline ones,
+// every two of them are connected by a message pipe
+func newTestNetwork(t *testing.T, keys []*ecdsa.PrivateKey, config *params.ChainConfig, offline ...int) *testNetwork {
+	net := &testNetwork{}
+	isOffline := func(i int) bool {
+		for _, j := range offline {
+			if i == j {
+				return true
+			}
+		}
+		return false
+	}
+	for i, key := range keys {
+		if isOffline(i) {
+			continue
+		}
+		db, _ := database.OpenMemDB()
+		engine := New(config.Bft, db)
+		engine.SetKey(key)
+		net.nodes = append(net.nodes, &testNode{t: t, engine: engine, chain: consensustest.NewChain(config, 0)})
+	}
+	for i, a := range net.nodes {
+		for j, b := range net.nodes[i+1:] {
+			rwa, rwb := p2p.MsgPipe()
+			net.pipes = append(net.pipes, rwa, rwb)
+			go a.engine.Protocol().Run(p2p.NewPeer(discover.NodeID{byte(i + j + 2)}, "b", nil), rwa)
+			go b.engine.Protocol().Run(p2p.NewPeer(discover.NodeID{byte(i + 1)}, "a", nil), rwb)
+		}
+	}
+	for _, n := range net.nodes {
+		n.engine.Start(n.chain, nil, n.insert)
+	}
+	for _, n := range net.nodes {
+		n.produce()
+	}
+	return net
+}
+
+func (net *testNetwork) stop() {
+	for _, rw := range net.pipes {
+		rw.Close()
+	}
+	for _, n := range net.nodes {
+		n.engine.Stop()
+	}
+}
+
+// waitHeight waits for all the nodes to commit number blocks
+func (net *testNetwork) waitHeight(t *testing.T, number uint64) {
+	deadline := time.Now().Add(30 * time.Second)
+	for _, n := range net.nodes {
+		for n.chain.Height() < number {
+			if time.Now().After(deadline) {
+				t.Fatalf("timeout waiting for block %d, node at %d", number, n.chain.Height())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func newTestConfig(n int) ([]*ecdsa.PrivateKey, *params.ChainConfig) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	config := &params.BftConfig{TimeoutPropose: 300, TimeoutPrevote: 100, TimeoutPrecommit: 100, TimeoutDelta: 100}
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		config.Validators = append(config.Validators, crypto.PubkeyToAddress(keys[i].PublicKey))
+	}
+	return keys, &params.ChainConfig{ChainId: big.NewInt(101), Bft: config}
+}
+
+func TestCommit(t *testing.T) {
+	keys, config := newTestConfig(4)
+	net := newTestNetwork(t, keys, config)
+	defer net.stop()
+
+	net.waitHeight(t, 5)
+
+	// the nodes agree on the blocks, each one carrying the commit of its parent
+	first := net.nodes[0]
+	for number := uint64(1); number <= 5; number++ {
+		header := first.chain.GetHeaderByNumber(number)
+		for _, n := range net.nodes[1:] {
+			if got := n.chain.GetHeaderByNumber(number).Hash(); got != header.Hash() {
+				t.Fatalf("block %d: hash %x, want %x", number, got, header.Hash())
+			}
+		}
+		data, err := DecodeConsensusData(&header.ConsensusData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if number == 1 {
+			if data.LastCommit != nil {
+				t.Errorf("block 1 carries a commit")
+			}
+			continue
+		}
+		if err := first.engine.VerifyCommit(first.chain, data.LastCommit, number-1, header.ParentHash); err != nil {
+			t.Errorf("commit of block %d: %v", number-1, err)
+		}
+	}
+
+	// the last committed block is final, also for a restarted engine
+	number, hash, ok := first.engine.Finalized()
+	net.waitHeight(t, number)
+	if !ok || number < 5 || first.chain.GetHeader(hash, number) == nil {
+		t.Errorf("finalized block %d %x %v", number, hash, ok)
+	}
+	restarted := New(config.Bft, first.engine.db)
+	if n, h, _ := restarted.Finalized(); n < number || restarted.GetCommit(5) == nil {
+		t.Errorf("restarted engine finalized %d %x", n, h)
+	}
+}
+
+func TestAggregateCommits(t *testing.T) {
+	keys, config := newTestConfig(4)
+	for _, key := range keys {
+		config.Bft.BlsKeys = append(config.Bft.BlsKeys, ConfigBlsKey(key))
+	}
+	net := newTestNetwork(t, keys, config)
+	defer net.stop()
+
+	net.waitHeight(t, 4)
+
+	// the blocks carry the aggregate commit of their parent
+	first := net.nodes[0]
+	for number := uint64(2); number <= 4; number++ {
+		header := first.chain.GetHeaderByNumber(number)
+		if header.ConsensusData.Id != AggregateConsensusId {
+			t.Fatalf("block %d carries %q consensus data", number, header.ConsensusData.Id)
+		}
+		aggregate, err := DecodeAggregateCommit(&header.ConsensusData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if aggregate.Height != number-1 || aggregate.BlockHash != header.ParentHash {
+			t.Errorf("block %d carries the commit of block %d %x", number, aggregate.Height, aggregate.BlockHash)
+		}
+		if err := first.engine.VerifyCommit(first.chain, aggregate.Commit(), number-1, header.ParentHash); err != nil {
+			t.Errorf("commit of block %d: %v", number-1, err)
+		}
+	}
+
+	// a header dropping a precommit from the aggregate is rejected
+	header := block.CopyHeader(first.chain.GetHeaderByNumber(3))
+	first.chain.Rewind(2)
+	aggregate, _ := DecodeAggregateCommit(&header.ConsensusData)
+	for i := range config.Bft.Validators {
+		if aggregate.Signed(i) {
+			aggregate.Signers[i/8] &^= 1 << uint(i%8)
+			break
+		}
+	}
+	header.ConsensusData, _ = aggregate.Encode()
+	block.SignHeaderInner(header, block.NewBlockSigner(config.ChainId), keys[0])
+	if err := first.engine.VerifyHeader(first.chain, header, true); err != ErrInvalidAggregateCommit {
+		t.Errorf("header with a tampered commit: %v, want %v", err, ErrInvalidAggregateCommit)
+	}
+
+	// so is a commit of a validator without bls key
+	db, _ := database.OpenMemDB()
+	partial := *config.Bft
+	partial.BlsKeys = partial.BlsKeys[:3]
+	engine := New(&partial, db)
+	if err := engine.VerifyCommit(first.chain, aggregate.Commit(), 2, aggregate.BlockHash); err != ErrMissingBlsKey {
+		t.Errorf("commit with a missing bls key: %v, want %v", err, ErrMissingBlsKey)
+	}
+}
+
+func TestRoundChange(t *testing.T) {
+	keys, config := newTestConfig(4)
+	// the proposer of round 0 of block 1 is offline, the others are still more than 2/3
+	net := newTestNetwork(t, keys, config, 1)
+	defer net.stop()
+
+	net.waitHeight(t, 6)
+
+	for _, n := range net.nodes {
+		for number := uint64(1); number <= 5; number++ {
+			commit := n.engine.GetCommit(number)
+			if commit == nil {
+				t.Fatalf("no commit for block %d", number)
+			}
+			if skipped := number%4 == 1; skipped != (commit.Round > 0) {
+				t.Errorf("block %d committed in round %d", number, commit.Round)
+			}
+		}
+	}
+}
+
+func TestVerifyCommit(t *testing.T) {
+	keys, config := newTestConfig(4)
+	db, _ := database.OpenMemDB()
+	engine := New(config.Bft, db)
+	chain := consensustest.NewChain(config, 0)
+	hash := types.Hash{1}
+
+	precommit := func(key *ecdsa.PrivateKey, hash types.Hash) []byte {
+		vote := &Vote{Type: Precommit, Height: 7, Round: 1, BlockHash: hash}
+		if err := vote.sign(key); err != nil {
+			t.Fatal(err)
+		}
+		return vote.Signature
+	}
+	outsider, _ := crypto.GenerateKey()
+
+	tests := []struct {
+		sigs [][]byte
+		err  error
+	}{
+		{[][]byte{precommit(keys[0], hash), precommit(keys[1], hash), precommit(keys[2], hash)}, nil},
+		{[][]byte{precommit(keys[0], hash), precommit(keys[1], hash)}, ErrInvalidCommit},
+		{[][]byte{precommit(keys[0], hash), precommit(keys[1], hash), precommit(keys[1], hash)}, ErrInvalidCommit},
+		{[][]byte{precommit(keys[0], hash), precommit(keys[1], hash), precommit(outsider, hash)}, ErrInvalidCommit},
+		{[][]byte{precommit(keys[0], hash), precommit(keys[1], hash), precommit(keys[2], types.Hash{})}, ErrInvalidCommit},
+	}
+	for i, test := range tests {
+		commit := &Commit{Height: 7, Round: 1, BlockHash: hash, Signatures: test.sigs}
+		if err := engine.VerifyCommit(chain, commit, 7, hash); err != test.err {
+			t.Errorf("test %d: %v, want %v", i, err, test.err)
+		}
+	}
+	commit := &Commit{Height: 7, Round: 1, BlockHash: hash, Signatures: tests[0].sigs}
+	if err := engine.VerifyCommit(chain, commit, 7, types.Hash{2}); err != ErrInvalidCommit {
+		t.Errorf("commit of another block: %v", err)
+	}
+
+	// a header needs the commit of its parent
+	engine.SetKey(keys[0])
+	parent := chain.CurrentHeader()
+	first := &block.Header{ParentHash: parent.Hash(), Number: types.NewBigInt(*big.NewInt(1)), Time: types.NewBigInt(*big.NewInt(1))}
+	if err := engine.Prepare(chain, first); err != nil {
+		t.Fatal(err)
+	}
+	block.SignHeaderInner(first, block.NewBlockSigner(config.ChainId), keys[0])
+	chain.InsertHeader(first)
+
+	second := &block.Header{ParentHash: first.Hash(), Number: types.NewBigInt(*big.NewInt(2)), Time: types.NewBigInt(*big.NewInt(2))}
+	if err := engine.Prepare(chain, second); err != errNoCommit {
+		t.Fatalf("prepare without commit: %v", err)
+	}
+	data := &ConsensusData{LastCommit: &Commit{Height: 1, BlockHash: first.Hash(), Signatures: [][]byte{}}}
+	second.ConsensusData, _ = data.Encode()
+	block.SignHeaderInner(second, block.NewBlockSigner(config.ChainId), keys[0])
+	if err := engine.VerifyHeader(chain, second, true); err != ErrInvalidCommit {
+		t.Errorf("header without enough precommits: %v", err)
+	}
+}
+
+func TestNoValidators(t *testing.T) {
+	_, config := newTestConfig(0)
+	db, _ := database.OpenMemDB()
+	engine := New(config.Bft, db)
+	chain := consensustest.NewChain(config, 0)
+
+	if _, err := engine.Proposer(chain, 1, 0); err != ErrNoValidators {
+		t.Errorf("proposer without validators: %v, want %v", err, ErrNoValidators)
+	}
+	api := engine.APIs(chain)[0].Service.(*API)
+	if _, err := api.GetProposers(2); err != ErrNoValidators {
+		t.Errorf("proposers without validators: %v, want %v", err, ErrNoValidators)
+	}
+}
+
+func TestFinalityOnImport(t *testing.T) {
+	keys, config := newTestConfig(4)
+	db, _ := database.OpenMemDB()
+	engine := New(config.Bft, db)
+	chain := consensustest.NewChain(config, 0)
+
+	parent := chain.CurrentHeader()
+	first := &block.Header{ParentHash: parent.Hash(), Number: types.NewBigInt(*big.NewInt(1)), Time: types.NewBigInt(*big.NewInt(1))}
+	first.ConsensusData, _ = (&ConsensusData{}).Encode()
+	block.SignHeaderInner(first, block.NewBlockSigner(config.ChainId), keys[0])
+	chain.InsertHeader(first)
+
+	commit := &Commit{Height: 1, BlockHash: first.Hash()}
+	for _, key := range keys[:3] {
+		vote := &Vote{Type: Precommit, Height: 1, BlockHash: first.Hash()}
+		vote.sign(key)
+		commit.Signatures = append(commit.Signatures, vote.Signature)
+	}
+	second := &block.Header{ParentHash: first.Hash(), Number: types.NewBigInt(*big.NewInt(2)), Time: types.NewBigInt(*big.NewInt(2))}
+	second.ConsensusData, _ = (&ConsensusData{LastCommit: commit}).Encode()
+	block.SignHeaderInner(second, block.NewBlockSigner(config.ChainId), keys[1])
+
+	// verifying the header does not finalize its parent, the block may still be invalid
+	if err := engine.VerifyHeader(chain, second, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := engine.Finalized(); ok || engine.GetCommit(1) != nil {
+		t.Fatal("verified header finalized its parent")
+	}
+	engine.Imported(chain, second)
+	if number, hash, ok := engine.Finalized(); !ok || number != 1 || hash != first.Hash() {
+		t.Errorf("finalized block %d %x %v, want 1 %x", number, hash, ok, first.Hash())
+	}
+}
+
+func TestElectedValidators(t *testing.T) {
+	keys, config := newTestConfig(4)
+	db, _ := database.OpenMemDB()
+	engine := New(config.Bft, db)
+	chain := consensustest.NewChain(config, 0)
+
+	// the configured validators run the chain until someone is elected by stake
+	if validators, err := engine.Validators(chain, 1); err != nil || len(validators) != 4 {
+		t.Fatalf("validators %v %v, want the configured ones", validators, err)
+	}
+	outsider, _ := crypto.GenerateKey()
+	chain.Elected = []types.Address{crypto.PubkeyToAddress(keys[2].PublicKey), crypto.PubkeyToAddress(outsider.PublicKey)}
+	if validators, err := engine.Validators(chain, 1); err != nil || len(validators) != 2 || validators[1] != chain.Elected[1] {
+		t.Fatalf("validators %v %v, want the elected ones", validators, err)
+	}
+	if proposer, err := engine.Proposer(chain, 1, 0); err != nil || proposer != chain.Elected[1] {
+		t.Errorf("proposer %x %v, want %x", proposer, err, chain.Elected[1])
+	}
+
+	// a commit needs the precommits of the elected validators
+	hash := types.Hash{1}
+	commit := &Commit{Height: 1, BlockHash: hash}
+	for _, key := range []*ecdsa.PrivateKey{keys[0], keys[1], keys[2]} {
+		vote := &Vote{Type: Precommit, Height: 1, BlockHash: hash}
+		vote.sign(key)
+		commit.Signatures = append(commit.Signatures, vote.Signature)
+	}
+	if err := engine.VerifyCommit(chain, commit, 1, hash); err != ErrInvalidCommit {
+		t.Errorf("commit of unelected validators: %v", err)
+	}
+	commit.Signatures = nil
+	for _, key := range []*ecdsa.PrivateKey{keys[2], outsider} {
+		vote := &Vote{Type: Precommit, Height: 1, BlockHash: hash}
+		vote.sign(key)
+		commit.Signatures = append(commit.Signatures, vote.Signature)
+	}
+	if err := engine.VerifyCommit(chain, commit, 1, hash); err != nil {
+		t.Errorf("commit of the elected validators: %v", err)
+	}
+}
+
+func TestUnknownElection(t *testing.T) {
+	keys, config := newTestConfig(4)
+	db, _ := database.OpenMemDB()
+	engine := New(config.Bft, db)
+	engine.SetKey(keys[0])
+	chain := consensustest.NewChain(config, 0)
+
+	// without the state of the election block the validators are unknown, the
+	// configured ones must not be used instead
+	chain.ElectedErr = consensus.ErrUnknownAncestor
+	if validators, err := engine.Validators(chain, 1); err != consensus.ErrUnknownAncestor {
+		t.Errorf("validators %v %v, want %v", validators, err, consensus.ErrUnknownAncestor)
+	}
+	if _, err := engine.Proposer(chain, 1, 0); err != consensus.ErrUnknownAncestor {
+		t.Errorf("proposer: %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+	header := &block.Header{ParentHash: chain.CurrentHeader().Hash(), Number: types.NewBigInt(*big.NewInt(1)), Time: types.NewBigInt(*big.NewInt(1))}
+	if err := engine.Prepare(chain, header); err != consensus.ErrUnknownAncestor {
+		t.Errorf("prepare: %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+	block.SignHeaderInner(header, block.NewBlockSigner(config.ChainId), keys[0])
+	if err := engine.VerifySeal(chain, header); err != consensus.ErrUnknownAncestor {
+		t.Errorf("verify seal: %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+	if err := engine.VerifyCommit(chain, &Commit{Height: 1, BlockHash: header.Hash()}, 1, header.Hash()); err != consensus.ErrUnknownAncestor {
+		t.Errorf("verify commit: %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+}
+
+func TestAPI(t *testing.T) {
+	keys, config := newTestConfig(4)
+	net := newTestNetwork(t, keys, config)
+	defer net.stop()
+
+	net.waitHeight(t, 3)
+
+	n := net.nodes[0]
+	api := n.engine.APIs(n.chain)[0].Service.(*API)
+
+	number := rpc.BlockNumber(2)
+	validators, err := api.GetValidators(&number)
+	if err != nil || len(validators) != 4 {
+		t.Fatalf("validators: %v %v", validators, err)
+	}
+	proposer, _ := n.engine.Proposer(n.chain, 2, 0)
+	if signer, err := api.GetSigner(&number); err != nil || signer != proposer {
+		t.Errorf("signer of block 2: %x %v", signer, err)
+	}
+	if commit, err := api.GetCommit(&number); err != nil || commit == nil || commit.Height != 2 {
+		t.Errorf("commit of block 2: %v %v", commit, err)
+	}
+	proposers, err := api.GetProposers(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := n.chain.CurrentHeader().Number.IntVal.Uint64()
+	for i, proposer := range proposers {
+		if want := validators[(head+uint64(i)+1)%4]; proposer != want {
+			t.Errorf("proposer %d: %x, want %x", i, proposer, want)
+		}
+	}
+	status, err := api.GetVotes()
+	if err != nil || status.Height <= 3 || status.Validators != 4 {
+		t.Errorf("round status: %+v %v", status, err)
+	}
+
+	n.engine.Stop()
+	if _, err := api.GetVotes(); err != errNotStarted {
+		t.Errorf("stopped engine status: %v, want %v", err, errNotStarted)
+	}
+}
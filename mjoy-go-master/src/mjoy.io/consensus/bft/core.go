@@ -0,0 +1,644 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: core.go
+// @Date: 2018/07/16 10:12:40
+////////////////////////////////////////////////////////////////////////////////
+
+package bft
+
+import (
+	"sync"
+	"time"
+
+	"mjoy.io/common/types"
+	"mjoy.io/consensus"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/crypto/bls"
+)
+
+type step uint8
+
+const (
+	stepPropose step = iota
+	stepPrevote
+	stepPrecommit
+)
+
+func (s step) String() string {
+	switch s {
+	case stepPropose:
+		return "propose"
+	case stepPrevote:
+		return "prevote"
+	case stepPrecommit:
+		return "precommit"
+	}
+	return "unknown"
+}
+
+const (
+	// max number of messages of the next height kept until the height starts
+	maxFutureMessages = 1024
+)
+
+// message is a proposal or a vote received from a peer or made locally
+type message struct {
+	proposal *Proposal
+	vote     *Vote
+}
+
+func (m *message) height() uint64 {
+	if m.proposal != nil {
+		return m.proposal.Height
+	}
+	return m.vote.Height
+}
+
+type timeoutEvent struct {
+	height uint64
+	round  uint64
+	step   step
+}
+
+// voteSet is the votes of one type in a round
+type voteSet struct {
+	votes  map[types.Address]*Vote
+	counts map[types.Hash]int
+}
+
+func newVoteSet() *voteSet {
+	return &voteSet{votes: make(map[types.Address]*Vote), counts: make(map[types.Hash]int)}
+}
+
+// add returns false if the validator has already voted in the round
+func (s *voteSet) add(signer types.Address, vote *Vote) bool {
+	if _, ok := s.votes[signer]; ok {
+		return false
+	}
+	s.votes[signer] = vote
+	s.counts[vote.BlockHash]++
+	return true
+}
+
+// tally returns the votes of the set by block
+func (s *voteSet) tally() []VoteTally {
+	byHash := make(map[types.Hash][]types.Address)
+	for signer, vote := range s.votes {
+		byHash[vote.BlockHash] = append(byHash[vote.BlockHash], signer)
+	}
+	tallies := make([]VoteTally, 0, len(byHash))
+	for hash, voters := range byHash {
+		tallies = append(tallies, VoteTally{BlockHash: hash, Voters: voters})
+	}
+	return tallies
+}
+
+// roundState is what is known about a round of the current height
+type roundState struct {
+	proposal      *Proposal
+	proposalValid bool
+	prevotes      *voteSet
+	precommits    *voteSet
+
+	// the rules applied once a round
+	proposed      bool
+	prevoteWait   bool
+	precommitWait bool
+	polkaSeen     bool
+}
+
+func newRoundState() *roundState {
+	return &roundState{prevotes: newVoteSet(), precommits: newVoteSet()}
+}
+
+// core runs the rounds of the heights one by one. A height starts with the proposal
+// of round 0; a validator prevotes the proposed block if it is valid and not
+// conflicting with its lock, precommits it and locks on it after more than 2/3
+// prevotes, and the block is committed after more than 2/3 precommits. A round without
+// decision times out and the next one starts with the next proposer.
+type core struct {
+	engine *Bft
+	chain  consensus.ChainReader
+
+	msgCh       chan *message
+	timeoutCh   chan timeoutEvent
+	candidateCh chan struct{}
+	statusCh    chan chan *RoundStatus
+	quit        chan struct{}
+	wg          sync.WaitGroup
+
+	candidateMu sync.Mutex
+	candidate   *block.Block // the block built by the local producer
+
+	// state of the current height, only used by the loop
+	parent      *block.Header
+	height      uint64
+	validators  []types.Address
+	round       uint64
+	step        step
+	rounds      map[uint64]*roundState
+	lockedRound int64
+	lockedBlock *block.Block
+	validRound  int64
+	validBlock  *block.Block
+	known       map[types.Hash]bool
+	future      []*message
+}
+
+func newCore(engine *Bft, chain consensus.ChainReader) *core {
+	return &core{
+		engine:      engine,
+		chain:       chain,
+		msgCh:       make(chan *message, 256),
+		timeoutCh:   make(chan timeoutEvent, 16),
+		candidateCh: make(chan struct{}, 1),
+		statusCh:    make(chan chan *RoundStatus),
+		quit:        make(chan struct{}),
+	}
+}
+
+func (c *core) start(head *block.Header) {
+	c.newHeight(head)
+	c.wg.Add(1)
+	go c.loop()
+}
+
+func (c *core) stop() {
+	close(c.quit)
+	c.wg.Wait()
+}
+
+// post hands a message received from a peer to the loop
+func (c *core) post(msg *message) {
+	select {
+	case c.msgCh <- msg:
+	case <-c.quit:
+	}
+}
+
+func (c *core) setCandidate(blk *block.Block) {
+	c.candidateMu.Lock()
+	c.candidate = blk
+	c.candidateMu.Unlock()
+	select {
+	case c.candidateCh <- struct{}{}:
+	default:
+	}
+}
+
+func (c *core) getCandidate() *block.Block {
+	c.candidateMu.Lock()
+	defer c.candidateMu.Unlock()
+	return c.candidate
+}
+
+func (c *core) loop() {
+	defer c.wg.Done()
+	for {
+		select {
+		case msg := <-c.msgCh:
+			c.handleMessage(msg)
+		case <-c.candidateCh:
+			c.handleCandidate()
+		case ev := <-c.timeoutCh:
+			c.handleTimeout(ev)
+		case ch := <-c.statusCh:
+			ch <- c.roundStatus()
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// status returns the state of the current round, nil if the loop is stopped
+func (c *core) status() *RoundStatus {
+	ch := make(chan *RoundStatus, 1)
+	select {
+	case c.statusCh <- ch:
+		return <-ch
+	case <-c.quit:
+		return nil
+	}
+}
+
+func (c *core) roundStatus() *RoundStatus {
+	rs := c.roundState(c.round)
+	status := &RoundStatus{
+		Height:     c.height,
+		Round:      c.round,
+		Step:       c.step.String(),
+		Validators: len(c.validators),
+		Prevotes:   rs.prevotes.tally(),
+		Precommits: rs.precommits.tally(),
+	}
+	if rs.proposal != nil && rs.proposal.Block != nil {
+		hash := rs.proposal.Block.Hash()
+		status.Proposal = &hash
+	}
+	if c.lockedBlock != nil {
+		hash := c.lockedBlock.Hash()
+		status.Locked = &hash
+	}
+	return status
+}
+
+// newHeight starts the height after parent
+func (c *core) newHeight(parent *block.Header) {
+	c.parent = parent
+	c.height = parent.Number.IntVal.Uint64() + 1
+	validators, err := c.engine.Validators(c.chain, c.height)
+	if err != nil {
+		// no vote counts until the validators are known
+		logger.Error("Failed to read the validators", "number", c.height, "err", err)
+	}
+	c.validators = validators
+	c.rounds = make(map[uint64]*roundState)
+	c.lockedRound, c.lockedBlock = -1, nil
+	c.validRound, c.validBlock = -1, nil
+	c.known = make(map[types.Hash]bool)
+
+	future := c.future
+	c.future = nil
+	c.startRound(0)
+	for _, msg := range future {
+		c.handleMessage(msg)
+	}
+}
+
+func (c *core) roundState(round uint64) *roundState {
+	rs, ok := c.rounds[round]
+	if !ok {
+		rs = newRoundState()
+		c.rounds[round] = rs
+	}
+	return rs
+}
+
+func (c *core) self() (types.Address, bool) {
+	prv := c.engine.key()
+	if prv == nil {
+		return types.Address{}, false
+	}
+	address := crypto.PubkeyToAddress(prv.PublicKey)
+	return address, isValidator(c.validators, address)
+}
+
+func (c *core) startRound(round uint64) {
+	logger.Debug("Starting bft round", "height", c.height, "round", round)
+	c.round = round
+	c.step = stepPropose
+	c.scheduleTimeout(stepPropose)
+	c.propose()
+	c.check()
+}
+
+func (c *core) scheduleTimeout(step step) {
+	ev := timeoutEvent{c.height, c.round, step}
+	time.AfterFunc(c.engine.timeout(step, c.round), func() {
+		select {
+		case c.timeoutCh <- ev:
+		case <-c.quit:
+		}
+	})
+}
+
+// propose sends the proposal of the round if the local validator is its proposer,
+// the block found valid in an earlier round is proposed again
+func (c *core) propose() {
+	self, ok := c.self()
+	if !ok {
+		return
+	}
+	if proposer, err := c.engine.Proposer(c.chain, c.height, c.round); err != nil || proposer != self {
+		return
+	}
+	rs := c.roundState(c.round)
+	if rs.proposed {
+		return
+	}
+	blk, validRound := c.validBlock, c.validRound
+	if blk == nil {
+		blk, validRound = c.getCandidate(), -1
+		if blk == nil || blk.NumberU64() != c.height || blk.ParentHash() != c.parent.Hash() {
+			return
+		}
+	}
+	proposal := &Proposal{Height: c.height, Round: c.round, ValidRound: validRound, Block: blk}
+	if err := proposal.sign(c.engine.key()); err != nil {
+		logger.Error("Failed to sign proposal", "err", err)
+		return
+	}
+	rs.proposed = true
+	c.handleMessage(&message{proposal: proposal})
+}
+
+func (c *core) vote(voteType uint8, hash types.Hash) {
+	if _, ok := c.self(); !ok {
+		return
+	}
+	vote := &Vote{Type: voteType, Height: c.height, Round: c.round, BlockHash: hash}
+	if err := vote.sign(c.engine.key()); err != nil {
+		logger.Error("Failed to sign vote", "err", err)
+		return
+	}
+	if voteType == Precommit && c.engine.aggregating() {
+		vote.BlsSignature = SignPrecommit(c.engine.blsKey(), c.height, c.round, hash).Marshal()
+	}
+	c.handleMessage(&message{vote: vote})
+}
+
+func (c *core) handleCandidate() {
+	blk := c.getCandidate()
+	if blk == nil {
+		return
+	}
+	// the chain got ahead of the rounds, e.g. by a sync
+	if blk.NumberU64() > c.height {
+		if parent := c.chain.GetHeader(blk.ParentHash(), blk.NumberU64()-1); parent != nil {
+			c.newHeight(parent)
+			return
+		}
+	}
+	if c.step == stepPropose {
+		c.propose()
+		c.check()
+	}
+}
+
+func (c *core) handleTimeout(ev timeoutEvent) {
+	if head := c.chain.CurrentHeader(); head.Number.IntVal.Uint64() >= c.height {
+		c.newHeight(head)
+		return
+	}
+	if ev.height != c.height || ev.round != c.round || ev.step != c.step {
+		return
+	}
+	switch ev.step {
+	case stepPropose:
+		c.step = stepPrevote
+		c.vote(Prevote, types.Hash{})
+	case stepPrevote:
+		c.step = stepPrecommit
+		c.vote(Precommit, types.Hash{})
+	case stepPrecommit:
+		c.startRound(c.round + 1)
+		return
+	}
+	c.check()
+}
+
+func messageHash(msg *message) types.Hash {
+	if msg.proposal != nil {
+		return crypto.Keccak256Hash(msg.proposal.Signature)
+	}
+	return crypto.Keccak256Hash(msg.vote.Signature)
+}
+
+func (c *core) handleMessage(msg *message) {
+	if height := msg.height(); height != c.height {
+		if height == c.height+1 && len(c.future) < maxFutureMessages {
+			c.future = append(c.future, msg)
+		}
+		return
+	}
+	hash := messageHash(msg)
+	if c.known[hash] {
+		return
+	}
+	var ok bool
+	if msg.proposal != nil {
+		ok = c.addProposal(msg.proposal)
+	} else {
+		ok = c.addVote(msg.vote)
+	}
+	if !ok {
+		return
+	}
+	c.known[hash] = true
+	c.engine.peers.broadcast(msg)
+	c.check()
+}
+
+func (c *core) addProposal(p *Proposal) bool {
+	signer, err := p.Signer()
+	if err == nil {
+		var proposer types.Address
+		if proposer, err = c.engine.Proposer(c.chain, p.Height, p.Round); err == nil && signer != proposer {
+			err = ErrUnauthorized
+		}
+	}
+	if err != nil {
+		logger.Debug("Invalid proposal signer", "height", p.Height, "round", p.Round, "err", err)
+		return false
+	}
+	if p.ValidRound >= int64(p.Round) {
+		return false
+	}
+	rs := c.roundState(p.Round)
+	if rs.proposal != nil {
+		return false
+	}
+	rs.proposal = p
+	rs.proposalValid = c.validProposal(p.Block)
+	return true
+}
+
+func (c *core) validProposal(blk *block.Block) bool {
+	if blk.NumberU64() != c.height || blk.ParentHash() != c.parent.Hash() {
+		return false
+	}
+	if err := c.engine.verifyHeader(c.chain, blk.Header(), c.parent, true); err != nil {
+		logger.Debug("Invalid proposed header", "height", c.height, "err", err)
+		return false
+	}
+	if c.engine.validate != nil {
+		if err := c.engine.validate(blk); err != nil {
+			logger.Debug("Invalid proposed block", "height", c.height, "err", err)
+			return false
+		}
+	}
+	return true
+}
+
+func (c *core) addVote(v *Vote) bool {
+	if v.Type != Prevote && v.Type != Precommit {
+		return false
+	}
+	signer, err := v.Signer()
+	if err != nil || !isValidator(c.validators, signer) {
+		return false
+	}
+	// a precommit to aggregate has to carry a valid bls signature
+	if v.Type == Precommit && c.engine.aggregating() && !c.engine.verifyBlsPrecommit(signer, v) {
+		return false
+	}
+	rs := c.roundState(v.Round)
+	if v.Type == Prevote {
+		return rs.prevotes.add(signer, v)
+	}
+	return rs.precommits.add(signer, v)
+}
+
+func (c *core) quorum(count int) bool {
+	return quorum(count, len(c.validators))
+}
+
+// proposalBlock returns the valid block with hash proposed in a round of the height
+func (c *core) proposalBlock(hash types.Hash) *block.Block {
+	for _, rs := range c.rounds {
+		if rs.proposal != nil && rs.proposalValid && rs.proposal.Block.Hash() == hash {
+			return rs.proposal.Block
+		}
+	}
+	return nil
+}
+
+// check applies the rules of the algorithm to the known messages
+func (c *core) check() {
+	// a block precommitted in any round by more than 2/3 is committed
+	for round, rs := range c.rounds {
+		for hash, count := range rs.precommits.counts {
+			if hash == (types.Hash{}) || !c.quorum(count) {
+				continue
+			}
+			if blk := c.proposalBlock(hash); blk != nil {
+				c.commit(blk, round, rs.precommits)
+				return
+			}
+		}
+	}
+
+	// more than 1/3 of the validators are in a later round
+	for round, rs := range c.rounds {
+		if round <= c.round {
+			continue
+		}
+		voters := make(map[types.Address]bool)
+		for signer := range rs.prevotes.votes {
+			voters[signer] = true
+		}
+		for signer := range rs.precommits.votes {
+			voters[signer] = true
+		}
+		if 3*len(voters) > len(c.validators) {
+			c.startRound(round)
+			return
+		}
+	}
+
+	rs := c.roundState(c.round)
+	p := rs.proposal
+
+	if c.step == stepPropose && p != nil {
+		hash := p.Block.Hash()
+		if p.ValidRound < 0 {
+			if !rs.proposalValid || (c.lockedRound >= 0 && c.lockedBlock.Hash() != hash) {
+				hash = types.Hash{}
+			}
+			c.step = stepPrevote
+			c.vote(Prevote, hash)
+			return
+		}
+		if pol, ok := c.rounds[uint64(p.ValidRound)]; ok && c.quorum(pol.prevotes.counts[hash]) {
+			if !rs.proposalValid || (c.lockedRound > p.ValidRound && c.lockedBlock.Hash() != hash) {
+				hash = types.Hash{}
+			}
+			c.step = stepPrevote
+			c.vote(Prevote, hash)
+			return
+		}
+	}
+
+	if c.step == stepPrevote && !rs.prevoteWait && c.quorum(len(rs.prevotes.votes)) {
+		rs.prevoteWait = true
+		c.scheduleTimeout(stepPrevote)
+	}
+
+	if c.step >= stepPrevote && p != nil && rs.proposalValid && !rs.polkaSeen {
+		hash := p.Block.Hash()
+		if c.quorum(rs.prevotes.counts[hash]) {
+			rs.polkaSeen = true
+			c.validRound, c.validBlock = int64(c.round), p.Block
+			if c.step == stepPrevote {
+				c.lockedRound, c.lockedBlock = int64(c.round), p.Block
+				c.step = stepPrecommit
+				c.vote(Precommit, hash)
+			}
+			return
+		}
+	}
+
+	if c.step == stepPrevote && c.quorum(rs.prevotes.counts[types.Hash{}]) {
+		c.step = stepPrecommit
+		c.vote(Precommit, types.Hash{})
+		return
+	}
+
+	if !rs.precommitWait && c.quorum(len(rs.precommits.votes)) {
+		rs.precommitWait = true
+		c.scheduleTimeout(stepPrecommit)
+	}
+}
+
+// commit finalizes the block with the precommits and starts the next height
+func (c *core) commit(blk *block.Block, round uint64, precommits *voteSet) {
+	hash := blk.Hash()
+	commit := &Commit{Height: c.height, Round: round, BlockHash: hash}
+	if c.engine.aggregating() {
+		var err error
+		if commit, err = c.aggregate(round, hash, precommits); err != nil {
+			logger.Error("Failed to aggregate the precommits", "number", c.height, "hash", hash, "err", err)
+			return
+		}
+	} else {
+		for _, vote := range precommits.votes {
+			if vote.BlockHash == hash {
+				commit.Signatures = append(commit.Signatures, vote.Signature)
+			}
+		}
+	}
+	logger.Info("Committed block", "number", c.height, "hash", hash, "round", round, "precommits", precommits.counts[hash])
+	c.engine.addCommit(commit)
+
+	if c.engine.commit != nil {
+		if err := c.engine.commit(blk); err != nil {
+			logger.Error("Failed to insert committed block", "number", c.height, "hash", hash, "err", err)
+		}
+	}
+	c.newHeight(blk.Header())
+}
+
+// aggregate aggregates the bls signatures of the precommits for the block hash, they
+// are checked by addVote
+func (c *core) aggregate(round uint64, hash types.Hash, precommits *voteSet) (*Commit, error) {
+	aggregate := NewAggregateCommit(c.height, round, hash, len(c.validators))
+	for i, validator := range c.validators {
+		vote, ok := precommits.votes[validator]
+		if !ok || vote.BlockHash != hash {
+			continue
+		}
+		sig, err := bls.ToSignature(vote.BlsSignature)
+		if err != nil {
+			return nil, err
+		}
+		if err := aggregate.Add(i, sig); err != nil {
+			return nil, err
+		}
+	}
+	return aggregate.Commit(), nil
+}
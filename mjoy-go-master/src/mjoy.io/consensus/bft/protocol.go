@@ -0,0 +1,175 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: protocol.go
+// @Date: 2018/07/16 10:12:40
+////////////////////////////////////////////////////////////////////////////////
+
+package bft
+
+import (
+	"fmt"
+	"sync"
+
+	"mjoy.io/communication/p2p"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	ProtocolName    = "bft"
+	ProtocolVersion = 1
+	ProtocolLength  = 2
+
+	ProposalMsg = 0x00
+	VoteMsg     = 0x01
+
+	// Maximum cap on the size of a protocol message
+	ProtocolMaxMsgSize = 10 * 1024 * 1024
+
+	// messages queued for a peer, more are dropped
+	maxQueuedMsgs = 256
+)
+
+type peerMsg struct {
+	code uint64
+	data interface{}
+}
+
+type peer struct {
+	id    string
+	rw    p2p.MsgReadWriter
+	queue chan peerMsg
+	term  chan struct{}
+}
+
+type peerSet struct {
+	lock  sync.RWMutex
+	peers map[string]*peer
+}
+
+func newPeerSet() *peerSet {
+	return &peerSet{peers: make(map[string]*peer)}
+}
+
+func (ps *peerSet) register(p *peer) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if _, ok := ps.peers[p.id]; ok {
+		return fmt.Errorf("bft peer %s already registered", p.id)
+	}
+	ps.peers[p.id] = p
+	return nil
+}
+
+func (ps *peerSet) unregister(id string) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	delete(ps.peers, id)
+}
+
+// broadcast queues the message for all the peers, they drop the messages they know
+func (ps *peerSet) broadcast(msg *message) {
+	pm := peerMsg{VoteMsg, msg.vote}
+	if msg.proposal != nil {
+		pm = peerMsg{ProposalMsg, msg.proposal}
+	}
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+	for _, p := range ps.peers {
+		select {
+		case p.queue <- pm:
+		default:
+			logger.Debug("Dropped bft message for slow peer", "peer", p.id)
+		}
+	}
+}
+
+func (p *peer) writeLoop() {
+	for {
+		select {
+		case msg := <-p.queue:
+			if err := p2p.Send(p.rw, msg.code, msg.data); err != nil {
+				return
+			}
+		case <-p.term:
+			return
+		}
+	}
+}
+
+// Protocol returns the p2p protocol carrying the proposals and votes, it runs beside
+// the mjoy protocol.
+func (b *Bft) Protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    ProtocolName,
+		Version: ProtocolVersion,
+		Length:  ProtocolLength,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			return b.handlePeer(p.ID().String(), rw)
+		},
+	}
+}
+
+func (b *Bft) handlePeer(id string, rw p2p.MsgReadWriter) error {
+	p := &peer{id: id, rw: rw, queue: make(chan peerMsg, maxQueuedMsgs), term: make(chan struct{})}
+	if err := b.peers.register(p); err != nil {
+		return err
+	}
+	defer b.peers.unregister(id)
+	go p.writeLoop()
+	defer close(p.term)
+
+	for {
+		if err := b.handleMsg(rw); err != nil {
+			logger.Debug("Bft message handling failed", "peer", id, "err", err)
+			return err
+		}
+	}
+}
+
+func (b *Bft) handleMsg(rw p2p.MsgReadWriter) error {
+	msg, err := rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return fmt.Errorf("message too large: %v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	m := &message{}
+	switch msg.Code {
+	case ProposalMsg:
+		m.proposal = &Proposal{}
+		if err := msg.Decode(m.proposal); err != nil {
+			return err
+		}
+		if m.proposal.Block == nil {
+			return fmt.Errorf("proposal without block")
+		}
+	case VoteMsg:
+		m.vote = &Vote{}
+		if err := msg.Decode(m.vote); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid message code %d", msg.Code)
+	}
+	if c := b.getCore(); c != nil {
+		c.post(m)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: types.go
+// @Date: 2018/07/16 10:12:40
+////////////////////////////////////////////////////////////////////////////////
+
+package bft
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+
+	"github.com/tinylib/msgp/msgp"
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/utils/crypto"
+)
+
+//go:generate msgp
+
+// ConsensusId is the Id of the ConsensusData of the bft blocks
+const ConsensusId = "bft"
+
+// vote types
+const (
+	Prevote   uint8 = 1
+	Precommit uint8 = 2
+)
+
+var (
+	// ErrInvalidConsensusData is returned if the ConsensusData of a header is
+	// not a bft one.
+	ErrInvalidConsensusData = errors.New("invalid bft consensus data")
+
+	// ErrInvalidCommit is returned if the commit certificate of a header is not
+	// signed by more than 2/3 of the validators for its parent.
+	ErrInvalidCommit = errors.New("invalid commit certificate")
+
+	// ErrUnauthorized is returned if a header or a message is signed by a non-validator.
+	ErrUnauthorized = errors.New("unauthorized validator")
+
+	// ErrNoValidators is returned if a block has no validator to propose it,
+	// e.g. for a config without validators.
+	ErrNoValidators = errors.New("no validators")
+)
+
+// Proposal is the block proposed by the proposer of a round. ValidRound is the
+// round the block got the prevotes of more than 2/3 of the validators in, -1 if none.
+type Proposal struct {
+	Height     uint64
+	Round      uint64
+	ValidRound int64
+	Block      *block.Block
+	Signature  []byte
+}
+
+// Vote is a prevote or a precommit of a validator, a zero BlockHash is a vote for no block.
+// On a chain aggregating the commits a precommit is also signed with the bls key of the
+// validator, BlsSignature signs the same hash as Signature.
+type Vote struct {
+	Type         uint8
+	Height       uint64
+	Round        uint64
+	BlockHash    types.Hash
+	Signature    []byte
+	BlsSignature []byte
+}
+
+// Commit is the certificate of a block: the precommits of more than 2/3 of the
+// validators for it in a round. On a chain aggregating the commits, the precommits
+// are the Aggregate of their bls signatures and Bitmap the signers by their order in
+// the validator set, the form of an AggregateCommit.
+type Commit struct {
+	Height     uint64
+	Round      uint64
+	BlockHash  types.Hash
+	Signatures [][]byte
+	Bitmap     []byte
+	Aggregate  []byte
+}
+
+// ConsensusData is the Para of the ConsensusData of a bft block. The hash of a block
+// covers its ConsensusData, so a block carries the commit of its parent.
+type ConsensusData struct {
+	LastCommit *Commit
+}
+
+func sigHash(kind uint8, height, round uint64, extra []byte, hash types.Hash) types.Hash {
+	buf := make([]byte, 17)
+	buf[0] = kind
+	binary.BigEndian.PutUint64(buf[1:], height)
+	binary.BigEndian.PutUint64(buf[9:], round)
+	return crypto.Keccak256Hash(buf, extra, hash[:])
+}
+
+func recoverSigner(hash types.Hash, sig []byte) (types.Address, error) {
+	pub, err := crypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return types.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// SigHash is the hash signed by the proposer.
+func (p *Proposal) SigHash() types.Hash {
+	validRound := make([]byte, 8)
+	binary.BigEndian.PutUint64(validRound, uint64(p.ValidRound))
+	return sigHash(0, p.Height, p.Round, validRound, p.Block.Hash())
+}
+
+func (p *Proposal) sign(prv *ecdsa.PrivateKey) (err error) {
+	hash := p.SigHash()
+	p.Signature, err = crypto.Sign(hash[:], prv)
+	return err
+}
+
+// Signer returns the address signing the proposal.
+func (p *Proposal) Signer() (types.Address, error) {
+	if p.Block == nil {
+		return types.Address{}, errors.New("proposal without block")
+	}
+	return recoverSigner(p.SigHash(), p.Signature)
+}
+
+// SigHash is the hash signed by the voter, it does not depend on the signature.
+func (v *Vote) SigHash() types.Hash {
+	return sigHash(v.Type, v.Height, v.Round, nil, v.BlockHash)
+}
+
+func (v *Vote) sign(prv *ecdsa.PrivateKey) (err error) {
+	hash := v.SigHash()
+	v.Signature, err = crypto.Sign(hash[:], prv)
+	return err
+}
+
+// Signer returns the address signing the vote.
+func (v *Vote) Signer() (types.Address, error) {
+	return recoverSigner(v.SigHash(), v.Signature)
+}
+
+// Signers returns the validators signing the precommits of the commit, an error is
+// returned if a signature is invalid or a validator signs more than once.
+func (c *Commit) Signers() ([]types.Address, error) {
+	hash := (&Vote{Type: Precommit, Height: c.Height, Round: c.Round, BlockHash: c.BlockHash}).SigHash()
+	signers := make([]types.Address, 0, len(c.Signatures))
+	seen := make(map[types.Address]bool)
+	for _, sig := range c.Signatures {
+		signer, err := recoverSigner(hash, sig)
+		if err != nil {
+			return nil, ErrInvalidCommit
+		}
+		if seen[signer] {
+			return nil, ErrInvalidCommit
+		}
+		seen[signer] = true
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// DecodeConsensusData decodes the bft data of a header.
+func DecodeConsensusData(data *block.ConsensusData) (*ConsensusData, error) {
+	if data.Id != ConsensusId {
+		return nil, ErrInvalidConsensusData
+	}
+	cd := &ConsensusData{}
+	if err := msgp.Decode(bytes.NewReader(data.Para), cd); err != nil {
+		return nil, ErrInvalidConsensusData
+	}
+	return cd, nil
+}
+
+// DecodeCommit decodes the commit of the parent carried by a header, nil for a child
+// of the genesis. An aggregate commit is returned in the form kept by the engine.
+func DecodeCommit(data *block.ConsensusData) (*Commit, error) {
+	if data.Id == AggregateConsensusId {
+		aggregate, err := DecodeAggregateCommit(data)
+		if err != nil {
+			return nil, err
+		}
+		return aggregate.Commit(), nil
+	}
+	cd, err := DecodeConsensusData(data)
+	if err != nil {
+		return nil, err
+	}
+	return cd.LastCommit, nil
+}
+
+// Encode encodes the data for a header.
+func (cd *ConsensusData) Encode() (block.ConsensusData, error) {
+	var buf bytes.Buffer
+	if err := msgp.Encode(&buf, cd); err != nil {
+		return block.ConsensusData{}, err
+	}
+	return block.ConsensusData{Id: ConsensusId, Para: buf.Bytes()}, nil
+}
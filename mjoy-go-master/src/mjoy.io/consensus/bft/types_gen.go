@@ -0,0 +1,820 @@
+package bft
+
+// NOTE: THIS FILE WAS PRODUCED BY THE
+// MSGP CODE GENERATION TOOL (github.com/tinylib/msgp)
+// DO NOT EDIT
+
+import (
+	"github.com/tinylib/msgp/msgp"
+	"mjoy.io/core/blockchain/block"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Commit) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Height":
+			z.Height, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		case "Round":
+			z.Round, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		case "BlockHash":
+			err = z.BlockHash.DecodeMsg(dc)
+			if err != nil {
+				return
+			}
+		case "Signatures":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Signatures) >= int(zb0002) {
+				z.Signatures = (z.Signatures)[:zb0002]
+			} else {
+				z.Signatures = make([][]byte, zb0002)
+			}
+			for za0001 := range z.Signatures {
+				z.Signatures[za0001], err = dc.ReadBytes(z.Signatures[za0001])
+				if err != nil {
+					return
+				}
+			}
+		case "Bitmap":
+			z.Bitmap, err = dc.ReadBytes(z.Bitmap)
+			if err != nil {
+				return
+			}
+		case "Aggregate":
+			z.Aggregate, err = dc.ReadBytes(z.Aggregate)
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Commit) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 6
+	// write "Height"
+	err = en.Append(0x86, 0xa6, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Height)
+	if err != nil {
+		return
+	}
+	// write "Round"
+	err = en.Append(0xa5, 0x52, 0x6f, 0x75, 0x6e, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Round)
+	if err != nil {
+		return
+	}
+	// write "BlockHash"
+	err = en.Append(0xa9, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.BlockHash.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	// write "Signatures"
+	err = en.Append(0xaa, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Signatures)))
+	if err != nil {
+		return
+	}
+	for za0001 := range z.Signatures {
+		err = en.WriteBytes(z.Signatures[za0001])
+		if err != nil {
+			return
+		}
+	}
+	// write "Bitmap"
+	err = en.Append(0xa6, 0x42, 0x69, 0x74, 0x6d, 0x61, 0x70)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Bitmap)
+	if err != nil {
+		return
+	}
+	// write "Aggregate"
+	err = en.Append(0xa9, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Aggregate)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Commit) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 6
+	// string "Height"
+	o = append(o, 0x86, 0xa6, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74)
+	o = msgp.AppendUint64(o, z.Height)
+	// string "Round"
+	o = append(o, 0xa5, 0x52, 0x6f, 0x75, 0x6e, 0x64)
+	o = msgp.AppendUint64(o, z.Round)
+	// string "BlockHash"
+	o = append(o, 0xa9, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68)
+	o, err = z.BlockHash.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	// string "Signatures"
+	o = append(o, 0xaa, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Signatures)))
+	for za0001 := range z.Signatures {
+		o = msgp.AppendBytes(o, z.Signatures[za0001])
+	}
+	// string "Bitmap"
+	o = append(o, 0xa6, 0x42, 0x69, 0x74, 0x6d, 0x61, 0x70)
+	o = msgp.AppendBytes(o, z.Bitmap)
+	// string "Aggregate"
+	o = append(o, 0xa9, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65)
+	o = msgp.AppendBytes(o, z.Aggregate)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Commit) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Height":
+			z.Height, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "Round":
+			z.Round, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "BlockHash":
+			bts, err = z.BlockHash.UnmarshalMsg(bts)
+			if err != nil {
+				return
+			}
+		case "Signatures":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Signatures) >= int(zb0002) {
+				z.Signatures = (z.Signatures)[:zb0002]
+			} else {
+				z.Signatures = make([][]byte, zb0002)
+			}
+			for za0001 := range z.Signatures {
+				z.Signatures[za0001], bts, err = msgp.ReadBytesBytes(bts, z.Signatures[za0001])
+				if err != nil {
+					return
+				}
+			}
+		case "Bitmap":
+			z.Bitmap, bts, err = msgp.ReadBytesBytes(bts, z.Bitmap)
+			if err != nil {
+				return
+			}
+		case "Aggregate":
+			z.Aggregate, bts, err = msgp.ReadBytesBytes(bts, z.Aggregate)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Commit) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 6 + msgp.Uint64Size + 10 + z.BlockHash.Msgsize() + 11 + msgp.ArrayHeaderSize
+	for za0001 := range z.Signatures {
+		s += msgp.BytesPrefixSize + len(z.Signatures[za0001])
+	}
+	s += 7 + msgp.BytesPrefixSize + len(z.Bitmap) + 10 + msgp.BytesPrefixSize + len(z.Aggregate)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ConsensusData) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "LastCommit":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					return
+				}
+				z.LastCommit = nil
+			} else {
+				if z.LastCommit == nil {
+					z.LastCommit = new(Commit)
+				}
+				err = z.LastCommit.DecodeMsg(dc)
+				if err != nil {
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ConsensusData) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "LastCommit"
+	err = en.Append(0x81, 0xaa, 0x4c, 0x61, 0x73, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74)
+	if err != nil {
+		return
+	}
+	if z.LastCommit == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.LastCommit.EncodeMsg(en)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ConsensusData) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "LastCommit"
+	o = append(o, 0x81, 0xaa, 0x4c, 0x61, 0x73, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74)
+	if z.LastCommit == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.LastCommit.MarshalMsg(o)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ConsensusData) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "LastCommit":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.LastCommit = nil
+			} else {
+				if z.LastCommit == nil {
+					z.LastCommit = new(Commit)
+				}
+				bts, err = z.LastCommit.UnmarshalMsg(bts)
+				if err != nil {
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ConsensusData) Msgsize() (s int) {
+	s = 1 + 11
+	if z.LastCommit == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.LastCommit.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Proposal) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Height":
+			z.Height, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		case "Round":
+			z.Round, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		case "ValidRound":
+			z.ValidRound, err = dc.ReadInt64()
+			if err != nil {
+				return
+			}
+		case "Block":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					return
+				}
+				z.Block = nil
+			} else {
+				if z.Block == nil {
+					z.Block = new(block.Block)
+				}
+				err = z.Block.DecodeMsg(dc)
+				if err != nil {
+					return
+				}
+			}
+		case "Signature":
+			z.Signature, err = dc.ReadBytes(z.Signature)
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Proposal) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 5
+	// write "Height"
+	err = en.Append(0x85, 0xa6, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Height)
+	if err != nil {
+		return
+	}
+	// write "Round"
+	err = en.Append(0xa5, 0x52, 0x6f, 0x75, 0x6e, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Round)
+	if err != nil {
+		return
+	}
+	// write "ValidRound"
+	err = en.Append(0xaa, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x52, 0x6f, 0x75, 0x6e, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.ValidRound)
+	if err != nil {
+		return
+	}
+	// write "Block"
+	err = en.Append(0xa5, 0x42, 0x6c, 0x6f, 0x63, 0x6b)
+	if err != nil {
+		return
+	}
+	if z.Block == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Block.EncodeMsg(en)
+		if err != nil {
+			return
+		}
+	}
+	// write "Signature"
+	err = en.Append(0xa9, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Signature)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Proposal) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 5
+	// string "Height"
+	o = append(o, 0x85, 0xa6, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74)
+	o = msgp.AppendUint64(o, z.Height)
+	// string "Round"
+	o = append(o, 0xa5, 0x52, 0x6f, 0x75, 0x6e, 0x64)
+	o = msgp.AppendUint64(o, z.Round)
+	// string "ValidRound"
+	o = append(o, 0xaa, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x52, 0x6f, 0x75, 0x6e, 0x64)
+	o = msgp.AppendInt64(o, z.ValidRound)
+	// string "Block"
+	o = append(o, 0xa5, 0x42, 0x6c, 0x6f, 0x63, 0x6b)
+	if z.Block == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Block.MarshalMsg(o)
+		if err != nil {
+			return
+		}
+	}
+	// string "Signature"
+	o = append(o, 0xa9, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65)
+	o = msgp.AppendBytes(o, z.Signature)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Proposal) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Height":
+			z.Height, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "Round":
+			z.Round, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "ValidRound":
+			z.ValidRound, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "Block":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Block = nil
+			} else {
+				if z.Block == nil {
+					z.Block = new(block.Block)
+				}
+				bts, err = z.Block.UnmarshalMsg(bts)
+				if err != nil {
+					return
+				}
+			}
+		case "Signature":
+			z.Signature, bts, err = msgp.ReadBytesBytes(bts, z.Signature)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Proposal) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 6 + msgp.Uint64Size + 11 + msgp.Int64Size + 6
+	if z.Block == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Block.Msgsize()
+	}
+	s += 10 + msgp.BytesPrefixSize + len(z.Signature)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Vote) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Type":
+			z.Type, err = dc.ReadUint8()
+			if err != nil {
+				return
+			}
+		case "Height":
+			z.Height, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		case "Round":
+			z.Round, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		case "BlockHash":
+			err = z.BlockHash.DecodeMsg(dc)
+			if err != nil {
+				return
+			}
+		case "Signature":
+			z.Signature, err = dc.ReadBytes(z.Signature)
+			if err != nil {
+				return
+			}
+		case "BlsSignature":
+			z.BlsSignature, err = dc.ReadBytes(z.BlsSignature)
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Vote) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 6
+	// write "Type"
+	err = en.Append(0x86, 0xa4, 0x54, 0x79, 0x70, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint8(z.Type)
+	if err != nil {
+		return
+	}
+	// write "Height"
+	err = en.Append(0xa6, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Height)
+	if err != nil {
+		return
+	}
+	// write "Round"
+	err = en.Append(0xa5, 0x52, 0x6f, 0x75, 0x6e, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Round)
+	if err != nil {
+		return
+	}
+	// write "BlockHash"
+	err = en.Append(0xa9, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.BlockHash.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	// write "Signature"
+	err = en.Append(0xa9, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Signature)
+	if err != nil {
+		return
+	}
+	// write "BlsSignature"
+	err = en.Append(0xac, 0x42, 0x6c, 0x73, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.BlsSignature)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Vote) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 6
+	// string "Type"
+	o = append(o, 0x86, 0xa4, 0x54, 0x79, 0x70, 0x65)
+	o = msgp.AppendUint8(o, z.Type)
+	// string "Height"
+	o = append(o, 0xa6, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74)
+	o = msgp.AppendUint64(o, z.Height)
+	// string "Round"
+	o = append(o, 0xa5, 0x52, 0x6f, 0x75, 0x6e, 0x64)
+	o = msgp.AppendUint64(o, z.Round)
+	// string "BlockHash"
+	o = append(o, 0xa9, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68)
+	o, err = z.BlockHash.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	// string "Signature"
+	o = append(o, 0xa9, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65)
+	o = msgp.AppendBytes(o, z.Signature)
+	// string "BlsSignature"
+	o = append(o, 0xac, 0x42, 0x6c, 0x73, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65)
+	o = msgp.AppendBytes(o, z.BlsSignature)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Vote) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Type":
+			z.Type, bts, err = msgp.ReadUint8Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "Height":
+			z.Height, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "Round":
+			z.Round, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "BlockHash":
+			bts, err = z.BlockHash.UnmarshalMsg(bts)
+			if err != nil {
+				return
+			}
+		case "Signature":
+			z.Signature, bts, err = msgp.ReadBytesBytes(bts, z.Signature)
+			if err != nil {
+				return
+			}
+		case "BlsSignature":
+			z.BlsSignature, bts, err = msgp.ReadBytesBytes(bts, z.BlsSignature)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Vote) Msgsize() (s int) {
+	s = 1 + 5 + msgp.Uint8Size + 7 + msgp.Uint64Size + 6 + msgp.Uint64Size + 10 + z.BlockHash.Msgsize() + 10 + msgp.BytesPrefixSize + len(z.Signature) + 13 + msgp.BytesPrefixSize + len(z.BlsSignature)
+	return
+}
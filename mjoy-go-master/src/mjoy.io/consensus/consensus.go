@@ -26,6 +26,7 @@ import (
 	"mjoy.io/params"
 	"mjoy.io/core/state"
 	"mjoy.io/core/transaction"
+	"mjoy.io/communication/rpc"
 	"errors"
 )
 
@@ -49,6 +50,10 @@ type ChainReader interface {
 
 	// GetBlock retrieves a block from the database by hash and number.
 	GetBlock(hash types.Hash, number uint64) *block.Block
+
+	// ElectedValidators retrieves the validators elected by stake for the block
+	// number, none if no candidate has enough stake.
+	ElectedValidators(number uint64) ([]types.Address, error)
 }
 
 // Engine is an algorithm agnostic consensus engine.
@@ -80,15 +85,39 @@ type Engine interface {
 	// Finalize runs any post-transaction state modifications (e.g. block rewards)
 	// and assembles the final block.
 	// Note: The block header and state database might be updated to reflect any
-	// consensus rules that happen at finalization (e.g. block rewards).
-	Finalize(chain ChainReader, header *block.Header, state *state.StateDB, txs []*transaction.Transaction, receipts []*transaction.Receipt, sign bool) (*block.Block, error)
+	// consensus rules that happen at finalization (e.g. block rewards). The rewards
+	// are paid with rewards, no reward is paid if it is nil.
+	Finalize(chain ChainReader, header *block.Header, state *state.StateDB, txs []*transaction.Transaction, receipts []*transaction.Receipt, rewards *RewardState, sign bool) (*block.Block, error)
 
 	// Seal generates a new block for the given input block with the local blockproducer's
 	// seal place on top.
 	Seal(chain ChainReader, block *block.Block, stop <-chan struct{}) (*block.Block, error)
 
 	// APIs returns the RPC APIs this consensus engine provides.
-	//APIs(chain ChainReader) []rpc.API
+	APIs(chain ChainReader) []rpc.API
+}
+
+// Weigher is implemented by the engines weighing the blocks by their signers, the
+// heaviest chain fork choice prefers the branch with the most weight.
+type Weigher interface {
+	// Weight returns the weight of the signer of header in the validator set of
+	// its parent.
+	Weight(chain ChainReader, header *block.Header) (uint64, error)
+}
+
+// Finalizer is implemented by the engines giving finality to blocks. The chain
+// never drops a finalized block from the canonical chain.
+type Finalizer interface {
+	// Finalized returns the number and hash of the last finalized block, ok is
+	// false if no block is finalized yet.
+	Finalized() (number uint64, hash types.Hash, ok bool)
+}
+
+// Importer is implemented by the engines keeping data of the blocks the chain
+// imports. The chain calls Imported once a block is fully validated and written,
+// the data of a header only verified may still belong to an invalid block.
+type Importer interface {
+	Imported(chain ChainReader, header *block.Header)
 }
 
 
@@ -104,4 +133,8 @@ var (
 	// ErrInvalidNumber is returned if a block's number doesn't equal it's parent's
 	// plus one.
 	ErrInvalidNumber = errors.New("invalid block number")
+
+	// ErrNotInTurn is returned by Prepare if the local key does not own the
+	// proposer slot of the block.
+	ErrNotInTurn = errors.New("not in turn")
 )
@@ -0,0 +1,161 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: chain.go
+// @Date: 2018/07/30 14:20:05
+////////////////////////////////////////////////////////////////////////////////
+
+// Package consensustest provides the chain the tests of the consensus engines
+// produce and verify blocks on.
+package consensustest
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"mjoy.io/common/types"
+	"mjoy.io/consensus"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+	"mjoy.io/utils/database"
+)
+
+// Chain is a chain of blocks kept in memory, it implements consensus.ChainReader.
+// Elected and ElectedErr are returned by ElectedValidators.
+type Chain struct {
+	config *params.ChainConfig
+	mu     sync.RWMutex
+	blocks []*block.Block
+
+	Elected    []types.Address
+	ElectedErr error
+}
+
+// NewChain returns a chain holding a genesis of time genesisTime.
+func NewChain(config *params.ChainConfig, genesisTime int64) *Chain {
+	genesis := &block.Header{Number: types.NewBigInt(*big.NewInt(0)), Time: types.NewBigInt(*big.NewInt(genesisTime))}
+	return &Chain{config: config, blocks: []*block.Block{block.NewBlockWithHeader(genesis)}}
+}
+
+func (c *Chain) Config() *params.ChainConfig { return c.config }
+
+func (c *Chain) CurrentHeader() *block.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.blocks[len(c.blocks)-1].Header()
+}
+
+func (c *Chain) GetHeader(hash types.Hash, number uint64) *block.Header {
+	if blk := c.GetBlock(hash, number); blk != nil {
+		return blk.Header()
+	}
+	return nil
+}
+
+func (c *Chain) GetHeaderByNumber(number uint64) *block.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if number < uint64(len(c.blocks)) {
+		return c.blocks[number].Header()
+	}
+	return nil
+}
+
+func (c *Chain) GetHeaderByHash(hash types.Hash) *block.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, blk := range c.blocks {
+		if blk.Hash() == hash {
+			return blk.Header()
+		}
+	}
+	return nil
+}
+
+func (c *Chain) GetBlock(hash types.Hash, number uint64) *block.Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if number < uint64(len(c.blocks)) && c.blocks[number].Hash() == hash {
+		return c.blocks[number]
+	}
+	return nil
+}
+
+func (c *Chain) ElectedValidators(number uint64) ([]types.Address, error) {
+	return c.Elected, c.ElectedErr
+}
+
+// Height returns the number of the head.
+func (c *Chain) Height() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return uint64(len(c.blocks) - 1)
+}
+
+// Insert makes blk the head, it has to be a child of the current one.
+func (c *Chain) Insert(blk *block.Block) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if blk.ParentHash() != c.blocks[len(c.blocks)-1].Hash() {
+		return fmt.Errorf("block %d is not on the head", blk.NumberU64())
+	}
+	c.blocks = append(c.blocks, blk)
+	return nil
+}
+
+// InsertHeader makes a block of header the head.
+func (c *Chain) InsertHeader(header *block.Header) error {
+	return c.Insert(block.NewBlockWithHeader(header))
+}
+
+// Rewind drops the blocks after number.
+func (c *Chain) Rewind(number uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if number < uint64(len(c.blocks)) {
+		c.blocks = c.blocks[:number+1]
+	}
+}
+
+// Next returns the header of the block after the head, a second after it.
+func (c *Chain) Next() *block.Header {
+	parent := c.CurrentHeader()
+	return &block.Header{
+		ParentHash: parent.Hash(),
+		Number:     types.NewBigInt(*new(big.Int).Add(&parent.Number.IntVal, big.NewInt(1))),
+		Time:       types.NewBigInt(*new(big.Int).Add(&parent.Time.IntVal, big.NewInt(1))),
+	}
+}
+
+// Produce prepares the block after the head with engine and finalizes it, it is
+// not inserted.
+func (c *Chain) Produce(engine consensus.Engine, txs []*transaction.Transaction) (*block.Block, error) {
+	header := c.Next()
+	if err := engine.Prepare(c, header); err != nil {
+		return nil, err
+	}
+	return c.Finalize(engine, header, txs)
+}
+
+// Finalize finalizes header with engine on an empty state and signs it.
+func (c *Chain) Finalize(engine consensus.Engine, header *block.Header, txs []*transaction.Transaction) (*block.Block, error) {
+	db, _ := database.OpenMemDB()
+	statedb, _ := state.New(types.Hash{}, state.NewDatabase(db))
+	return engine.Finalize(c, header, statedb, txs, nil, nil, true)
+}
@@ -0,0 +1,57 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: api.go
+// @Date: 2018/07/31 11:38:50
+////////////////////////////////////////////////////////////////////////////////
+
+package dev
+
+import (
+	"mjoy.io/common/types"
+	"mjoy.io/communication/rpc"
+	"mjoy.io/consensus"
+)
+
+// API is the RPC API of the dev engine.
+type API struct {
+	chain consensus.ChainReader
+	dev   *Dev
+}
+
+// APIs implements consensus.Engine, publishing the dev namespace.
+func (d *Dev) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "dev",
+		Version:   "1.0",
+		Service:   &API{chain: chain, dev: d},
+		Public:    true,
+	}}
+}
+
+// GetValidators returns the signer of the chain, the only validator.
+func (api *API) GetValidators() []types.Address {
+	return []types.Address{api.dev.config.Signer}
+}
+
+// GetSigner returns the signer of block number.
+func (api *API) GetSigner(number *rpc.BlockNumber) (types.Address, error) {
+	header, err := consensus.HeaderByNumber(api.chain, number)
+	if err != nil {
+		return types.Address{}, err
+	}
+	return api.dev.Author(api.chain, header)
+}
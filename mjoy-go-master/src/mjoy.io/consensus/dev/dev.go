@@ -0,0 +1,193 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: dev.go
+// @Date: 2018/07/26 15:40:12
+////////////////////////////////////////////////////////////////////////////////
+
+// Package dev implements the consensus engine of a local development chain: the
+// blocks are signed by a single key and sealed as soon as there are transactions,
+// or every period.
+package dev
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"mjoy.io/common"
+	"mjoy.io/common/types"
+	"mjoy.io/consensus"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+)
+
+var (
+	errMissingKey = errors.New("no key found for signing header")
+
+	// ErrUnauthorizedSigner is returned if a header is not signed by the signer of
+	// the chain.
+	ErrUnauthorizedSigner = errors.New("unauthorized signer")
+)
+
+// Dev is the development consensus engine.
+type Dev struct {
+	config *params.DevConfig
+
+	lock sync.RWMutex
+	prv  *ecdsa.PrivateKey // key for sign header
+}
+
+// New creates a dev engine, prv may be nil for a node not producing blocks.
+func New(config *params.DevConfig, prv *ecdsa.PrivateKey) *Dev {
+	return &Dev{
+		config: config,
+		prv:    prv,
+	}
+}
+
+func (d *Dev) SetKey(prv *ecdsa.PrivateKey) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.prv = prv
+}
+
+func (d *Dev) Author(chain consensus.ChainReader, header *block.Header) (types.Address, error) {
+	signer := block.NewBlockSigner(chain.Config().ChainId)
+	return signer.Sender(header)
+}
+
+func (d *Dev) VerifyHeader(chain consensus.ChainReader, header *block.Header, seal bool) error {
+	//if the header is known, verify success
+	number := header.Number.IntVal.Uint64()
+	if chain.GetHeader(header.Hash(), number) != nil {
+		return nil
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return d.verifyHeader(chain, header, parent, seal)
+}
+
+func (d *Dev) verifyHeader(chain consensus.ChainReader, header, parent *block.Header, seal bool) error {
+	// Verify that the block number is parent's +1
+	if diff := new(big.Int).Sub(&header.Number.IntVal, &parent.Number.IntVal); diff.Cmp(common.Big1) != 0 {
+		return consensus.ErrInvalidNumber
+	}
+
+	//verify time, blocks sealed on transactions may share the second of their parent
+	minTime := new(big.Int).Add(&parent.Time.IntVal, new(big.Int).SetUint64(d.config.Period))
+	if header.Time.IntVal.Cmp(minTime) < 0 {
+		return consensus.ErrBlockTime
+	}
+	if err := consensus.VerifySlotTime(chain.Config(), header, parent); err != nil {
+		return err
+	}
+
+	if _, err := d.Author(chain, header); err != nil {
+		return consensus.ErrSignature
+	}
+	if seal {
+		return d.VerifySeal(chain, header)
+	}
+	return nil
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
+// concurrently. The method returns a quit channel to abort the operations and
+// a results channel to retrieve the async verifications.
+func (d *Dev) VerifyHeaders(chain consensus.ChainReader, headers []*block.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	return consensus.VerifyHeaders(chain, headers, seals, func(header, parent *block.Header, seal bool) error {
+		return d.verifyHeader(chain, header, parent, seal)
+	})
+}
+
+// VerifySeal checks that the header is signed by the signer of the chain.
+func (d *Dev) VerifySeal(chain consensus.ChainReader, header *block.Header) error {
+	signer, err := d.Author(chain, header)
+	if err != nil {
+		return consensus.ErrSignature
+	}
+	if signer != d.config.Signer {
+		return ErrUnauthorizedSigner
+	}
+	return nil
+}
+
+// Prepare sets the time of the header: a block sealed on transactions takes the current
+// time, a periodic block the time its period ends.
+func (d *Dev) Prepare(chain consensus.ChainReader, header *block.Header) error {
+	parent := chain.GetHeader(header.ParentHash, header.Number.IntVal.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	tstamp := new(big.Int).Add(&parent.Time.IntVal, new(big.Int).SetUint64(d.config.Period))
+	if now := big.NewInt(time.Now().Unix()); tstamp.Cmp(now) < 0 {
+		tstamp = now
+	}
+	header.Time = &types.BigInt{IntVal: *tstamp}
+	return nil
+}
+
+func (d *Dev) Finalize(chain consensus.ChainReader, header *block.Header, state *state.StateDB, txs []*transaction.Transaction, receipts []*transaction.Receipt, rewards *consensus.RewardState, sign bool) (*block.Block, error) {
+	if rewards != nil {
+		if err := consensus.AccumulateRewards(chain.Config(), header, state, receipts, rewards); err != nil {
+			return nil, err
+		}
+	}
+	header.StateRootHash = state.IntermediateRoot()
+	blk := block.NewBlock(header, txs, receipts)
+	if !sign {
+		return blk, nil
+	}
+
+	d.lock.RLock()
+	prv := d.prv
+	d.lock.RUnlock()
+	if prv == nil {
+		return nil, errMissingKey
+	}
+	if err := block.SignHeaderInner(blk.B_header, block.NewBlockSigner(chain.Config().ChainId), prv); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+// Seal returns the block at once if the chain seals on transactions, an empty block is
+// not sealed then and nil is returned. A periodic block is returned when its time comes.
+func (d *Dev) Seal(chain consensus.ChainReader, block *block.Block, stop <-chan struct{}) (*block.Block, error) {
+	header := block.Header()
+	if d.config.Period == 0 {
+		if len(block.Transactions()) == 0 {
+			return nil, nil
+		}
+		return block.WithSeal(header), nil
+	}
+
+	delay := time.Unix(header.Time.IntVal.Int64(), 0).Sub(time.Now())
+	logger.Trace("Waiting for the period of the block", "number", header.Number.IntVal.Uint64(), "delay", common.PrettyDuration(delay))
+	select {
+	case <-stop:
+		return nil, nil
+	case <-time.After(delay):
+	}
+	return block.WithSeal(header), nil
+}
@@ -0,0 +1,114 @@
+package dev
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"mjoy.io/common/types"
+	"mjoy.io/consensus"
+	"mjoy.io/consensus/consensustest"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+)
+
+// produce makes the next block with engine, it is not added to the chain
+func produce(t *testing.T, chain *consensustest.Chain, engine *Dev, txs []*transaction.Transaction) *block.Block {
+	blk, err := chain.Produce(engine, txs)
+	if err != nil {
+		t.Fatalf("produce: %v", err)
+	}
+	return blk
+}
+
+func newTestEngine(t *testing.T, period uint64) (*Dev, *consensustest.Chain) {
+	key, _ := crypto.GenerateKey()
+	config := &params.ChainConfig{
+		ChainId: big.NewInt(int64(params.DevChainId)),
+		Dev:     &params.DevConfig{Period: period, Signer: crypto.PubkeyToAddress(key.PublicKey)},
+	}
+	return New(config.Dev, key), consensustest.NewChain(config, time.Now().Unix())
+}
+
+func TestSealOnTransactions(t *testing.T) {
+	engine, chain := newTestEngine(t, 0)
+
+	// an empty block is not sealed
+	empty := produce(t, chain, engine, nil)
+	if sealed, err := engine.Seal(chain, empty, make(chan struct{})); sealed != nil || err != nil {
+		t.Fatalf("empty block sealed: %v, %v", sealed, err)
+	}
+
+	// a block of the same second as its parent is valid
+	blk := produce(t, chain, engine, []*transaction.Transaction{transaction.NewTransaction(0, nil)})
+	sealed, err := engine.Seal(chain, blk, make(chan struct{}))
+	if err != nil || sealed == nil {
+		t.Fatalf("block not sealed: %v", err)
+	}
+	if err := engine.VerifyHeader(chain, sealed.Header(), true); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := chain.InsertHeader(sealed.Header()); err != nil {
+		t.Fatal(err)
+	}
+
+	// a block older than its parent is not
+	header := produce(t, chain, engine, nil).Header()
+	header.Time = types.NewBigInt(*new(big.Int).Sub(&chain.CurrentHeader().Time.IntVal, big.NewInt(1)))
+	if err := engine.VerifyHeader(chain, header, false); err != consensus.ErrBlockTime {
+		t.Fatalf("error mismatch: have %v, want %v", err, consensus.ErrBlockTime)
+	}
+}
+
+func TestSealPeriod(t *testing.T) {
+	engine, chain := newTestEngine(t, 1)
+
+	blk := produce(t, chain, engine, nil)
+	if have, want := blk.Header().Time.IntVal.Int64(), chain.CurrentHeader().Time.IntVal.Int64()+1; have < want {
+		t.Fatalf("block time mismatch: have %d, want at least %d", have, want)
+	}
+	// the period is sealed when it is over, empty or not
+	sealed, err := engine.Seal(chain, blk, make(chan struct{}))
+	if err != nil || sealed == nil {
+		t.Fatalf("block not sealed: %v", err)
+	}
+	if now := time.Now().Unix(); now < sealed.Header().Time.IntVal.Int64() {
+		t.Fatalf("block sealed before its time: now %d, block %d", now, sealed.Header().Time.IntVal.Int64())
+	}
+	if err := engine.VerifyHeader(chain, sealed.Header(), true); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if err := chain.InsertHeader(sealed.Header()); err != nil {
+		t.Fatal(err)
+	}
+
+	// a stopped seal returns nothing
+	next := produce(t, chain, engine, nil)
+	if next.Header().Time.IntVal.Int64() > time.Now().Unix() {
+		stop := make(chan struct{})
+		close(stop)
+		if sealed, err := engine.Seal(chain, next, stop); sealed != nil || err != nil {
+			t.Fatalf("stopped seal returned: %v, %v", sealed, err)
+		}
+	}
+
+	// a block shorter than the period is rejected
+	header := next.Header()
+	header.Time = types.NewBigInt(chain.CurrentHeader().Time.IntVal)
+	if err := engine.VerifyHeader(chain, header, false); err != consensus.ErrBlockTime {
+		t.Fatalf("error mismatch: have %v, want %v", err, consensus.ErrBlockTime)
+	}
+}
+
+func TestUnauthorizedSigner(t *testing.T) {
+	engine, chain := newTestEngine(t, 0)
+	other, _ := newTestEngine(t, 0)
+
+	blk := produce(t, chain, other, nil)
+	if err := engine.VerifyHeader(chain, blk.Header(), true); err != ErrUnauthorizedSigner {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrUnauthorizedSigner)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"mjoy.io/common/types"
 	"mjoy.io/core/state"
 	"mjoy.io/core/transaction"
+	"mjoy.io/communication/rpc"
 )
 
 type Engine_empty struct {
@@ -67,3 +68,8 @@ func (empty *Engine_empty) Seal(chain ChainReader, block *block.Block, stop <-ch
 	header := block.Header()
 	return block.WithSeal(header), nil
 }
+
+// APIs implements Engine, the engine has no APIs.
+func (empty *Engine_empty) APIs(chain ChainReader) []rpc.API {
+	return nil
+}
@@ -0,0 +1,58 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: api.go
+// @Date: 2018/08/02 14:03:27
+////////////////////////////////////////////////////////////////////////////////
+
+package lottery
+
+import (
+	"mjoy.io/common/types"
+	"mjoy.io/communication/rpc"
+	"mjoy.io/consensus"
+)
+
+// API is the RPC API of the lottery engine.
+type API struct {
+	chain   consensus.ChainReader
+	lottery *Lottery
+}
+
+// APIs implements consensus.Engine, publishing the lottery namespace.
+func (l *Lottery) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "lottery",
+		Version:   "1.0",
+		Service:   &API{chain: chain, lottery: l},
+		Public:    true,
+	}}
+}
+
+// GetValidators returns the validators taking part in the lottery of the block after
+// the current one.
+func (api *API) GetValidators() ([]types.Address, error) {
+	return api.lottery.Validators(api.chain, api.chain.CurrentHeader().Number.IntVal.Uint64()+1)
+}
+
+// GetSigner returns the signer of block number.
+func (api *API) GetSigner(number *rpc.BlockNumber) (types.Address, error) {
+	header, err := consensus.HeaderByNumber(api.chain, number)
+	if err != nil {
+		return types.Address{}, err
+	}
+	return api.lottery.Author(api.chain, header)
+}
@@ -0,0 +1,347 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: lottery.go
+// @Date: 2018/08/02 14:03:27
+////////////////////////////////////////////////////////////////////////////////
+
+// Package lottery implements the VRF leader lottery consensus engine: time is cut in
+// slots, and a validator is eligible to produce the block of a slot if its VRF output
+// over the parent hash and the slot is below a threshold. Nobody can tell the leaders
+// of a slot before they produce, and anyone can check a leader was eligible with the
+// proof the block carries.
+package lottery
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"mjoy.io/common"
+	"mjoy.io/common/types"
+	"mjoy.io/consensus"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/crypto/vrf"
+)
+
+// ConsensusId is the Id of the ConsensusData of the lottery blocks, the Para is the
+// compressed public key of the signer followed by its VRF proof.
+const ConsensusId = "lottery"
+
+const (
+	pubkeyLength = 33
+	paraLength   = pubkeyLength + vrf.ProofLength
+
+	// max number of slots Prepare looks ahead for one the local validator is eligible in
+	maxLookahead = 4096
+)
+
+var (
+	errMissingKey = errors.New("no key found for signing header")
+
+	// ErrInvalidConsensusData is returned if the ConsensusData of a header is not a
+	// lottery one, or its public key is not the one of the signer.
+	ErrInvalidConsensusData = errors.New("invalid lottery consensus data")
+
+	// ErrInvalidSlot is returned if a header is not in a slot after the one of its parent.
+	ErrInvalidSlot = errors.New("invalid slot")
+
+	// ErrUnauthorized is returned if a header is signed by a non-validator.
+	ErrUnauthorized = errors.New("unauthorized validator")
+
+	// ErrNotEligible is returned if the VRF output of the signer of a header is not
+	// below the threshold of its slot.
+	ErrNotEligible = errors.New("validator not eligible for the slot")
+
+	two256 = new(big.Int).Lsh(common.Big1, 256)
+)
+
+// Lottery is the VRF leader lottery consensus engine.
+type Lottery struct {
+	config *params.LotteryConfig
+
+	lock sync.RWMutex
+	prv  *ecdsa.PrivateKey // key for sign header and prove the VRF outputs
+}
+
+// New creates a lottery engine, prv may be nil for a node not producing blocks.
+func New(config *params.LotteryConfig, prv *ecdsa.PrivateKey) *Lottery {
+	return &Lottery{
+		config: config,
+		prv:    prv,
+	}
+}
+
+func (l *Lottery) SetKey(prv *ecdsa.PrivateKey) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.prv = prv
+}
+
+func (l *Lottery) key() *ecdsa.PrivateKey {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.prv
+}
+
+func (l *Lottery) period() uint64 {
+	if l.config.Period == 0 {
+		return 1
+	}
+	return l.config.Period
+}
+
+// Slot returns the slot of the header.
+func (l *Lottery) Slot(header *block.Header) uint64 {
+	return header.Time.IntVal.Uint64() / l.period()
+}
+
+// Validators returns the validators of the block number: the ones elected by stake,
+// or the configured ones as long as no candidate is elected. An error is returned if
+// the election can not be read, the configured validators may not be the ones of the
+// block then.
+func (l *Lottery) Validators(chain consensus.ChainReader, number uint64) ([]types.Address, error) {
+	elected, err := chain.ElectedValidators(number)
+	if err != nil {
+		return nil, err
+	}
+	if len(elected) > 0 {
+		return elected, nil
+	}
+	return l.config.Validators, nil
+}
+
+func isValidator(validators []types.Address, address types.Address) bool {
+	for _, v := range validators {
+		if v == address {
+			return true
+		}
+	}
+	return false
+}
+
+// threshold is the bound of the VRF outputs eligible among n validators, Leaders of
+// them are eligible for a slot on average.
+func (l *Lottery) threshold(n int) *big.Int {
+	leaders := l.config.Leaders
+	if leaders == 0 {
+		leaders = 1
+	}
+	if n == 0 || leaders >= uint64(n) {
+		return two256
+	}
+	t := new(big.Int).Mul(two256, new(big.Int).SetUint64(leaders))
+	return t.Div(t, big.NewInt(int64(n)))
+}
+
+// lotteryInput is the VRF input of a slot after parent
+func lotteryInput(parent types.Hash, slot uint64) []byte {
+	input := make([]byte, types.HashLength+8)
+	copy(input, parent[:])
+	binary.BigEndian.PutUint64(input[types.HashLength:], slot)
+	return input
+}
+
+func (l *Lottery) Author(chain consensus.ChainReader, header *block.Header) (types.Address, error) {
+	signer := block.NewBlockSigner(chain.Config().ChainId)
+	return signer.Sender(header)
+}
+
+func (l *Lottery) VerifyHeader(chain consensus.ChainReader, header *block.Header, seal bool) error {
+	//if the header is known, verify success
+	number := header.Number.IntVal.Uint64()
+	if chain.GetHeader(header.Hash(), number) != nil {
+		return nil
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return l.verifyHeader(chain, header, parent, seal)
+}
+
+func (l *Lottery) verifyHeader(chain consensus.ChainReader, header, parent *block.Header, seal bool) error {
+	// Verify that the block number is parent's +1
+	if diff := new(big.Int).Sub(&header.Number.IntVal, &parent.Number.IntVal); diff.Cmp(common.Big1) != 0 {
+		return consensus.ErrInvalidNumber
+	}
+
+	//verify time, a block is in a slot after the one of its parent
+	if header.Time.IntVal.Cmp(&parent.Time.IntVal) <= 0 {
+		return consensus.ErrBlockTime
+	}
+	if header.Time.IntVal.Cmp(big.NewInt(time.Now().Unix()+int64(params.AllowedFutureBlockTime))) > 0 {
+		return consensus.ErrFutureBlock
+	}
+	if err := consensus.VerifySlotTime(chain.Config(), header, parent); err != nil {
+		return err
+	}
+	if l.Slot(header) <= l.Slot(parent) {
+		return ErrInvalidSlot
+	}
+
+	if _, err := l.Author(chain, header); err != nil {
+		return consensus.ErrSignature
+	}
+	if seal {
+		return l.verifySeal(chain, header, parent)
+	}
+	return nil
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
+// concurrently. The method returns a quit channel to abort the operations and
+// a results channel to retrieve the async verifications.
+func (l *Lottery) VerifyHeaders(chain consensus.ChainReader, headers []*block.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	return consensus.VerifyHeaders(chain, headers, seals, func(header, parent *block.Header, seal bool) error {
+		return l.verifyHeader(chain, header, parent, seal)
+	})
+}
+
+// VerifySeal checks that the header is signed by a validator eligible for its slot,
+// by the VRF proof of the ConsensusData.
+func (l *Lottery) VerifySeal(chain consensus.ChainReader, header *block.Header) error {
+	parent := chain.GetHeader(header.ParentHash, header.Number.IntVal.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return l.verifySeal(chain, header, parent)
+}
+
+func (l *Lottery) verifySeal(chain consensus.ChainReader, header, parent *block.Header) error {
+	signer, err := l.Author(chain, header)
+	if err != nil {
+		return consensus.ErrSignature
+	}
+	data := header.ConsensusData
+	if data.Id != ConsensusId || len(data.Para) != paraLength {
+		return ErrInvalidConsensusData
+	}
+	pub, err := crypto.DecompressPubkey(data.Para[:pubkeyLength])
+	if err != nil || crypto.PubkeyToAddress(*pub) != signer {
+		return ErrInvalidConsensusData
+	}
+	validators, err := l.Validators(chain, header.Number.IntVal.Uint64())
+	if err != nil {
+		return err
+	}
+	if !isValidator(validators, signer) {
+		return ErrUnauthorized
+	}
+	output, err := vrf.Verify(pub, lotteryInput(parent.Hash(), l.Slot(header)), data.Para[pubkeyLength:])
+	if err != nil {
+		return err
+	}
+	if new(big.Int).SetBytes(output[:]).Cmp(l.threshold(len(validators))) >= 0 {
+		return ErrNotEligible
+	}
+	return nil
+}
+
+// Prepare looks for the first slot from now on the local validator is eligible in,
+// and fills the time and the ConsensusData of the header for it. The block is sealed
+// once its slot starts.
+func (l *Lottery) Prepare(chain consensus.ChainReader, header *block.Header) error {
+	prv := l.key()
+	if prv == nil {
+		return errMissingKey
+	}
+	signer := crypto.PubkeyToAddress(prv.PublicKey)
+
+	number := header.Number.IntVal.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	validators, err := l.Validators(chain, number)
+	if err != nil {
+		return err
+	}
+	if !isValidator(validators, signer) {
+		return ErrUnauthorized
+	}
+	threshold := l.threshold(len(validators))
+
+	slot := l.Slot(parent) + 1
+	if now := uint64(time.Now().Unix()) / l.period(); now > slot {
+		slot = now
+	}
+	for end := slot + maxLookahead; slot < end; slot++ {
+		input := lotteryInput(parent.Hash(), slot)
+		output, err := vrf.Evaluate(prv, input)
+		if err != nil {
+			return err
+		}
+		if new(big.Int).SetBytes(output[:]).Cmp(threshold) >= 0 {
+			continue
+		}
+		_, proof, err := vrf.Prove(prv, input)
+		if err != nil {
+			return err
+		}
+		// a block takes the time its slot starts, always after the time of its parent
+		header.Time = &types.BigInt{IntVal: *new(big.Int).SetUint64(slot * l.period())}
+		header.ConsensusData = block.ConsensusData{
+			Id:   ConsensusId,
+			Para: append(crypto.CompressPubkey(&prv.PublicKey), proof...),
+		}
+		header.BlockProducer = signer
+		return nil
+	}
+	return consensus.ErrNotInTurn
+}
+
+func (l *Lottery) Finalize(chain consensus.ChainReader, header *block.Header, state *state.StateDB, txs []*transaction.Transaction, receipts []*transaction.Receipt, rewards *consensus.RewardState, sign bool) (*block.Block, error) {
+	if rewards != nil {
+		if err := consensus.AccumulateRewards(chain.Config(), header, state, receipts, rewards); err != nil {
+			return nil, err
+		}
+	}
+	header.StateRootHash = state.IntermediateRoot()
+	blk := block.NewBlock(header, txs, receipts)
+	if !sign {
+		return blk, nil
+	}
+
+	prv := l.key()
+	if prv == nil {
+		return nil, errMissingKey
+	}
+	if err := block.SignHeaderInner(blk.B_header, block.NewBlockSigner(chain.Config().ChainId), prv); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+// Seal waits for the slot of the block to start.
+func (l *Lottery) Seal(chain consensus.ChainReader, block *block.Block, stop <-chan struct{}) (*block.Block, error) {
+	header := block.Header()
+	delay := time.Unix(header.Time.IntVal.Int64(), 0).Sub(time.Now())
+	logger.Trace("Waiting for the slot of the block", "number", header.Number.IntVal.Uint64(), "delay", common.PrettyDuration(delay))
+	select {
+	case <-stop:
+		return nil, nil
+	case <-time.After(delay):
+	}
+	return block.WithSeal(header), nil
+}
@@ -0,0 +1,164 @@
+package lottery
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"mjoy.io/common/types"
+	"mjoy.io/consensus"
+	"mjoy.io/consensus/consensustest"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/crypto/vrf"
+)
+
+// produce makes the next header with engine, it is not added to the chain
+func produce(t *testing.T, chain *consensustest.Chain, engine *Lottery) *block.Header {
+	blk, err := chain.Produce(engine, nil)
+	if err != nil {
+		t.Fatalf("produce: %v", err)
+	}
+	return blk.Header()
+}
+
+// finalize signs header with engine
+func finalize(t *testing.T, chain *consensustest.Chain, engine *Lottery, header *block.Header) *block.Header {
+	blk, err := chain.Finalize(engine, header, nil)
+	if err != nil {
+		t.Fatalf("finalize: %v", err)
+	}
+	return blk.Header()
+}
+
+// newTestEngines returns an engine for each of n validators sharing a chain
+func newTestEngines(n int, leaders uint64) ([]*Lottery, *consensustest.Chain) {
+	config := &params.ChainConfig{
+		ChainId: big.NewInt(1),
+		Lottery: &params.LotteryConfig{Period: 1, Leaders: leaders},
+	}
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < n; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		config.Lottery.Validators = append(config.Lottery.Validators, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	engines := make([]*Lottery, n)
+	for i, key := range keys {
+		engines[i] = New(config.Lottery, key)
+	}
+	return engines, consensustest.NewChain(config, time.Now().Unix()-1)
+}
+
+func TestLottery(t *testing.T) {
+	engines, chain := newTestEngines(4, 1)
+
+	for i, engine := range engines {
+		header := produce(t, chain, engine)
+		if err := engine.VerifyHeader(chain, header, true); err != nil {
+			t.Fatalf("validator %d: verify: %v", i, err)
+		}
+		if header.ConsensusData.Id != ConsensusId || len(header.ConsensusData.Para) != paraLength {
+			t.Fatalf("validator %d: invalid consensus data %v", i, header.ConsensusData)
+		}
+		if slot := engine.Slot(header); slot <= engine.Slot(chain.CurrentHeader()) {
+			t.Fatalf("validator %d: slot %d not after the one of the parent", i, slot)
+		}
+	}
+}
+
+func TestNotEligible(t *testing.T) {
+	engines, chain := newTestEngines(4, 1)
+	engine := engines[0]
+	parent := chain.CurrentHeader()
+
+	// find a slot the validator is not eligible in and prove it anyway
+	threshold := engine.threshold(len(engine.config.Validators))
+	slot := engine.Slot(parent) + 1
+	for ; ; slot++ {
+		output, _ := vrf.Evaluate(engine.key(), lotteryInput(parent.Hash(), slot))
+		if new(big.Int).SetBytes(output[:]).Cmp(threshold) >= 0 {
+			break
+		}
+	}
+	_, proof, err := vrf.Prove(engine.key(), lotteryInput(parent.Hash(), slot))
+	if err != nil {
+		t.Fatalf("prove: %v", err)
+	}
+	header := finalize(t, chain, engine, &block.Header{
+		ParentHash:    parent.Hash(),
+		Number:        types.NewBigInt(*big.NewInt(1)),
+		Time:          types.NewBigInt(*new(big.Int).SetUint64(slot)),
+		BlockProducer: crypto.PubkeyToAddress(engine.key().PublicKey),
+		ConsensusData: block.ConsensusData{Id: ConsensusId, Para: append(crypto.CompressPubkey(&engine.key().PublicKey), proof...)},
+	})
+	if err := engine.VerifySeal(chain, header); err != ErrNotEligible {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNotEligible)
+	}
+}
+
+func TestInvalidSeal(t *testing.T) {
+	engines, chain := newTestEngines(1, 1)
+	engine := engines[0]
+
+	// a tampered proof
+	header := produce(t, chain, engine)
+	header.ConsensusData.Para[len(header.ConsensusData.Para)-1] ^= 1
+	header = finalize(t, chain, engine, header)
+	if err := engine.VerifySeal(chain, header); err != vrf.ErrInvalidProof {
+		t.Fatalf("error mismatch: have %v, want %v", err, vrf.ErrInvalidProof)
+	}
+
+	// the proof of another key
+	other, _ := crypto.GenerateKey()
+	header = produce(t, chain, engine)
+	copy(header.ConsensusData.Para, crypto.CompressPubkey(&other.PublicKey))
+	header = finalize(t, chain, engine, header)
+	if err := engine.VerifySeal(chain, header); err != ErrInvalidConsensusData {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrInvalidConsensusData)
+	}
+
+	// a signer out of the validators
+	outsider := New(engine.config, other)
+	header = produce(t, chain, engine)
+	header.ConsensusData.Para = append(crypto.CompressPubkey(&other.PublicKey), header.ConsensusData.Para[pubkeyLength:]...)
+	header = finalize(t, chain, outsider, header)
+	if err := engine.VerifySeal(chain, header); err != ErrUnauthorized {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrUnauthorized)
+	}
+	if err := outsider.Prepare(chain, &block.Header{ParentHash: chain.CurrentHeader().Hash(), Number: types.NewBigInt(*big.NewInt(1))}); err != ErrUnauthorized {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrUnauthorized)
+	}
+
+	// a block in the slot of its parent
+	header = produce(t, chain, engine)
+	if err := chain.InsertHeader(header); err != nil {
+		t.Fatal(err)
+	}
+	next := produce(t, chain, engine)
+	next.Time = types.NewBigInt(header.Time.IntVal)
+	if err := engine.VerifyHeader(chain, next, false); err != consensus.ErrBlockTime {
+		t.Fatalf("error mismatch: have %v, want %v", err, consensus.ErrBlockTime)
+	}
+}
+
+func TestUnknownElection(t *testing.T) {
+	engines, chain := newTestEngines(1, 1)
+	engine := engines[0]
+	header := produce(t, chain, engine)
+
+	// without the state of the election block the configured validators must not
+	// be used instead of the elected ones
+	chain.ElectedErr = consensus.ErrUnknownAncestor
+	if validators, err := engine.Validators(chain, 1); err != consensus.ErrUnknownAncestor {
+		t.Errorf("validators %v %v, want %v", validators, err, consensus.ErrUnknownAncestor)
+	}
+	if err := engine.VerifySeal(chain, header); err != consensus.ErrUnknownAncestor {
+		t.Errorf("verify seal: %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+	if err := engine.Prepare(chain, &block.Header{ParentHash: chain.CurrentHeader().Hash(), Number: types.NewBigInt(*big.NewInt(1))}); err != consensus.ErrUnknownAncestor {
+		t.Errorf("prepare: %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+}
@@ -0,0 +1,135 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: api.go
+// @Date: 2018/07/31 10:26:03
+////////////////////////////////////////////////////////////////////////////////
+
+package poa
+
+import (
+	"mjoy.io/common/types"
+	"mjoy.io/communication/rpc"
+	"mjoy.io/consensus"
+)
+
+// API is the RPC API of the poa engine, the namespace operators monitor the
+// validators and the pending votes with.
+type API struct {
+	chain consensus.ChainReader
+	poa   *Poa
+}
+
+// PrivateAPI is the RPC API the operator of a validator casts its votes with.
+type PrivateAPI struct {
+	poa *Poa
+}
+
+// APIs implements consensus.Engine, publishing the poa namespace.
+func (p *Poa) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "poa",
+		Version:   "1.0",
+		Service:   &API{chain: chain, poa: p},
+		Public:    true,
+	}, {
+		Namespace: "poa",
+		Version:   "1.0",
+		Service:   &PrivateAPI{poa: p},
+		Public:    false,
+	}}
+}
+
+// GetSnapshot returns the validator set and the pending votes after block number,
+// the head one if number is nil.
+func (api *API) GetSnapshot(number *rpc.BlockNumber) (*Snapshot, error) {
+	header, err := consensus.HeaderByNumber(api.chain, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.poa.Snapshot(api.chain, header)
+}
+
+// GetValidators returns the validators after block number.
+func (api *API) GetValidators(number *rpc.BlockNumber) ([]Validator, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Validators, nil
+}
+
+// GetVotes returns the pending votes after block number, with the validators voting
+// for them.
+func (api *API) GetVotes(number *rpc.BlockNumber) ([]Tally, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Tallies, nil
+}
+
+// GetSigner returns the validator signing block number.
+func (api *API) GetSigner(number *rpc.BlockNumber) (types.Address, error) {
+	header, err := consensus.HeaderByNumber(api.chain, number)
+	if err != nil {
+		return types.Address{}, err
+	}
+	return api.poa.Author(api.chain, header)
+}
+
+// GetProposers returns the proposers of the count blocks after the head, as long as
+// no vote changes the validator set.
+func (api *API) GetProposers(count uint64) ([]types.Address, error) {
+	if count > consensus.MaxScheduleLength {
+		return nil, consensus.ErrScheduleTooLong
+	}
+	head := api.chain.CurrentHeader()
+	snap, err := api.poa.Snapshot(api.chain, head)
+	if err != nil {
+		return nil, err
+	}
+	number := head.Number.IntVal.Uint64()
+	proposers := make([]types.Address, count)
+	for i := range proposers {
+		proposers[i] = snap.Proposer(number + uint64(i) + 1)
+	}
+	return proposers, nil
+}
+
+// Proposals returns the votes the local validator casts in its blocks.
+func (api *API) Proposals() []Vote {
+	return api.poa.Proposals()
+}
+
+// Propose casts a vote in the local blocks to add validator with weight, 1 if
+// none is given, or to remove it if authorize is false.
+func (api *PrivateAPI) Propose(validator types.Address, authorize bool, weight *uint64) {
+	if !authorize {
+		api.poa.ProposeRemoval(validator)
+		return
+	}
+	var w uint64
+	if weight != nil {
+		w = *weight
+	}
+	api.poa.Propose(validator, w)
+}
+
+// Discard drops the vote about validator the local blocks would cast.
+func (api *PrivateAPI) Discard(validator types.Address) {
+	api.poa.Discard(validator)
+}
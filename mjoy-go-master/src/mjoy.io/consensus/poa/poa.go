@@ -0,0 +1,304 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: poa.go
+// @Date: 2018/07/09 14:02:51
+////////////////////////////////////////////////////////////////////////////////
+
+// Package poa implements the proof-of-authority consensus engine: blocks are
+// signed in turn by a validator set which changes by the votes of its members.
+package poa
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"mjoy.io/common"
+	"mjoy.io/common/types"
+	"mjoy.io/consensus"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+)
+
+var (
+	errMissingKey = errors.New("no key found for signing header")
+)
+
+// Poa is the proof-of-authority consensus engine.
+type Poa struct {
+	config *params.PoaConfig
+
+	lock      sync.RWMutex
+	prv       *ecdsa.PrivateKey      // key for sign header
+	proposals map[types.Address]Vote // votes to cast in the local blocks
+}
+
+// New creates a poa engine, prv may be nil for a node not producing blocks.
+func New(config *params.PoaConfig, prv *ecdsa.PrivateKey) *Poa {
+	return &Poa{
+		config:    config,
+		prv:       prv,
+		proposals: make(map[types.Address]Vote),
+	}
+}
+
+func (p *Poa) SetKey(prv *ecdsa.PrivateKey) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.prv = prv
+}
+
+// Propose adds a vote to add validator with weight, or to change its weight. It is
+// cast in the local blocks until the change is made.
+func (p *Poa) Propose(validator types.Address, weight uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.proposals[validator] = Vote{Validator: validator, Authorize: true, Weight: normalizeWeight(weight)}
+}
+
+// ProposeRemoval adds a vote to remove validator.
+func (p *Poa) ProposeRemoval(validator types.Address) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.proposals[validator] = Vote{Validator: validator}
+}
+
+// Discard drops the proposal about validator.
+func (p *Poa) Discard(validator types.Address) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.proposals, validator)
+}
+
+// Proposals returns the votes cast in the local blocks.
+func (p *Poa) Proposals() []Vote {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	votes := make([]Vote, 0, len(p.proposals))
+	for _, vote := range p.proposals {
+		votes = append(votes, vote)
+	}
+	sort.Slice(votes, func(i, j int) bool {
+		return bytes.Compare(votes[i].Validator[:], votes[j].Validator[:]) < 0
+	})
+	return votes
+}
+
+// Snapshot returns the validator set after header.
+func (p *Poa) Snapshot(chain consensus.ChainReader, header *block.Header) (*Snapshot, error) {
+	if header.Number.IntVal.Sign() == 0 {
+		return newGenesisSnapshot(p.config)
+	}
+	return DecodeSnapshot(&header.ConsensusData)
+}
+
+func (p *Poa) Author(chain consensus.ChainReader, header *block.Header) (types.Address, error) {
+	signer := block.NewBlockSigner(chain.Config().ChainId)
+	return signer.Sender(header)
+}
+
+func (p *Poa) VerifyHeader(chain consensus.ChainReader, header *block.Header, seal bool) error {
+	//if the header is known, verify success
+	number := header.Number.IntVal.Uint64()
+	if chain.GetHeader(header.Hash(), number) != nil {
+		return nil
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return p.verifyHeader(chain, header, parent, seal)
+}
+
+func (p *Poa) verifyHeader(chain consensus.ChainReader, header, parent *block.Header, seal bool) error {
+	// Verify that the block number is parent's +1
+	if diff := new(big.Int).Sub(&header.Number.IntVal, &parent.Number.IntVal); diff.Cmp(common.Big1) != 0 {
+		return consensus.ErrInvalidNumber
+	}
+
+	//verify time
+	if header.Time.IntVal.Cmp(&parent.Time.IntVal) <= 0 {
+		return consensus.ErrBlockTime
+	}
+	if err := consensus.VerifySlotTime(chain.Config(), header, parent); err != nil {
+		return err
+	}
+
+	//the validator set of the header must be the one of the parent changed by the vote of the signer
+	parentSnap, err := p.Snapshot(chain, parent)
+	if err != nil {
+		return err
+	}
+	snap, err := DecodeSnapshot(&header.ConsensusData)
+	if err != nil {
+		return err
+	}
+	signer, err := p.Author(chain, header)
+	if err != nil {
+		return consensus.ErrSignature
+	}
+	expected, err := parentSnap.apply(signer, snap.Vote, header.Number.IntVal.Uint64(), p.config.Epoch)
+	if err != nil {
+		return err
+	}
+	if !expected.equal(snap) {
+		return ErrMismatchingSnapshot
+	}
+
+	if seal {
+		return p.verifySeal(chain, header, parentSnap)
+	}
+	return nil
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
+// concurrently. The method returns a quit channel to abort the operations and
+// a results channel to retrieve the async verifications.
+func (p *Poa) VerifyHeaders(chain consensus.ChainReader, headers []*block.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	return consensus.VerifyHeaders(chain, headers, seals, func(header, parent *block.Header, seal bool) error {
+		return p.verifyHeader(chain, header, parent, seal)
+	})
+}
+
+// VerifySeal checks that the header is signed by the validator owning its slot.
+func (p *Poa) VerifySeal(chain consensus.ChainReader, header *block.Header) error {
+	parent := chain.GetHeader(header.ParentHash, header.Number.IntVal.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	snap, err := p.Snapshot(chain, parent)
+	if err != nil {
+		return err
+	}
+	return p.verifySeal(chain, header, snap)
+}
+
+func (p *Poa) verifySeal(chain consensus.ChainReader, header *block.Header, snap *Snapshot) error {
+	signer, err := p.Author(chain, header)
+	if err != nil {
+		return consensus.ErrSignature
+	}
+	if !snap.Contains(signer) {
+		return ErrUnauthorized
+	}
+	if snap.Proposer(header.Number.IntVal.Uint64()) != signer {
+		return consensus.ErrNotInTurn
+	}
+	return nil
+}
+
+// Weight implements consensus.Weigher, the weight of a block is the one of its signer
+// in the validator set recorded by the parent.
+func (p *Poa) Weight(chain consensus.ChainReader, header *block.Header) (uint64, error) {
+	parent := chain.GetHeader(header.ParentHash, header.Number.IntVal.Uint64()-1)
+	if parent == nil {
+		return 0, consensus.ErrUnknownAncestor
+	}
+	snap, err := p.Snapshot(chain, parent)
+	if err != nil {
+		return 0, err
+	}
+	signer, err := p.Author(chain, header)
+	if err != nil {
+		return 0, consensus.ErrSignature
+	}
+	if !snap.Contains(signer) {
+		return 0, ErrUnauthorized
+	}
+	return snap.weightOf([]types.Address{signer}), nil
+}
+
+// Prepare fills the ConsensusData of the header if the local key owns its slot,
+// casting the first proposal which still changes the validator set.
+func (p *Poa) Prepare(chain consensus.ChainReader, header *block.Header) error {
+	p.lock.RLock()
+	prv := p.prv
+	p.lock.RUnlock()
+	if prv == nil {
+		return errMissingKey
+	}
+	signer := crypto.PubkeyToAddress(prv.PublicKey)
+
+	number := header.Number.IntVal.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	parentSnap, err := p.Snapshot(chain, parent)
+	if err != nil {
+		return err
+	}
+	if !parentSnap.Contains(signer) {
+		return ErrUnauthorized
+	}
+	if parentSnap.Proposer(number) != signer {
+		return consensus.ErrNotInTurn
+	}
+
+	var vote *Vote
+	for _, proposal := range p.Proposals() {
+		if parentSnap.validVote(&proposal) {
+			vote = &proposal
+			break
+		}
+	}
+	snap, err := parentSnap.apply(signer, vote, number, p.config.Epoch)
+	if err != nil {
+		return err
+	}
+	if header.ConsensusData, err = snap.ConsensusData(); err != nil {
+		return err
+	}
+	header.BlockProducer = signer
+	return nil
+}
+
+func (p *Poa) Finalize(chain consensus.ChainReader, header *block.Header, state *state.StateDB, txs []*transaction.Transaction, receipts []*transaction.Receipt, rewards *consensus.RewardState, sign bool) (*block.Block, error) {
+	if rewards != nil {
+		if err := consensus.AccumulateRewards(chain.Config(), header, state, receipts, rewards); err != nil {
+			return nil, err
+		}
+	}
+	header.StateRootHash = state.IntermediateRoot()
+	blk := block.NewBlock(header, txs, receipts)
+	if !sign {
+		return blk, nil
+	}
+
+	p.lock.RLock()
+	prv := p.prv
+	p.lock.RUnlock()
+	if prv == nil {
+		return nil, errMissingKey
+	}
+	if err := block.SignHeaderInner(blk.B_header, block.NewBlockSigner(chain.Config().ChainId), prv); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+func (p *Poa) Seal(chain consensus.ChainReader, block *block.Block, stop <-chan struct{}) (*block.Block, error) {
+	header := block.Header()
+	return block.WithSeal(header), nil
+}
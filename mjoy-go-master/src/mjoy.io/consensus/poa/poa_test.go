@@ -0,0 +1,474 @@
+package poa
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"mjoy.io/common/types"
+	"mjoy.io/communication/rpc"
+	"mjoy.io/consensus"
+	"mjoy.io/consensus/consensustest"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/interpreter"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/interpreter/intertest"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/staking"
+	"mjoy.io/core/sdk"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/database"
+)
+
+// produce makes the next header with engine, it is not added to the chain
+func produce(chain *consensustest.Chain, engine *Poa) (*block.Header, error) {
+	blk, err := chain.Produce(engine, nil)
+	if err != nil {
+		return nil, err
+	}
+	return blk.Header(), nil
+}
+
+// step makes the next header with the engine owning the slot and inserts it after verifying
+func step(t *testing.T, chain *consensustest.Chain, engines map[types.Address]*Poa) *Snapshot {
+	for _, engine := range engines {
+		header, err := produce(chain, engine)
+		if err == consensus.ErrNotInTurn || err == ErrUnauthorized {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("produce: %v", err)
+		}
+		if err := engine.VerifyHeader(chain, header, true); err != nil {
+			t.Fatalf("verify block %d: %v", header.Number.IntVal.Uint64(), err)
+		}
+		if err := chain.InsertHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		snap, err := engine.Snapshot(chain, header)
+		if err != nil {
+			t.Fatalf("snapshot: %v", err)
+		}
+		return snap
+	}
+	t.Fatalf("no validator in turn for block %d", chain.Height()+1)
+	return nil
+}
+
+func newValidators(t *testing.T, n int) ([]*ecdsa.PrivateKey, []types.Address) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	addrs := make([]types.Address, n)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	return keys, addrs
+}
+
+func newTestConfig(addrs []types.Address, weights ...uint64) *params.ChainConfig {
+	poa := &params.PoaConfig{}
+	for i, addr := range addrs {
+		v := params.PoaValidator{Address: addr}
+		if i < len(weights) {
+			v.Weight = weights[i]
+		}
+		poa.Validators = append(poa.Validators, v)
+	}
+	return &params.ChainConfig{ChainId: big.NewInt(101), Poa: poa}
+}
+
+func TestProposerSlots(t *testing.T) {
+	_, addrs := newValidators(t, 3)
+	snap, err := newGenesisSnapshot(newTestConfig(addrs, 1, 3, 0).Poa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []types.Address{addrs[0], addrs[1], addrs[1], addrs[1], addrs[2], addrs[0]}
+	for i, addr := range want {
+		if got := snap.Proposer(uint64(i)); got != addr {
+			t.Errorf("slot %d: proposer %x, want %x", i, got, addr)
+		}
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	keys, addrs := newValidators(t, 4)
+	config := newTestConfig(addrs[:3])
+	chain := consensustest.NewChain(config, 0)
+
+	engines := make(map[types.Address]*Poa)
+	for i, key := range keys {
+		engines[addrs[i]] = New(config.Poa, key)
+	}
+	for i := 1; i <= 6; i++ {
+		step(t, chain, engines)
+		signer, err := engines[addrs[0]].Author(chain, chain.CurrentHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signer != addrs[i%3] {
+			t.Errorf("block %d signed by %x, want %x", i, signer, addrs[i%3])
+		}
+	}
+
+	// a validator out of turn and an outsider can not sign the next block
+	if _, err := produce(chain, engines[addrs[0]]); err != consensus.ErrNotInTurn {
+		t.Errorf("out of turn prepare: %v, want %v", err, consensus.ErrNotInTurn)
+	}
+	if _, err := produce(chain, engines[addrs[3]]); err != ErrUnauthorized {
+		t.Errorf("outsider prepare: %v, want %v", err, ErrUnauthorized)
+	}
+
+	// the same header signed by the wrong keys
+	header, _ := produce(chain, engines[addrs[1]])
+	verifier := New(config.Poa, nil)
+	for i, want := range []error{consensus.ErrNotInTurn, ErrUnauthorized} {
+		forged := block.CopyHeader(header)
+		if err := block.SignHeaderInner(forged, block.NewBlockSigner(config.ChainId), keys[2+i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := verifier.VerifyHeader(chain, forged, true); err != want {
+			t.Errorf("header signed by key %d: %v, want %v", 2+i, err, want)
+		}
+	}
+	if err := verifier.VerifyHeader(chain, header, true); err != nil {
+		t.Errorf("header: %v", err)
+	}
+}
+
+func TestVoting(t *testing.T) {
+	keys, addrs := newValidators(t, 4)
+	config := newTestConfig(addrs[:3])
+	chain := consensustest.NewChain(config, 0)
+
+	engines := make(map[types.Address]*Poa)
+	for i, key := range keys {
+		engines[addrs[i]] = New(config.Poa, key)
+	}
+
+	// one vote of three is not enough
+	engines[addrs[1]].Propose(addrs[3], 2)
+	var snap *Snapshot
+	for i := 0; i < 3; i++ {
+		snap = step(t, chain, engines)
+	}
+	if snap.Contains(addrs[3]) || len(snap.Tallies) != 1 || len(snap.Tallies[0].Voters) != 1 {
+		t.Fatalf("validator added by one vote: %+v", snap)
+	}
+
+	// the second vote adds it with its weight
+	engines[addrs[2]].Propose(addrs[3], 2)
+	for i := 0; i < 3; i++ {
+		snap = step(t, chain, engines)
+	}
+	if !snap.Contains(addrs[3]) || snap.TotalWeight() != 5 || len(snap.Tallies) != 0 {
+		t.Fatalf("validator not added: %+v", snap)
+	}
+	engines[addrs[1]].Discard(addrs[3])
+	engines[addrs[2]].Discard(addrs[3])
+
+	// the weight of the new validator counts when removing validator 0
+	engines[addrs[3]].ProposeRemoval(addrs[0])
+	engines[addrs[1]].ProposeRemoval(addrs[0])
+	for i := 0; i < 5 && snap.Contains(addrs[0]); i++ {
+		snap = step(t, chain, engines)
+	}
+	if snap.Contains(addrs[0]) || len(snap.Validators) != 3 {
+		t.Fatalf("validator not removed: %+v", snap)
+	}
+	if _, err := produce(chain, engines[addrs[0]]); err != ErrUnauthorized && err != consensus.ErrNotInTurn {
+		t.Errorf("removed validator prepare: %v", err)
+	}
+}
+
+func TestVerifyConsensusData(t *testing.T) {
+	keys, addrs := newValidators(t, 2)
+	config := newTestConfig(addrs)
+	chain := consensustest.NewChain(config, 0)
+	engine := New(config.Poa, keys[1])
+	verifier := New(config.Poa, nil)
+	signer := block.NewBlockSigner(config.ChainId)
+
+	header, err := produce(chain, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a set changed without votes
+	snap, _ := DecodeSnapshot(&header.ConsensusData)
+	snap.Validators = snap.Validators[:1]
+	forged := block.CopyHeader(header)
+	forged.ConsensusData, _ = snap.ConsensusData()
+	block.SignHeaderInner(forged, signer, keys[1])
+	if err := verifier.VerifyHeader(chain, forged, true); err != ErrMismatchingSnapshot {
+		t.Errorf("changed set: %v, want %v", err, ErrMismatchingSnapshot)
+	}
+
+	// a vote for a validator already in the set
+	snap, _ = DecodeSnapshot(&header.ConsensusData)
+	snap.Vote = &Vote{Validator: addrs[0], Authorize: true, Weight: 1}
+	forged.ConsensusData, _ = snap.ConsensusData()
+	block.SignHeaderInner(forged, signer, keys[1])
+	if err := verifier.VerifyHeader(chain, forged, true); err != ErrInvalidVote {
+		t.Errorf("useless vote: %v, want %v", err, ErrInvalidVote)
+	}
+
+	forged.ConsensusData = block.ConsensusData{Id: "basic"}
+	block.SignHeaderInner(forged, signer, keys[1])
+	if err := verifier.VerifyHeader(chain, forged, true); err != ErrInvalidConsensusData {
+		t.Errorf("foreign data: %v, want %v", err, ErrInvalidConsensusData)
+	}
+
+	// batch verification checks the headers against each other
+	headers := []*block.Header{header}
+	if err := chain.InsertHeader(header); err != nil {
+		t.Fatal(err)
+	}
+	next, err := produce(chain, New(config.Poa, keys[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.Rewind(0)
+	headers = append(headers, next, forged)
+	forged.ParentHash = next.Hash()
+	forged.Number = types.NewBigInt(*big.NewInt(3))
+	forged.Time = types.NewBigInt(*big.NewInt(3))
+	block.SignHeaderInner(forged, signer, keys[1])
+
+	_, results := verifier.VerifyHeaders(chain, headers, []bool{true, true, true})
+	for i, want := range []error{nil, nil, ErrInvalidConsensusData} {
+		if err := <-results; err != want {
+			t.Errorf("header %d: %v, want %v", i, err, want)
+		}
+	}
+}
+
+func TestFinalizeRewards(t *testing.T) {
+	keys, addrs := newValidators(t, 1)
+	treasury := types.Address{9}
+	config := newTestConfig(addrs)
+	config.Reward = &params.RewardConfig{InitialReward: big.NewInt(1000), HalvingInterval: 2, TreasuryShare: 10, Treasury: treasury}
+	engine := New(config.Poa, nil)
+	engine.SetKey(keys[0])
+
+	db, _ := database.OpenMemDB()
+	statedb, _ := state.New(types.Hash{}, state.NewDatabase(db))
+	sysparam := intertypes.MakeSystemParams(sdk.NewTmpStatusManager(db, statedb, addrs[0]), nil)
+	// the transactions of the block paid their fees to the producer
+	fees, _ := balancetransfer.EncodeBalance(big.NewInt(200), false)
+	sdk.Sys_SetValue(sysparam.SdkHandler, balancetransfer.BalanceTransferAddress, addrs[0][:], fees)
+	receipts := []*transaction.Receipt{{ResourceUsed: 150}, {ResourceUsed: 50}}
+
+	// the reward of block 3 was halved once
+	header := &block.Header{Number: types.NewBigInt(*big.NewInt(3)), Time: types.NewBigInt(*big.NewInt(3))}
+	rewards := &consensus.RewardState{SysParams: sysparam, Cache: make(map[string]interpreter.MemDatabase)}
+	blk, err := engine.Finalize(consensustest.NewChain(config, 0), header, statedb, nil, receipts, rewards, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for addr, want := range map[types.Address]int64{addrs[0]: 200 + 450 - 20, treasury: 50 + 20} {
+		balance, err := balancetransfer.BalanceOf(sysparam, addr)
+		if err != nil || balance.Int64() != want {
+			t.Errorf("balance of %x: have %v %v, want %d", addr, balance, err, want)
+		}
+	}
+	if len(rewards.Cache) != 2 {
+		t.Errorf("%d values cached, want 2", len(rewards.Cache))
+	}
+	if blk.Root() == (types.Hash{}) || blk.Root() != statedb.IntermediateRoot() {
+		t.Errorf("state root %x does not hold the rewards", blk.Root())
+	}
+}
+
+func TestFinalizeStakingRewards(t *testing.T) {
+	keys, addrs := newValidators(t, 1)
+	validator, delegator, treasury := addrs[0], types.Address{8}, types.Address{9}
+	config := newTestConfig(addrs)
+	config.StakingBlock = big.NewInt(0)
+	config.Reward = &params.RewardConfig{InitialReward: big.NewInt(1000), HalvingInterval: 2, TreasuryShare: 10, Treasury: treasury}
+	engine := New(config.Poa, nil)
+	engine.SetKey(keys[0])
+
+	db, _ := database.OpenMemDB()
+	statedb, _ := state.New(types.Hash{}, state.NewDatabase(db))
+	sysparam := intertypes.MakeSystemParams(sdk.NewTmpStatusManager(db, statedb, validator), intertest.NewVm(map[types.Address]intertest.Contract{
+		balancetransfer.BalanceTransferAddress: balancetransfer.NewContractBalancer(),
+		staking.StakingAddress:                 staking.NewContractStaking(),
+	}))
+	for _, addr := range []types.Address{validator, delegator} {
+		if _, err := balancetransfer.Reward(sysparam, addr, big.NewInt(20000)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the validator takes 10% of its rewards and shares the rest with an equal stake
+	if _, err := intertest.AsAt(validator, 1, sysparam, staking.NewContractStaking(), staking.MakeRegisterCandidateParam(10, big.NewInt(10000))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := intertest.AsAt(delegator, 1, sysparam, staking.NewContractStaking(), staking.MakeDelegateParam(validator, big.NewInt(10000))); err != nil {
+		t.Fatal(err)
+	}
+	sysparam.SdkHandler.TakeCallWrites()
+
+	// the producer share of block 3 is 450
+	header := &block.Header{Number: types.NewBigInt(*big.NewInt(3)), Time: types.NewBigInt(*big.NewInt(3))}
+	rewards := &consensus.RewardState{SysParams: sysparam, Cache: make(map[string]interpreter.MemDatabase)}
+	blk, err := engine.Finalize(consensustest.NewChain(config, 0), header, statedb, nil, nil, rewards, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blk.Root() != statedb.IntermediateRoot() {
+		t.Errorf("state root %x does not hold the rewards", blk.Root())
+	}
+	for addr, want := range map[types.Address]int64{validator: 10000, delegator: 10000, staking.StakingAddress: 20000 + 450, treasury: 50} {
+		if balance, err := balancetransfer.BalanceOf(sysparam, addr); err != nil || balance.Int64() != want {
+			t.Errorf("balance of %x: have %v %v, want %d", addr, balance, err, want)
+		}
+	}
+
+	// the delegator claims half of the 405 shared, the validator the rest and the commission
+	for addr, want := range map[types.Address]int64{delegator: 10000 + 202, validator: 10000 + 45 + 202} {
+		if _, err := intertest.AsAt(addr, 4, sysparam, staking.NewContractStaking(), staking.MakeClaimRewardsParam(validator)); err != nil {
+			t.Fatal(err)
+		}
+		if balance, err := balancetransfer.BalanceOf(sysparam, addr); err != nil || balance.Int64() != want {
+			t.Errorf("balance of %x after the claim: have %v %v, want %d", addr, balance, err, want)
+		}
+	}
+}
+
+func TestBlockReward(t *testing.T) {
+	tests := []struct {
+		config params.RewardConfig
+		number uint64
+		want   int64
+	}{
+		{params.RewardConfig{InitialReward: big.NewInt(1000)}, 1000000, 1000},
+		{params.RewardConfig{InitialReward: big.NewInt(1000), HalvingInterval: 10}, 9, 1000},
+		{params.RewardConfig{InitialReward: big.NewInt(1000), HalvingInterval: 10}, 25, 250},
+		{params.RewardConfig{InitialReward: big.NewInt(1000), HalvingInterval: 10, DecayPercent: 10}, 20, 810},
+		{params.RewardConfig{InitialReward: big.NewInt(1000), HalvingInterval: 10, DecayPercent: 100}, 10, 0},
+		{params.RewardConfig{InitialReward: big.NewInt(1000), HalvingInterval: 1}, 1 << 40, 0},
+		{params.RewardConfig{}, 1, 0},
+	}
+	for i, test := range tests {
+		if have := test.config.BlockReward(test.number); have.Int64() != test.want {
+			t.Errorf("test %d: reward of block %d is %d, want %d", i, test.number, have, test.want)
+		}
+	}
+}
+
+func TestAPI(t *testing.T) {
+	keys, addrs := newValidators(t, 3)
+	config := newTestConfig(addrs)
+	chain := consensustest.NewChain(config, 0)
+
+	engines := make(map[types.Address]*Poa)
+	for i, key := range keys {
+		engines[addrs[i]] = New(config.Poa, key)
+	}
+	engines[addrs[1]].Propose(addrs[2], 2)
+	for i := 0; i < 2; i++ {
+		step(t, chain, engines)
+	}
+	api := engines[addrs[0]].APIs(chain)[0].Service.(*API)
+
+	validators, err := api.GetValidators(nil)
+	if err != nil || len(validators) != 3 {
+		t.Fatalf("validators: %v %v", validators, err)
+	}
+	votes, err := api.GetVotes(nil)
+	if err != nil || len(votes) != 1 || votes[0].Vote.Validator != addrs[2] || votes[0].Voters[0] != addrs[1] {
+		t.Errorf("votes: %v %v", votes, err)
+	}
+	number := rpc.BlockNumber(1)
+	if signer, err := api.GetSigner(&number); err != nil || signer != addrs[1] {
+		t.Errorf("signer of block 1: %x %v, want %x", signer, err, addrs[1])
+	}
+	number = 5
+	if _, err := api.GetSigner(&number); err != consensus.ErrUnknownBlock {
+		t.Errorf("signer of unknown block: %v, want %v", err, consensus.ErrUnknownBlock)
+	}
+	proposers, err := api.GetProposers(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, proposer := range proposers {
+		if want := addrs[(i+3)%3]; proposer != want {
+			t.Errorf("proposer of block %d: %x, want %x", i+3, proposer, want)
+		}
+	}
+	if _, err := api.GetProposers(consensus.MaxScheduleLength + 1); err != consensus.ErrScheduleTooLong {
+		t.Errorf("long schedule: %v, want %v", err, consensus.ErrScheduleTooLong)
+	}
+}
+
+func TestPrivateAPI(t *testing.T) {
+	keys, addrs := newValidators(t, 4)
+	config := newTestConfig(addrs[:3])
+	chain := consensustest.NewChain(config, 0)
+
+	engines := make(map[types.Address]*Poa)
+	for i, key := range keys {
+		engines[addrs[i]] = New(config.Poa, key)
+	}
+	server := rpc.NewServer()
+	for _, api := range engines[addrs[1]].APIs(chain) {
+		if err := server.RegisterName(api.Namespace, api.Service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	weight := uint64(2)
+	if err := client.Call(nil, "poa_propose", addrs[3], true, &weight); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(nil, "poa_propose", addrs[0], false); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(nil, "poa_discard", addrs[0]); err != nil {
+		t.Fatal(err)
+	}
+	var votes []Vote
+	if err := client.Call(&votes, "poa_proposals"); err != nil {
+		t.Fatal(err)
+	}
+	if len(votes) != 1 || votes[0] != (Vote{Validator: addrs[3], Authorize: true, Weight: 2}) {
+		t.Fatalf("proposals: %+v", votes)
+	}
+
+	// the vote is cast in the blocks of the validator
+	var snap *Snapshot
+	for i := 0; i < 3; i++ {
+		snap = step(t, chain, engines)
+	}
+	if len(snap.Tallies) != 1 || snap.Tallies[0].Vote != votes[0] || snap.Tallies[0].Voters[0] != addrs[1] {
+		t.Errorf("tallies: %+v", snap.Tallies)
+	}
+}
+
+func TestWeight(t *testing.T) {
+	keys, addrs := newValidators(t, 2)
+	config := newTestConfig(addrs, 1, 3)
+	chain := consensustest.NewChain(config, 0)
+
+	engines := make(map[types.Address]*Poa)
+	for i, key := range keys {
+		engines[addrs[i]] = New(config.Poa, key)
+	}
+	for i := 1; i <= 4; i++ {
+		step(t, chain, engines)
+		signer, _ := engines[addrs[0]].Author(chain, chain.CurrentHeader())
+		weight, err := engines[addrs[0]].Weight(chain, chain.CurrentHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[types.Address]uint64{addrs[0]: 1, addrs[1]: 3}[signer]; weight != want {
+			t.Errorf("block %d signed by %x: weight %d, want %d", i, signer, weight, want)
+		}
+	}
+}
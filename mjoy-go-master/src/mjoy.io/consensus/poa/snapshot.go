@@ -0,0 +1,296 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: snapshot.go
+// @Date: 2018/07/09 14:02:51
+////////////////////////////////////////////////////////////////////////////////
+
+package poa
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/tinylib/msgp/msgp"
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+)
+
+//go:generate msgp
+
+// ConsensusId is the Id of the ConsensusData of the poa blocks
+const ConsensusId = "poa"
+
+var (
+	// ErrNoValidators is returned if the genesis validator set is empty.
+	ErrNoValidators = errors.New("no poa validators")
+
+	// ErrInvalidConsensusData is returned if the ConsensusData of a header
+	// is not a poa snapshot.
+	ErrInvalidConsensusData = errors.New("invalid poa consensus data")
+
+	// ErrUnauthorized is returned if a header is signed by a non-validator.
+	ErrUnauthorized = errors.New("unauthorized validator")
+
+	// ErrInvalidVote is returned if a vote does not change the validator set,
+	// such as adding a validator with its current weight or removing the last one.
+	ErrInvalidVote = errors.New("invalid validator vote")
+
+	// ErrMismatchingSnapshot is returned if the validator set of a header is not
+	// the one its parent's set and its vote lead to.
+	ErrMismatchingSnapshot = errors.New("mismatching validator snapshot")
+)
+
+// Validator is a block signer with its number of proposer slots per round.
+type Validator struct {
+	Address types.Address `json:"address"`
+	Weight  uint64        `json:"weight"`
+}
+
+// Vote is cast by the signer of a block. Authorize adds the validator, or
+// changes its weight, and a vote without Authorize removes it.
+type Vote struct {
+	Validator types.Address `json:"validator"`
+	Authorize bool          `json:"authorize"`
+	Weight    uint64        `json:"weight"`
+}
+
+// Tally is a pending change with the validators voting for it.
+type Tally struct {
+	Vote   Vote            `json:"vote"`
+	Voters []types.Address `json:"voters"`
+}
+
+// Snapshot is the validator set after a block, the pending votes and the vote
+// cast by the block. It is encoded in the ConsensusData of the block, so every
+// header can be checked against its parent only.
+type Snapshot struct {
+	Validators []Validator `json:"validators"`
+	Tallies    []Tally     `json:"tallies"`
+	Vote       *Vote       `json:"vote"`
+}
+
+// newGenesisSnapshot makes the snapshot of the genesis block from the config.
+func newGenesisSnapshot(config *params.PoaConfig) (*Snapshot, error) {
+	if config == nil || len(config.Validators) == 0 {
+		return nil, ErrNoValidators
+	}
+	snap := &Snapshot{}
+	for _, v := range config.Validators {
+		if snap.index(v.Address) >= 0 {
+			return nil, ErrInvalidVote
+		}
+		snap.Validators = append(snap.Validators, Validator{v.Address, normalizeWeight(v.Weight)})
+	}
+	return snap, nil
+}
+
+// DecodeSnapshot decodes the snapshot from the ConsensusData of a poa header.
+func DecodeSnapshot(data *block.ConsensusData) (*Snapshot, error) {
+	if data.Id != ConsensusId {
+		return nil, ErrInvalidConsensusData
+	}
+	snap := &Snapshot{}
+	if err := msgp.Decode(bytes.NewReader(data.Para), snap); err != nil {
+		return nil, ErrInvalidConsensusData
+	}
+	if len(snap.Validators) == 0 {
+		return nil, ErrInvalidConsensusData
+	}
+	return snap, nil
+}
+
+// ConsensusData encodes the snapshot for a header.
+func (s *Snapshot) ConsensusData() (block.ConsensusData, error) {
+	var buf bytes.Buffer
+	if err := msgp.Encode(&buf, s); err != nil {
+		return block.ConsensusData{}, err
+	}
+	return block.ConsensusData{Id: ConsensusId, Para: buf.Bytes()}, nil
+}
+
+func (s *Snapshot) copy() *Snapshot {
+	cpy := &Snapshot{
+		Validators: make([]Validator, len(s.Validators)),
+		Tallies:    make([]Tally, len(s.Tallies)),
+	}
+	copy(cpy.Validators, s.Validators)
+	for i, tally := range s.Tallies {
+		cpy.Tallies[i] = Tally{tally.Vote, append([]types.Address(nil), tally.Voters...)}
+	}
+	return cpy
+}
+
+func (s *Snapshot) index(address types.Address) int {
+	for i, v := range s.Validators {
+		if v.Address == address {
+			return i
+		}
+	}
+	return -1
+}
+
+// Contains returns whether address is in the validator set.
+func (s *Snapshot) Contains(address types.Address) bool {
+	return s.index(address) >= 0
+}
+
+// TotalWeight returns the number of proposer slots of a round.
+func (s *Snapshot) TotalWeight() uint64 {
+	total := uint64(0)
+	for _, v := range s.Validators {
+		total += v.Weight
+	}
+	return total
+}
+
+// Proposer returns the validator owning the slot of block number. The slots
+// of a round follow the set order, each validator getting Weight consecutive ones.
+func (s *Snapshot) Proposer(number uint64) types.Address {
+	slot := number % s.TotalWeight()
+	for _, v := range s.Validators {
+		if slot < v.Weight {
+			return v.Address
+		}
+		slot -= v.Weight
+	}
+	return types.Address{}
+}
+
+// validVote returns whether the vote would change the validator set.
+func (s *Snapshot) validVote(vote *Vote) bool {
+	i := s.index(vote.Validator)
+	if vote.Authorize {
+		return vote.Weight != 0 && (i < 0 || s.Validators[i].Weight != vote.Weight)
+	}
+	return vote.Weight == 0 && i >= 0 && len(s.Validators) > 1
+}
+
+// apply returns the snapshot after a block of number signed by signer casting vote.
+// The pending votes are dropped at every epoch block, and a change is made once the
+// weight of its voters is more than half of the total.
+func (s *Snapshot) apply(signer types.Address, vote *Vote, number, epoch uint64) (*Snapshot, error) {
+	if !s.Contains(signer) {
+		return nil, ErrUnauthorized
+	}
+	snap := s.copy()
+	if epoch != 0 && number%epoch == 0 {
+		snap.Tallies = nil
+	}
+	if vote == nil {
+		return snap, nil
+	}
+	if !snap.validVote(vote) {
+		return nil, ErrInvalidVote
+	}
+	v := *vote
+	snap.Vote = &v
+
+	// a validator has one vote for every target, a new one replaces the old
+	snap.discardVoter(signer, func(tally *Tally) bool {
+		return tally.Vote.Validator == v.Validator && tally.Vote != v
+	})
+	i := 0
+	for ; i < len(snap.Tallies); i++ {
+		if snap.Tallies[i].Vote == v {
+			break
+		}
+	}
+	if i == len(snap.Tallies) {
+		snap.Tallies = append(snap.Tallies, Tally{Vote: v})
+	}
+	tally := &snap.Tallies[i]
+	for _, voter := range tally.Voters {
+		if voter == signer {
+			return snap, nil
+		}
+	}
+	tally.Voters = append(tally.Voters, signer)
+
+	if 2*snap.weightOf(tally.Voters) <= snap.TotalWeight() {
+		return snap, nil
+	}
+	logger.Infof("poa vote passed,validator %s authorize %v weight %d", v.Validator.Hex(), v.Authorize, v.Weight)
+
+	tallies := snap.Tallies[:0]
+	for _, tally := range snap.Tallies {
+		if tally.Vote.Validator != v.Validator {
+			tallies = append(tallies, tally)
+		}
+	}
+	snap.Tallies = tallies
+
+	if j := snap.index(v.Validator); !v.Authorize {
+		snap.Validators = append(snap.Validators[:j], snap.Validators[j+1:]...)
+		snap.discardVoter(v.Validator, func(*Tally) bool { return true })
+	} else if j >= 0 {
+		snap.Validators[j].Weight = v.Weight
+	} else {
+		snap.Validators = append(snap.Validators, Validator{v.Validator, v.Weight})
+	}
+	return snap, nil
+}
+
+// discardVoter removes voter from the tallies matched by match, dropping the tallies left without voters.
+func (s *Snapshot) discardVoter(voter types.Address, match func(*Tally) bool) {
+	tallies := s.Tallies[:0]
+	for _, tally := range s.Tallies {
+		if match(&tally) {
+			voters := tally.Voters[:0]
+			for _, v := range tally.Voters {
+				if v != voter {
+					voters = append(voters, v)
+				}
+			}
+			tally.Voters = voters
+		}
+		if len(tally.Voters) > 0 {
+			tallies = append(tallies, tally)
+		}
+	}
+	s.Tallies = tallies
+}
+
+func (s *Snapshot) weightOf(voters []types.Address) uint64 {
+	weight := uint64(0)
+	for _, voter := range voters {
+		if i := s.index(voter); i >= 0 {
+			weight += s.Validators[i].Weight
+		}
+	}
+	return weight
+}
+
+// equal compares the snapshots as they are encoded.
+func (s *Snapshot) equal(other *Snapshot) bool {
+	a, err := s.ConsensusData()
+	if err != nil {
+		return false
+	}
+	b, err := other.ConsensusData()
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(a.Para, b.Para)
+}
+
+func normalizeWeight(weight uint64) uint64 {
+	if weight == 0 {
+		return 1
+	}
+	return weight
+}
@@ -0,0 +1,939 @@
+package poa
+
+// NOTE: THIS FILE WAS PRODUCED BY THE
+// MSGP CODE GENERATION TOOL (github.com/tinylib/msgp)
+// DO NOT EDIT
+
+import (
+	"github.com/tinylib/msgp/msgp"
+	"mjoy.io/common/types"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Snapshot) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Validators":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Validators) >= int(zb0002) {
+				z.Validators = (z.Validators)[:zb0002]
+			} else {
+				z.Validators = make([]Validator, zb0002)
+			}
+			for za0001 := range z.Validators {
+				var zb0003 uint32
+				zb0003, err = dc.ReadMapHeader()
+				if err != nil {
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Address":
+						err = z.Validators[za0001].Address.DecodeMsg(dc)
+						if err != nil {
+							return
+						}
+					case "Weight":
+						z.Validators[za0001].Weight, err = dc.ReadUint64()
+						if err != nil {
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
+		case "Tallies":
+			var zb0004 uint32
+			zb0004, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Tallies) >= int(zb0004) {
+				z.Tallies = (z.Tallies)[:zb0004]
+			} else {
+				z.Tallies = make([]Tally, zb0004)
+			}
+			for za0002 := range z.Tallies {
+				err = z.Tallies[za0002].DecodeMsg(dc)
+				if err != nil {
+					return
+				}
+			}
+		case "Vote":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					return
+				}
+				z.Vote = nil
+			} else {
+				if z.Vote == nil {
+					z.Vote = new(Vote)
+				}
+				var zb0005 uint32
+				zb0005, err = dc.ReadMapHeader()
+				if err != nil {
+					return
+				}
+				for zb0005 > 0 {
+					zb0005--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Validator":
+						err = z.Vote.Validator.DecodeMsg(dc)
+						if err != nil {
+							return
+						}
+					case "Authorize":
+						z.Vote.Authorize, err = dc.ReadBool()
+						if err != nil {
+							return
+						}
+					case "Weight":
+						z.Vote.Weight, err = dc.ReadUint64()
+						if err != nil {
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Snapshot) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "Validators"
+	err = en.Append(0x83, 0xaa, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Validators)))
+	if err != nil {
+		return
+	}
+	for za0001 := range z.Validators {
+		// map header, size 2
+		// write "Address"
+		err = en.Append(0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+		if err != nil {
+			return
+		}
+		err = z.Validators[za0001].Address.EncodeMsg(en)
+		if err != nil {
+			return
+		}
+		// write "Weight"
+		err = en.Append(0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+		if err != nil {
+			return
+		}
+		err = en.WriteUint64(z.Validators[za0001].Weight)
+		if err != nil {
+			return
+		}
+	}
+	// write "Tallies"
+	err = en.Append(0xa7, 0x54, 0x61, 0x6c, 0x6c, 0x69, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Tallies)))
+	if err != nil {
+		return
+	}
+	for za0002 := range z.Tallies {
+		err = z.Tallies[za0002].EncodeMsg(en)
+		if err != nil {
+			return
+		}
+	}
+	// write "Vote"
+	err = en.Append(0xa4, 0x56, 0x6f, 0x74, 0x65)
+	if err != nil {
+		return
+	}
+	if z.Vote == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		// map header, size 3
+		// write "Validator"
+		err = en.Append(0x83, 0xa9, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72)
+		if err != nil {
+			return
+		}
+		err = z.Vote.Validator.EncodeMsg(en)
+		if err != nil {
+			return
+		}
+		// write "Authorize"
+		err = en.Append(0xa9, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65)
+		if err != nil {
+			return
+		}
+		err = en.WriteBool(z.Vote.Authorize)
+		if err != nil {
+			return
+		}
+		// write "Weight"
+		err = en.Append(0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+		if err != nil {
+			return
+		}
+		err = en.WriteUint64(z.Vote.Weight)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Snapshot) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "Validators"
+	o = append(o, 0x83, 0xaa, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Validators)))
+	for za0001 := range z.Validators {
+		// map header, size 2
+		// string "Address"
+		o = append(o, 0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+		o, err = z.Validators[za0001].Address.MarshalMsg(o)
+		if err != nil {
+			return
+		}
+		// string "Weight"
+		o = append(o, 0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+		o = msgp.AppendUint64(o, z.Validators[za0001].Weight)
+	}
+	// string "Tallies"
+	o = append(o, 0xa7, 0x54, 0x61, 0x6c, 0x6c, 0x69, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Tallies)))
+	for za0002 := range z.Tallies {
+		o, err = z.Tallies[za0002].MarshalMsg(o)
+		if err != nil {
+			return
+		}
+	}
+	// string "Vote"
+	o = append(o, 0xa4, 0x56, 0x6f, 0x74, 0x65)
+	if z.Vote == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		// map header, size 3
+		// string "Validator"
+		o = append(o, 0x83, 0xa9, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72)
+		o, err = z.Vote.Validator.MarshalMsg(o)
+		if err != nil {
+			return
+		}
+		// string "Authorize"
+		o = append(o, 0xa9, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65)
+		o = msgp.AppendBool(o, z.Vote.Authorize)
+		// string "Weight"
+		o = append(o, 0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+		o = msgp.AppendUint64(o, z.Vote.Weight)
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Snapshot) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Validators":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Validators) >= int(zb0002) {
+				z.Validators = (z.Validators)[:zb0002]
+			} else {
+				z.Validators = make([]Validator, zb0002)
+			}
+			for za0001 := range z.Validators {
+				var zb0003 uint32
+				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Address":
+						bts, err = z.Validators[za0001].Address.UnmarshalMsg(bts)
+						if err != nil {
+							return
+						}
+					case "Weight":
+						z.Validators[za0001].Weight, bts, err = msgp.ReadUint64Bytes(bts)
+						if err != nil {
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
+		case "Tallies":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Tallies) >= int(zb0004) {
+				z.Tallies = (z.Tallies)[:zb0004]
+			} else {
+				z.Tallies = make([]Tally, zb0004)
+			}
+			for za0002 := range z.Tallies {
+				bts, err = z.Tallies[za0002].UnmarshalMsg(bts)
+				if err != nil {
+					return
+				}
+			}
+		case "Vote":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Vote = nil
+			} else {
+				if z.Vote == nil {
+					z.Vote = new(Vote)
+				}
+				var zb0005 uint32
+				zb0005, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					return
+				}
+				for zb0005 > 0 {
+					zb0005--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Validator":
+						bts, err = z.Vote.Validator.UnmarshalMsg(bts)
+						if err != nil {
+							return
+						}
+					case "Authorize":
+						z.Vote.Authorize, bts, err = msgp.ReadBoolBytes(bts)
+						if err != nil {
+							return
+						}
+					case "Weight":
+						z.Vote.Weight, bts, err = msgp.ReadUint64Bytes(bts)
+						if err != nil {
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Snapshot) Msgsize() (s int) {
+	s = 1 + 11 + msgp.ArrayHeaderSize
+	for za0001 := range z.Validators {
+		s += 1 + 8 + z.Validators[za0001].Address.Msgsize() + 7 + msgp.Uint64Size
+	}
+	s += 8 + msgp.ArrayHeaderSize
+	for za0002 := range z.Tallies {
+		s += z.Tallies[za0002].Msgsize()
+	}
+	s += 5
+	if z.Vote == nil {
+		s += msgp.NilSize
+	} else {
+		s += 1 + 10 + z.Vote.Validator.Msgsize() + 10 + msgp.BoolSize + 7 + msgp.Uint64Size
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Tally) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Vote":
+			var zb0002 uint32
+			zb0002, err = dc.ReadMapHeader()
+			if err != nil {
+				return
+			}
+			for zb0002 > 0 {
+				zb0002--
+				field, err = dc.ReadMapKeyPtr()
+				if err != nil {
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "Validator":
+					err = z.Vote.Validator.DecodeMsg(dc)
+					if err != nil {
+						return
+					}
+				case "Authorize":
+					z.Vote.Authorize, err = dc.ReadBool()
+					if err != nil {
+						return
+					}
+				case "Weight":
+					z.Vote.Weight, err = dc.ReadUint64()
+					if err != nil {
+						return
+					}
+				default:
+					err = dc.Skip()
+					if err != nil {
+						return
+					}
+				}
+			}
+		case "Voters":
+			var zb0003 uint32
+			zb0003, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Voters) >= int(zb0003) {
+				z.Voters = (z.Voters)[:zb0003]
+			} else {
+				z.Voters = make([]types.Address, zb0003)
+			}
+			for za0001 := range z.Voters {
+				err = z.Voters[za0001].DecodeMsg(dc)
+				if err != nil {
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Tally) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Vote"
+	// map header, size 3
+	// write "Validator"
+	err = en.Append(0x82, 0xa4, 0x56, 0x6f, 0x74, 0x65, 0x83, 0xa9, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	err = z.Vote.Validator.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	// write "Authorize"
+	err = en.Append(0xa9, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.Vote.Authorize)
+	if err != nil {
+		return
+	}
+	// write "Weight"
+	err = en.Append(0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Vote.Weight)
+	if err != nil {
+		return
+	}
+	// write "Voters"
+	err = en.Append(0xa6, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Voters)))
+	if err != nil {
+		return
+	}
+	for za0001 := range z.Voters {
+		err = z.Voters[za0001].EncodeMsg(en)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Tally) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Vote"
+	// map header, size 3
+	// string "Validator"
+	o = append(o, 0x82, 0xa4, 0x56, 0x6f, 0x74, 0x65, 0x83, 0xa9, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72)
+	o, err = z.Vote.Validator.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	// string "Authorize"
+	o = append(o, 0xa9, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65)
+	o = msgp.AppendBool(o, z.Vote.Authorize)
+	// string "Weight"
+	o = append(o, 0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+	o = msgp.AppendUint64(o, z.Vote.Weight)
+	// string "Voters"
+	o = append(o, 0xa6, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Voters)))
+	for za0001 := range z.Voters {
+		o, err = z.Voters[za0001].MarshalMsg(o)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Tally) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Vote":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			for zb0002 > 0 {
+				zb0002--
+				field, bts, err = msgp.ReadMapKeyZC(bts)
+				if err != nil {
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "Validator":
+					bts, err = z.Vote.Validator.UnmarshalMsg(bts)
+					if err != nil {
+						return
+					}
+				case "Authorize":
+					z.Vote.Authorize, bts, err = msgp.ReadBoolBytes(bts)
+					if err != nil {
+						return
+					}
+				case "Weight":
+					z.Vote.Weight, bts, err = msgp.ReadUint64Bytes(bts)
+					if err != nil {
+						return
+					}
+				default:
+					bts, err = msgp.Skip(bts)
+					if err != nil {
+						return
+					}
+				}
+			}
+		case "Voters":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Voters) >= int(zb0003) {
+				z.Voters = (z.Voters)[:zb0003]
+			} else {
+				z.Voters = make([]types.Address, zb0003)
+			}
+			for za0001 := range z.Voters {
+				bts, err = z.Voters[za0001].UnmarshalMsg(bts)
+				if err != nil {
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Tally) Msgsize() (s int) {
+	s = 1 + 5 + 1 + 10 + z.Vote.Validator.Msgsize() + 10 + msgp.BoolSize + 7 + msgp.Uint64Size + 7 + msgp.ArrayHeaderSize
+	for za0001 := range z.Voters {
+		s += z.Voters[za0001].Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Validator) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Address":
+			err = z.Address.DecodeMsg(dc)
+			if err != nil {
+				return
+			}
+		case "Weight":
+			z.Weight, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Validator) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Address"
+	err = en.Append(0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+	if err != nil {
+		return
+	}
+	err = z.Address.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	// write "Weight"
+	err = en.Append(0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Weight)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Validator) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Address"
+	o = append(o, 0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+	o, err = z.Address.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	// string "Weight"
+	o = append(o, 0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+	o = msgp.AppendUint64(o, z.Weight)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Validator) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Address":
+			bts, err = z.Address.UnmarshalMsg(bts)
+			if err != nil {
+				return
+			}
+		case "Weight":
+			z.Weight, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Validator) Msgsize() (s int) {
+	s = 1 + 8 + z.Address.Msgsize() + 7 + msgp.Uint64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Vote) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Validator":
+			err = z.Validator.DecodeMsg(dc)
+			if err != nil {
+				return
+			}
+		case "Authorize":
+			z.Authorize, err = dc.ReadBool()
+			if err != nil {
+				return
+			}
+		case "Weight":
+			z.Weight, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Vote) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "Validator"
+	err = en.Append(0x83, 0xa9, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	err = z.Validator.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	// write "Authorize"
+	err = en.Append(0xa9, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.Authorize)
+	if err != nil {
+		return
+	}
+	// write "Weight"
+	err = en.Append(0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Weight)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Vote) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "Validator"
+	o = append(o, 0x83, 0xa9, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72)
+	o, err = z.Validator.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	// string "Authorize"
+	o = append(o, 0xa9, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65)
+	o = msgp.AppendBool(o, z.Authorize)
+	// string "Weight"
+	o = append(o, 0xa6, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74)
+	o = msgp.AppendUint64(o, z.Weight)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Vote) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Validator":
+			bts, err = z.Validator.UnmarshalMsg(bts)
+			if err != nil {
+				return
+			}
+		case "Authorize":
+			z.Authorize, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				return
+			}
+		case "Weight":
+			z.Weight, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Vote) Msgsize() (s int) {
+	s = 1 + 10 + z.Validator.Msgsize() + 10 + msgp.BoolSize + 7 + msgp.Uint64Size
+	return
+}
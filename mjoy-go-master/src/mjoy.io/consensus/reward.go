@@ -0,0 +1,130 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: reward.go
+// @Date: 2018/07/25 16:20:43
+////////////////////////////////////////////////////////////////////////////////
+
+package consensus
+
+import (
+	"errors"
+	"math/big"
+
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/interpreter"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/staking"
+	"mjoy.io/core/sdk"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+)
+
+var errNoCoinbase = errors.New("no coinbase to reward")
+
+// RewardState is the contract storage the transactions of a block left, Finalize
+// pays the block rewards with it. SysParams reads and writes the storage, Cache
+// collects the values written, to be stored with the block.
+type RewardState struct {
+	SysParams *intertypes.SystemParams
+	Cache     map[string]interpreter.MemDatabase
+}
+
+// AccumulateRewards pays the producer of the block the reward of the schedule of
+// the chain, and moves the treasury share of the reward and of the resource fees
+// the transactions paid to the producer to the treasury. From the Staking fork on
+// the share of a producer which is a staking candidate goes through the staking
+// contract, so its delegators get their part. The state processor and the block
+// producer both run it through Finalize, so they reach the same root.
+func AccumulateRewards(config *params.ChainConfig, header *block.Header, statedb *state.StateDB, receipts []*transaction.Receipt, rewards *RewardState) error {
+	coinbase := sdk.Sys_GetCoinbase(rewards.SysParams.SdkHandler)
+	if coinbase == nil {
+		return errNoCoinbase
+	}
+	schedule := config.RewardSchedule()
+	reward := schedule.BlockReward(header.Number.IntVal.Uint64())
+
+	var used uint64
+	for _, receipt := range receipts {
+		used += receipt.ResourceUsed
+	}
+	fees := balancetransfer.ResourceFee(used)
+
+	treasuryReward := schedule.TreasuryPart(reward)
+	producerReward := new(big.Int).Sub(reward, treasuryReward)
+	results, err := balancetransfer.Reward(rewards.SysParams, *coinbase, producerReward)
+	if err != nil {
+		return err
+	}
+	rewards.write(statedb, balancetransfer.BalanceTransferAddress, results)
+	if config.IsStaking(&header.Number.IntVal) {
+		if err = rewards.distribute(statedb, *coinbase, producerReward); err != nil {
+			return err
+		}
+	}
+	if results, err = balancetransfer.Reward(rewards.SysParams, schedule.Treasury, treasuryReward); err != nil {
+		return err
+	}
+	rewards.write(statedb, balancetransfer.BalanceTransferAddress, results)
+	if results, err = balancetransfer.MoveFee(rewards.SysParams, *coinbase, schedule.Treasury, schedule.TreasuryPart(fees)); err != nil {
+		return err
+	}
+	rewards.write(statedb, balancetransfer.BalanceTransferAddress, results)
+	return nil
+}
+
+// distribute hands the reward the validator got to the staking contract, which
+// keeps the commission of the validator and shares the rest by its stake. A
+// validator which is no candidate, e.g. a configured one, keeps the reward.
+func (r *RewardState) distribute(statedb *state.StateDB, validator types.Address, amount *big.Int) error {
+	if amount.Sign() == 0 {
+		return nil
+	}
+	candidate, err := staking.IsCandidate(r.SysParams, validator)
+	if err != nil || !candidate {
+		return err
+	}
+	// the contract moves the reward out of the balance of the validator, as if
+	// the validator had sent the transaction
+	handler := r.SysParams.SdkHandler
+	handler.SetTxContext(&sdk.TxContext{Sender: validator})
+	defer handler.SetTxContext(nil)
+
+	results, err := staking.DistributeReward([]interface{}{validator, amount}, r.SysParams)
+	if err != nil {
+		return err
+	}
+	for _, write := range handler.TakeCallWrites() {
+		r.write(statedb, write.Address, []intertypes.ActionResult{{Key: write.Key, Val: write.Val}})
+	}
+	r.write(statedb, staking.StakingAddress, results)
+	return nil
+}
+
+// write stores the results of the contract at address like the state transition
+// does for the results of the actions
+func (r *RewardState) write(statedb *state.StateDB, address types.Address, results []intertypes.ActionResult) {
+	for _, result := range results {
+		storageKey := append(address.Bytes(), result.Key...)
+		valueHash := crypto.Keccak256Hash(result.Val)
+		statedb.SetState(address, crypto.Keccak256Hash(storageKey), valueHash)
+		r.Cache[string(storageKey)] = interpreter.MemDatabase{Address: address, Key: valueHash.Bytes(), Val: result.Val}
+	}
+}
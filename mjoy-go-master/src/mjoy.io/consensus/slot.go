@@ -0,0 +1,62 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: slot.go
+// @Date: 2018/07/27 10:05:31
+////////////////////////////////////////////////////////////////////////////////
+
+package consensus
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+)
+
+var (
+	// ErrBlockTooSoon is returned if a block follows its parent by less than the
+	// block interval of the slot schedule.
+	ErrBlockTooSoon = errors.New("block before the end of the interval")
+
+	// ErrEmptyBlock is returned if a chain skipping empty blocks gets one before its
+	// max idle time passed.
+	ErrEmptyBlock = errors.New("empty block before the max idle time")
+)
+
+// VerifySlotTime checks the time of header against the slot schedule of the chain, a
+// chain without a schedule has no rules beyond the ones of its engine.
+func VerifySlotTime(config *params.ChainConfig, header, parent *block.Header) error {
+	slot := config.Slot
+	if slot == nil {
+		return nil
+	}
+	if header.Time.IntVal.Cmp(big.NewInt(time.Now().Unix()+int64(params.AllowedFutureBlockTime))) > 0 {
+		return ErrFutureBlock
+	}
+	elapsed := new(big.Int).Sub(&header.Time.IntVal, &parent.Time.IntVal)
+	if elapsed.Cmp(new(big.Int).SetUint64(slot.Interval)) < 0 {
+		return ErrBlockTooSoon
+	}
+	if slot.SkipEmpty && header.TxRootHash == block.EmptyRootHash {
+		if slot.MaxIdle == 0 || elapsed.Cmp(new(big.Int).SetUint64(slot.MaxIdle)) < 0 {
+			return ErrEmptyBlock
+		}
+	}
+	return nil
+}
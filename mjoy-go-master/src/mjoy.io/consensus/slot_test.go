@@ -0,0 +1,64 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: slot_test.go
+// @Date: 2018/07/27 11:03:52
+////////////////////////////////////////////////////////////////////////////////
+
+package consensus
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+)
+
+func TestVerifySlotTime(t *testing.T) {
+	now := time.Now().Unix()
+	config := &params.ChainConfig{Slot: &params.SlotConfig{Interval: 5, SkipEmpty: true, MaxIdle: 60}}
+
+	header := func(t int64, txs bool) *block.Header {
+		h := &block.Header{Time: &types.BigInt{IntVal: *big.NewInt(t)}, TxRootHash: block.EmptyRootHash}
+		if txs {
+			h.TxRootHash = types.Hash{1}
+		}
+		return h
+	}
+	parent := header(now-100, true)
+
+	tests := []struct {
+		header *block.Header
+		err    error
+	}{
+		{header(now-97, true), ErrBlockTooSoon},
+		{header(now-95, true), nil},
+		{header(now-95, false), ErrEmptyBlock},
+		{header(now-40, false), nil},
+		{header(now+int64(params.AllowedFutureBlockTime)+10, true), ErrFutureBlock},
+	}
+	for i, tt := range tests {
+		if err := VerifySlotTime(config, tt.header, parent); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	if err := VerifySlotTime(&params.ChainConfig{}, header(now-99, false), parent); err != nil {
+		t.Errorf("error without a slot schedule: %v", err)
+	}
+}
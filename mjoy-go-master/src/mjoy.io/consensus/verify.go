@@ -0,0 +1,103 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: verify.go
+// @Date: 2018/07/30 11:12:46
+////////////////////////////////////////////////////////////////////////////////
+
+package consensus
+
+import (
+	"runtime"
+
+	"mjoy.io/core/blockchain/block"
+)
+
+// HeaderVerifier checks a header against its parent, seal tells whether the seal
+// is checked too.
+type HeaderVerifier func(header, parent *block.Header, seal bool) error
+
+// VerifyHeaders verifies a batch of headers concurrently with verify, the parent
+// of a header is the previous one of the batch or, for the first one, a header of
+// the chain. Headers the chain already has are not verified again. The method
+// returns a quit channel to abort the operations and a results channel to
+// retrieve the async verifications, in the order of the headers.
+func VerifyHeaders(chain ChainReader, headers []*block.Header, seals []bool, verify HeaderVerifier) (chan<- struct{}, <-chan error) {
+	workers := runtime.GOMAXPROCS(0)
+	if len(headers) < workers {
+		workers = len(headers)
+	}
+	// Create a task channel and spawn the verifiers
+	var (
+		inputs = make(chan int)
+		done   = make(chan int, workers)
+		errors = make([]error, len(headers))
+		abort  = make(chan struct{})
+	)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for index := range inputs {
+				errors[index] = verifyHeaderWorker(chain, headers, seals, index, verify)
+				done <- index
+			}
+		}()
+	}
+
+	errorsOut := make(chan error, len(headers))
+	go func() {
+		defer close(inputs)
+		var (
+			in, out = 0, 0
+			checked = make([]bool, len(headers))
+			inputs  = inputs
+		)
+		for {
+			select {
+			case inputs <- in:
+				if in++; in == len(headers) {
+					// Reached end of headers. Stop sending to workers.
+					inputs = nil
+				}
+			case index := <-done:
+				for checked[index] = true; checked[out]; out++ {
+					errorsOut <- errors[out]
+					if out == len(headers)-1 {
+						return
+					}
+				}
+			case <-abort:
+				return
+			}
+		}
+	}()
+	return abort, errorsOut
+}
+
+func verifyHeaderWorker(chain ChainReader, headers []*block.Header, seals []bool, index int, verify HeaderVerifier) error {
+	var parent *block.Header
+	if index == 0 {
+		parent = chain.GetHeader(headers[0].ParentHash, headers[0].Number.IntVal.Uint64()-1)
+	} else if headers[index-1].Hash() == headers[index].ParentHash {
+		parent = headers[index-1]
+	}
+	if parent == nil {
+		return ErrUnknownAncestor
+	}
+	if chain.GetHeader(headers[index].Hash(), headers[index].Number.IntVal.Uint64()) != nil {
+		return nil // known block
+	}
+	return verify(headers[index], parent, seals[index])
+}
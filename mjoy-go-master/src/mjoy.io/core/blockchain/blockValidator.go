@@ -28,6 +28,8 @@ import (
 	"mjoy.io/consensus"
 	"mjoy.io/core/blockchain/block"
 	"mjoy.io/core"
+	"mjoy.io/core/interpreter"
+	"mjoy.io/core/interpreter/balancetransfer"
 	"mjoy.io/params"
 )
 
@@ -68,6 +70,45 @@ func (v *BlockValidator) ValidateBody(blk *block.Block) error {
 	if hash := block.DeriveSha(blk.Transactions()); hash != header.TxRootHash {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash.String(), header.TxRootHash.String())
 	}
+	if err := v.validateSlotLimits(blk); err != nil {
+		return err
+	}
+	// The engine pays the block rewards, a transaction can not, and the contracts of
+	// forks that are not active yet can not be called
+	rules := v.config.Rules(&header.Number.IntVal)
+	for _, tx := range blk.Transactions() {
+		for _, action := range tx.Data.Actions {
+			if balancetransfer.IsRewardCall(action.Address, action.Params) {
+				return core.ErrRewardTransaction
+			}
+			if action.Address != nil && interpreter.InactiveInnerContract(*action.Address, rules) {
+				return core.ErrInactiveContract
+			}
+		}
+	}
+	return nil
+}
+
+// validateSlotLimits checks the transactions of the block against the limits of the
+// slot schedule
+func (v *BlockValidator) validateSlotLimits(blk *block.Block) error {
+	slot := v.config.Slot
+	if slot == nil {
+		return nil
+	}
+	txs := blk.Transactions()
+	if slot.MaxTxs > 0 && uint64(len(txs)) > slot.MaxTxs {
+		return core.ErrTooManyTransactions
+	}
+	if slot.MaxBytes > 0 {
+		size := uint64(0)
+		for _, tx := range txs {
+			size += uint64(tx.Size())
+		}
+		if size > slot.MaxBytes {
+			return core.ErrBlockTooLarge
+		}
+	}
 	return nil
 }
 
@@ -59,6 +59,7 @@ const (
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
 	badBlockLimit       = 10
+	signedHeaderLimit   = 1024
 	triesInMemory       = 128
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
@@ -124,6 +125,8 @@ type BlockChain struct {
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
 	logsFeed      event.Feed
+	doubleSignFeed event.Feed
+	forkRejectedFeed event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *block.Block
 
@@ -153,6 +156,11 @@ type BlockChain struct {
 	//vmConfig  vm.Config //todo for future vm
 
 	badBlocks *lru.Cache // Bad block cache
+
+	signedHeaders *lru.Cache // Last header seen signed by a validator at a height, to catch double signs
+
+	forkChoice    ForkChoice // Rule choosing the canonical chain between the branches
+	maxReorgDepth uint64     // Max number of canonical blocks a reorg drops, 0 for no limit
 }
 
 
@@ -165,6 +173,7 @@ func NewBlockChain(chainDb database.IDatabase, config *params.ChainConfig, engin
 	blockCache, _ := lru.New(blockCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 	badBlocks, _ := lru.New(badBlockLimit)
+	signedHeaders, _ := lru.New(signedHeaderLimit)
 
 	bc := &BlockChain{
 		config:       config,
@@ -177,6 +186,8 @@ func NewBlockChain(chainDb database.IDatabase, config *params.ChainConfig, engin
 		futureBlocks: futureBlocks,
 		engine:       engine,
 		badBlocks:    badBlocks,
+		signedHeaders: signedHeaders,
+		forkChoice:   NewFinalizedChain(engine, NewLongestChain()),
 	}
 	bc.SetValidator(NewBlockValidator(config, bc, engine))
 	bc.SetProcessor(stateprocessor.NewStateProcessor(config,bc, engine))
@@ -378,6 +389,42 @@ func (bc *BlockChain) SetValidator(validator Validator) {
 	bc.validator = validator
 }
 
+// SetForkChoice sets the rule choosing the canonical chain between the branches.
+func (bc *BlockChain) SetForkChoice(forkChoice ForkChoice) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.forkChoice = forkChoice
+}
+
+// SetMaxReorgDepth sets the max number of canonical blocks a reorg may drop, 0 for
+// no limit.
+func (bc *BlockChain) SetMaxReorgDepth(depth uint64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.maxReorgDepth = depth
+}
+
+// reorgNeeded asks the fork choice whether blk, off the canonical chain, replaces
+// the head, and checks the reorg is not deeper than the max depth. This method
+// assumes that the chain manager mutex is held.
+func (bc *BlockChain) reorgNeeded(blk *block.Block) (bool, error) {
+	current := bc.currentBlock.Header()
+	reorg, err := bc.forkChoice.ReorgNeeded(bc, current, blk.Header())
+	if err != nil || !reorg {
+		return false, err
+	}
+	if bc.maxReorgDepth > 0 {
+		fork := commonAncestor(bc, current, blk.Header())
+		if fork == nil {
+			return false, consensus.ErrUnknownAncestor
+		}
+		if current.Number.IntVal.Uint64()-fork.Number.IntVal.Uint64() > bc.maxReorgDepth {
+			return false, ErrReorgTooDeep
+		}
+	}
+	return true, nil
+}
+
 // Validator returns the current validator.
 func (bc *BlockChain) Validator() Validator {
 	bc.procmu.RLock()
@@ -803,8 +850,6 @@ func (bc *BlockChain) WriteBlockAndState(block *block.Block, receipts []*transac
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
-	bcCurrentBlockNumber := bc.currentBlock.NumberU64()
-
 	blockNumber := block.Number().Uint64()
 
 	// Write other block data using a batch.
@@ -819,8 +864,17 @@ func (bc *BlockChain) WriteBlockAndState(block *block.Block, receipts []*transac
 		return NonStatTy, err
 	}
 
-	//if new bigger block number coming, need reorg the chain
-	reorg := blockNumber > bcCurrentBlockNumber
+	//a block on the head extends the chain, the fork choice decides for the other ones
+	reorg := block.ParentHash() == bc.currentBlock.Hash()
+	if !reorg {
+		reorg, err = bc.reorgNeeded(block)
+		if err != nil {
+			//the other chain stays a side chain
+			logger.Warn("Rejected fork", "number", blockNumber, "hash", block.Hash(), "err", err)
+			go bc.forkRejectedFeed.Send(core.ForkRejectedEvent{Block: block, Err: err})
+			reorg = false
+		}
+	}
 
 	if reorg {
 		// Reorganise the chain if the parent is not the head block
@@ -860,6 +914,7 @@ func (bc *BlockChain) WriteBlockAndState(block *block.Block, receipts []*transac
 	bc.futureBlocks.Remove(block.Hash())
 	return status, nil
 }
+
 // InsertChain attempts to insert the given batch of blocks in to the canonical
 // chain or, otherwise, create a fork. If an error is returned it will return
 // the index number of the failing block as well an error describing what went
@@ -927,6 +982,7 @@ func (bc *BlockChain) insertChain(chain block.Blocks) (int, []interface{}, []*tr
 
 		err := <-results
 		if err == nil {
+			bc.checkDoubleSign(blk.Header())
 			err = bc.Validator().ValidateBody(blk)
 		}
 		if err != nil {
@@ -987,6 +1043,9 @@ func (bc *BlockChain) insertChain(chain block.Blocks) (int, []interface{}, []*tr
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
+		if importer, ok := bc.engine.(consensus.Importer); ok {
+			importer.Imported(bc, blk.Header())
+		}
 
 
 		switch status {
@@ -1259,6 +1318,9 @@ func (bc *BlockChain) InsertHeaderChain(chain []*block.Header, checkFreq int) (i
 	if i, err := bc.hc.ValidateHeaderChain(chain, checkFreq); err != nil {
 		return i, err
 	}
+	for _, header := range chain {
+		bc.checkDoubleSign(block.CopyHeader(header))
+	}
 
 	// Make sure only one thread manipulates the chain at once
 	bc.chainmu.Lock()
@@ -1337,6 +1399,12 @@ func (bc *BlockChain) GetHeaderByNumber(number uint64) *block.Header {
 	return bc.hc.GetHeaderByNumber(number)
 }
 
+// ElectedValidators retrieves the validators the staking contract elects for the
+// block number.
+func (bc *BlockChain) ElectedValidators(number uint64) ([]types.Address, error) {
+	return bc.hc.ElectedValidators(number)
+}
+
 // Config retrieves the blockchain's chain configuration.
 func (bc *BlockChain) Config() *params.ChainConfig { return bc.config }
 
@@ -1380,6 +1448,16 @@ func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) eve
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
 }
 
+// SubscribeDoubleSignEvent registers a subscription of DoubleSignEvent.
+func (bc *BlockChain) SubscribeDoubleSignEvent(ch chan<- core.DoubleSignEvent) event.Subscription {
+	return bc.scope.Track(bc.doubleSignFeed.Subscribe(ch))
+}
+
+// SubscribeForkRejectedEvent registers a subscription of ForkRejectedEvent.
+func (bc *BlockChain) SubscribeForkRejectedEvent(ch chan<- core.ForkRejectedEvent) event.Subscription {
+	return bc.scope.Track(bc.forkRejectedFeed.Subscribe(ch))
+}
+
 // SubscribeLogsEvent registers a subscription of []*types.Log.
 func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*transaction.Log) event.Subscription {
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
@@ -0,0 +1,66 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: doublesign.go
+// @Date: 2018/07/23 09:41:17
+////////////////////////////////////////////////////////////////////////////////
+
+package blockchain
+
+import (
+	"mjoy.io/common/types"
+	"mjoy.io/core"
+	"mjoy.io/core/blockchain/block"
+)
+
+// signedHeaderKey identifies the header a validator signed at a height.
+type signedHeaderKey struct {
+	signer types.Address
+	number uint64
+}
+
+// checkDoubleSign compares a verified header with the header its signer signed
+// before at the same height, the last one seen or else the canonical one. If the
+// signed contents differ, a DoubleSignEvent carrying both headers is posted so
+// the signer can be reported, whichever of the two ends up canonical.
+func (bc *BlockChain) checkDoubleSign(header *block.Header) {
+	if header.Number == nil || header.Number.IntVal.Sign() == 0 {
+		return
+	}
+	signer, err := bc.engine.Author(bc, header)
+	if err != nil {
+		return
+	}
+	key := signedHeaderKey{signer: signer, number: header.Number.IntVal.Uint64()}
+
+	var seen *block.Header
+	if cached, ok := bc.signedHeaders.Get(key); ok {
+		seen = cached.(*block.Header)
+	} else if canon := bc.GetHeaderByNumber(key.number); canon != nil {
+		canon = block.CopyHeader(canon)
+		if author, err := bc.engine.Author(bc, canon); err == nil && author == signer {
+			seen = canon
+		}
+	}
+	bc.signedHeaders.Add(key, header)
+
+	if seen == nil || seen.HashNoSig() == header.HashNoSig() {
+		return
+	}
+	logger.Warn("Validator signed two headers of a height", "signer", signer.Hex(), "number", key.number,
+		"first", seen.Hash().Hex(), "second", header.Hash().Hex())
+	go bc.doubleSignFeed.Send(core.DoubleSignEvent{First: seen, Second: header})
+}
@@ -0,0 +1,183 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: forkchoice.go
+// @Date: 2018/08/01 09:35:12
+////////////////////////////////////////////////////////////////////////////////
+
+package blockchain
+
+import (
+	"errors"
+
+	"mjoy.io/consensus"
+	"mjoy.io/core/blockchain/block"
+)
+
+var (
+	// ErrFinalizedReorg is returned by the fork choice for a branch dropping the last
+	// block finalized by the consensus engine.
+	ErrFinalizedReorg = errors.New("reorg dropping a finalized block")
+
+	// ErrReorgTooDeep is returned for a branch dropping more blocks of the canonical
+	// chain than the max reorg depth.
+	ErrReorgTooDeep = errors.New("reorg deeper than the max depth")
+)
+
+// ForkChoice decides which of two branches of the chain is the canonical one.
+type ForkChoice interface {
+	// ReorgNeeded reports whether the branch of header, a block off the canonical
+	// chain, replaces the branch of the current head. An error is returned if the
+	// branch is rejected whatever its length or weight.
+	ReorgNeeded(chain consensus.ChainReader, current, header *block.Header) (bool, error)
+}
+
+// longestChain prefers the branch with the highest block.
+type longestChain struct{}
+
+// NewLongestChain returns the fork choice of the longest chain, a branch replaces
+// the canonical chain once it is longer.
+func NewLongestChain() ForkChoice {
+	return longestChain{}
+}
+
+func (longestChain) ReorgNeeded(chain consensus.ChainReader, current, header *block.Header) (bool, error) {
+	return header.Number.IntVal.Cmp(&current.Number.IntVal) > 0, nil
+}
+
+// heaviestChain prefers the branch with the most validator weight since the fork.
+type heaviestChain struct {
+	engine consensus.Engine
+}
+
+// NewHeaviestChain returns the fork choice of the heaviest chain, a branch replaces
+// the canonical chain once the weight of its signers since the fork is higher. The
+// weights are the ones of the engine if it is a consensus.Weigher, every block
+// weighs 1 else.
+func NewHeaviestChain(engine consensus.Engine) ForkChoice {
+	return &heaviestChain{engine: engine}
+}
+
+func (h *heaviestChain) ReorgNeeded(chain consensus.ChainReader, current, header *block.Header) (bool, error) {
+	fork := commonAncestor(chain, current, header)
+	if fork == nil {
+		return false, consensus.ErrUnknownAncestor
+	}
+	currentWeight, err := h.branchWeight(chain, current, fork)
+	if err != nil {
+		return false, err
+	}
+	weight, err := h.branchWeight(chain, header, fork)
+	if err != nil {
+		return false, err
+	}
+	return weight > currentWeight, nil
+}
+
+// branchWeight sums the weights of the blocks from header down to fork, excluded
+func (h *heaviestChain) branchWeight(chain consensus.ChainReader, header, fork *block.Header) (uint64, error) {
+	weigher, _ := h.engine.(consensus.Weigher)
+
+	total := uint64(0)
+	for ; header != nil && header.Hash() != fork.Hash(); header = chain.GetHeader(header.ParentHash, header.Number.IntVal.Uint64()-1) {
+		if weigher == nil {
+			total++
+			continue
+		}
+		weight, err := weigher.Weight(chain, header)
+		if err != nil {
+			return 0, err
+		}
+		total += weight
+	}
+	if header == nil {
+		return 0, consensus.ErrUnknownAncestor
+	}
+	return total, nil
+}
+
+// finalizedChain rejects the branches dropping the last finalized block.
+type finalizedChain struct {
+	engine consensus.Engine
+	base   ForkChoice
+}
+
+// NewFinalizedChain returns a fork choice rejecting the branches which drop the last
+// block finalized by the engine if it is a consensus.Finalizer, base chooses between
+// the other ones.
+func NewFinalizedChain(engine consensus.Engine, base ForkChoice) ForkChoice {
+	return &finalizedChain{engine: engine, base: base}
+}
+
+func (f *finalizedChain) ReorgNeeded(chain consensus.ChainReader, current, header *block.Header) (bool, error) {
+	reorg, err := f.base.ReorgNeeded(chain, current, header)
+	if err != nil || !reorg {
+		return reorg, err
+	}
+	if !f.keepsFinalized(chain, header) {
+		return false, ErrFinalizedReorg
+	}
+	return true, nil
+}
+
+// keepsFinalized returns whether the chain of header contains the last block
+// finalized by the consensus engine.
+func (f *finalizedChain) keepsFinalized(chain consensus.ChainReader, header *block.Header) bool {
+	finalizer, ok := f.engine.(consensus.Finalizer)
+	if !ok {
+		return true
+	}
+	number, hash, ok := finalizer.Finalized()
+	if !ok {
+		return true
+	}
+	if header.Number.IntVal.Uint64() < number {
+		//the finalized block must descend from header
+		finalized := chain.GetHeader(hash, number)
+		if finalized == nil {
+			return true
+		}
+		ancestor := ancestor(chain, finalized, header.Number.IntVal.Uint64())
+		return ancestor != nil && ancestor.Hash() == header.Hash()
+	}
+	ancestor := ancestor(chain, header, number)
+	return ancestor != nil && ancestor.Hash() == hash
+}
+
+// ancestor returns the ancestor of header with the number, nil if a header is missing.
+func ancestor(chain consensus.ChainReader, header *block.Header, number uint64) *block.Header {
+	for header != nil && header.Number.IntVal.Uint64() > number {
+		header = chain.GetHeader(header.ParentHash, header.Number.IntVal.Uint64()-1)
+	}
+	return header
+}
+
+// commonAncestor returns the last block a and b descend from, nil if a header is missing.
+func commonAncestor(chain consensus.ChainReader, a, b *block.Header) *block.Header {
+	if a.Number.IntVal.Uint64() > b.Number.IntVal.Uint64() {
+		a = ancestor(chain, a, b.Number.IntVal.Uint64())
+	} else {
+		b = ancestor(chain, b, a.Number.IntVal.Uint64())
+	}
+	for a != nil && b != nil && a.Hash() != b.Hash() {
+		a = chain.GetHeader(a.ParentHash, a.Number.IntVal.Uint64()-1)
+		b = chain.GetHeader(b.ParentHash, b.Number.IntVal.Uint64()-1)
+	}
+	if a == nil || b == nil {
+		return nil
+	}
+	return a
+}
@@ -0,0 +1,156 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: forkchoice_test.go
+// @Date: 2018/08/01 11:20:46
+////////////////////////////////////////////////////////////////////////////////
+
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"mjoy.io/common/types"
+	"mjoy.io/consensus"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+)
+
+// forkChain is a chain of headers with branches, the weight of a header is its
+// producer's first byte
+type forkChain struct {
+	headers map[types.Hash]*block.Header
+}
+
+func (c *forkChain) Config() *params.ChainConfig  { return params.TestChainConfig }
+func (c *forkChain) CurrentHeader() *block.Header { return nil }
+func (c *forkChain) GetHeader(hash types.Hash, number uint64) *block.Header {
+	return c.headers[hash]
+}
+func (c *forkChain) GetHeaderByNumber(number uint64) *block.Header   { return nil }
+func (c *forkChain) GetHeaderByHash(hash types.Hash) *block.Header   { return c.headers[hash] }
+func (c *forkChain) GetBlock(hash types.Hash, number uint64) *block.Block { return nil }
+func (c *forkChain) ElectedValidators(number uint64) ([]types.Address, error) {
+	return nil, nil
+}
+
+// extend adds n headers after parent weighing weight each and returns the last one
+func (c *forkChain) extend(parent *block.Header, n int, weight byte) *block.Header {
+	for i := 0; i < n; i++ {
+		header := &block.Header{
+			ParentHash:    parent.Hash(),
+			Number:        types.NewBigInt(*new(big.Int).Add(&parent.Number.IntVal, big.NewInt(1))),
+			Time:          types.NewBigInt(*big.NewInt(int64(len(c.headers)))),
+			BlockProducer: types.Address{weight},
+		}
+		c.headers[header.Hash()] = header
+		parent = header
+	}
+	return parent
+}
+
+func newForkChain() (*forkChain, *block.Header) {
+	genesis := &block.Header{Number: types.NewBigInt(*big.NewInt(0)), Time: types.NewBigInt(*big.NewInt(0))}
+	c := &forkChain{headers: map[types.Hash]*block.Header{genesis.Hash(): genesis}}
+	return c, genesis
+}
+
+// forkEngine weighs the headers by producer and finalizes a fixed block
+type forkEngine struct {
+	consensus.Engine
+	finalized *block.Header
+}
+
+func (e *forkEngine) Weight(chain consensus.ChainReader, header *block.Header) (uint64, error) {
+	return uint64(header.BlockProducer[0]), nil
+}
+
+func (e *forkEngine) Finalized() (uint64, types.Hash, bool) {
+	if e.finalized == nil {
+		return 0, types.Hash{}, false
+	}
+	return e.finalized.Number.IntVal.Uint64(), e.finalized.Hash(), true
+}
+
+func TestLongestChain(t *testing.T) {
+	chain, genesis := newForkChain()
+	current := chain.extend(genesis, 3, 1)
+
+	fc := NewLongestChain()
+	if reorg, _ := fc.ReorgNeeded(chain, current, chain.extend(genesis, 3, 5)); reorg {
+		t.Errorf("reorg to a branch as long as the head")
+	}
+	if reorg, _ := fc.ReorgNeeded(chain, current, chain.extend(genesis, 4, 1)); !reorg {
+		t.Errorf("no reorg to a longer branch")
+	}
+}
+
+func TestHeaviestChain(t *testing.T) {
+	chain, genesis := newForkChain()
+	fork := chain.extend(genesis, 2, 1)
+	current := chain.extend(fork, 3, 1)
+
+	fc := NewHeaviestChain(&forkEngine{})
+	if reorg, err := fc.ReorgNeeded(chain, current, chain.extend(fork, 2, 2)); err != nil || !reorg {
+		t.Errorf("no reorg to a shorter heavier branch: %v", err)
+	}
+	if reorg, err := fc.ReorgNeeded(chain, current, chain.extend(fork, 5, 0)); err != nil || reorg {
+		t.Errorf("reorg to a longer lighter branch: %v", err)
+	}
+	if reorg, _ := fc.ReorgNeeded(chain, current, chain.extend(fork, 3, 1)); reorg {
+		t.Errorf("reorg to a branch as heavy as the head")
+	}
+
+	// without weights every block weighs 1
+	fc = NewHeaviestChain(nil)
+	if reorg, _ := fc.ReorgNeeded(chain, current, chain.extend(fork, 4, 0)); !reorg {
+		t.Errorf("no reorg to a longer branch without weights")
+	}
+}
+
+func TestFinalizedChain(t *testing.T) {
+	chain, genesis := newForkChain()
+	fork := chain.extend(genesis, 2, 1)
+	finalized := chain.extend(fork, 1, 1)
+	current := chain.extend(finalized, 2, 1)
+
+	engine := &forkEngine{finalized: finalized}
+	fc := NewFinalizedChain(engine, NewLongestChain())
+	if _, err := fc.ReorgNeeded(chain, current, chain.extend(fork, 5, 1)); err != ErrFinalizedReorg {
+		t.Errorf("reorg dropping the finalized block: %v, want %v", err, ErrFinalizedReorg)
+	}
+	if reorg, err := fc.ReorgNeeded(chain, current, chain.extend(finalized, 3, 1)); err != nil || !reorg {
+		t.Errorf("no reorg to a longer branch keeping the finalized block: %v", err)
+	}
+	engine.finalized = nil
+	if reorg, err := fc.ReorgNeeded(chain, current, chain.extend(fork, 5, 1)); err != nil || !reorg {
+		t.Errorf("no reorg without a finalized block: %v", err)
+	}
+}
+
+func TestCommonAncestor(t *testing.T) {
+	chain, genesis := newForkChain()
+	fork := chain.extend(genesis, 2, 1)
+	a, b := chain.extend(fork, 4, 1), chain.extend(fork, 1, 2)
+
+	if ancestor := commonAncestor(chain, a, b); ancestor == nil || ancestor.Hash() != fork.Hash() {
+		t.Errorf("common ancestor %v, want %v", ancestor, fork.Number)
+	}
+	if ancestor := commonAncestor(chain, b, fork); ancestor == nil || ancestor.Hash() != fork.Hash() {
+		t.Errorf("common ancestor of a block and its parent %v, want %v", ancestor, fork.Number)
+	}
+}
@@ -35,11 +35,16 @@ import (
 	"mjoy.io/common/math"
 	"errors"
 	"mjoy.io/consensus"
+	"mjoy.io/core/state"
+	"mjoy.io/core/sdk"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/staking"
 )
 
 const (
 	headerCacheLimit = 512
 	numberCacheLimit = 2048
+	electedCacheLimit = 16
 )
 
 // HeaderChain implements the basic block header chain logic that is shared by
@@ -58,6 +63,7 @@ type HeaderChain struct {
 
 	headerCache *lru.Cache // Cache for the most recent block headers
 	numberCache *lru.Cache // Cache for the most recent block numbers
+	electedCache *lru.Cache // Cache for the validators elected on the most recent election blocks
 
 	procInterrupt func() bool
 
@@ -72,6 +78,7 @@ type HeaderChain struct {
 func NewHeaderChain(chainDb database.IDatabase, config *params.ChainConfig, engine consensus.Engine, procInterrupt func() bool) (*HeaderChain, error) {
 	headerCache, _ := lru.New(headerCacheLimit)
 	numberCache, _ := lru.New(numberCacheLimit)
+	electedCache, _ := lru.New(electedCacheLimit)
 
 	// Seed a fast but crypto originating random generator
 	seed, err := crand.Int(crand.Reader, big.NewInt(math.MaxInt64))
@@ -84,6 +91,7 @@ func NewHeaderChain(chainDb database.IDatabase, config *params.ChainConfig, engi
 		chainDb:       chainDb,
 		headerCache:   headerCache,
 		numberCache:   numberCache,
+		electedCache:  electedCache,
 		procInterrupt: procInterrupt,
 		rand:          mrand.New(mrand.NewSource(seed.Int64())),
 		engine:        engine,
@@ -319,6 +327,31 @@ func (hc *HeaderChain) GetHeaderByNumber(number uint64) *block.Header {
 	return hc.GetHeader(hash, number)
 }
 
+// ElectedValidators retrieves the validators the staking contract elects for the
+// block number, that is on the state of the last canonical block of the previous
+// epoch. The state has to be available.
+func (hc *HeaderChain) ElectedValidators(number uint64) ([]types.Address, error) {
+	header := hc.GetHeaderByNumber(staking.ElectionBlock(number))
+	if header == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	hash := header.Hash()
+	if validators, ok := hc.electedCache.Get(hash); ok {
+		return validators.([]types.Address), nil
+	}
+	statedb, err := state.New(header.StateRootHash, state.NewDatabase(hc.chainDb))
+	if err != nil {
+		return nil, err
+	}
+	sdkHandler := sdk.NewTmpStatusManager(hc.chainDb, statedb, types.Address{})
+	validators, err := staking.Elect(intertypes.MakeSystemParams(sdkHandler, nil))
+	if err != nil {
+		return nil, err
+	}
+	hc.electedCache.Add(hash, validators)
+	return validators, nil
+}
+
 // CurrentHeader retrieves the current head header of the canonical chain. The
 // header is retrieved from the HeaderChain's internal cache.
 func (hc *HeaderChain) CurrentHeader() *block.Header {
@@ -36,4 +36,40 @@ var (
 	// ErrNonceTooLow is returned if the nonce of a transaction is lower than the
 	// one present in the local chain.
 	ErrNonceTooLow = errors.New("nonce too low")
+
+	// ErrResourceLimitTooHigh is returned if a transaction declares a resource limit
+	// above params.MaxTxResourceLimit.
+	ErrResourceLimitTooHigh = errors.New("resource limit too high")
+
+	// ErrInsufficientFundsForFee is returned if the sender of a transaction can not
+	// pay the fee of the resource limit it declares.
+	ErrInsufficientFundsForFee = errors.New("insufficient funds for resource fee")
+
+	// ErrTxNotYetValid is returned if a transaction is applied in a block before
+	// the valid after bound it declares.
+	ErrTxNotYetValid = errors.New("transaction not yet valid")
+
+	// ErrTxExpired is returned if a transaction is applied in a block after the
+	// valid until bound it declares.
+	ErrTxExpired = errors.New("transaction expired")
+
+	// ErrTxTimeBoundUnchecked is returned if a transaction declaring a bound in
+	// block time is applied in a context without a block time to check it against.
+	ErrTxTimeBoundUnchecked = errors.New("transaction time bound unchecked")
+
+	// ErrRewardTransaction is returned if a block carries a transaction paying a block
+	// reward, the rewards are paid by the consensus engine.
+	ErrRewardTransaction = errors.New("reward transaction in block")
+
+	// ErrInactiveContract is returned if a block carries a transaction calling an inner
+	// contract whose fork is not active at the block yet.
+	ErrInactiveContract = errors.New("contract not active at block")
+
+	// ErrTooManyTransactions is returned if a block carries more transactions than
+	// the slot schedule allows.
+	ErrTooManyTransactions = errors.New("too many transactions in block")
+
+	// ErrBlockTooLarge is returned if the transactions of a block take more bytes than
+	// the slot schedule allows.
+	ErrBlockTooLarge = errors.New("block transactions too large")
 )
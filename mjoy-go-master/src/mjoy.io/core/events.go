@@ -57,3 +57,17 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *block.Block }
+
+// DoubleSignEvent is posted when two different headers of the same height signed
+// by the same validator are seen, the headers are the evidence to report.
+type DoubleSignEvent struct {
+	First  *block.Header
+	Second *block.Header
+}
+
+// ForkRejectedEvent is posted when the fork choice rejects a branch, e.g. one dropping
+// a finalized block or deeper than the max reorg depth. Block stays a side block.
+type ForkRejectedEvent struct {
+	Block *block.Block
+	Err   error
+}
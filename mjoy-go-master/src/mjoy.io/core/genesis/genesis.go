@@ -30,16 +30,24 @@ import (
 	"mjoy.io/core/blockchain"
 	"mjoy.io/core/blockchain/block"
 	"mjoy.io/core/state"
+	"mjoy.io/common/types/util/hex"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/utils/crypto"
 )
 
 var errGenesisNoConfig = errors.New("genesis has no chain configuration")
 
+// the block number of a hash that is not in the database
+const missingNumber = uint64(0xffffffffffffffff)
+
 // Genesis specifies the header fields, state of a genesis block. It also defines hard
 // fork switch-over blocks through the chain configuration.
 type Genesis struct {
 	Config     *params.ChainConfig `json:"config"`
 	Timestamp  uint64              `json:"timestamp"`
 	Alloc      GenesisAlloc        `json:"alloc"`
+	// values of inner contracts,balances are given in Alloc
+	ContractStorage []GenesisContractValue `json:"contractStorage,omitempty"`
 
 	// These fields are used for consensus tests. Please don't use them
 	// in actual genesis blocks.
@@ -50,10 +58,27 @@ type Genesis struct {
 type GenesisAlloc map[types.Address]GenesisAccount
 
 // GenesisAccount is an account in the state of the genesis block.
+// Storage holds raw trie entries,the balance is kept by the balancetransfer contract.
 type GenesisAccount struct {
 	Code       []byte                      `json:"code,omitempty"`
 	Storage    map[types.Hash]types.Hash   `json:"storage,omitempty"`
 	Nonce      uint64                      `json:"nonce,omitempty"`
+	Balance    *big.Int                    `json:"balance,omitempty"`
+}
+
+// GenesisContractValue is a value an inner contract reads with sdk.Sys_GetValue.
+type GenesisContractValue struct {
+	Contract   types.Address   `json:"contract"`
+	Key        hex.Bytes       `json:"key"`
+	Value      hex.Bytes       `json:"value"`
+}
+
+// contractValue is a value of the genesis state and its hashes in the state trie
+type contractValue struct {
+	contract  types.Address
+	keyHash   types.Hash
+	valueHash types.Hash
+	value     []byte
 }
 
 // GenesisMismatchError is raised when trying to overwrite an existing
@@ -84,6 +109,11 @@ func SetupGenesisBlock(db database.IDatabase, genesis *Genesis) (*params.ChainCo
 	if genesis != nil && genesis.Config == nil {
 		return params.DefaultChainConfig, types.Hash{}, errGenesisNoConfig
 	}
+	if genesis != nil {
+		if _, err := genesis.contractValues(); err != nil {
+			return genesis.Config, types.Hash{}, err
+		}
+	}
 
 	// Just commit the new block if there is no stored genesis block.
 	stored := blockchain.GetCanonicalHash(db, 0)
@@ -104,23 +134,37 @@ func SetupGenesisBlock(db database.IDatabase, genesis *Genesis) (*params.ChainCo
 		hash := block.Hash()
 		if hash != stored {
 			return genesis.Config, block.Hash(), &GenesisMismatchError{stored, hash}
-		}else{
-			return genesis.Config, block.Hash(), nil
 		}
 	}
 
-	//genesis == nil, return the dabatase genesis block
+	// Get the existing chain configuration.
+	newcfg := genesis.configOrDefault(stored)
 	storedcfg, err := blockchain.GetChainConfig(db, stored)
 	if err != nil {
 		if err == blockchain.ErrChainConfigNotFound {
 			// This case happens if a genesis write was interrupted.
 			logger.Warn("Found genesis block without chain config")
-			err = blockchain.WriteChainConfig(db, stored, params.DefaultChainConfig)
+			err = blockchain.WriteChainConfig(db, stored, newcfg)
 		}
-		return params.DefaultChainConfig, stored, err
+		return newcfg, stored, err
+	}
+	// Special case: don't change the existing config of a non-default chain if no new
+	// config is supplied.
+	if genesis == nil {
+		return storedcfg, stored, nil
 	}
-	return storedcfg,stored,nil
 
+	// Check config compatibility and write the config. Compatibility errors
+	// are returned to the caller unless we're already at block zero.
+	height := blockchain.GetBlockNumber(db, blockchain.GetHeadHeaderHash(db))
+	if height == missingNumber {
+		return newcfg, stored, fmt.Errorf("missing block number for head header hash")
+	}
+	compatErr := storedcfg.CheckCompatible(newcfg, height)
+	if compatErr != nil && height != 0 && compatErr.RewindTo != 0 {
+		return newcfg, stored, compatErr
+	}
+	return newcfg, stored, blockchain.WriteChainConfig(db, stored, newcfg)
 }
 
 func (g *Genesis) configOrDefault(ghash types.Hash) *params.ChainConfig {
@@ -137,13 +181,73 @@ func (g *Genesis) configOrDefault(ghash types.Hash) *params.ChainConfig {
 func DefaultGenesisBlock() *Genesis {
 	return &Genesis{
 		Config:     params.DefaultChainConfig,
-		Alloc: map[types.Address]GenesisAccount{
-			// todo : here  just avoid stateobject deltete empty object bug when inner contract has no code field
-			types.Address{}: {Code: []byte{1,2,3,4,5}},
+	}
+}
+
+// DeveloperGenesisBlock returns the genesis block of a local development chain, signed by
+// faucet which holds the whole supply. A zero period seals a block whenever transactions
+// arrive.
+func DeveloperGenesisBlock(period uint64, faucet types.Address) *Genesis {
+	config := &params.ChainConfig{
+		ChainId:         big.NewInt(int64(params.DevChainId)),
+		BigBalanceBlock: big.NewInt(0),
+		StakingBlock:    big.NewInt(0),
+		RewardBlock:     big.NewInt(0),
+		Dev:             &params.DevConfig{Period: period, Signer: faucet},
+	}
+	return &Genesis{
+		Config: config,
+		Alloc: GenesisAlloc{
+			faucet: {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(27), nil)},
 		},
 	}
 }
 
+// contractValues returns the balances and contract storage of the genesis, keyed the way
+// sdk.TmpStatusManager looks them up: the state trie maps the hash of the contract address
+// and key to the hash of the value, and the value store maps that hash to the value.
+func (g *Genesis) contractValues() ([]contractValue, error) {
+	config := g.Config
+	if config == nil {
+		config = params.DefaultChainConfig
+	}
+	// a chain forking at the genesis never runs the balance upgrade, its balances start upgraded
+	legacy := !config.IsBigBalance(big.NewInt(0))
+
+	values := []contractValue{}
+	add := func(contract types.Address, key, value []byte) {
+		storageKey := append(contract.Bytes(), key...)
+		values = append(values, contractValue{
+			contract:  contract,
+			keyHash:   crypto.Keccak256Hash(storageKey),
+			valueHash: crypto.Keccak256Hash(value),
+			value:     value,
+		})
+	}
+	for addr, account := range g.Alloc {
+		if account.Balance == nil {
+			continue
+		}
+		data, err := balancetransfer.EncodeBalance(account.Balance, legacy)
+		if err != nil {
+			return nil, fmt.Errorf("genesis balance of %x: %v", addr, err)
+		}
+		add(balancetransfer.BalanceTransferAddress, addr[:], data)
+	}
+	// the format record is what switches the contract to big balances, so it is written even
+	// when no account is funded yet
+	if !legacy {
+		add(balancetransfer.BalanceTransferAddress, balancetransfer.BalanceFormatKey, balancetransfer.BalanceFormatBig)
+	}
+	for i, entry := range g.ContractStorage {
+		if len(entry.Key) == 0 || len(entry.Value) == 0 {
+			return nil, fmt.Errorf("genesis contract storage %d: empty key or value", i)
+		}
+		add(entry.Contract, entry.Key, entry.Value)
+	}
+	return values, nil
+}
+
 
 // ToBlock creates the block and state of a genesis specification.
 func (g *Genesis) ToBlock() (*block.Block, *state.StateDB) {
@@ -156,6 +260,14 @@ func (g *Genesis) ToBlock() (*block.Block, *state.StateDB) {
 			statedb.SetState(addr, key, value)
 		}
 	}
+	values, err := g.contractValues()
+	if err != nil {
+		// SetupGenesisBlock and Commit check the values first
+		panic(err)
+	}
+	for _, value := range values {
+		statedb.SetState(value.contract, value.keyHash, value.valueHash)
+	}
 	root := statedb.IntermediateRoot()
 	head := &block.Header{
 		Number:     		types.NewBigInt(*new(big.Int).SetUint64(g.Number)),
@@ -170,13 +282,24 @@ func (g *Genesis) ToBlock() (*block.Block, *state.StateDB) {
 // Commit writes the block and state of a genesis specification to the database.
 // The block is committed as the canonical head block.
 func (g *Genesis) Commit(db database.IDatabase) (*block.Block, error) {
+	values, err := g.contractValues()
+	if err != nil {
+		return nil, err
+	}
 	block, statedb := g.ToBlock()
 	if block.Number().Sign() != 0 {
 		return nil, fmt.Errorf("can't commit genesis block with number > 0")
 	}
-	if _, err := statedb.CommitTo(db, false); err != nil {
+	// ToBlock dropped the empty accounts from the root, drop them here too
+	if _, err := statedb.CommitTo(db, true); err != nil {
 		return nil, fmt.Errorf("cannot write state: %v", err)
 	}
+	// the values the state trie points to
+	for _, value := range values {
+		if err := db.Put(value.valueHash[:], value.value); err != nil {
+			return nil, err
+		}
+	}
 	if err := blockchain.WriteBlock(db, block); err != nil {
 		return nil, err
 	}
@@ -212,7 +335,6 @@ func (g *Genesis) MustCommit(db database.IDatabase) *block.Block {
 }
 
 func GenesisBlockForTesting(db database.IDatabase, addr types.Address, balance *big.Int) *block.Block {
-	//todo
-	g := Genesis{Alloc: GenesisAlloc{addr: {}}}
+	g := Genesis{Alloc: GenesisAlloc{addr: {Balance: balance}}}
 	return g.MustCommit(db)
 }
\ No newline at end of file
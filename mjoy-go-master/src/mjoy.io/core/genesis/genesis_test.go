@@ -30,6 +30,10 @@ import (
 	"mjoy.io/common/types"
 	"mjoy.io/utils/database"
 	"mjoy.io/core/blockchain"
+	"mjoy.io/core/state"
+	"mjoy.io/core/sdk"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/balancetransfer"
 )
 
 var defaultGenesisHexHash = "a0623059b648f7b592166b5c165e5c0c79a018d11ef739c049d451884f8c23eb"
@@ -45,18 +49,18 @@ func TestDefaultGenesisBlock(t *testing.T) {
 
 func TestSetupGenesis(t *testing.T) {
 	var (
-		customghash = types.HexToHash("0x11873b8c6b93ee8e86d78734a089f5f2b546cf29d3e9ec785fe84253eca91e6b")
+		customghash = types.HexToHash("0xc559a8e216e29dce2a35d40dd1f80db9ad0c667a33403a1fa52c8c9dc59396c3")
 		customg     = Genesis{
-			Config:  &params.ChainConfig{big.NewInt(500)},
+			Config:  &params.ChainConfig{ChainId: big.NewInt(500)},
 			Alloc: GenesisAlloc{
 				{1}: {Balance: big.NewInt(1), Storage: map[types.Hash]types.Hash{{1}: {1}}},
 			},
 		}
 		oldcustomg = customg
 
-		customghash2 = types.HexToHash("0xab0d510f06569cb8a0a2e60eaa6a0bd1ba1a0fd5d94d5f49cc771bcae08ef648")
+		customghash2 = types.HexToHash("0x0e9c736fcb475e42fc965f8843a48fd8e09934415cc6ae6a543d5d256591b2a0")
 		customg2     = Genesis{
-			Config:  &params.ChainConfig{big.NewInt(700)},
+			Config:  &params.ChainConfig{ChainId: big.NewInt(700)},
 			Alloc: GenesisAlloc{
 				{1}: {Balance: big.NewInt(2), Storage: map[types.Hash]types.Hash{{2}: {2}}},
 			},
@@ -123,6 +127,15 @@ func TestSetupGenesis(t *testing.T) {
 			wantHash:   customghash,
 			wantConfig: customg.Config,
 		},
+		{
+			name: "compatible config in DB",
+			fn: func(db database.IDatabase) (*params.ChainConfig, types.Hash, error) {
+				oldcustomg.MustCommit(db)
+				return SetupGenesisBlock(db, &customg)
+			},
+			wantHash:   customghash,
+			wantConfig: customg.Config,
+		},
 
 	}
 
@@ -148,3 +161,81 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+func TestGenesisContractValues(t *testing.T) {
+	funded := types.Address{1}
+	contract := types.Address{9}
+	big1 := new(big.Int).Lsh(big.NewInt(1), 100)
+	tests := []struct {
+		config  *params.ChainConfig
+		balance *big.Int
+		err     bool
+	}{
+		{config: &params.ChainConfig{ChainId: big.NewInt(1)}, balance: big.NewInt(1000)},
+		{config: &params.ChainConfig{ChainId: big.NewInt(1)}, balance: big1, err: true},
+		{config: &params.ChainConfig{ChainId: big.NewInt(1)}, balance: big.NewInt(-1), err: true},
+		{config: &params.ChainConfig{ChainId: big.NewInt(1), BigBalanceBlock: big.NewInt(0)}, balance: big1},
+	}
+	for i, test := range tests {
+		g := &Genesis{
+			Config: test.config,
+			Alloc:  GenesisAlloc{funded: {Balance: test.balance}},
+			ContractStorage: []GenesisContractValue{
+				{Contract: contract, Key: []byte("key"), Value: []byte("value")},
+			},
+		}
+		db, _ := database.OpenMemDB()
+		_, _, err := SetupGenesisBlock(db, g)
+		if test.err {
+			if err == nil {
+				t.Errorf("test %d: invalid genesis accepted", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		head := blockchain.GetBlock(db, blockchain.GetCanonicalHash(db, 0), 0)
+		statedb, err := state.New(head.Root(), state.NewDatabase(db))
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		sysparam := intertypes.MakeSystemParams(sdk.NewTmpStatusManager(db, statedb, types.Address{}), nil)
+		balance, err := balancetransfer.BalanceOf(sysparam, funded)
+		if err != nil || balance.Cmp(test.balance) != 0 {
+			t.Errorf("test %d: balance mismatch: have %v, %v, want %v", i, balance, err, test.balance)
+		}
+		if value := sdk.Sys_GetValue(sysparam.SdkHandler, contract, []byte("key")); string(value) != "value" {
+			t.Errorf("test %d: storage mismatch: have %q", i, value)
+		}
+	}
+}
+
+// Tests that a chain forking to big balances at the genesis moves balances above
+// MaxInt64 even when the genesis funds no account.
+func TestGenesisBigBalanceUnfunded(t *testing.T) {
+	g := &Genesis{Config: &params.ChainConfig{ChainId: big.NewInt(1), BigBalanceBlock: big.NewInt(0)}}
+	db, _ := database.OpenMemDB()
+	if _, _, err := SetupGenesisBlock(db, g); err != nil {
+		t.Fatal(err)
+	}
+	head := blockchain.GetBlock(db, blockchain.GetCanonicalHash(db, 0), 0)
+	statedb, err := state.New(head.Root(), state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sysparam := intertypes.MakeSystemParams(sdk.NewTmpStatusManager(db, statedb, types.Address{}), nil)
+
+	from, to := types.Address{1}, types.Address{2}
+	amount := new(big.Int).Lsh(big.NewInt(1), 64)
+	if _, err := balancetransfer.Reward(sysparam, from, amount); err != nil {
+		t.Fatalf("failed to credit %v: %v", amount, err)
+	}
+	if _, err := balancetransfer.MoveFee(sysparam, from, to, amount); err != nil {
+		t.Fatalf("failed to transfer %v: %v", amount, err)
+	}
+	if balance, err := balancetransfer.BalanceOf(sysparam, to); err != nil || balance.Cmp(amount) != 0 {
+		t.Errorf("balance mismatch: have %v, %v, want %v", balance, err, amount)
+	}
+}
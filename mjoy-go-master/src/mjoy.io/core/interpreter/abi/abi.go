@@ -0,0 +1,175 @@
+package abi
+
+/*
+abi describes the methods of a contract: the name of every method and the types of its arguments and results,
+and the events it emits.
+A call is encoded with msgp as an array [method name , [arguments...]] and the results of a method as an array
+[results...], every value is encoded by the type the schema gives it, so a contract never has to guess types.
+*/
+
+import (
+	"errors"
+	"fmt"
+)
+
+//Type of an argument
+type Type string
+
+const (
+	AddressTy      Type = "address"
+	HashTy         Type = "hash"
+	BoolTy         Type = "bool"
+	Uint64Ty       Type = "uint64"
+	Int64Ty        Type = "int64"
+	BigIntTy       Type = "bigint"
+	StringTy       Type = "string"
+	BytesTy        Type = "bytes"
+	AddressSliceTy Type = "address[]"
+	BigIntSliceTy  Type = "bigint[]"
+)
+
+var (
+	ErrUnknownMethod = errors.New("abi: unknown method")
+	ErrUnknownEvent  = errors.New("abi: unknown event")
+	ErrArgCount      = errors.New("abi: argument count mismatch")
+	ErrTrailingData  = errors.New("abi: trailing data after call")
+)
+
+//Argument is a named and typed parameter or result of a method
+type Argument struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+}
+
+func Arg(name string, typ Type) Argument {
+	return Argument{Name: name, Type: typ}
+}
+
+type Arguments []Argument
+
+//Method of a contract,a constant method only reads the state
+type Method struct {
+	Name     string    `json:"name"`
+	Inputs   Arguments `json:"inputs"`
+	Outputs  Arguments `json:"outputs"`
+	Constant bool      `json:"constant"`
+}
+
+//ABI is the schema of all methods and events of a contract
+type ABI struct {
+	Name    string    `json:"name"`
+	Methods []*Method `json:"methods"`
+	Events  []*Event  `json:"events,omitempty"`
+}
+
+//New makes a schema,the method names must be unique
+func New(name string, methods ...*Method) *ABI {
+	names := make(map[string]bool)
+	for _, m := range methods {
+		if names[m.Name] {
+			panic(fmt.Sprintf("abi: duplicate method %s in %s", m.Name, name))
+		}
+		names[m.Name] = true
+	}
+	return &ABI{Name: name, Methods: methods}
+}
+
+//Method returns the method with the name
+func (abi *ABI) Method(name string) (*Method, error) {
+	for _, m := range abi.Methods {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%v: %s", ErrUnknownMethod, name)
+}
+
+//WithEvents adds events to the schema,the event names must be unique
+func (abi *ABI) WithEvents(events ...*Event) *ABI {
+	for _, e := range events {
+		if _, err := abi.Event(e.Name); err == nil {
+			panic(fmt.Sprintf("abi: duplicate event %s in %s", e.Name, abi.Name))
+		}
+		if len(e.Indexed) > MaxIndexed {
+			panic(fmt.Sprintf("abi: event %s in %s has more than %d indexed arguments", e.Name, abi.Name, MaxIndexed))
+		}
+		abi.Events = append(abi.Events, e)
+	}
+	return abi
+}
+
+//Event returns the event with the name
+func (abi *ABI) Event(name string) (*Event, error) {
+	for _, e := range abi.Events {
+		if e.Name == name {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%v: %s", ErrUnknownEvent, name)
+}
+
+//Pack encodes a call of the method,the arguments must have the go types of the schema
+func (abi *ABI) Pack(name string, args ...interface{}) ([]byte, error) {
+	m, err := abi.Method(name)
+	if err != nil {
+		return nil, err
+	}
+	return m.Pack(args...)
+}
+
+//Unpack decodes a call,the returned arguments have the go types of the schema
+func (abi *ABI) Unpack(data []byte) (*Method, []interface{}, error) {
+	name, rest, err := readCallHeader(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := abi.Method(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	args, rest, err := m.Inputs.unpack(rest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("abi: %s: %v", name, err)
+	}
+	if len(rest) != 0 {
+		return nil, nil, ErrTrailingData
+	}
+	return m, args, nil
+}
+
+//MethodName returns the name of the method a call is made to,whether the schema knows it or not
+func MethodName(data []byte) (string, error) {
+	name, _, err := readCallHeader(data)
+	return name, err
+}
+
+//Pack encodes a call of the method
+func (m *Method) Pack(args ...interface{}) ([]byte, error) {
+	b := appendCallHeader(nil, m.Name)
+	b, err := m.Inputs.pack(b, args)
+	if err != nil {
+		return nil, fmt.Errorf("abi: %s: %v", m.Name, err)
+	}
+	return b, nil
+}
+
+//PackOutputs encodes the results of the method
+func (m *Method) PackOutputs(values ...interface{}) ([]byte, error) {
+	b, err := m.Outputs.pack(nil, values)
+	if err != nil {
+		return nil, fmt.Errorf("abi: %s outputs: %v", m.Name, err)
+	}
+	return b, nil
+}
+
+//UnpackOutputs decodes the results of the method
+func (m *Method) UnpackOutputs(data []byte) ([]interface{}, error) {
+	values, rest, err := m.Outputs.unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("abi: %s outputs: %v", m.Name, err)
+	}
+	if len(rest) != 0 {
+		return nil, ErrTrailingData
+	}
+	return values, nil
+}
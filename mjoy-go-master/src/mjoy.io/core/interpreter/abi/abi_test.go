@@ -0,0 +1,202 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"mjoy.io/common/types"
+	"mjoy.io/utils/crypto"
+)
+
+var testAbi = New("test",
+	&Method{
+		Name: "all",
+		Inputs: Arguments{
+			Arg("addr", AddressTy), Arg("hash", HashTy), Arg("flag", BoolTy),
+			Arg("u", Uint64Ty), Arg("i", Int64Ty), Arg("big", BigIntTy),
+			Arg("s", StringTy), Arg("b", BytesTy),
+			Arg("addrs", AddressSliceTy), Arg("bigs", BigIntSliceTy),
+		},
+	},
+	&Method{
+		Name:     "balance",
+		Inputs:   Arguments{Arg("owner", AddressTy)},
+		Outputs:  Arguments{Arg("amount", BigIntTy), Arg("data", BytesTy)},
+		Constant: true,
+	},
+)
+
+func TestPackUnpack(t *testing.T) {
+	args := []interface{}{
+		types.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"),
+		types.HexToHash("0x1234"),
+		true,
+		uint64(1) << 63,
+		int64(-42),
+		new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 200)),
+		"mjoy",
+		[]byte{1, 2, 3},
+		[]types.Address{{1}, {2}},
+		[]*big.Int{big.NewInt(0), big.NewInt(7)},
+	}
+	data, err := testAbi.Pack("all", args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method, decoded, err := testAbi.Unpack(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method.Name != "all" {
+		t.Fatalf("method mismatch: have %s", method.Name)
+	}
+	for i := range args {
+		if a, ok := args[i].(*big.Int); ok {
+			if a.Cmp(decoded[i].(*big.Int)) != 0 {
+				t.Errorf("arg %d mismatch: have %v, want %v", i, decoded[i], a)
+			}
+			continue
+		}
+		if as, ok := args[i].([]*big.Int); ok {
+			ds := decoded[i].([]*big.Int)
+			for j := range as {
+				if as[j].Cmp(ds[j]) != 0 {
+					t.Errorf("arg %d mismatch: have %v, want %v", i, ds, as)
+				}
+			}
+			continue
+		}
+		if !reflect.DeepEqual(args[i], decoded[i]) {
+			t.Errorf("arg %d mismatch: have %v, want %v", i, decoded[i], args[i])
+		}
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	if _, err := testAbi.Pack("missing"); err == nil {
+		t.Error("unknown method packed")
+	}
+	if _, err := testAbi.Pack("balance"); err == nil {
+		t.Error("missing argument packed")
+	}
+	if _, err := testAbi.Pack("balance", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"); err == nil {
+		t.Error("string packed as address")
+	}
+}
+
+func TestUnpackMalformed(t *testing.T) {
+	valid, _ := testAbi.Pack("balance", types.Address{1})
+	inputs := [][]byte{
+		nil,
+		[]byte(`{"funcId":"0"}`),
+		valid[:len(valid)-1],
+		append(append([]byte{}, valid...), 0),
+	}
+	//address with a wrong length
+	short := appendCallHeader(nil, "balance")
+	short = append(short, 0x91, 0xc4, 0x01, 0x01)
+	inputs = append(inputs, short)
+
+	for i, input := range inputs {
+		if _, _, err := testAbi.Unpack(input); err == nil {
+			t.Errorf("input %d: malformed call accepted", i)
+		}
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	method, _ := testAbi.Method("balance")
+	data, err := method.PackOutputs(big.NewInt(1000), []byte{0xff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := method.OutputsToJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := json.Marshal(out)
+	if want := `{"amount":"1000","data":"0xff"}`; string(enc) != want {
+		t.Fatalf("json mismatch: have %s, want %s", enc, want)
+	}
+}
+
+func TestPackJSON(t *testing.T) {
+	method, _ := testAbi.Method("all")
+	raw := []json.RawMessage{
+		json.RawMessage(`"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"`),
+		json.RawMessage(`"0x0000000000000000000000000000000000000000000000000000000000001234"`),
+		json.RawMessage(`true`),
+		json.RawMessage(`"0x10"`),
+		json.RawMessage(`-5`),
+		json.RawMessage(`"100000000000000000000000"`),
+		json.RawMessage(`"mjoy"`),
+		json.RawMessage(`"0x0102"`),
+		json.RawMessage(`["0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"]`),
+		json.RawMessage(`[1, "2"]`),
+	}
+	data, err := method.PackJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, args, err := testAbi.Unpack(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[3].(uint64) != 16 || args[4].(int64) != -5 || !bytes.Equal(args[7].([]byte), []byte{1, 2}) {
+		t.Fatalf("decoded args mismatch: %v", args)
+	}
+	if args[5].(*big.Int).String() != "100000000000000000000000" {
+		t.Fatalf("bigint mismatch: %v", args[5])
+	}
+
+	raw[3] = json.RawMessage(`-1`)
+	if _, err := method.PackJSON(raw); err == nil {
+		t.Fatal("negative uint64 accepted")
+	}
+}
+
+func TestEvent(t *testing.T) {
+	a := New("events").WithEvents(&Event{
+		Name:    "Transfer",
+		Indexed: Arguments{Arg("symbol", StringTy), Arg("from", AddressTy), Arg("id", HashTy)},
+		Inputs:  Arguments{Arg("amount", BigIntTy)},
+	})
+	event, err := a.Event("Transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.ID() != crypto.Keccak256Hash([]byte("Transfer")) {
+		t.Fatalf("event id mismatch: %x", event.ID())
+	}
+
+	from := types.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	id := types.HexToHash("0x1234")
+	topics, data, err := event.Pack([]interface{}{"MJ", from, id}, big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbolTopic, _ := Topic(StringTy, "MJ")
+	if len(topics) != 3 || topics[0] != symbolTopic || topics[1] != types.BytesToHash(from[:]) || topics[2] != id {
+		t.Fatalf("topics mismatch: %x", topics)
+	}
+	values, err := event.UnpackData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].(*big.Int).Int64() != 7 {
+		t.Fatalf("data mismatch: %v", values)
+	}
+
+	if _, _, err := event.Pack([]interface{}{"MJ", from}, big.NewInt(7)); err == nil {
+		t.Error("missing indexed argument accepted")
+	}
+	if _, _, err := event.Pack([]interface{}{"MJ", "from", id}, big.NewInt(7)); err == nil {
+		t.Error("indexed argument of wrong type accepted")
+	}
+	if _, err := a.Event("Approval"); err == nil {
+		t.Error("unknown event found")
+	}
+}
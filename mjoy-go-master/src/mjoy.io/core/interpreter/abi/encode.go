@@ -0,0 +1,228 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/tinylib/msgp/msgp"
+	"mjoy.io/common/types"
+)
+
+func appendCallHeader(b []byte, name string) []byte {
+	b = msgp.AppendArrayHeader(b, 2)
+	return msgp.AppendString(b, name)
+}
+
+func readCallHeader(b []byte) (string, []byte, error) {
+	sz, b, err := msgp.ReadArrayHeaderBytes(b)
+	if err != nil {
+		return "", nil, err
+	}
+	if sz != 2 {
+		return "", nil, fmt.Errorf("abi: call has %d elements, want 2", sz)
+	}
+	return msgp.ReadStringBytes(b)
+}
+
+func (args Arguments) pack(b []byte, values []interface{}) ([]byte, error) {
+	if len(values) != len(args) {
+		return nil, ErrArgCount
+	}
+	b = msgp.AppendArrayHeader(b, uint32(len(args)))
+	for i, arg := range args {
+		var err error
+		if b, err = appendValue(b, arg.Type, values[i]); err != nil {
+			return nil, fmt.Errorf("%s: %v", arg.Name, err)
+		}
+	}
+	return b, nil
+}
+
+func (args Arguments) unpack(b []byte) ([]interface{}, []byte, error) {
+	sz, b, err := msgp.ReadArrayHeaderBytes(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if int(sz) != len(args) {
+		return nil, nil, ErrArgCount
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if values[i], b, err = readValue(b, arg.Type); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", arg.Name, err)
+		}
+	}
+	return values, b, nil
+}
+
+func typeError(typ Type, v interface{}) error {
+	return fmt.Errorf("value of type %T is not %s", v, typ)
+}
+
+func appendValue(b []byte, typ Type, v interface{}) ([]byte, error) {
+	switch typ {
+	case AddressTy:
+		if addr, ok := v.(types.Address); ok {
+			return msgp.AppendBytes(b, addr[:]), nil
+		}
+	case HashTy:
+		if h, ok := v.(types.Hash); ok {
+			return msgp.AppendBytes(b, h[:]), nil
+		}
+	case BoolTy:
+		if x, ok := v.(bool); ok {
+			return msgp.AppendBool(b, x), nil
+		}
+	case Uint64Ty:
+		if x, ok := v.(uint64); ok {
+			return msgp.AppendUint64(b, x), nil
+		}
+	case Int64Ty:
+		if x, ok := v.(int64); ok {
+			return msgp.AppendInt64(b, x), nil
+		}
+	case BigIntTy:
+		if x, ok := v.(*big.Int); ok && x != nil {
+			return appendBigInt(b, x), nil
+		}
+	case StringTy:
+		if x, ok := v.(string); ok {
+			return msgp.AppendString(b, x), nil
+		}
+	case BytesTy:
+		if x, ok := v.([]byte); ok {
+			return msgp.AppendBytes(b, x), nil
+		}
+	case AddressSliceTy:
+		if x, ok := v.([]types.Address); ok {
+			b = msgp.AppendArrayHeader(b, uint32(len(x)))
+			for _, addr := range x {
+				b = msgp.AppendBytes(b, addr[:])
+			}
+			return b, nil
+		}
+	case BigIntSliceTy:
+		if x, ok := v.([]*big.Int); ok {
+			b = msgp.AppendArrayHeader(b, uint32(len(x)))
+			for _, i := range x {
+				if i == nil {
+					return nil, typeError(typ, v)
+				}
+				b = appendBigInt(b, i)
+			}
+			return b, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %s", typ)
+	}
+	return nil, typeError(typ, v)
+}
+
+func readValue(b []byte, typ Type) (interface{}, []byte, error) {
+	switch typ {
+	case AddressTy:
+		return readAddress(b)
+	case HashTy:
+		raw, rest, err := msgp.ReadBytesZC(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(raw) != types.HashLength {
+			return nil, nil, fmt.Errorf("hash has %d bytes", len(raw))
+		}
+		return types.BytesToHash(raw), rest, nil
+	case BoolTy:
+		return msgp.ReadBoolBytes(b)
+	case Uint64Ty:
+		return msgp.ReadUint64Bytes(b)
+	case Int64Ty:
+		return msgp.ReadInt64Bytes(b)
+	case BigIntTy:
+		return readBigInt(b)
+	case StringTy:
+		return msgp.ReadStringBytes(b)
+	case BytesTy:
+		return msgp.ReadBytesBytes(b, nil)
+	case AddressSliceTy:
+		sz, rest, err := msgp.ReadArrayHeaderBytes(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		addrs := make([]types.Address, 0, sz)
+		for i := uint32(0); i < sz; i++ {
+			var v interface{}
+			if v, rest, err = readAddress(rest); err != nil {
+				return nil, nil, err
+			}
+			addrs = append(addrs, v.(types.Address))
+		}
+		return addrs, rest, nil
+	case BigIntSliceTy:
+		sz, rest, err := msgp.ReadArrayHeaderBytes(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		ints := make([]*big.Int, 0, sz)
+		for i := uint32(0); i < sz; i++ {
+			var v interface{}
+			if v, rest, err = readBigInt(rest); err != nil {
+				return nil, nil, err
+			}
+			ints = append(ints, v.(*big.Int))
+		}
+		return ints, rest, nil
+	}
+	return nil, nil, fmt.Errorf("unknown type %s", typ)
+}
+
+func readAddress(b []byte) (interface{}, []byte, error) {
+	raw, rest, err := msgp.ReadBytesZC(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(raw) != types.AddressLength {
+		return nil, nil, fmt.Errorf("address has %d bytes", len(raw))
+	}
+	return types.BytesToAddress(raw), rest, nil
+}
+
+//big ints are a sign byte followed by the magnitude,the same as types.BigInt
+func appendBigInt(b []byte, x *big.Int) []byte {
+	mag := x.Bytes()
+	buf := make([]byte, 1+len(mag))
+	buf[0] = byte(x.Sign())
+	copy(buf[1:], mag)
+	return msgp.AppendBytes(b, buf)
+}
+
+func readBigInt(b []byte) (interface{}, []byte, error) {
+	raw, rest, err := msgp.ReadBytesZC(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil, fmt.Errorf("empty bigint")
+	}
+	if len(raw) > 1 && raw[1] == 0 {
+		return nil, nil, fmt.Errorf("bigint has leading zero bytes")
+	}
+	x := new(big.Int).SetBytes(raw[1:])
+	switch raw[0] {
+	case 0:
+		if x.Sign() != 0 {
+			return nil, nil, fmt.Errorf("bigint sign mismatch")
+		}
+	case 1:
+		if x.Sign() == 0 {
+			return nil, nil, fmt.Errorf("bigint sign mismatch")
+		}
+	case 255:
+		if x.Sign() == 0 {
+			return nil, nil, fmt.Errorf("bigint sign mismatch")
+		}
+		x.Neg(x)
+	default:
+		return nil, nil, fmt.Errorf("bigint sign byte %d", raw[0])
+	}
+	return x, rest, nil
+}
@@ -0,0 +1,83 @@
+package abi
+
+import (
+	"fmt"
+
+	"mjoy.io/common/types"
+	"mjoy.io/utils/crypto"
+)
+
+//MaxIndexed is the max number of indexed arguments of an event,the first topic of a log is the event id
+const MaxIndexed = 3
+
+//Event of a contract.The indexed arguments become the topics of the log after the event id,
+//the other arguments are packed into the data of the log
+type Event struct {
+	Name    string    `json:"name"`
+	Indexed Arguments `json:"indexed"`
+	Inputs  Arguments `json:"inputs"`
+}
+
+//EventID is the first topic of the logs of the event with the name
+func EventID(name string) types.Hash {
+	return crypto.Keccak256Hash([]byte(name))
+}
+
+//ID is the first topic of the logs of the event
+func (e *Event) ID() types.Hash {
+	return EventID(e.Name)
+}
+
+//Pack encodes the indexed arguments into topics and the others into data
+func (e *Event) Pack(indexed []interface{}, values ...interface{}) ([]types.Hash, []byte, error) {
+	if len(indexed) != len(e.Indexed) {
+		return nil, nil, fmt.Errorf("abi: %s indexed: %v", e.Name, ErrArgCount)
+	}
+	topics := make([]types.Hash, len(indexed))
+	for i, arg := range e.Indexed {
+		topic, err := Topic(arg.Type, indexed[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("abi: %s: %s: %v", e.Name, arg.Name, err)
+		}
+		topics[i] = topic
+	}
+	data, err := e.Inputs.pack(nil, values)
+	if err != nil {
+		return nil, nil, fmt.Errorf("abi: %s: %v", e.Name, err)
+	}
+	return topics, data, nil
+}
+
+//UnpackData decodes the data of a log of the event
+func (e *Event) UnpackData(data []byte) ([]interface{}, error) {
+	values, rest, err := e.Inputs.unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("abi: %s data: %v", e.Name, err)
+	}
+	if len(rest) != 0 {
+		return nil, ErrTrailingData
+	}
+	return values, nil
+}
+
+//Topic encodes a value of an indexed argument,it is exported for building log filters.
+//Addresses and hashes are kept as they are,other values are hashed from their encoding
+func Topic(typ Type, v interface{}) (types.Hash, error) {
+	switch typ {
+	case AddressTy:
+		if a, ok := v.(types.Address); ok {
+			return types.BytesToHash(a[:]), nil
+		}
+		return types.Hash{}, typeError(typ, v)
+	case HashTy:
+		if h, ok := v.(types.Hash); ok {
+			return h, nil
+		}
+		return types.Hash{}, typeError(typ, v)
+	}
+	b, err := appendValue(nil, typ, v)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return crypto.Keccak256Hash(b), nil
+}
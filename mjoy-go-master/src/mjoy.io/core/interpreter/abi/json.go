@@ -0,0 +1,172 @@
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"mjoy.io/common/types"
+	"mjoy.io/common/types/util/hex"
+)
+
+//ParseJSONArgs converts the json arguments of a rpc request to the go types of the method inputs.
+//Numbers may be json numbers or strings in decimal or 0x prefixed hex,bytes are 0x prefixed hex
+func (m *Method) ParseJSONArgs(raw []json.RawMessage) ([]interface{}, error) {
+	if len(raw) != len(m.Inputs) {
+		return nil, ErrArgCount
+	}
+	args := make([]interface{}, len(raw))
+	for i, arg := range m.Inputs {
+		v, err := parseJSONValue(arg.Type, raw[i])
+		if err != nil {
+			return nil, fmt.Errorf("abi: %s: %s: %v", m.Name, arg.Name, err)
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+//PackJSON encodes a call of the method from json arguments
+func (m *Method) PackJSON(raw []json.RawMessage) ([]byte, error) {
+	args, err := m.ParseJSONArgs(raw)
+	if err != nil {
+		return nil, err
+	}
+	return m.Pack(args...)
+}
+
+//OutputsToJSON decodes the results of the method into a map from result name to a json friendly value,
+//big ints are given as decimal strings
+func (m *Method) OutputsToJSON(data []byte) (map[string]interface{}, error) {
+	values, err := m.UnpackOutputs(data)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]interface{}, len(values))
+	for i, arg := range m.Outputs {
+		out[arg.Name] = jsonValue(values[i])
+	}
+	return out, nil
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case *big.Int:
+		return x.String()
+	case []*big.Int:
+		strs := make([]string, len(x))
+		for i, n := range x {
+			strs[i] = n.String()
+		}
+		return strs
+	case []byte:
+		return hex.Bytes(x)
+	case uint64:
+		return hex.Uint64(x)
+	}
+	return v
+}
+
+func parseJSONValue(typ Type, raw json.RawMessage) (interface{}, error) {
+	switch typ {
+	case AddressTy:
+		var addr types.Address
+		if err := json.Unmarshal(raw, &addr); err != nil {
+			return nil, err
+		}
+		return addr, nil
+	case HashTy:
+		var h types.Hash
+		if err := json.Unmarshal(raw, &h); err != nil {
+			return nil, err
+		}
+		return h, nil
+	case BoolTy:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case Uint64Ty, Int64Ty, BigIntTy:
+		n, err := parseJSONNumber(raw)
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case Uint64Ty:
+			if n.Sign() < 0 || !n.IsUint64() {
+				return nil, fmt.Errorf("%s out of uint64 range", n)
+			}
+			return n.Uint64(), nil
+		case Int64Ty:
+			if !n.IsInt64() {
+				return nil, fmt.Errorf("%s out of int64 range", n)
+			}
+			return n.Int64(), nil
+		}
+		return n, nil
+	case StringTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case BytesTy:
+		var b hex.Bytes
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return nil, err
+		}
+		return []byte(b), nil
+	case AddressSliceTy:
+		var addrs []types.Address
+		if err := json.Unmarshal(raw, &addrs); err != nil {
+			return nil, err
+		}
+		return addrs, nil
+	case BigIntSliceTy:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return nil, err
+		}
+		ints := make([]*big.Int, len(elems))
+		for i, elem := range elems {
+			n, err := parseJSONNumber(elem)
+			if err != nil {
+				return nil, err
+			}
+			ints[i] = n
+		}
+		return ints, nil
+	}
+	return nil, fmt.Errorf("unknown type %s", typ)
+}
+
+func parseJSONNumber(raw json.RawMessage) (*big.Int, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		//not a string,must be a json number
+		var num json.Number
+		if err := json.Unmarshal(raw, &num); err != nil {
+			return nil, err
+		}
+		s = num.String()
+	}
+	s = strings.TrimSpace(s)
+	n := new(big.Int)
+	var ok bool
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		n, ok = n.SetString(s[2:], 16)
+	} else if strings.HasPrefix(s, "-0x") || strings.HasPrefix(s, "-0X") {
+		if n, ok = n.SetString(s[3:], 16); ok {
+			n.Neg(n)
+		}
+	} else {
+		n, ok = n.SetString(s, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid number %s", strconv.Quote(s))
+	}
+	return n, nil
+}
@@ -0,0 +1,91 @@
+package balancetransfer
+
+import (
+	"errors"
+	"fmt"
+	"mjoy.io/common/types"
+	"mjoy.io/core/sdk"
+	"encoding/json"
+	"mjoy.io/core/interpreter/intertypes"
+	"math/big"
+)
+
+var (
+	errInsufficientBalance = errors.New("insufficient balance")
+)
+
+//checkAmount rejects the amounts no transfer may move
+func checkAmount(amount *big.Int)error{
+	if amount == nil {
+		return errors.New("nil amount")
+	}
+	if amount.Sign() < 0 {
+		return errors.New(fmt.Sprintf("negative amount %s" , amount.String()))
+	}
+	if amount.Sign() == 0 {
+		return errors.New("zero amount")
+	}
+	return nil
+}
+
+//legacyFormat reports whether balances are still written as json numbers,that is the BigBalance fork has not happened
+func legacyFormat(sysparam *intertypes.SystemParams)bool{
+	return sdk.Sys_GetValue(sysparam.SdkHandler , BalanceTransferAddress , BalanceFormatKey) == nil
+}
+
+//BalanceOf returns the balance of address,zero if it never had one
+func BalanceOf(sysparam *intertypes.SystemParams , address types.Address)(*big.Int , error){
+	data := sdk.Sys_GetValue(sysparam.SdkHandler , BalanceTransferAddress , address[:])
+	if nil == data {
+		return new(big.Int) , nil
+	}
+	balance := new(BalanceValue)
+	if err := json.Unmarshal(data , balance);err != nil {
+		return nil , errors.New(fmt.Sprintf("Unmarshal json:%s" , err.Error()))
+	}
+	return balance.Amount , nil
+}
+
+func setBalance(sysparam *intertypes.SystemParams , address types.Address , amount *big.Int , legacy bool)(intertypes.ActionResult , error){
+	data , err := EncodeBalance(amount , legacy)
+	if err != nil {
+		return intertypes.ActionResult{} , err
+	}
+	if err = sdk.Sys_SetValue(sysparam.SdkHandler , BalanceTransferAddress , address[:] , data);err != nil {
+		return intertypes.ActionResult{} , err
+	}
+	return intertypes.ActionResult{Key:address[:] , Val:data} , nil
+}
+
+//moveBalance moves amount from one account to another,a zero amount or a transfer to oneself writes nothing
+func moveBalance(sysparam *intertypes.SystemParams , from , to types.Address , amount *big.Int)([]intertypes.ActionResult , error){
+	if amount.Sign() < 0 {
+		return nil , errors.New(fmt.Sprintf("negative amount %s" , amount.String()))
+	}
+	balanceFrom , err := BalanceOf(sysparam , from)
+	if err != nil {
+		return nil , err
+	}
+	if balanceFrom.Cmp(amount) < 0 {
+		logger.Tracef("%s has %s , but want %s" , from.Hex() , balanceFrom.String() , amount.String())
+		return nil , errInsufficientBalance
+	}
+	if amount.Sign() == 0 || from == to {
+		return nil , nil
+	}
+	balanceTo , err := BalanceOf(sysparam , to)
+	if err != nil {
+		return nil , err
+	}
+
+	legacy := legacyFormat(sysparam)
+	resultFrom , err := setBalance(sysparam , from , balanceFrom.Sub(balanceFrom , amount) , legacy)
+	if err != nil {
+		return nil , err
+	}
+	resultTo , err := setBalance(sysparam , to , balanceTo.Add(balanceTo , amount) , legacy)
+	if err != nil {
+		return nil , err
+	}
+	return []intertypes.ActionResult{resultFrom , resultTo} , nil
+}
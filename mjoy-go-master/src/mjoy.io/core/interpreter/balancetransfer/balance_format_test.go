@@ -0,0 +1,103 @@
+package balancetransfer
+
+import (
+	"testing"
+	"math/big"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/intertest"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/sdk"
+)
+
+func setTestBalance(t *testing.T , sysparam *intertypes.SystemParams , address types.Address , data string){
+	if err := sdk.Sys_SetValue(sysparam.SdkHandler , BalanceTransferAddress , address[:] , []byte(data));err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBalanceRecordFormats(t *testing.T){
+	tests := []struct{
+		data     string
+		amount   string
+		upgraded string
+	}{
+		{`{"amount":1000}` , "1000" , `{"amount":"1000"}`},
+		{`{"amount":"1000"}` , "1000" , ""},
+		{`{"amount":"123456789012345678901234567890"}` , "123456789012345678901234567890" , ""},
+	}
+	for i , test := range tests {
+		balance := new(BalanceValue)
+		if err := balance.UnmarshalJSON([]byte(test.data));err != nil {
+			t.Fatalf("test %d: %v" , i , err)
+		}
+		if balance.Amount.String() != test.amount {
+			t.Errorf("test %d: amount mismatch: have %v, want %s" , i , balance.Amount , test.amount)
+		}
+		upgraded , changed , err := UpgradeBalanceRecord([]byte(test.data))
+		if err != nil {
+			t.Fatalf("test %d: %v" , i , err)
+		}
+		if changed != (test.upgraded != "") || string(upgraded) != test.upgraded {
+			t.Errorf("test %d: upgrade mismatch: have %s (%v), want %s" , i , upgraded , changed , test.upgraded)
+		}
+	}
+
+	for _ , data := range []string{`{"amount":-1}` , `{"amount":"-5"}` , `{"amount":"1e3"}` , `{"amount":"abc"}`} {
+		if _ , _ , err := UpgradeBalanceRecord([]byte(data));err == nil {
+			t.Errorf("invalid record %s accepted" , data)
+		}
+	}
+}
+
+func TestTransferBalanceAmounts(t *testing.T){
+	sysparam := intertest.NewSysParams(t , nil)
+	contract := NewContractBalancer()
+	from := types.Address{1}
+	to := types.Address{2}
+	setTestBalance(t , sysparam , from , `{"amount":1000}`)
+
+	//nobody signed for the balance
+	if _ , err := contract.DoFun(MakaBalanceTransferParam(to , 10) , sysparam);err == nil {
+		t.Fatal("transfer without sender accepted")
+	}
+	sysparam.SdkHandler.SetTxContext(&sdk.TxContext{Sender:from})
+
+	for _ , amount := range []*big.Int{big.NewInt(0) , big.NewInt(-1) , big.NewInt(1001)} {
+		params , _ := BalancerAbi.Pack(TransferBalance_Method , to , amount)
+		if _ , err := contract.DoFun(params , sysparam);err == nil {
+			t.Errorf("transfer of %v accepted" , amount)
+		}
+	}
+
+	//before the fork balances keep the legacy format
+	results , err := contract.DoFun(MakaBalanceTransferParam(to , 10) , sysparam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(results[0].Val) != `{"amount":990}` || string(results[1].Val) != `{"amount":10}` {
+		t.Fatalf("legacy results mismatch: %s %s" , results[0].Val , results[1].Val)
+	}
+
+	//a legacy balance can not exceed the Go int
+	setTestBalance(t , sysparam , from , `{"amount":"9223372036854775807"}`)
+	setTestBalance(t , sysparam , to , `{"amount":"9223372036854775807"}`)
+	if _ , err := contract.DoFun(MakaBalanceTransferParam(to , 1) , sysparam);err == nil {
+		t.Fatal("overflowing legacy balance accepted")
+	}
+
+	//after it they are decimal strings of any size
+	if err := sdk.Sys_SetValue(sysparam.SdkHandler , BalanceTransferAddress , BalanceFormatKey , BalanceFormatBig);err != nil {
+		t.Fatal(err)
+	}
+	results , err = contract.DoFun(MakaBalanceTransferParam(to , 1) , sysparam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(results[1].Val) != `{"amount":"9223372036854775808"}` {
+		t.Fatalf("big result mismatch: %s" , results[1].Val)
+	}
+	balance , err := BalanceOf(sysparam , to)
+	if err != nil || balance.String() != "9223372036854775808" {
+		t.Fatalf("balance mismatch: have %v, %v" , balance , err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"mjoy.io/core/interpreter/intertypes"
 	"errors"
+	"math/big"
 )
 
 type Para struct {
@@ -72,8 +73,8 @@ func GetBalanceForTest(param map[string]interface{} )([]intertypes.ActionResult
 		fmt.Printf("WeParsed Address:%s\n" , v)
 		//check Balance
 		balanceCheckVal := new(BalanceValue)
-		balanceCheckVal.Amount = 10
-		balanceCheckResult.All = append(balanceCheckResult.All , AccountBalance{v ,balanceCheckVal.Amount })
+		balanceCheckVal.Amount = big.NewInt(10)
+		balanceCheckResult.All = append(balanceCheckResult.All , AccountBalance{v ,balanceCheckVal.Amount.String() })
 
 
 	}
@@ -1,29 +1,65 @@
 package balancetransfer
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"mjoy.io/common/types"
 	"mjoy.io/core/interpreter/intertypes"
-	"strconv"
+	"mjoy.io/core/interpreter/abi"
 )
 
+//method names of the balancetransfer contract
 const(
-	TransferBalance_FunId = iota
-	RewordBlockProducer_FunId
-	TransferFee_FunId
-	GetBalance_FunId
+	TransferBalance_Method = "transferBalance"
+	TransferFee_Method = "transferFee"
+	GetBalance_Method = "getBalance"
 )
 
+//RewordBlockProducer_Method is not a method any more,the engine pays the block rewards in Finalize.
+//The name is kept to reject the blocks which still call it,see IsRewardCall
+const RewordBlockProducer_Method = "rewordBlockProducer"
+
+//Transfer_Event is emitted by every balance transfer
+const Transfer_Event = "Transfer"
+
 var BalanceTransferAddress  = types.Address{}
 
+//BalancerAbi is the schema of the balancetransfer contract
+var BalancerAbi = abi.New("balancetransfer" ,
+	&abi.Method{
+		Name:TransferBalance_Method ,
+		Inputs:abi.Arguments{abi.Arg("to" , abi.AddressTy) , abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:TransferFee_Method ,
+		Inputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:GetBalance_Method ,
+		Inputs:abi.Arguments{abi.Arg("addresses" , abi.AddressSliceTy)},
+		Outputs:abi.Arguments{abi.Arg("balances" , abi.BigIntSliceTy)},
+		Constant:true,
+	},
+).WithEvents(
+	&abi.Event{
+		Name:Transfer_Event ,
+		Indexed:abi.Arguments{abi.Arg("from" , abi.AddressTy) , abi.Arg("to" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy)},
+	},
+)
+
+var (
+	getBalanceMethod , _ = BalancerAbi.Method(GetBalance_Method)
+	transferEvent , _ = BalancerAbi.Event(Transfer_Event)
+)
 
-type DoFunc func(map[string]interface{} ,  *intertypes.SystemParams)([]intertypes.ActionResult , error)
+//DoFunc get the arguments decoded by BalancerAbi,so the types of them are checked
+type DoFunc func([]interface{} ,  *intertypes.SystemParams)([]intertypes.ActionResult , error)
 
 type ContractBalancer struct {
-	funcMapper map[int]DoFunc
+	funcMapper map[string]DoFunc
 }
+
 //managed by vm
 func NewContractBalancer()*ContractBalancer{
 	b := new(ContractBalancer)
@@ -33,51 +69,25 @@ func NewContractBalancer()*ContractBalancer{
 
 func (this *ContractBalancer)init(){
 	//register call Back
-	this.funcMapper = make(map[int]DoFunc)
-	this.funcMapper[TransferBalance_FunId] = TransferBalance        //user's balance transfer
-	this.funcMapper[RewordBlockProducer_FunId] = RewordBlockProducer    //reword for coinbase
-	this.funcMapper[TransferFee_FunId] = TransferFee            //transaction fee cut
-	this.funcMapper[GetBalance_FunId] = GetBalance
+	this.funcMapper = make(map[string]DoFunc)
+	this.funcMapper[TransferBalance_Method] = TransferBalance        //user's balance transfer
+	this.funcMapper[TransferFee_Method] = TransferFee            //transaction fee cut
+	this.funcMapper[GetBalance_Method] = GetBalance
 }
 
-
-
-func ParseParms(param []byte)(map[string]interface{} , error){
-	pResult:= make(map[string]interface{})
-	err := json.Unmarshal(param , &pResult)
-	if err != nil{
-		return nil , err
-	}
-	return pResult , nil
-
+func (this *ContractBalancer)Abi()*abi.ABI{
+	return BalancerAbi
 }
 
-
 func (this *ContractBalancer)DoFun( params []byte,sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
-	//unmarshal params
-	jsonParams , err := ParseParms(params)
+	//decode params
+	method , args , err := BalancerAbi.Unpack(params)
 	if err != nil {
-		return nil,err
-	}
-	var funcId int
-	if v,ok := jsonParams["funcId"];!ok{
-		return nil , errors.New(fmt.Sprintf("ContractBalancer: Params not contain funcId" ))
-	} else {
-		funcId, err = strconv.Atoi(v.(string))
-		if err!= nil {
-			return nil , errors.New(fmt.Sprintf("ContractBalancer: Params  funcId format is not right" ))
-		}
+		return nil , errors.New(fmt.Sprintf("ContractBalancer: %s" , err.Error()))
 	}
 
-	if doFunc,ok := this.funcMapper[int(funcId)];ok {
-		return doFunc(jsonParams , sysparam)
+	if doFunc,ok := this.funcMapper[method.Name];ok {
+		return doFunc(args , sysparam)
 	}
-
-	return nil , errors.New(fmt.Sprintf("ContractBalancer: no Func Id:%d find in map" , funcId))
+	return nil , errors.New(fmt.Sprintf("ContractBalancer: no method %s find in map" , method.Name))
 }
-
-
-
-
-
-
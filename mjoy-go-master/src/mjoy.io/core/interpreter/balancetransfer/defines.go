@@ -1,22 +1,103 @@
 package balancetransfer
 
 import (
-	"encoding/json"
 	"mjoy.io/common/types"
+	"math/big"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 )
 
 //here for test,do not add msgp
+//The amount is stored as a decimal string.Before the BigBalance fork it was a json number of a Go int,
+//such legacy records are still read,and are written until UpgradeBalanceRecord rewrote all of them
 type BalanceValue struct {
-	Amount int    `json:"amount"`
+	Amount *big.Int    `json:"amount"`
+}
+
+type balanceRecord struct {
+	Amount string `json:"amount"`
+}
+
+//a json.Number takes both a number and a string holding one
+type anyBalanceRecord struct {
+	Amount json.Number `json:"amount"`
 }
 
+type legacyBalanceValue struct {
+	Amount int64 `json:"amount"`
+}
 
+//BalanceFormatKey is the key of the record written by the BigBalance fork,once it exists all balances are
+//written as decimal strings
+var (
+	BalanceFormatKey = []byte("balanceFormat")
+	BalanceFormatBig = []byte("bigint")
+)
+
+var maxLegacyAmount = big.NewInt(math.MaxInt64)
+
+func (this *BalanceValue)MarshalJSON()([]byte , error){
+	amount := this.Amount
+	if amount == nil {
+		amount = new(big.Int)
+	}
+	return json.Marshal(&balanceRecord{Amount:amount.String()})
+}
+
+//UnmarshalJSON accepts both the decimal string and the legacy number
+func (this *BalanceValue)UnmarshalJSON(data []byte)error{
+	record := new(anyBalanceRecord)
+	if err := json.Unmarshal(data , record);err != nil {
+		return err
+	}
+	amount , ok := new(big.Int).SetString(record.Amount.String() , 10)
+	if !ok {
+		return errors.New(fmt.Sprintf("invalid balance amount %q" , record.Amount.String()))
+	}
+	if amount.Sign() < 0 {
+		return errors.New(fmt.Sprintf("negative balance amount %s" , amount.String()))
+	}
+	this.Amount = amount
+	return nil
+}
+
+//EncodeBalance marshals the amount in the legacy format if legacy is true,an amount beyond the legacy Go int overflows
+func EncodeBalance(amount *big.Int , legacy bool)([]byte , error){
+	if amount.Sign() < 0 {
+		return nil , errors.New(fmt.Sprintf("negative balance %s" , amount.String()))
+	}
+	if legacy {
+		if amount.Cmp(maxLegacyAmount) > 0 {
+			return nil , errors.New(fmt.Sprintf("balance %s overflows" , amount.String()))
+		}
+		return json.Marshal(&legacyBalanceValue{Amount:amount.Int64()})
+	}
+	return json.Marshal(&BalanceValue{Amount:amount})
+}
+
+//UpgradeBalanceRecord rewrites a stored balance in the decimal string format,it reports false if data was written
+//in that format already
+func UpgradeBalanceRecord(data []byte)([]byte , bool , error){
+	balance := new(BalanceValue)
+	if err := json.Unmarshal(data , balance);err != nil {
+		return nil , false , err
+	}
+	upgraded , err := EncodeBalance(balance.Amount , false)
+	if err != nil {
+		return nil , false , err
+	}
+	if string(upgraded) == string(data) {
+		return nil , false , nil
+	}
+	return upgraded , true , nil
+}
 
 //Balance Check Result
 type AccountBalance struct {
 	Address string   `json:"address"`
-	Amount int              `json:"amount"`
+	Amount string           `json:"amount"`
 }
 
 type AccountsBalance struct {
@@ -24,33 +105,27 @@ type AccountsBalance struct {
 }
 
 
-func MakeActionParamsReword(producer types.Address)[]byte{
-	a := make(map[string]interface{})
-	a["funcId"] = "1"
-
-	a["producer"] = producer.Hex()
-
-	r , err :=json.Marshal(a)
+//MakaBalanceTransferParam moves amount from the sender of the transaction to the address to
+func MakaBalanceTransferParam(to types.Address , amount int)[]byte{
+	r , err := BalancerAbi.Pack(TransferBalance_Method , to , big.NewInt(int64(amount)))
 	if err != nil {
 		return nil
 	}
 	return r
 }
 
-
-//for inner test
-func MakaBalanceTransferParam(from , to types.Address , amount int)[]byte{
-	a := make(map[string]interface{})
-
-	a["funcId"] = "0"
-	a["from"] = from.Hex()
-	a["to"] = to.Hex()
-	a["amount"] = fmt.Sprintf("%d" , amount)
-
-	r , err :=json.Marshal(a)
+func MakeTransferFeeParam(amount int)[]byte{
+	r , err := BalancerAbi.Pack(TransferFee_Method , big.NewInt(int64(amount)))
 	if err != nil {
 		return nil
 	}
 	return r
 }
 
+func MakeGetBalanceParam(addresses ...types.Address)[]byte{
+	r , err := BalancerAbi.Pack(GetBalance_Method , addresses)
+	if err != nil {
+		return nil
+	}
+	return r
+}
@@ -0,0 +1,46 @@
+package balancetransfer
+
+import (
+	"errors"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/sdk"
+	"mjoy.io/params"
+	"math/big"
+)
+
+/*
+The resource fee of a transaction is not an action of the transaction,it is moved by the state transition:
+the fee for the whole declared limit is charged to the coinbase before the actions run,and the unused part
+is refunded after them.These functions are not registered in the funcMapper,so no action can reach them.
+*/
+
+var ErrInsufficientFee = errors.New("insufficient balance for the resource fee")
+
+//ResourceFee returns the fee of the resource units
+func ResourceFee(units uint64)*big.Int{
+	fee := new(big.Int).SetUint64(units)
+	return fee.Mul(fee , new(big.Int).SetUint64(params.ResourcePrice))
+}
+
+//ChargeFee moves amount from payer to the coinbase
+func ChargeFee(sysparam *intertypes.SystemParams , payer types.Address , amount *big.Int)([]intertypes.ActionResult , error){
+	coinbase := sdk.Sys_GetCoinbase(sysparam.SdkHandler)
+	if coinbase == nil {
+		return nil , errors.New("Sys_GetCoinbase return Nil")
+	}
+	results , err := moveBalance(sysparam , payer , *coinbase , amount)
+	if err == errInsufficientBalance {
+		return nil , ErrInsufficientFee
+	}
+	return results , err
+}
+
+//RefundFee gives the unused part of a charged fee back to payer
+func RefundFee(sysparam *intertypes.SystemParams , payer types.Address , amount *big.Int)([]intertypes.ActionResult , error){
+	coinbase := sdk.Sys_GetCoinbase(sysparam.SdkHandler)
+	if coinbase == nil {
+		return nil , errors.New("Sys_GetCoinbase return Nil")
+	}
+	return moveBalance(sysparam , *coinbase , payer , amount)
+}
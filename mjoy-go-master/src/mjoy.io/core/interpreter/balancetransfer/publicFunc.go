@@ -2,40 +2,40 @@ package balancetransfer
 
 import (
 	"mjoy.io/common/types"
-	"strconv"
 	"errors"
+	"math/big"
 )
 
+
 func CheckFee(addr types.Address , params []byte)(int , error){
-	if addr != BalanceTransferAddress {
-		return 0 , errors.New("Contract address wrong")
-	}
-	jsonParams , err := ParseParms(params)
+	fee , err := FeeAmount(addr , params)
 	if err != nil {
 		return 0 , err
 	}
-	if v,ok := jsonParams["funcId"];!ok {
-		return 0 , errors.New("NoFuncId for CheckFee")
-	}else{
-		vi,err := strconv.Atoi(v.(string))
-		if err != nil {
-			return 0 , err
-		}
-		if vi != TransferFee_FunId {
-			return 0 , errors.New("funcId != CheckFee funcId")
-		}
-		//parse amounts
-		if amountStr , ok := jsonParams["amount"];!ok {
-			return 0 , errors.New("No Amount in CheckFee params")
-		}else{
-			amountFee,err := strconv.Atoi(amountStr.(string))
-			if err != nil {
-				return 0 , err
-			}
-			return amountFee , nil
-		}
+	//the priority saturates at the largest int
+	if maxPriority := big.NewInt(int64(^uint(0) >> 1));fee.Cmp(maxPriority) > 0 {
+		return int(maxPriority.Int64()) , nil
 	}
+	return int(fee.Int64()) , nil
+}
 
-	return 0 , errors.New("CheckFee Unkown Error")
+//FeeAmount returns the fee a transferFee action moves,an error if the action is not a valid transferFee one
+func FeeAmount(addr types.Address , params []byte)(*big.Int , error){
+	if addr != BalanceTransferAddress {
+		return nil , errors.New("Contract address wrong")
+	}
 
+	method , args , err := BalancerAbi.Unpack(params)
+	if err != nil {
+		return nil , err
+	}
+
+	if method.Name != TransferFee_Method {
+		return nil , errors.New("method != CheckFee method")
+	}
+	fee := args[0].(*big.Int)
+	if err := checkAmount(fee);err != nil {
+		return nil , err
+	}
+	return new(big.Int).Set(fee) , nil
 }
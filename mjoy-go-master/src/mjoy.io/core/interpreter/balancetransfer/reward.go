@@ -0,0 +1,49 @@
+package balancetransfer
+
+import (
+	"errors"
+	"fmt"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/interpreter/intertypes"
+	"math/big"
+)
+
+/*
+The block rewards are paid by the consensus engine when it finalizes a block,after all its transactions.
+Like the fee functions these are not registered in the funcMapper,no action can reach them.
+*/
+
+//Reward adds amount of new balance to the account to
+func Reward(sysparam *intertypes.SystemParams , to types.Address , amount *big.Int)([]intertypes.ActionResult , error){
+	if amount.Sign() < 0 {
+		return nil , errors.New(fmt.Sprintf("negative reward %s" , amount.String()))
+	}
+	if amount.Sign() == 0 {
+		return nil , nil
+	}
+	balance , err := BalanceOf(sysparam , to)
+	if err != nil {
+		return nil , err
+	}
+	result , err := setBalance(sysparam , to , balance.Add(balance , amount) , legacyFormat(sysparam))
+	if err != nil {
+		return nil , err
+	}
+	return []intertypes.ActionResult{result} , nil
+}
+
+//MoveFee moves amount of the fees collected by from to the account to
+func MoveFee(sysparam *intertypes.SystemParams , from , to types.Address , amount *big.Int)([]intertypes.ActionResult , error){
+	return moveBalance(sysparam , from , to , amount)
+}
+
+//IsRewardCall reports whether an action calls the former reward method of the contract.Every node could sign
+//such a call,so a block carrying one is invalid
+func IsRewardCall(address *types.Address , params []byte)bool{
+	if address == nil || *address != BalanceTransferAddress {
+		return false
+	}
+	name , err := abi.MethodName(params)
+	return err == nil && name == RewordBlockProducer_Method
+}
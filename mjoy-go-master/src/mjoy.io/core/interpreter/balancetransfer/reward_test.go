@@ -0,0 +1,65 @@
+package balancetransfer
+
+import (
+	"testing"
+	"math/big"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/interpreter/intertest"
+)
+
+func TestReward(t *testing.T){
+	sysparam := intertest.NewSysParams(t , nil)
+	producer := types.Address{1}
+	treasury := types.Address{2}
+
+	if _ , err := Reward(sysparam , producer , big.NewInt(1000));err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := Reward(sysparam , producer , big.NewInt(-1));err == nil {
+		t.Fatal("negative reward paid")
+	}
+	if _ , err := MoveFee(sysparam , producer , treasury , big.NewInt(1001));err == nil {
+		t.Fatal("moved more fees than the balance")
+	}
+	if _ , err := MoveFee(sysparam , producer , treasury , big.NewInt(100));err != nil {
+		t.Fatal(err)
+	}
+	for address , want := range map[types.Address]int64{producer:900 , treasury:100} {
+		if balance , err := BalanceOf(sysparam , address);err != nil || balance.Int64() != want {
+			t.Errorf("balance of %x: have %v %v, want %d" , address , balance , err , want)
+		}
+	}
+}
+
+func TestIsRewardCall(t *testing.T){
+	//the schema the reward transactions were made with
+	legacy := abi.New("balancetransfer" , &abi.Method{
+		Name:RewordBlockProducer_Method ,
+		Inputs:abi.Arguments{abi.Arg("producer" , abi.AddressTy)},
+	})
+	reward , err := legacy.Pack(RewordBlockProducer_Method , types.Address{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := types.Address{1}
+	tests := []struct{
+		address *types.Address
+		params []byte
+		want bool
+	}{
+		{&BalanceTransferAddress , reward , true},
+		{&other , reward , false},
+		{nil , reward , false},
+		{&BalanceTransferAddress , MakaBalanceTransferParam(other , 10) , false},
+		{&BalanceTransferAddress , []byte{1 , 2} , false},
+	}
+	for i , test := range tests {
+		if have := IsRewardCall(test.address , test.params);have != test.want {
+			t.Errorf("test %d: have %v, want %v" , i , have , test.want)
+		}
+	}
+	if _ , _ , err := BalancerAbi.Unpack(reward);err == nil {
+		t.Error("reward method still in the schema")
+	}
+}
@@ -0,0 +1,111 @@
+package bytecode
+
+import "fmt"
+
+//OpCode is a single byte instruction of the contract bytecode
+type OpCode byte
+
+const (
+	STOP OpCode = 0x00
+	ADD  OpCode = 0x01
+	MUL  OpCode = 0x02
+	SUB  OpCode = 0x03
+	DIV  OpCode = 0x04
+	MOD  OpCode = 0x06
+
+	LT     OpCode = 0x10
+	GT     OpCode = 0x11
+	EQ     OpCode = 0x14
+	ISZERO OpCode = 0x15
+	AND    OpCode = 0x16
+	OR     OpCode = 0x17
+	XOR    OpCode = 0x18
+	NOT    OpCode = 0x19
+
+	SHA3 OpCode = 0x20
+
+	ADDRESS      OpCode = 0x30
+	CALLDATALOAD OpCode = 0x35
+	CALLDATASIZE OpCode = 0x36
+	CALLDATACOPY OpCode = 0x37
+
+	COINBASE OpCode = 0x41
+
+	POP      OpCode = 0x50
+	MLOAD    OpCode = 0x51
+	MSTORE   OpCode = 0x52
+	MSTORE8  OpCode = 0x53
+	SLOAD    OpCode = 0x54
+	SSTORE   OpCode = 0x55
+	JUMP     OpCode = 0x56
+	JUMPI    OpCode = 0x57
+	PC       OpCode = 0x58
+	MSIZE    OpCode = 0x59
+	JUMPDEST OpCode = 0x5b
+
+	PUSH1  OpCode = 0x60
+	PUSH32 OpCode = 0x7f
+	DUP1   OpCode = 0x80
+	DUP16  OpCode = 0x8f
+	SWAP1  OpCode = 0x90
+	SWAP16 OpCode = 0x9f
+
+	RETURN OpCode = 0xf3
+	REVERT OpCode = 0xfd
+)
+
+//step costs of every defined instruction,an opcode without an entry is invalid.
+//storage access is charged again by the sdk when the execution is metered:
+//params.StorageReadResourceCost for SLOAD, params.StorageWriteResourceCost plus
+//params.StorageByteResourceCost per byte for SSTORE. SLOAD and SSTORE only pay
+//for the step here, keeping the unmetered costs of 200 and 5000 would charge
+//every storage access twice
+var stepCosts = map[OpCode]uint64{
+	STOP: 0, ADD: 3, MUL: 5, SUB: 3, DIV: 5, MOD: 5,
+	LT: 3, GT: 3, EQ: 3, ISZERO: 3, AND: 3, OR: 3, XOR: 3, NOT: 3,
+	SHA3:    30,
+	ADDRESS: 2, CALLDATALOAD: 3, CALLDATASIZE: 2, CALLDATACOPY: 3,
+	COINBASE: 2,
+	POP:      2, MLOAD: 3, MSTORE: 3, MSTORE8: 3,
+	SLOAD:    20, SSTORE: 50,
+	JUMP:     8, JUMPI: 10, PC: 2, MSIZE: 2, JUMPDEST: 1,
+	RETURN:   0, REVERT: 0,
+}
+
+var opCodeNames = map[OpCode]string{
+	STOP: "STOP", ADD: "ADD", MUL: "MUL", SUB: "SUB", DIV: "DIV", MOD: "MOD",
+	LT: "LT", GT: "GT", EQ: "EQ", ISZERO: "ISZERO", AND: "AND", OR: "OR", XOR: "XOR", NOT: "NOT",
+	SHA3:    "SHA3",
+	ADDRESS: "ADDRESS", CALLDATALOAD: "CALLDATALOAD", CALLDATASIZE: "CALLDATASIZE", CALLDATACOPY: "CALLDATACOPY",
+	COINBASE: "COINBASE",
+	POP:      "POP", MLOAD: "MLOAD", MSTORE: "MSTORE", MSTORE8: "MSTORE8",
+	SLOAD:    "SLOAD", SSTORE: "SSTORE",
+	JUMP:     "JUMP", JUMPI: "JUMPI", PC: "PC", MSIZE: "MSIZE", JUMPDEST: "JUMPDEST",
+	RETURN:   "RETURN", REVERT: "REVERT",
+}
+
+func init() {
+	for op := PUSH1; op <= PUSH32; op++ {
+		stepCosts[op] = 3
+		opCodeNames[op] = fmt.Sprintf("PUSH%d", int(op-PUSH1)+1)
+	}
+	for op := DUP1; op <= DUP16; op++ {
+		stepCosts[op] = 3
+		opCodeNames[op] = fmt.Sprintf("DUP%d", int(op-DUP1)+1)
+	}
+	for op := SWAP1; op <= SWAP16; op++ {
+		stepCosts[op] = 3
+		opCodeNames[op] = fmt.Sprintf("SWAP%d", int(op-SWAP1)+1)
+	}
+}
+
+func (op OpCode) IsPush() bool {
+	return op >= PUSH1 && op <= PUSH32
+}
+
+func (op OpCode) String() string {
+	if name, ok := opCodeNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("Missing opcode 0x%x", int(op))
+}
@@ -0,0 +1,445 @@
+package bytecode
+
+/*
+bytecode is a small deterministic stack machine for contracts deployed by interpreter.Create.
+Every instruction is metered in steps, the machine has no access to the host except storage
+and system values reached through the sdk,so the same code with the same input always gives
+the same result on every node.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"mjoy.io/common/math"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/sdk"
+	"mjoy.io/utils/crypto"
+)
+
+const (
+	DefaultStepLimit uint64 = 10000000 //steps a single action may use when the caller gives no limit
+	StackLimit              = 1024     //max depth of the word stack
+	MaxMemorySize           = 1 << 20  //max bytes of machine memory
+	WordSize                = 32
+)
+
+var (
+	ErrOutOfSteps        = errors.New("out of steps")
+	ErrStackOverflow     = errors.New("stack overflow")
+	ErrStackUnderflow    = errors.New("stack underflow")
+	ErrInvalidJump       = errors.New("invalid jump destination")
+	ErrMemoryLimit       = errors.New("memory limit exceeded")
+	ErrExecutionReverted = errors.New("execution reverted")
+	ErrEmptyCode         = errors.New("no code to execute")
+)
+
+//Contract is the code and input of a single execution
+type Contract struct {
+	Address types.Address
+	Code    []byte
+	Input   []byte
+}
+
+//Result of a successful execution
+type Result struct {
+	Return    []byte                    //data given by RETURN
+	Writes    []intertypes.ActionResult //storage writes in execution order
+	StepsUsed uint64
+}
+
+//Machine holds the running state of one execution
+type Machine struct {
+	contract  *Contract
+	handler   *sdk.TmpStatusManager
+	stack     []*big.Int
+	memory    []byte
+	jumpdests []bool
+	stepLimit uint64
+	steps     uint64
+
+	//storage writes are kept here until the execution succeeds
+	writes    map[string][]byte
+	writeKeys []string
+}
+
+func newMachine(contract *Contract, handler *sdk.TmpStatusManager, stepLimit uint64) *Machine {
+	return &Machine{
+		contract:  contract,
+		handler:   handler,
+		stack:     make([]*big.Int, 0, 16),
+		jumpdests: analyseJumpdests(contract.Code),
+		stepLimit: stepLimit,
+		writes:    make(map[string][]byte),
+	}
+}
+
+//Execute runs the contract code,storage writes are only applied to the sdk handler when the execution succeeds
+func Execute(contract *Contract, handler *sdk.TmpStatusManager, stepLimit uint64) (*Result, error) {
+	if len(contract.Code) == 0 {
+		return nil, ErrEmptyCode
+	}
+	if stepLimit == 0 {
+		stepLimit = DefaultStepLimit
+	}
+	m := newMachine(contract, handler, stepLimit)
+	ret, err := m.run()
+	if err != nil {
+		return &Result{StepsUsed: m.steps}, err
+	}
+
+	result := &Result{Return: ret, StepsUsed: m.steps}
+	for _, k := range m.writeKeys {
+		key := []byte(k)
+		val := m.writes[k]
+		if err := sdk.Sys_SetValue(handler, contract.Address, key, val); err != nil {
+			return &Result{StepsUsed: m.steps}, err
+		}
+		result.Writes = append(result.Writes, intertypes.ActionResult{Key: key, Val: val})
+	}
+	return result, nil
+}
+
+//analyseJumpdests marks the JUMPDEST positions which are not inside PUSH data
+func analyseJumpdests(code []byte) []bool {
+	dests := make([]bool, len(code))
+	for pc := 0; pc < len(code); pc++ {
+		op := OpCode(code[pc])
+		if op == JUMPDEST {
+			dests[pc] = true
+		} else if op.IsPush() {
+			pc += int(op-PUSH1) + 1
+		}
+	}
+	return dests
+}
+
+func (m *Machine) useSteps(n uint64) error {
+	if m.steps+n < m.steps || m.steps+n > m.stepLimit {
+		m.steps = m.stepLimit
+		return ErrOutOfSteps
+	}
+	m.steps += n
+	return nil
+}
+
+func (m *Machine) push(v *big.Int) error {
+	if len(m.stack) >= StackLimit {
+		return ErrStackOverflow
+	}
+	m.stack = append(m.stack, v)
+	return nil
+}
+
+func (m *Machine) pop() (*big.Int, error) {
+	if len(m.stack) == 0 {
+		return nil, ErrStackUnderflow
+	}
+	v := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return v, nil
+}
+
+func (m *Machine) popN(n int) ([]*big.Int, error) {
+	if len(m.stack) < n {
+		return nil, ErrStackUnderflow
+	}
+	vals := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		vals[i] = m.stack[len(m.stack)-1-i]
+	}
+	m.stack = m.stack[:len(m.stack)-n]
+	return vals, nil
+}
+
+//memoryRange checks offset and size and grows the memory,every new word costs one step
+func (m *Machine) memoryRange(offset, size *big.Int) (uint64, uint64, error) {
+	if size.Sign() == 0 {
+		return 0, 0, nil
+	}
+	if !offset.IsUint64() || !size.IsUint64() {
+		return 0, 0, ErrMemoryLimit
+	}
+	off, sz := offset.Uint64(), size.Uint64()
+	end := off + sz
+	if end < off || end > MaxMemorySize {
+		return 0, 0, ErrMemoryLimit
+	}
+	if end > uint64(len(m.memory)) {
+		newSize := (end + WordSize - 1) / WordSize * WordSize
+		if err := m.useSteps((newSize - uint64(len(m.memory))) / WordSize); err != nil {
+			return 0, 0, err
+		}
+		m.memory = append(m.memory, make([]byte, newSize-uint64(len(m.memory)))...)
+	}
+	return off, sz, nil
+}
+
+func (m *Machine) sload(key []byte) []byte {
+	if v, ok := m.writes[string(key)]; ok {
+		return v
+	}
+	return sdk.Sys_GetValue(m.handler, m.contract.Address, key)
+}
+
+func (m *Machine) sstore(key, val []byte) {
+	k := string(key)
+	if _, ok := m.writes[k]; !ok {
+		m.writeKeys = append(m.writeKeys, k)
+	}
+	m.writes[k] = val
+}
+
+func boolWord(b bool) *big.Int {
+	if b {
+		return big.NewInt(1)
+	}
+	return new(big.Int)
+}
+
+func (m *Machine) run() ([]byte, error) {
+	code := m.contract.Code
+	var pc uint64
+	for pc < uint64(len(code)) {
+		op := OpCode(code[pc])
+		cost, ok := stepCosts[op]
+		if !ok {
+			return nil, fmt.Errorf("invalid opcode 0x%x at %d", int(op), pc)
+		}
+		if err := m.useSteps(cost); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case op.IsPush():
+			n := uint64(op-PUSH1) + 1
+			data := make([]byte, n)
+			if pc+1 < uint64(len(code)) {
+				copy(data, code[pc+1:])
+			}
+			if err := m.push(new(big.Int).SetBytes(data)); err != nil {
+				return nil, err
+			}
+			pc += n + 1
+			continue
+		case op >= DUP1 && op <= DUP16:
+			n := int(op-DUP1) + 1
+			if len(m.stack) < n {
+				return nil, ErrStackUnderflow
+			}
+			if err := m.push(new(big.Int).Set(m.stack[len(m.stack)-n])); err != nil {
+				return nil, err
+			}
+			pc++
+			continue
+		case op >= SWAP1 && op <= SWAP16:
+			n := int(op-SWAP1) + 1
+			if len(m.stack) < n+1 {
+				return nil, ErrStackUnderflow
+			}
+			top := len(m.stack) - 1
+			m.stack[top], m.stack[top-n] = m.stack[top-n], m.stack[top]
+			pc++
+			continue
+		}
+
+		switch op {
+		case STOP:
+			return nil, nil
+		case ADD, MUL, SUB, DIV, MOD, LT, GT, EQ, AND, OR, XOR:
+			args, err := m.popN(2)
+			if err != nil {
+				return nil, err
+			}
+			x, y := args[0], args[1]
+			var r *big.Int
+			switch op {
+			case ADD:
+				r = math.U256(new(big.Int).Add(x, y))
+			case MUL:
+				r = math.U256(new(big.Int).Mul(x, y))
+			case SUB:
+				r = math.U256(new(big.Int).Sub(x, y))
+			case DIV:
+				r = new(big.Int)
+				if y.Sign() != 0 {
+					r.Div(x, y)
+				}
+			case MOD:
+				r = new(big.Int)
+				if y.Sign() != 0 {
+					r.Mod(x, y)
+				}
+			case LT:
+				r = boolWord(x.Cmp(y) < 0)
+			case GT:
+				r = boolWord(x.Cmp(y) > 0)
+			case EQ:
+				r = boolWord(x.Cmp(y) == 0)
+			case AND:
+				r = new(big.Int).And(x, y)
+			case OR:
+				r = new(big.Int).Or(x, y)
+			case XOR:
+				r = new(big.Int).Xor(x, y)
+			}
+			m.push(r)
+		case ISZERO, NOT:
+			x, err := m.pop()
+			if err != nil {
+				return nil, err
+			}
+			if op == ISZERO {
+				m.push(boolWord(x.Sign() == 0))
+			} else {
+				m.push(math.U256(new(big.Int).Not(x)))
+			}
+		case SHA3:
+			args, err := m.popN(2)
+			if err != nil {
+				return nil, err
+			}
+			off, sz, err := m.memoryRange(args[0], args[1])
+			if err != nil {
+				return nil, err
+			}
+			if err := m.useSteps((sz + WordSize - 1) / WordSize * 6); err != nil {
+				return nil, err
+			}
+			m.push(new(big.Int).SetBytes(crypto.Keccak256(m.memory[off : off+sz])))
+		case ADDRESS:
+			if err := m.push(new(big.Int).SetBytes(m.contract.Address[:])); err != nil {
+				return nil, err
+			}
+		case COINBASE:
+			coinbase := sdk.Sys_GetCoinbase(m.handler)
+			v := new(big.Int)
+			if coinbase != nil {
+				v.SetBytes(coinbase[:])
+			}
+			if err := m.push(v); err != nil {
+				return nil, err
+			}
+		case CALLDATALOAD:
+			x, err := m.pop()
+			if err != nil {
+				return nil, err
+			}
+			m.push(new(big.Int).SetBytes(getData(m.contract.Input, x, WordSize)))
+		case CALLDATASIZE:
+			if err := m.push(new(big.Int).SetUint64(uint64(len(m.contract.Input)))); err != nil {
+				return nil, err
+			}
+		case CALLDATACOPY:
+			args, err := m.popN(3)
+			if err != nil {
+				return nil, err
+			}
+			off, sz, err := m.memoryRange(args[0], args[2])
+			if err != nil {
+				return nil, err
+			}
+			copy(m.memory[off:off+sz], getData(m.contract.Input, args[1], sz))
+		case POP:
+			if _, err := m.pop(); err != nil {
+				return nil, err
+			}
+		case MLOAD:
+			x, err := m.pop()
+			if err != nil {
+				return nil, err
+			}
+			off, _, err := m.memoryRange(x, big.NewInt(WordSize))
+			if err != nil {
+				return nil, err
+			}
+			m.push(new(big.Int).SetBytes(m.memory[off : off+WordSize]))
+		case MSTORE, MSTORE8:
+			args, err := m.popN(2)
+			if err != nil {
+				return nil, err
+			}
+			size := int64(WordSize)
+			if op == MSTORE8 {
+				size = 1
+			}
+			off, _, err := m.memoryRange(args[0], big.NewInt(size))
+			if err != nil {
+				return nil, err
+			}
+			if op == MSTORE8 {
+				m.memory[off] = byte(args[1].Uint64())
+			} else {
+				copy(m.memory[off:off+WordSize], math.PaddedBigBytes(args[1], WordSize))
+			}
+		case SLOAD:
+			x, err := m.pop()
+			if err != nil {
+				return nil, err
+			}
+			m.push(math.U256(new(big.Int).SetBytes(m.sload(math.PaddedBigBytes(x, WordSize)))))
+		case SSTORE:
+			args, err := m.popN(2)
+			if err != nil {
+				return nil, err
+			}
+			m.sstore(math.PaddedBigBytes(args[0], WordSize), math.PaddedBigBytes(args[1], WordSize))
+		case JUMP, JUMPI:
+			n := 1
+			if op == JUMPI {
+				n = 2
+			}
+			args, err := m.popN(n)
+			if err != nil {
+				return nil, err
+			}
+			if op == JUMPI && args[1].Sign() == 0 {
+				break
+			}
+			dest := args[0]
+			if !dest.IsUint64() || dest.Uint64() >= uint64(len(code)) || !m.jumpdests[dest.Uint64()] {
+				return nil, ErrInvalidJump
+			}
+			pc = dest.Uint64()
+			continue
+		case PC:
+			if err := m.push(new(big.Int).SetUint64(pc)); err != nil {
+				return nil, err
+			}
+		case MSIZE:
+			if err := m.push(new(big.Int).SetUint64(uint64(len(m.memory)))); err != nil {
+				return nil, err
+			}
+		case JUMPDEST:
+		case RETURN, REVERT:
+			args, err := m.popN(2)
+			if err != nil {
+				return nil, err
+			}
+			off, sz, err := m.memoryRange(args[0], args[1])
+			if err != nil {
+				return nil, err
+			}
+			if op == REVERT {
+				return nil, ErrExecutionReverted
+			}
+			ret := make([]byte, sz)
+			copy(ret, m.memory[off:off+sz])
+			return ret, nil
+		}
+		pc++
+	}
+	return nil, nil
+}
+
+//getData returns size bytes of data from start,missing bytes are zero
+func getData(data []byte, start *big.Int, size uint64) []byte {
+	ret := make([]byte, size)
+	if !start.IsUint64() || start.Uint64() >= uint64(len(data)) {
+		return ret
+	}
+	copy(ret, data[start.Uint64():])
+	return ret
+}
@@ -0,0 +1,110 @@
+package bytecode
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"mjoy.io/common/math"
+	"mjoy.io/common/types"
+	"mjoy.io/core/sdk"
+	"mjoy.io/core/state"
+	"mjoy.io/utils/database"
+)
+
+//counter increase the word at key 0 and return the new value
+var counterCode = []byte{
+	byte(PUSH1), 0x00, byte(SLOAD),
+	byte(PUSH1), 0x01, byte(ADD),
+	byte(DUP1), byte(PUSH1), 0x00, byte(SSTORE),
+	byte(PUSH1), 0x00, byte(MSTORE),
+	byte(PUSH1), 0x20, byte(PUSH1), 0x00, byte(RETURN),
+}
+
+func newTestHandler(t *testing.T) *sdk.TmpStatusManager {
+	db, err := database.OpenMemDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateDb, err := state.New(types.Hash{}, state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sdk.NewTmpStatusManager(db, stateDb, types.Address{})
+}
+
+func TestCounter(t *testing.T) {
+	handler := newTestHandler(t)
+	contract := &Contract{Address: types.HexToAddress("0x1234"), Code: counterCode}
+
+	for i := int64(1); i <= 3; i++ {
+		r, err := Execute(contract, handler, 0)
+		if err != nil {
+			t.Fatalf("execution %d failed: %v", i, err)
+		}
+		want := math.PaddedBigBytes(big.NewInt(i), WordSize)
+		if !bytes.Equal(r.Return, want) {
+			t.Fatalf("execution %d: return mismatch: have %x, want %x", i, r.Return, want)
+		}
+		if len(r.Writes) != 1 || !bytes.Equal(r.Writes[0].Val, want) {
+			t.Fatalf("execution %d: writes mismatch: %v", i, r.Writes)
+		}
+		if r.StepsUsed == 0 {
+			t.Fatalf("execution %d: no steps metered", i)
+		}
+	}
+}
+
+func TestCallData(t *testing.T) {
+	handler := newTestHandler(t)
+	//return calldata word 0 multiplied by 2
+	code := []byte{
+		byte(PUSH1), 0x00, byte(CALLDATALOAD), byte(PUSH1), 0x02, byte(MUL),
+		byte(PUSH1), 0x00, byte(MSTORE),
+		byte(PUSH1), 0x20, byte(PUSH1), 0x00, byte(RETURN),
+	}
+	input := math.PaddedBigBytes(big.NewInt(21), WordSize)
+	r, err := Execute(&Contract{Code: code, Input: input}, handler, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(big.Int).SetBytes(r.Return).Int64() != 42 {
+		t.Fatalf("return mismatch: have %x", r.Return)
+	}
+}
+
+func TestExecutionErrors(t *testing.T) {
+	tests := []struct {
+		code []byte
+		err  error
+	}{
+		{[]byte{byte(JUMPDEST), byte(PUSH1), 0x00, byte(JUMP)}, ErrOutOfSteps},
+		{[]byte{byte(PUSH1), 0x05, byte(JUMP)}, ErrInvalidJump},
+		{[]byte{byte(PUSH1), 0x5b, byte(PUSH1), 0x01, byte(JUMP)}, ErrInvalidJump},
+		{[]byte{byte(ADD)}, ErrStackUnderflow},
+		{[]byte{byte(PUSH1), 0xff, byte(PUSH1), 0xff, byte(MSTORE), byte(PUSH1), 0x01, byte(PUSH32)}, nil},
+		{[]byte{byte(PUSH1), 0x20, byte(PUSH1 + 7), 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, byte(RETURN)}, ErrMemoryLimit},
+		{nil, ErrEmptyCode},
+	}
+	for i, tt := range tests {
+		_, err := Execute(&Contract{Code: tt.code}, newTestHandler(t), 10000)
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestRevertDiscardsWrites(t *testing.T) {
+	handler := newTestHandler(t)
+	addr := types.HexToAddress("0x1234")
+	code := []byte{
+		byte(PUSH1), 0x01, byte(PUSH1), 0x00, byte(SSTORE),
+		byte(PUSH1), 0x00, byte(PUSH1), 0x00, byte(REVERT),
+	}
+	if _, err := Execute(&Contract{Address: addr, Code: code}, handler, 0); err != ErrExecutionReverted {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrExecutionReverted)
+	}
+	if v := sdk.Sys_GetValue(handler, addr, make([]byte, WordSize)); len(v) != 0 {
+		t.Fatalf("reverted write is visible: %x", v)
+	}
+}
@@ -0,0 +1,42 @@
+package interpreter
+
+import (
+	"math/big"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/sdk"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/utils/database"
+)
+
+//DeclaredFee returns the fee a transaction declares:the fee of its whole resource limit,charged before
+//its actions run,and the fees its transferFee actions move,see GetPriority
+func DeclaredFee(tx *transaction.Transaction)*big.Int{
+	fee := balancetransfer.ResourceFee(tx.ResourceLimit())
+	return fee.Add(fee , GetPriority(tx))
+}
+
+//FeeChecker checks the sender of a transaction can pay the fee it declares out of its balancetransfer
+//balance,the transaction pool calls it on admission
+type FeeChecker struct {
+	db database.IDatabaseGetter
+}
+
+func NewFeeChecker(db database.IDatabaseGetter)*FeeChecker{
+	return &FeeChecker{db:db}
+}
+
+//CheckFee returns balancetransfer.ErrInsufficientFee if from can not pay the fee of tx on statedb
+func (this *FeeChecker)CheckFee(statedb *state.StateDB , from types.Address , tx *transaction.Transaction)error{
+	sdkHandler := sdk.NewTmpStatusManager(this.db , statedb , types.Address{})
+	balance , err := balancetransfer.BalanceOf(intertypes.MakeSystemParams(sdkHandler , nil) , from)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(DeclaredFee(tx)) < 0 {
+		return balancetransfer.ErrInsufficientFee
+	}
+	return nil
+}
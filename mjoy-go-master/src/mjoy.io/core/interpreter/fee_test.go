@@ -0,0 +1,78 @@
+package interpreter
+
+import (
+	"math/big"
+	"testing"
+
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/database"
+)
+
+func TestFeeChecker(t *testing.T) {
+	db, _ := database.OpenMemDB()
+	statedb, _ := state.New(types.Hash{}, state.NewDatabase(db))
+
+	// store a balance the way a block writes it
+	rich, poor := types.Address{1}, types.Address{2}
+	resourceFee := balancetransfer.ResourceFee(100)
+	data, _ := balancetransfer.EncodeBalance(new(big.Int).Add(resourceFee, big.NewInt(10)), false)
+	key := crypto.Keccak256Hash(append(balancetransfer.BalanceTransferAddress.Bytes(), rich[:]...))
+	val := crypto.Keccak256Hash(data)
+	statedb.SetState(balancetransfer.BalanceTransferAddress, key, val)
+	db.Put(val[:], data)
+
+	fee := func(amount int) transaction.Action {
+		return transaction.MakeAction(balancetransfer.BalanceTransferAddress, balancetransfer.MakeTransferFeeParam(amount))
+	}
+	transfer := transaction.MakeAction(balancetransfer.BalanceTransferAddress, balancetransfer.MakaBalanceTransferParam(poor, 1000))
+
+	tests := []struct {
+		from    types.Address
+		actions transaction.ActionSlice
+		fee     *big.Int
+		err     error
+	}{
+		{rich, transaction.ActionSlice{transfer}, resourceFee, nil},
+		{rich, transaction.ActionSlice{fee(10), transfer}, new(big.Int).Add(resourceFee, big.NewInt(10)), nil},
+		{rich, transaction.ActionSlice{fee(6), fee(5)}, new(big.Int).Add(resourceFee, big.NewInt(11)), balancetransfer.ErrInsufficientFee},
+		{poor, transaction.ActionSlice{transfer}, resourceFee, balancetransfer.ErrInsufficientFee},
+	}
+	checker := NewFeeChecker(db)
+	for i, test := range tests {
+		tx := transaction.NewTransactionWithLimit(0, 100, test.actions)
+		if have := DeclaredFee(tx); have.Cmp(test.fee) != 0 {
+			t.Errorf("test %d: fee mismatch: have %v, want %v", i, have, test.fee)
+		}
+		if err := checker.CheckFee(statedb, test.from, tx); err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestGetPriority(t *testing.T) {
+	fee := func(amount int) transaction.Action {
+		return transaction.MakeAction(balancetransfer.BalanceTransferAddress, balancetransfer.MakeTransferFeeParam(amount))
+	}
+	transfer := transaction.MakeAction(balancetransfer.BalanceTransferAddress, balancetransfer.MakaBalanceTransferParam(types.Address{1}, 1000))
+	other := transaction.MakeAction(types.Address{1}, balancetransfer.MakeTransferFeeParam(50))
+
+	tests := []struct {
+		actions  transaction.ActionSlice
+		priority int64
+	}{
+		{nil, 0},
+		{transaction.ActionSlice{transfer}, 0},
+		{transaction.ActionSlice{fee(7), transfer}, 7},
+		{transaction.ActionSlice{transfer, fee(7), fee(3)}, 10},
+		{transaction.ActionSlice{other, fee(1)}, 1}, // only the fees paid to the balance contract count
+	}
+	for i, test := range tests {
+		if have := GetPriority(transaction.NewTransaction(0, test.actions)); have.Int64() != test.priority {
+			t.Errorf("test %d: priority mismatch: have %v, want %d", i, have, test.priority)
+		}
+	}
+}
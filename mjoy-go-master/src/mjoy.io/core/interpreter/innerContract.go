@@ -10,24 +10,31 @@ import (
 	"math/big"
 	"mjoy.io/core/interpreter/intertypes"
 	"fmt"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/params"
 )
 
 //InnerContrancInterface
 type InnerContract interface {
 	DoFun( params []byte , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error)
-
+	//Abi is the schema of the params DoFun accepts
+	Abi()*abi.ABI
 }
 
 //InnerContranctMap is a innerContract controller,like check contract ,do a contract
 type InnerContractManager struct {
 	mu sync.RWMutex
 	Inners map[types.Address]InnerContract
+	Abis map[types.Address]*abi.ABI
+	Actives map[types.Address]activeFun
 }
 
 //New A InnerContractMaper
 func NewInnerContractManager()*InnerContractManager{
 	maper := new(InnerContractManager)
 	maper.Inners = make(map[types.Address]InnerContract)
+	maper.Abis = make(map[types.Address]*abi.ABI)
+	maper.Actives = make(map[types.Address]activeFun)
 	maper.init()
 	return maper
 }
@@ -50,6 +57,10 @@ func (this *InnerContractManager)register(){
 	for _ , obj := range allInnerRegister {
 		fmt.Println("registerAddr :" , obj.address.Hex())
 		this.Inners[obj.address] = obj.inner
+		this.Abis[obj.address] = obj.inner.Abi()
+		if obj.active != nil {
+			this.Actives[obj.address] = obj.active
+		}
 		//if obj.address != zeroAddress{
 		//	this.Inners[obj.address] = obj.inner
 		//}
@@ -68,6 +79,28 @@ func (this *InnerContractManager)Exist(address types.Address)bool{
 	return false
 }
 
+//check innerContract is callable under the fork rules,a innerContract without a activation is always callable
+func (this *InnerContractManager)Active(address types.Address , rules params.Rules)bool{
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	if _ , ok := this.Inners[address];!ok{
+		return false
+	}
+	if active , ok := this.Actives[address];ok{
+		return active(rules)
+	}
+	return true
+}
+
+//get the schema of a innerContract,nil if the innerContract is not exist
+func (this *InnerContractManager)GetAbi(address types.Address)*abi.ABI{
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	return this.Abis[address]
+}
+
 //call a innerContract.Please call Exist ensure a innerContract is exist or not before this
 func (this *InnerContractManager)DoFun(address types.Address , params []byte,sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
 	inner := this.Inners[address]
@@ -7,16 +7,41 @@ package interpreter
 import (
 	"mjoy.io/common/types"
 	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/interpreter/token"
+	"mjoy.io/core/interpreter/staking"
+	"mjoy.io/core/interpreter/slashing"
+	"mjoy.io/params"
 )
 
+//activeFun tells whether a innerContract is callable under the fork rules of a block,nil means always
+type activeFun func(rules params.Rules)bool
+
 type innerRegisterMap struct {
 	address types.Address
 	inner   InnerContract
+	active  activeFun
 }
 
 type InnersRegister []innerRegisterMap
 
 var allInnerRegister InnersRegister = InnersRegister{
-	{balancetransfer.BalanceTransferAddress , balancetransfer.NewContractBalancer()},
+	{balancetransfer.BalanceTransferAddress , balancetransfer.NewContractBalancer() , nil},
+	{token.TokenAddress , token.NewContractToken() , nil},
+	{staking.StakingAddress , staking.NewContractStaking() , isStaking},
+	{slashing.SlashingAddress , slashing.NewContractSlashing() , isStaking},
+}
+
+func isStaking(rules params.Rules)bool{
+	return rules.IsStaking
+}
+
+//InactiveInnerContract reports whether address is a innerContract the rules do not enable yet
+func InactiveInnerContract(address types.Address , rules params.Rules)bool{
+	for _ , obj := range allInnerRegister {
+		if obj.address == address {
+			return obj.active != nil && !obj.active(rules)
+		}
+	}
+	return false
 }
 
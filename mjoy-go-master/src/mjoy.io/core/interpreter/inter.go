@@ -7,8 +7,13 @@ import (
 	"mjoy.io/common/types"
 	"time"
 	"fmt"
+	"math/big"
 	"mjoy.io/core/interpreter/intertypes"
 	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/interpreter/bytecode"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/sdk"
+	"mjoy.io/params"
 )
 
 //Test addressd
@@ -74,15 +79,66 @@ func (this *Vms)DealActions(pWork *Work)error{
 //DealActions is a little part of full work
 func (this *Vms)DealAction(contractAddress types.Address , action transaction.Action ,sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
 	if this.pInnerContractMaper.Exist(contractAddress){
+		//out of a block every innerContract is callable
+		if sysparam != nil {
+			if rules , ok := sdk.Sys_GetRules(sysparam.SdkHandler);ok && !this.pInnerContractMaper.Active(contractAddress , rules){
+				return nil , errors.New(fmt.Sprintf("innerContract %s is not active at this block" , contractAddress.Hex()))
+			}
+		}
 		results , err := this.pInnerContractMaper.DoFun(contractAddress , action.Params , sysparam)
 		if err != nil {
 			return nil , err
 		}
 		return results , nil
 	}
+
+	//not a innerContract,try the code deployed by Create
+	if sysparam != nil {
+		if code := sdk.Sys_GetCode(sysparam.SdkHandler , contractAddress);len(code) > 0{
+			return this.runCode(contractAddress , code , action.Params , sysparam)
+		}
+	}
 	return nil , errors.New("innerContract Not Exist....")
 }
 
+//runCode execute the deployed code in the bytecode machine,the returned data is the first result with a nil key
+//(the same as the innerContract queries),followed by the storage writes
+func (this *Vms)runCode(contractAddress types.Address , code []byte , input []byte , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	stepLimit := bytecode.DefaultStepLimit
+	if remaining , ok := sdk.Sys_GetResourceRemaining(sysparam.SdkHandler);ok {
+		if steps := remaining / params.StepResourceCost;steps < stepLimit{
+			stepLimit = steps
+		}
+		if stepLimit == 0 {
+			return nil , sdk.ErrResourceExhausted
+		}
+	}
+
+	contract := &bytecode.Contract{Address:contractAddress , Code:code , Input:input}
+	r , err := bytecode.Execute(contract , sysparam.SdkHandler , stepLimit)
+	if r != nil {
+		if chargeErr := sdk.Sys_ChargeResource(sysparam.SdkHandler , r.StepsUsed * params.StepResourceCost);chargeErr != nil && err == nil {
+			err = chargeErr
+		}
+	}
+	if err != nil {
+		logger.Debugf("code contract %s failed: %v" , contractAddress.Hex() , err)
+		return nil , err
+	}
+
+	results := make([]intertypes.ActionResult , 0 , len(r.Writes) + 1)
+	if len(r.Return) > 0 {
+		results = append(results , intertypes.ActionResult{Key:nil , Val:r.Return})
+	}
+	results = append(results , r.Writes...)
+	return results , nil
+}
+
+//GetAbi returns the schema registered for a contract address,nil if there is none
+func (this *Vms)GetAbi(contractAddress types.Address)*abi.ABI{
+	return this.pInnerContractMaper.GetAbi(contractAddress)
+}
+
 /********************************************************************/
 //Deal Work..........
 /********************************************************************/
@@ -148,12 +204,17 @@ func (this *Vms)TestRun(){
 }
 
 
-//will uesed by txpool
-func GetPriority(from types.Address , actions []transaction.Action)int{
-	//some calculation for priority
-	priority , err :=balancetransfer.CheckFee(*actions[0].Address , actions[0].Params)
-	if err != nil {
-		logger.Errorf("Get Err When call GetPriority:" , err.Error())
+//GetPriority returns the priority of a transaction in the txpool:the fee it declares with its transferFee actions,
+//zero if it has none
+func GetPriority(tx *transaction.Transaction)*big.Int{
+	priority := new(big.Int)
+	for _ , action := range tx.Data.Actions{
+		if action.Address == nil {
+			continue
+		}
+		if fee , err := balancetransfer.FeeAmount(*action.Address , action.Params);err == nil {
+			priority.Add(priority , fee)
+		}
 	}
 	return priority
 }
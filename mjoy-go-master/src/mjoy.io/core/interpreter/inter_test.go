@@ -7,10 +7,17 @@ import (
 	"mjoy.io/core/sdk"
 	"mjoy.io/common/types"
 	"fmt"
-	"reflect"
 	"testing"
 	"mjoy.io/core/transaction"
 	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/state"
+	"mjoy.io/core/interpreter/bytecode"
+	"math/big"
+	"errors"
+	"bytes"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/interpreter/slashing"
+	"mjoy.io/params"
 )
 
 func checkResultsData(sdkHandler *sdk.TmpStatusManager){
@@ -59,7 +66,7 @@ func makeTestData()*sdk.TmpStatusManager{
 	}
 
 	a := new(balancetransfer.BalanceValue)
-	a.Amount = 1000
+	a.Amount = big.NewInt(1000)
 
 	lastAccountInfoData , err := json.Marshal(a)
 	if err != nil {
@@ -67,7 +74,11 @@ func makeTestData()*sdk.TmpStatusManager{
 		return nil
 	}
 	//store the data
-	sdkHandler := sdk.NewTmpStatusManager(types.Hash{} , db)
+	stateDb , err := state.New(types.Hash{} , state.NewDatabase(db))
+	if err != nil {
+		panic(err)
+	}
+	sdkHandler := sdk.NewTmpStatusManager(db , stateDb , types.Address{})
 	contractAddr := types.Address{}
 	contractAddr[0] = 1
 
@@ -84,40 +95,10 @@ func makeTestData()*sdk.TmpStatusManager{
 }
 
 func makeActionParams()[]byte{
-	a := make(map[string]interface{})
-	a["funcId"] = "0"
-
-	fromAddr := types.Address{}
-	fromAddr[2] = 1
-	a["from"] = fromAddr.Hex()
-
 	toAddr := types.Address{}
 	toAddr[3] = 1
-	a["to"] = toAddr.Hex()
-
-	a["amount"] = int64(10)
-
-	fmt.Println("type amount:" , reflect.TypeOf(a["amount"]))
-	r , err :=json.Marshal(a)
-	if err != nil {
-		return nil
-	}
-	return r
-}
 
-func makeActionParamsReword()[]byte{
-	a := make(map[string]interface{})
-	a["funcId"] = "1"
-
-	fromAddr := types.Address{}
-	fromAddr[2] = 1
-	a["producer"] = fromAddr.Hex()
-
-	r , err :=json.Marshal(a)
-	if err != nil {
-		return nil
-	}
-	return r
+	return balancetransfer.MakaBalanceTransferParam(toAddr , 10)
 }
 
 /*
@@ -151,9 +132,12 @@ func TestInterDbNoDataBefore(t *testing.T){
 	fmt.Println("Start Testing....")
 
 
-	//create system params
+	//create system params,the balance of the sender is transferred
 	sysparam := intertypes.MakeSystemParams(sdkHandler , pNewVm)
-	rChan := pNewVm.SendWork(types.Address{} , action , sysparam)
+	fromAddr := types.Address{}
+	fromAddr[2] = 1
+	sdkHandler.SetTxContext(&sdk.TxContext{Sender:fromAddr})
+	rChan := pNewVm.SendWork(fromAddr , action , sysparam)
 	rw := <-rChan
 	fmt.Println("get A result")
 	fmt.Println("resultsLen :" , len(rw.Results))
@@ -161,10 +145,248 @@ func TestInterDbNoDataBefore(t *testing.T){
 	_ = rw
 	checkResultsData(sdkHandler)
 
-	action.Params = makeActionParamsReword()
-	rChan = pNewVm.SendWork(types.Address{} , action , sysparam)
-	rw = <-rChan
-	//time.Sleep(1*time.Second)
-	checkResultsData(sdkHandler)
+}
+
+func TestCodeContract(t *testing.T){
+	db , _ := database.OpenMemDB()
+	stateDb , err := state.New(types.Hash{} , state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//store the calldata word at key 0 and return it
+	code := []byte{
+		byte(bytecode.PUSH1) , 0x00 , byte(bytecode.CALLDATALOAD) ,
+		byte(bytecode.DUP1) , byte(bytecode.PUSH1) , 0x00 , byte(bytecode.SSTORE) ,
+		byte(bytecode.PUSH1) , 0x00 , byte(bytecode.MSTORE) ,
+		byte(bytecode.PUSH1) , 0x20 , byte(bytecode.PUSH1) , 0x00 , byte(bytecode.RETURN) ,
+	}
+	contractAddr := types.HexToAddress("0x1234")
+	stateDb.SetCode(contractAddr , code)
+
+	sdkHandler := sdk.NewTmpStatusManager(db , stateDb , types.Address{})
+	pNewVm := NewVm()
+	sysparam := intertypes.MakeSystemParams(sdkHandler , pNewVm)
+
+	input := types.BigToHash(big.NewInt(7))
+	action := transaction.Action{Address:&contractAddr , Params:input[:]}
+	rw := <-pNewVm.SendWork(types.Address{} , action , sysparam)
+	if rw.Err != nil {
+		t.Fatal(rw.Err)
+	}
+	if len(rw.Results) != 2 || rw.Results[0].Key != nil || types.BytesToHash(rw.Results[0].Val) != input {
+		t.Fatalf("unexpected results:%v" , rw.Results)
+	}
+	if stored := sdk.Sys_GetValue(sdkHandler , contractAddr , make([]byte , 32));types.BytesToHash(stored) != input {
+		t.Fatalf("stored value mismatch:%x" , stored)
+	}
+
+	//no innerContract and no code
+	other := types.HexToAddress("0x5678")
+	action.Address = &other
+	if rw = <-pNewVm.SendWork(types.Address{} , action , sysparam);rw.Err == nil {
+		t.Fatal("expected error for address without code")
+	}
+}
+
+func TestInnerContractAbi(t *testing.T){
+	sdkHandler := makeTestData()
+	pNewVm := NewVm()
+	sysparam := intertypes.MakeSystemParams(sdkHandler , pNewVm)
+
+	if pNewVm.GetAbi(balancetransfer.BalanceTransferAddress) != balancetransfer.BalancerAbi {
+		t.Fatal("balancetransfer abi not registered")
+	}
+
+	fromAddr := types.Address{}
+	fromAddr[2] = 1
+	contractAddr := balancetransfer.BalanceTransferAddress
+	action := transaction.Action{Address:&contractAddr , Params:balancetransfer.MakeGetBalanceParam(fromAddr)}
+	getResult := pNewVm.GetStorage(types.Address{} , action , sysparam)
+	if getResult.Err != nil {
+		t.Fatal(getResult.Err)
+	}
+	method , _ := balancetransfer.BalancerAbi.Method(balancetransfer.GetBalance_Method)
+	outputs , err := method.UnpackOutputs(getResult.Var)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances := outputs[0].([]*big.Int);len(balances) != 1 || balances[0].Int64() != 1000 {
+		t.Fatalf("balance mismatch:%v" , balances)
+	}
+
+	//malformed params must be rejected instead of panic
+	for _ , params := range [][]byte{nil , []byte(`{"funcId":"0"}`) , {0x92 , 0xa3 , 'f' , 'o' , 'o' , 0x90}} {
+		action.Params = params
+		if rw := <-pNewVm.SendWork(types.Address{} , action , sysparam);rw.Err == nil {
+			t.Fatalf("params %x accepted" , params)
+		}
+	}
+}
+
+//funcContract is a innerContract made of a function,for testing calls between contracts
+type funcContract func(params []byte , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error)
 
-}
\ No newline at end of file
+func (this funcContract)DoFun(params []byte , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	return this(params , sysparam)
+}
+
+func (this funcContract)Abi()*abi.ABI{
+	return nil
+}
+
+func TestContractCall(t *testing.T){
+	sdkHandler := makeTestData()
+	pNewVm := NewVm()
+	sysparam := intertypes.MakeSystemParams(sdkHandler , pNewVm)
+
+	router := types.HexToAddress("0x10")
+	failing := types.HexToAddress("0x11")
+	key := []byte("k")
+	errFailing := errors.New("failing contract")
+
+	//failing writes a value and fails
+	pNewVm.pInnerContractMaper.Inners[failing] = funcContract(func(params []byte , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+		sdk.Sys_SetValue(sysparam.SdkHandler , failing , key , []byte{1})
+		return nil , errFailing
+	})
+	//router calls the contract and the params given by its own params
+	pNewVm.pInnerContractMaper.Inners[router] = funcContract(func(params []byte , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+		propagate := params[0] == 1
+		contract := types.BytesToAddress(params[1:21])
+		ret , err := sdk.Sys_Call(sysparam.SdkHandler , router , contract , params[21:])
+		if err != nil && propagate {
+			return nil , err
+		}
+		sdk.Sys_SetValue(sysparam.SdkHandler , router , key , []byte{2})
+		return []intertypes.ActionResult{{Key:nil , Val:ret} , {Key:key , Val:[]byte{2}}} , nil
+	})
+	routerParams := func(propagate byte , contract types.Address , params []byte)[]byte{
+		return append(append([]byte{propagate} , contract[:]...) , params...)
+	}
+	deal := func(params []byte)([]intertypes.ActionResult , error){
+		return pNewVm.DealAction(router , transaction.MakeAction(router , params) , sysparam)
+	}
+
+	//a transfer of the balance of the router
+	sdk.Sys_SetValue(sdkHandler , balancetransfer.BalanceTransferAddress , router[:] , []byte(`{"amount":100}`))
+	results , err := deal(routerParams(0 , balancetransfer.BalanceTransferAddress , makeActionParams()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results mismatch:%v" , results)
+	}
+	writes := sdkHandler.TakeCallWrites()
+	if len(writes) != 2 || writes[0].Address != balancetransfer.BalanceTransferAddress {
+		t.Fatalf("call writes mismatch:%v" , writes)
+	}
+	to := types.Address{}
+	to[3] = 1
+	if balance , _ := balancetransfer.BalanceOf(sysparam , to);balance.Int64() != 10 {
+		t.Fatalf("balance mismatch:have %v, want 10" , balance)
+	}
+	if balance , _ := balancetransfer.BalanceOf(sysparam , router);balance.Int64() != 90 {
+		t.Fatalf("balance of the router mismatch:have %v, want 90" , balance)
+	}
+
+	//the failed call is reverted,the router goes on
+	snapshot := sdkHandler.Snapshot()
+	if _ , err := deal(routerParams(0 , failing , nil));err != nil {
+		t.Fatal(err)
+	}
+	if v := sdk.Sys_GetValue(sdkHandler , failing , key);v != nil {
+		t.Fatalf("write of the failed call survived:%x" , v)
+	}
+	if v := sdk.Sys_GetValue(sdkHandler , router , key);!bytes.Equal(v , []byte{2}) {
+		t.Fatalf("write of the router mismatch:%x" , v)
+	}
+	if writes := sdkHandler.TakeCallWrites();len(writes) != 0 {
+		t.Fatalf("writes of the failed call taken:%v" , writes)
+	}
+	sdkHandler.RevertToSnapshot(snapshot)
+
+	//the error is given back by the router
+	if _ , err := deal(routerParams(1 , failing , nil));err != errFailing {
+		t.Fatalf("error mismatch:have %v, want %v" , err , errFailing)
+	}
+	//a running contract can not be called
+	if _ , err := deal(routerParams(1 , router , routerParams(0 , failing , nil)));err != sdk.ErrReentrantCall {
+		t.Fatalf("error mismatch:have %v, want %v" , err , sdk.ErrReentrantCall)
+	}
+
+	traces := sdkHandler.TakeCallTraces()
+	if len(traces) != 4 {
+		t.Fatalf("traces mismatch:%v" , traces)
+	}
+	if traces[0].Contract != balancetransfer.BalanceTransferAddress || traces[0].Caller != router || traces[0].Depth != 1 || traces[0].Error != "" {
+		t.Errorf("transfer trace mismatch:%+v" , traces[0])
+	}
+	for _ , trace := range traces[1:] {
+		if trace.Error == "" {
+			t.Errorf("failed call traced without error:%+v" , trace)
+		}
+	}
+}
+
+func TestContractCallDepth(t *testing.T){
+	sdkHandler := makeTestData()
+	pNewVm := NewVm()
+	sysparam := intertypes.MakeSystemParams(sdkHandler , pNewVm)
+
+	//every contract of the chain calls the next one
+	chain := make([]types.Address , params.CallDepthLimit + 2)
+	for i := range chain {
+		chain[i] = types.BigToAddress(big.NewInt(int64(0x100 + i)))
+	}
+	for i := range chain {
+		i := i
+		pNewVm.pInnerContractMaper.Inners[chain[i]] = funcContract(func(params []byte , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+			if i == len(chain) - 1 {
+				return nil , nil
+			}
+			_ , err := sdk.Sys_Call(sysparam.SdkHandler , chain[i] , chain[i + 1] , nil)
+			return nil , err
+		})
+	}
+
+	_ , err := pNewVm.DealAction(chain[0] , transaction.MakeAction(chain[0] , nil) , sysparam)
+	if err != sdk.ErrCallDepth {
+		t.Fatalf("error mismatch:have %v, want %v" , err , sdk.ErrCallDepth)
+	}
+	traces := sdkHandler.TakeCallTraces()
+	if len(traces) != params.CallDepthLimit + 1 || traces[params.CallDepthLimit].Depth != params.CallDepthLimit + 1 {
+		t.Fatalf("traces mismatch:%d" , len(traces))
+	}
+}
+
+func TestInnerContractActivation(t *testing.T){
+	sdkHandler := makeTestData()
+	pNewVm := NewVm()
+	sysparam := intertypes.MakeSystemParams(sdkHandler , pNewVm)
+
+	config := &params.ChainConfig{ChainId:big.NewInt(1) , StakingBlock:big.NewInt(10)}
+	contractAddr := slashing.SlashingAddress
+	action := transaction.MakeAction(contractAddr , slashing.MakeIsReportedParam(types.Address{1} , 1))
+
+	//before the fork the contract can not be called
+	sdkHandler.SetBlockContext(&sdk.BlockContext{Number:big.NewInt(9) , Time:big.NewInt(9) , ChainId:big.NewInt(1) , Rules:config.Rules(big.NewInt(9))})
+	if _ , err := pNewVm.DealAction(contractAddr , action , sysparam);err == nil {
+		t.Fatal("inactive contract called")
+	}
+	if !InactiveInnerContract(contractAddr , config.Rules(big.NewInt(9))) {
+		t.Fatal("contract not reported inactive before the fork")
+	}
+
+	sdkHandler.SetBlockContext(&sdk.BlockContext{Number:big.NewInt(10) , Time:big.NewInt(10) , ChainId:big.NewInt(1) , Rules:config.Rules(big.NewInt(10))})
+	if _ , err := pNewVm.DealAction(contractAddr , action , sysparam);err != nil {
+		t.Fatal(err)
+	}
+	if InactiveInnerContract(contractAddr , config.Rules(big.NewInt(10))) {
+		t.Fatal("contract reported inactive at the fork")
+	}
+
+	//the contracts without a fork are always callable
+	if InactiveInnerContract(balancetransfer.BalanceTransferAddress , config.Rules(big.NewInt(0))) {
+		t.Fatal("balancetransfer reported inactive")
+	}
+}
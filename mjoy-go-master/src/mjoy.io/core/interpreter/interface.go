@@ -35,7 +35,7 @@ type vms struct {}
 // deployed contract addresses (relevant after the account abstraction).
 var emptyCodeHash = crypto.Keccak256Hash(nil)
 
-func  Create(sender types.Address, stateDb *state.StateDB, actions transaction.ActionSlice) ( actionReuslts []intertypes.ActionResult, contractAddr types.Address, err error) {
+func  Create(sender types.Address, stateDb *state.StateDB, actions transaction.ActionSlice, sysparam *intertypes.SystemParams) ( actionReuslts []intertypes.ActionResult, contractAddr types.Address, err error) {
 
 	// Ensure there's no existing contract already at the designated address
 
@@ -58,13 +58,11 @@ func  Create(sender types.Address, stateDb *state.StateDB, actions transaction.A
 	}
 
 	// fee transfer
-	vm := NewVm()
-	//todo,fill sysparam
-	resulstChan := vm.SendWork(sender,actions[0] , nil)
+	resulstChan := sysparam.VmHandler.SendWork(sender,actions[0] , sysparam)
 	result := <-resulstChan
 	if result.Err != nil {
 		stateDb.RevertToSnapshot(snapshot)
-		return nil, types.Address{}, err
+		return nil, types.Address{}, result.Err
 	}
 
 	//2. save contract code
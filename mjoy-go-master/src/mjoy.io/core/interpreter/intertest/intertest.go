@@ -0,0 +1,80 @@
+//Package intertest provides the fixtures the tests of the inner contracts share
+package intertest
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/sdk"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+	"mjoy.io/utils/database"
+)
+
+//Contract is an inner contract the Vm can run
+type Contract interface {
+	DoFun(params []byte , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error)
+}
+
+//Vm runs the contracts called by Sys_Call in the calling goroutine
+type Vm struct {
+	Contracts map[types.Address]Contract
+}
+
+//NewVm returns a vm running contracts
+func NewVm(contracts map[types.Address]Contract)*Vm{
+	return &Vm{Contracts:contracts}
+}
+
+func (this *Vm)SendWork(address types.Address , action transaction.Action , sysparam *intertypes.SystemParams)<-chan intertypes.WorkResult{
+	ch := make(chan intertypes.WorkResult , 1)
+	results , err := this.DealAction(address , action , sysparam)
+	ch <- intertypes.WorkResult{Err:err , Results:results}
+	return ch
+}
+
+func (this *Vm)GetStorage(address types.Address , action transaction.Action , sysparam *intertypes.SystemParams)intertypes.GetResult{
+	return intertypes.GetResult{Err:errors.New("not supported")}
+}
+
+func (this *Vm)DealAction(address types.Address , action transaction.Action , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	contract , ok := this.Contracts[address]
+	if !ok {
+		return nil , errors.New("no contract at address")
+	}
+	return contract.DoFun(action.Params , sysparam)
+}
+
+//NewSysParams returns the system params of an empty state in memory,contracts calling others need a vm
+func NewSysParams(t *testing.T , vm intertypes.VmInterface)*intertypes.SystemParams{
+	db , err := database.OpenMemDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateDb , err := state.New(types.Hash{} , state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return intertypes.MakeSystemParams(sdk.NewTmpStatusManager(db , stateDb , types.Address{}) , vm)
+}
+
+//BlockContext is the block number of a chain with all forks active
+func BlockContext(number int64)*sdk.BlockContext{
+	return &sdk.BlockContext{Number:big.NewInt(number) , Time:big.NewInt(number) , ChainId:big.NewInt(1) , Rules:params.TestChainConfig.Rules(big.NewInt(number))}
+}
+
+//As calls contract in a transaction signed by sender
+func As(sender types.Address , sysparam *intertypes.SystemParams , contract Contract , params []byte)([]intertypes.ActionResult , error){
+	sysparam.SdkHandler.SetTxContext(&sdk.TxContext{Sender:sender})
+	defer sysparam.SdkHandler.SetTxContext(nil)
+	return contract.DoFun(params , sysparam)
+}
+
+//AsAt calls contract in a transaction signed by sender in the block number
+func AsAt(sender types.Address , number int64 , sysparam *intertypes.SystemParams , contract Contract , params []byte)([]intertypes.ActionResult , error){
+	sysparam.SdkHandler.SetBlockContext(BlockContext(number))
+	return As(sender , sysparam , contract , params)
+}
@@ -1,6 +1,7 @@
 package intertypes
 
 import (
+	"errors"
 	"mjoy.io/core/sdk"
 	"mjoy.io/common/types"
 	"mjoy.io/core/transaction"
@@ -27,6 +28,8 @@ type GetResult struct {
 type VmInterface interface {
 	SendWork( types.Address ,  transaction.Action ,  *SystemParams)<-chan WorkResult
 	GetStorage(address types.Address , action transaction.Action , params *SystemParams)GetResult
+	//DealAction runs the action on the contract address in the calling goroutine
+	DealAction(contractAddress types.Address , action transaction.Action , params *SystemParams)([]ActionResult , error)
 }
 
 //SystemParams contain all system running params
@@ -39,7 +42,49 @@ func MakeSystemParams(sdkHandler *sdk.TmpStatusManager , vmHandler VmInterface )
 	s := new(SystemParams)
 	s.SdkHandler = sdkHandler
 	s.VmHandler = vmHandler
+	if sdkHandler != nil && vmHandler != nil {
+		sdkHandler.SetCaller(&vmCaller{sysparam:s})
+	}
 	return s
 }
 
+//ErrNoSender is returned by Sender if there is no account a contract may act for
+var ErrNoSender = errors.New("no sender to act for")
+
+//Sender returns the account a contract acts for:the signed sender of the transaction,or the contract calling it
+func Sender(sysparam *SystemParams)(types.Address , error){
+	caller , ok := sdk.Sys_GetCaller(sysparam.SdkHandler)
+	if !ok {
+		return types.Address{} , ErrNoSender
+	}
+	return caller , nil
+}
+
+//vmCaller runs the contracts called by sdk.Sys_Call in the vm of the system params
+type vmCaller struct {
+	sysparam *SystemParams
+}
+
+func (this *vmCaller)Call(contract types.Address , params []byte)([]byte , []sdk.CallWrite , error){
+	action := transaction.MakeAction(contract , params)
+	results , err := this.sysparam.VmHandler.DealAction(contract , action , this.sysparam)
+	if err != nil {
+		return nil , nil , err
+	}
+
+	//a result with a nil key is the returned data,the others are storage writes
+	var ret []byte
+	writes := make([]sdk.CallWrite , 0 , len(results))
+	for _ , result := range results {
+		if result.Key == nil {
+			if ret == nil {
+				ret = result.Val
+			}
+			continue
+		}
+		writes = append(writes , sdk.CallWrite{Address:contract , Key:result.Key , Val:result.Val})
+	}
+	return ret , writes , nil
+}
+
 
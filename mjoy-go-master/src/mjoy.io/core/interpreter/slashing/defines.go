@@ -0,0 +1,52 @@
+package slashing
+
+import (
+	"encoding/binary"
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+)
+
+//evidencePrefix is followed by the signer and the number of the double signed headers
+var evidencePrefix = []byte("slashing/evidence/")
+
+//Evidence is stored under the evidence key once the double sign of Signer at Number is taken
+type Evidence struct {
+	Signer   types.Address `json:"signer"`
+	Number   uint64        `json:"number"`
+	First    types.Hash    `json:"first"`
+	Second   types.Hash    `json:"second"`
+	Reporter types.Address `json:"reporter"`
+}
+
+func EvidenceKey(signer types.Address , number uint64)[]byte{
+	key := append([]byte{} , evidencePrefix...)
+	key = append(key , signer[:]...)
+	var num [8]byte
+	binary.BigEndian.PutUint64(num[:] , number)
+	return append(key , num[:]...)
+}
+
+//MakeReportDoubleSignParam reports two signed headers of the same height and signer
+func MakeReportDoubleSignParam(first , second *block.Header)[]byte{
+	firstBytes , err := first.MarshalMsg(nil)
+	if err != nil {
+		return nil
+	}
+	secondBytes , err := second.MarshalMsg(nil)
+	if err != nil {
+		return nil
+	}
+	r , err := SlashingAbi.Pack(ReportDoubleSign_Method , firstBytes , secondBytes)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeIsReportedParam(signer types.Address , number uint64)[]byte{
+	r , err := SlashingAbi.Pack(IsReported_Method , signer , number)
+	if err != nil {
+		return nil
+	}
+	return r
+}
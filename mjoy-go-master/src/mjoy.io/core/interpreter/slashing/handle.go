@@ -0,0 +1,126 @@
+package slashing
+
+import (
+	"errors"
+	"fmt"
+	"mjoy.io/common/types"
+	"mjoy.io/core/sdk"
+	"encoding/json"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/staking"
+	"mjoy.io/params"
+)
+
+//ReportDoubleSign takes two headers of the same height signed by the same validator and slashes it
+func ReportDoubleSign(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	reporter , ok := sdk.Sys_GetCaller(sysparam.SdkHandler)
+	if !ok {
+		return nil , errors.New("ReportDoubleSign:no sender to act for")
+	}
+	number := sdk.Sys_GetBlockNumber(sysparam.SdkHandler)
+	if number == nil {
+		return nil , errors.New("ReportDoubleSign:no block being applied")
+	}
+	first , err := decodeHeader(args[0].([]byte))
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:first header:%s" , err.Error()))
+	}
+	second , err := decodeHeader(args[1].([]byte))
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:second header:%s" , err.Error()))
+	}
+
+	signer , err := VerifyDoubleSign(block.NewBlockSigner(sdk.Sys_GetChainId(sysparam.SdkHandler)) , first , second)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:%s" , err.Error()))
+	}
+	height := first.Number.IntVal.Uint64()
+	logger.Tracef("Start: ReportDoubleSign %s at %d" , signer.Hex() , height)
+	//the stake of an older double sign may be withdrawn already
+	if height + params.UnbondingPeriod < number.Uint64() {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:evidence of block %d expired" , height))
+	}
+	if reported(sysparam , signer , height) {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:%s at %d already reported" , signer.Hex() , height))
+	}
+	if _ , err = sdk.Sys_Call(sysparam.SdkHandler , SlashingAddress , staking.StakingAddress , staking.MakeSlashParam(signer));err != nil {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:%s" , err.Error()))
+	}
+
+	evidence := &Evidence{
+		Signer:signer ,
+		Number:height ,
+		First:first.HashNoSig() ,
+		Second:second.HashNoSig() ,
+		Reporter:reporter ,
+	}
+	key := EvidenceKey(signer , height)
+	data , err := json.Marshal(evidence)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:Marshal json:%s" , err.Error()))
+	}
+	if err = sdk.Sys_SetValue(sysparam.SdkHandler , SlashingAddress , key , data);err != nil {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:%s" , err.Error()))
+	}
+	topics , eventData , err := doubleSignEvent.Pack([]interface{}{signer} , height , reporter)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:%s" , err.Error()))
+	}
+	if err = sdk.Sys_EmitEvent(sysparam.SdkHandler , SlashingAddress , doubleSignEvent.Name , topics , eventData);err != nil {
+		return nil , errors.New(fmt.Sprintf("ReportDoubleSign:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{{Key:key , Val:data}} , nil
+}
+
+//IsReported returns whether the double sign of signer at number is taken already
+func IsReported(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	signer := args[0].(types.Address)
+	number := args[1].(uint64)
+
+	resultBytes , err := isReportedMethod.PackOutputs(reported(sysparam , signer , number))
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("IsReported Last Pack Err:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{{Key:nil , Val:resultBytes}} , nil
+}
+
+//VerifyDoubleSign returns the signer of two different headers of the same height,the headers are not modified
+func VerifyDoubleSign(signer block.BlockSigner , first , second *block.Header)(types.Address , error){
+	if first == nil || second == nil || first.Number == nil || second.Number == nil {
+		return types.Address{} , errors.New("incomplete header")
+	}
+	if first.Number.IntVal.Sign() == 0 || first.Number.IntVal.Cmp(&second.Number.IntVal) != 0 {
+		return types.Address{} , errors.New(fmt.Sprintf("headers of block %s and %s" , first.Number.IntVal.String() , second.Number.IntVal.String()))
+	}
+	if signer.Hash(first) == signer.Hash(second) {
+		return types.Address{} , errors.New("same header signed")
+	}
+	if first.V == nil || first.R == nil || first.S == nil || second.V == nil || second.R == nil || second.S == nil {
+		return types.Address{} , errors.New("unsigned header")
+	}
+	firstSigner , err := signer.Sender(block.CopyHeader(first))
+	if err != nil {
+		return types.Address{} , err
+	}
+	secondSigner , err := signer.Sender(block.CopyHeader(second))
+	if err != nil {
+		return types.Address{} , err
+	}
+	if firstSigner != secondSigner {
+		return types.Address{} , errors.New(fmt.Sprintf("headers signed by %s and %s" , firstSigner.Hex() , secondSigner.Hex()))
+	}
+	return firstSigner , nil
+}
+
+func decodeHeader(data []byte)(*block.Header , error){
+	header := new(block.Header)
+	if _ , err := header.UnmarshalMsg(data);err != nil {
+		return nil , err
+	}
+	return header , nil
+}
+
+func reported(sysparam *intertypes.SystemParams , signer types.Address , number uint64)bool{
+	return sdk.Sys_GetValue(sysparam.SdkHandler , SlashingAddress , EvidenceKey(signer , number)) != nil
+}
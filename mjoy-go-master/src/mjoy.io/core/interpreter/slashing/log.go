@@ -0,0 +1,22 @@
+package slashing
+
+import (
+	"mjoy.io/log"
+	"fmt"
+	"os"
+)
+
+var (
+	logTag = "interpreter.slashing"
+	logger log.Logger
+)
+
+
+
+func init() {
+	logger = log.GetLogger(logTag)
+	if logger == nil {
+		fmt.Errorf("Can not get logger(%s)\n", logTag)
+		os.Exit(1)
+	}
+}
\ No newline at end of file
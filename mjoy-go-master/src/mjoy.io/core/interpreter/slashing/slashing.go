@@ -0,0 +1,92 @@
+package slashing
+
+/*
+slashing is a innerContract taking the evidence of a validator signing two different headers of the same height.
+Anyone can report the two signed headers,every node checks them again by VerifyDoubleSign before the signer is
+slashed by the staking contract:a part of its stake is burned and it is jailed,so it is not elected any more.
+An evidence is taken once,and only while the stake of the signer can not be withdrawn yet.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/interpreter/staking"
+)
+
+//method names of the slashing contract
+const(
+	ReportDoubleSign_Method = "reportDoubleSign"
+	IsReported_Method = "isReported"
+)
+
+//event names of the slashing contract
+const(
+	DoubleSign_Event = "DoubleSign"
+)
+
+//SlashingAddress is the address the staking contract takes slashes from
+var SlashingAddress = staking.SlasherAddress
+
+//SlashingAbi is the schema of the slashing contract
+var SlashingAbi = abi.New("slashing" ,
+	&abi.Method{
+		Name:ReportDoubleSign_Method ,
+		Inputs:abi.Arguments{abi.Arg("first" , abi.BytesTy) , abi.Arg("second" , abi.BytesTy)},
+	},
+	&abi.Method{
+		Name:IsReported_Method ,
+		Inputs:abi.Arguments{abi.Arg("signer" , abi.AddressTy) , abi.Arg("number" , abi.Uint64Ty)},
+		Outputs:abi.Arguments{abi.Arg("reported" , abi.BoolTy)},
+		Constant:true,
+	},
+).WithEvents(
+	&abi.Event{
+		Name:DoubleSign_Event ,
+		Indexed:abi.Arguments{abi.Arg("signer" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("number" , abi.Uint64Ty) , abi.Arg("reporter" , abi.AddressTy)},
+	},
+)
+
+var (
+	isReportedMethod , _ = SlashingAbi.Method(IsReported_Method)
+
+	doubleSignEvent , _ = SlashingAbi.Event(DoubleSign_Event)
+)
+
+//DoFunc get the arguments decoded by SlashingAbi,so the types of them are checked
+type DoFunc func([]interface{} ,  *intertypes.SystemParams)([]intertypes.ActionResult , error)
+
+type ContractSlashing struct {
+	funcMapper map[string]DoFunc
+}
+
+//managed by vm
+func NewContractSlashing()*ContractSlashing{
+	s := new(ContractSlashing)
+	s.init()
+	return s
+}
+
+func (this *ContractSlashing)init(){
+	this.funcMapper = make(map[string]DoFunc)
+	this.funcMapper[ReportDoubleSign_Method] = ReportDoubleSign
+	this.funcMapper[IsReported_Method] = IsReported
+}
+
+func (this *ContractSlashing)Abi()*abi.ABI{
+	return SlashingAbi
+}
+
+func (this *ContractSlashing)DoFun( params []byte,sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	method , args , err := SlashingAbi.Unpack(params)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ContractSlashing: %s" , err.Error()))
+	}
+
+	if doFunc,ok := this.funcMapper[method.Name];ok {
+		return doFunc(args , sysparam)
+	}
+	return nil , errors.New(fmt.Sprintf("ContractSlashing: no method %s find in map" , method.Name))
+}
@@ -0,0 +1,117 @@
+package slashing
+
+import (
+	"testing"
+	"crypto/ecdsa"
+	"math/big"
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/interpreter/intertest"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/staking"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+)
+
+func newTestSysParams(t *testing.T , balances map[types.Address]int64)*intertypes.SystemParams{
+	sysparam := intertest.NewSysParams(t , intertest.NewVm(map[types.Address]intertest.Contract{
+		balancetransfer.BalanceTransferAddress:balancetransfer.NewContractBalancer() ,
+		staking.StakingAddress:staking.NewContractStaking() ,
+		SlashingAddress:NewContractSlashing() ,
+	}))
+	for address , amount := range balances {
+		if _ , err := balancetransfer.Reward(sysparam , address , big.NewInt(amount));err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sysparam
+}
+
+func signedHeader(t *testing.T , key *ecdsa.PrivateKey , number int64 , time int64)*block.Header{
+	header := &block.Header{Number:types.NewBigInt(*big.NewInt(number)) , Time:types.NewBigInt(*big.NewInt(time))}
+	if err := block.SignHeaderInner(header , block.NewBlockSigner(big.NewInt(1)) , key);err != nil {
+		t.Fatal(err)
+	}
+	return header
+}
+
+func TestVerifyDoubleSign(t *testing.T){
+	key , _ := crypto.GenerateKey()
+	other , _ := crypto.GenerateKey()
+	signer := block.NewBlockSigner(big.NewInt(1))
+	first := signedHeader(t , key , 5 , 1)
+
+	tests := []struct{
+		second *block.Header
+		ok bool
+	}{
+		{signedHeader(t , key , 5 , 2) , true},
+		{signedHeader(t , key , 5 , 1) , false},
+		{signedHeader(t , key , 6 , 2) , false},
+		{signedHeader(t , other , 5 , 2) , false},
+	}
+	for i , test := range tests {
+		address , err := VerifyDoubleSign(signer , first , test.second)
+		if test.ok && (err != nil || address != crypto.PubkeyToAddress(key.PublicKey)) {
+			t.Errorf("test %d: have %x %v, want the signer" , i , address , err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("test %d: double sign verified" , i)
+		}
+	}
+	if _ , err := VerifyDoubleSign(block.NewBlockSigner(big.NewInt(2)) , first , tests[0].second);err == nil {
+		t.Error("double sign of another chain verified")
+	}
+}
+
+func TestReportDoubleSign(t *testing.T){
+	key , _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+	reporter := types.Address{9}
+	sysparam := newTestSysParams(t , map[types.Address]int64{validator:100000})
+	if _ , err := intertest.AsAt(validator , 1 , sysparam , staking.NewContractStaking() , staking.MakeRegisterCandidateParam(0 , big.NewInt(20000)));err != nil {
+		t.Fatal(err)
+	}
+
+	first , second := signedHeader(t , key , 5 , 1) , signedHeader(t , key , 5 , 2)
+	slashing := NewContractSlashing()
+	if _ , err := intertest.AsAt(reporter , 6 , sysparam , slashing , MakeReportDoubleSignParam(first , first));err == nil {
+		t.Fatal("same header taken as a double sign")
+	}
+	if _ , err := intertest.AsAt(reporter , 6 + int64(params.UnbondingPeriod) , sysparam , slashing , MakeReportDoubleSignParam(first , second));err == nil {
+		t.Fatal("expired evidence taken")
+	}
+	if _ , err := intertest.AsAt(reporter , 6 , sysparam , slashing , MakeReportDoubleSignParam(first , second));err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := intertest.AsAt(reporter , 7 , sysparam , slashing , MakeReportDoubleSignParam(second , first));err == nil {
+		t.Fatal("double sign reported twice")
+	}
+
+	results , err := slashing.DoFun(MakeIsReportedParam(validator , 5) , sysparam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values , err := isReportedMethod.UnpackOutputs(results[0].Val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !values[0].(bool) {
+		t.Fatal("double sign not reported")
+	}
+
+	//the staking contract burned 10% of the stake and jailed the validator
+	results , err = staking.NewContractStaking().DoFun(staking.MakeGetCandidateParam(validator) , sysparam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	getCandidate , _ := staking.StakingAbi.Method(staking.GetCandidate_Method)
+	values , err = getCandidate.UnpackOutputs(results[0].Val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stake , jailed := values[1].(*big.Int) , values[2].(bool);stake.Int64() != 18000 || !jailed {
+		t.Fatalf("candidate stake %d jailed %v, want 18000 jailed" , stake.Int64() , jailed)
+	}
+}
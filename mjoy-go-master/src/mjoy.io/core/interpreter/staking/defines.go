@@ -0,0 +1,152 @@
+package staking
+
+import (
+	"mjoy.io/common/types"
+	"math/big"
+)
+
+//the commission of a candidate is a percentage of the rewards of its validator
+const MaxCommission = 100
+
+//rewardScale keeps the fractions of the reward per share of a candidate
+var rewardScale = new(big.Int).Exp(big.NewInt(10) , big.NewInt(18) , nil)
+
+//storage keys and key prefixes,the addresses follow the prefixes
+var (
+	candidatesKey = []byte("staking/candidates")
+	candidatePrefix = []byte("staking/candidate/")
+	delegationPrefix = []byte("staking/delegation/")
+	unbondingPrefix = []byte("staking/unbonding/")
+)
+
+//Candidate is stored under the candidate key of its address.RewardPerShare is the reward every unit of its
+//stake got since it registered,scaled by rewardScale
+type Candidate struct {
+	Address        types.Address `json:"address"`
+	Commission     uint64        `json:"commission"`
+	TotalStake     *big.Int      `json:"totalStake"`
+	RewardPerShare *big.Int      `json:"rewardPerShare"`
+	Jailed         bool          `json:"jailed"`
+}
+
+//Delegation is the stake of a delegator on a candidate.RewardDebt is the part of the rewards of the stake
+//already settled into Pending
+type Delegation struct {
+	Amount     *big.Int `json:"amount"`
+	RewardDebt *big.Int `json:"rewardDebt"`
+	Pending    *big.Int `json:"pending"`
+}
+
+//Unbonding is an undelegated stake,it can be withdrawn from the block Release on
+type Unbonding struct {
+	Amount  *big.Int `json:"amount"`
+	Release uint64   `json:"release"`
+}
+
+//CandidateList is stored under the candidates key,in the order of registration
+type CandidateList struct {
+	Addresses []types.Address `json:"addresses"`
+}
+
+//UnbondingList is stored under the unbonding key of a delegator
+type UnbondingList struct {
+	Entries []Unbonding `json:"entries"`
+}
+
+func CandidateKey(candidate types.Address)[]byte{
+	key := append([]byte{} , candidatePrefix...)
+	return append(key , candidate[:]...)
+}
+
+func DelegationKey(candidate , delegator types.Address)[]byte{
+	key := append([]byte{} , delegationPrefix...)
+	key = append(key , candidate[:]...)
+	return append(key , delegator[:]...)
+}
+
+func UnbondingKey(delegator types.Address)[]byte{
+	key := append([]byte{} , unbondingPrefix...)
+	return append(key , delegator[:]...)
+}
+
+//the Make*Param functions of the writing methods act for the sender of the transaction
+
+func MakeRegisterCandidateParam(commission uint64 , stake *big.Int)[]byte{
+	r , err := StakingAbi.Pack(RegisterCandidate_Method , commission , stake)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeDelegateParam(candidate types.Address , amount *big.Int)[]byte{
+	r , err := StakingAbi.Pack(Delegate_Method , candidate , amount)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeUndelegateParam(candidate types.Address , amount *big.Int)[]byte{
+	r , err := StakingAbi.Pack(Undelegate_Method , candidate , amount)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeWithdrawParam()[]byte{
+	r , err := StakingAbi.Pack(Withdraw_Method)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeClaimRewardsParam(candidate types.Address)[]byte{
+	r , err := StakingAbi.Pack(ClaimRewards_Method , candidate)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeDistributeRewardParam(validator types.Address , amount *big.Int)[]byte{
+	r , err := StakingAbi.Pack(DistributeReward_Method , validator , amount)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeSlashParam(candidate types.Address)[]byte{
+	r , err := StakingAbi.Pack(Slash_Method , candidate)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeGetCandidateParam(candidate types.Address)[]byte{
+	r , err := StakingAbi.Pack(GetCandidate_Method , candidate)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeGetDelegationParam(candidate , delegator types.Address)[]byte{
+	r , err := StakingAbi.Pack(GetDelegation_Method , candidate , delegator)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeGetValidatorsParam()[]byte{
+	r , err := StakingAbi.Pack(GetValidators_Method)
+	if err != nil {
+		return nil
+	}
+	return r
+}
@@ -0,0 +1,56 @@
+package staking
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/params"
+)
+
+//Elect returns the validators elected by the stakes:the candidates not jailed with at least params.MinCandidateStake,
+//the params.MaxElectedValidators largest stakes first,equal stakes in the order of the addresses.
+//The chain elects the validators of an epoch on the state of the block the epoch starts after,see ElectionBlock
+func Elect(sysparam *intertypes.SystemParams)([]types.Address , error){
+	list , err := getCandidateList(sysparam)
+	if err != nil {
+		return nil , err
+	}
+	minStake := new(big.Int).SetUint64(params.MinCandidateStake)
+	candidates := make([]*Candidate , 0 , len(list.Addresses))
+	for _ , address := range list.Addresses {
+		candidate , err := getExistingCandidate(sysparam , address)
+		if err != nil {
+			return nil , err
+		}
+		if candidate.Jailed || candidate.TotalStake.Cmp(minStake) < 0 {
+			continue
+		}
+		candidates = append(candidates , candidate)
+	}
+	sort.Slice(candidates , func(i , j int)bool{
+		if c := candidates[i].TotalStake.Cmp(candidates[j].TotalStake);c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(candidates[i].Address[:] , candidates[j].Address[:]) < 0
+	})
+	if len(candidates) > params.MaxElectedValidators {
+		candidates = candidates[:params.MaxElectedValidators]
+	}
+
+	validators := make([]types.Address , len(candidates))
+	for i , candidate := range candidates {
+		validators[i] = candidate.Address
+	}
+	return validators , nil
+}
+
+//ElectionBlock returns the block whose state elects the validators of the block number:the last block of the
+//previous epoch,the genesis for the first epoch
+func ElectionBlock(number uint64)uint64{
+	if number == 0 {
+		return 0
+	}
+	return (number - 1) / params.StakingEpoch * params.StakingEpoch
+}
@@ -0,0 +1,571 @@
+package staking
+
+import (
+	"errors"
+	"fmt"
+	"mjoy.io/common/types"
+	"mjoy.io/core/sdk"
+	"encoding/json"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/params"
+	"math/big"
+)
+
+func RegisterCandidate(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	address , err := sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:%s" , err.Error()))
+	}
+	commission := args[0].(uint64)
+	stake := args[1].(*big.Int)
+
+	logger.Tracef("Start: RegisterCandidate %s" , address.Hex())
+	if commission > MaxCommission {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:commission %d above %d" , commission , MaxCommission))
+	}
+	if stake.Cmp(new(big.Int).SetUint64(params.MinCandidateStake)) < 0 {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:stake %s below %d" , stake.String() , params.MinCandidateStake))
+	}
+	candidate , err := getCandidate(sysparam , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:%s" , err.Error()))
+	}
+	if candidate != nil {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:%s already registered" , address.Hex()))
+	}
+	list , err := getCandidateList(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:%s" , err.Error()))
+	}
+	if err = pay(sysparam , address , StakingAddress , stake);err != nil {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:%s" , err.Error()))
+	}
+
+	candidate = &Candidate{
+		Address:address ,
+		Commission:commission ,
+		TotalStake:new(big.Int).Set(stake) ,
+		RewardPerShare:new(big.Int) ,
+	}
+	list.Addresses = append(list.Addresses , address)
+	delegation := &Delegation{Amount:stake , RewardDebt:new(big.Int) , Pending:new(big.Int)}
+
+	results := make([]intertypes.ActionResult , 0 , 3)
+	if results , err = appendValue(results , sysparam , CandidateKey(address) , candidate);err != nil {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:%s" , err.Error()))
+	}
+	if results , err = appendValue(results , sysparam , candidatesKey , list);err != nil {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:%s" , err.Error()))
+	}
+	if results , err = appendValue(results , sysparam , DelegationKey(address , address) , delegation);err != nil {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:%s" , err.Error()))
+	}
+	if err = emit(sysparam , registerEvent , []interface{}{address} , commission , stake);err != nil {
+		return nil , errors.New(fmt.Sprintf("RegisterCandidate:%s" , err.Error()))
+	}
+	return results , nil
+}
+
+func Delegate(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	delegator , err := sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Delegate:%s" , err.Error()))
+	}
+	address := args[0].(types.Address)
+	amount := args[1].(*big.Int)
+
+	if amount.Sign() <= 0 {
+		return nil , errors.New(fmt.Sprintf("Delegate:amount %s is not positive" , amount.String()))
+	}
+	candidate , err := getExistingCandidate(sysparam , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Delegate:%s" , err.Error()))
+	}
+	if candidate.Jailed {
+		return nil , errors.New(fmt.Sprintf("Delegate:candidate %s is jailed" , address.Hex()))
+	}
+	list , err := getCandidateList(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Delegate:%s" , err.Error()))
+	}
+	if list.index(address) < 0 {
+		return nil , errors.New(fmt.Sprintf("Delegate:candidate %s is unregistered" , address.Hex()))
+	}
+	delegation , err := getDelegation(sysparam , address , delegator)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Delegate:%s" , err.Error()))
+	}
+	if err = pay(sysparam , delegator , StakingAddress , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("Delegate:%s" , err.Error()))
+	}
+
+	settle(candidate , delegation)
+	delegation.Amount.Add(delegation.Amount , amount)
+	delegation.RewardDebt = accrued(candidate , delegation.Amount)
+	candidate.TotalStake.Add(candidate.TotalStake , amount)
+
+	results , err := writeStake(sysparam , candidate , delegator , delegation)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Delegate:%s" , err.Error()))
+	}
+	if err = emit(sysparam , delegateEvent , []interface{}{address , delegator} , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("Delegate:%s" , err.Error()))
+	}
+	return results , nil
+}
+
+//Undelegate takes amount off the stake of the sender on a candidate,it can be withdrawn after the unbonding period.
+//The own stake of a candidate is what its slashing takes,it can only go below params.MinCandidateStake all at
+//once,which unregisters the candidate
+func Undelegate(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	delegator , err := sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+	}
+	address := args[0].(types.Address)
+	amount := args[1].(*big.Int)
+
+	number , err := blockNumber(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+	}
+	if amount.Sign() <= 0 {
+		return nil , errors.New(fmt.Sprintf("Undelegate:amount %s is not positive" , amount.String()))
+	}
+	candidate , err := getExistingCandidate(sysparam , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+	}
+	delegation , err := getDelegation(sysparam , address , delegator)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+	}
+	if delegation.Amount.Cmp(amount) < 0 {
+		return nil , errors.New(fmt.Sprintf("Undelegate:has %s , but want %s" , delegation.Amount.String() , amount.String()))
+	}
+	unregister := false
+	if delegator == address {
+		left := new(big.Int).Sub(delegation.Amount , amount)
+		if left.Sign() == 0 {
+			unregister = true
+		}else if left.Cmp(new(big.Int).SetUint64(params.MinCandidateStake)) < 0 {
+			return nil , errors.New(fmt.Sprintf("Undelegate:own stake %s left below %d" , left.String() , params.MinCandidateStake))
+		}
+	}
+	unbondings , err := getUnbondings(sysparam , delegator)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+	}
+
+	settle(candidate , delegation)
+	delegation.Amount.Sub(delegation.Amount , amount)
+	delegation.RewardDebt = accrued(candidate , delegation.Amount)
+	candidate.TotalStake.Sub(candidate.TotalStake , amount)
+	release := number + params.UnbondingPeriod
+	unbondings.Entries = append(unbondings.Entries , Unbonding{Amount:amount , Release:release})
+
+	results , err := writeStake(sysparam , candidate , delegator , delegation)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+	}
+	if results , err = appendValue(results , sysparam , UnbondingKey(delegator) , unbondings);err != nil {
+		return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+	}
+	if unregister {
+		//the candidate stays stored,its delegators can still undelegate and claim their rewards
+		list , err := getCandidateList(sysparam)
+		if err != nil {
+			return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+		}
+		if i := list.index(address);i >= 0 {
+			list.Addresses = append(list.Addresses[:i] , list.Addresses[i + 1:]...)
+		}
+		if results , err = appendValue(results , sysparam , candidatesKey , list);err != nil {
+			return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+		}
+	}
+	if err = emit(sysparam , undelegateEvent , []interface{}{address , delegator} , amount , release);err != nil {
+		return nil , errors.New(fmt.Sprintf("Undelegate:%s" , err.Error()))
+	}
+	return results , nil
+}
+
+//Withdraw pays the sender back all its undelegated stakes past the unbonding period
+func Withdraw(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	delegator , err := sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Withdraw:%s" , err.Error()))
+	}
+	number , err := blockNumber(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Withdraw:%s" , err.Error()))
+	}
+	unbondings , err := getUnbondings(sysparam , delegator)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Withdraw:%s" , err.Error()))
+	}
+
+	amount := new(big.Int)
+	locked := make([]Unbonding , 0 , len(unbondings.Entries))
+	for _ , entry := range unbondings.Entries {
+		if entry.Release <= number {
+			amount.Add(amount , entry.Amount)
+		}else{
+			locked = append(locked , entry)
+		}
+	}
+	if amount.Sign() == 0 {
+		return nil , errors.New(fmt.Sprintf("Withdraw:nothing released for %s" , delegator.Hex()))
+	}
+	if err = pay(sysparam , StakingAddress , delegator , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("Withdraw:%s" , err.Error()))
+	}
+
+	unbondings.Entries = locked
+	result , err := setValue(sysparam , UnbondingKey(delegator) , unbondings)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Withdraw:%s" , err.Error()))
+	}
+	if err = emit(sysparam , withdrawEvent , []interface{}{delegator} , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("Withdraw:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{result} , nil
+}
+
+//ClaimRewards pays the sender the rewards of its stake on a candidate
+func ClaimRewards(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	delegator , err := sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ClaimRewards:%s" , err.Error()))
+	}
+	address := args[0].(types.Address)
+
+	candidate , err := getExistingCandidate(sysparam , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ClaimRewards:%s" , err.Error()))
+	}
+	delegation , err := getDelegation(sysparam , address , delegator)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ClaimRewards:%s" , err.Error()))
+	}
+	settle(candidate , delegation)
+	reward := delegation.Pending
+	if reward.Sign() == 0 {
+		return nil , errors.New(fmt.Sprintf("ClaimRewards:no reward for %s" , delegator.Hex()))
+	}
+	if err = pay(sysparam , StakingAddress , delegator , reward);err != nil {
+		return nil , errors.New(fmt.Sprintf("ClaimRewards:%s" , err.Error()))
+	}
+	delegation.Pending = new(big.Int)
+
+	result , err := setValue(sysparam , DelegationKey(address , delegator) , delegation)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ClaimRewards:%s" , err.Error()))
+	}
+	if err = emit(sysparam , claimEvent , []interface{}{address , delegator} , reward);err != nil {
+		return nil , errors.New(fmt.Sprintf("ClaimRewards:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{result} , nil
+}
+
+//DistributeReward gives amount of the balance of the sender to a validator.The candidate takes its commission,
+//the rest is shared by its stake
+func DistributeReward(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	from , err := sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("DistributeReward:%s" , err.Error()))
+	}
+	address := args[0].(types.Address)
+	amount := args[1].(*big.Int)
+
+	if amount.Sign() <= 0 {
+		return nil , errors.New(fmt.Sprintf("DistributeReward:amount %s is not positive" , amount.String()))
+	}
+	candidate , err := getExistingCandidate(sysparam , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("DistributeReward:%s" , err.Error()))
+	}
+	own , err := getDelegation(sysparam , address , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("DistributeReward:%s" , err.Error()))
+	}
+	if err = pay(sysparam , from , StakingAddress , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("DistributeReward:%s" , err.Error()))
+	}
+
+	commission := new(big.Int).Mul(amount , new(big.Int).SetUint64(candidate.Commission))
+	commission.Div(commission , big.NewInt(MaxCommission))
+	shared := new(big.Int).Sub(amount , commission)
+	if candidate.TotalStake.Sign() == 0 {
+		commission.Add(commission , shared)
+	}else{
+		perShare := new(big.Int).Mul(shared , rewardScale)
+		candidate.RewardPerShare.Add(candidate.RewardPerShare , perShare.Div(perShare , candidate.TotalStake))
+	}
+	own.Pending.Add(own.Pending , commission)
+
+	results , err := writeStake(sysparam , candidate , address , own)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("DistributeReward:%s" , err.Error()))
+	}
+	if err = emit(sysparam , rewardEvent , []interface{}{address} , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("DistributeReward:%s" , err.Error()))
+	}
+	return results , nil
+}
+
+//IsCandidate reports whether address ever registered as a candidate,jailed or unregistered since.The consensus engine only hands
+//the rewards of candidates to DistributeReward
+func IsCandidate(sysparam *intertypes.SystemParams , address types.Address)(bool , error){
+	candidate , err := getCandidate(sysparam , address)
+	if err != nil {
+		return false , err
+	}
+	return candidate != nil , nil
+}
+
+//Slash burns params.DoubleSignSlashPercent of the stake of a candidate,taken from its own stake,and jails it.
+//Only the slashing contract can call it
+func Slash(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	caller , err := sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Slash:%s" , err.Error()))
+	}
+	if caller != SlasherAddress {
+		return nil , errors.New(fmt.Sprintf("Slash:%s is not the slashing contract" , caller.Hex()))
+	}
+	address := args[0].(types.Address)
+
+	candidate , err := getExistingCandidate(sysparam , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Slash:%s" , err.Error()))
+	}
+	own , err := getDelegation(sysparam , address , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Slash:%s" , err.Error()))
+	}
+
+	penalty := new(big.Int).Mul(candidate.TotalStake , new(big.Int).SetUint64(params.DoubleSignSlashPercent))
+	penalty.Div(penalty , big.NewInt(100))
+	if penalty.Cmp(own.Amount) > 0 {
+		penalty.Set(own.Amount)
+	}
+	//the penalty stays in the staking contract,nobody can claim it
+	settle(candidate , own)
+	own.Amount.Sub(own.Amount , penalty)
+	own.RewardDebt = accrued(candidate , own.Amount)
+	candidate.TotalStake.Sub(candidate.TotalStake , penalty)
+	candidate.Jailed = true
+
+	results , err := writeStake(sysparam , candidate , address , own)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Slash:%s" , err.Error()))
+	}
+	if err = emit(sysparam , slashEvent , []interface{}{address} , penalty);err != nil {
+		return nil , errors.New(fmt.Sprintf("Slash:%s" , err.Error()))
+	}
+	return results , nil
+}
+
+func GetCandidate(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	address := args[0].(types.Address)
+
+	candidate , err := getExistingCandidate(sysparam , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("GetCandidate:%s" , err.Error()))
+	}
+	resultBytes , err := getCandidateMethod.PackOutputs(candidate.Commission , candidate.TotalStake , candidate.Jailed)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("GetCandidate Last Pack Err:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{{Key:nil , Val:resultBytes}} , nil
+}
+
+//GetDelegation returns the stake of a delegator on a candidate and the reward it can claim
+func GetDelegation(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	address := args[0].(types.Address)
+	delegator := args[1].(types.Address)
+
+	candidate , err := getExistingCandidate(sysparam , address)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("GetDelegation:%s" , err.Error()))
+	}
+	delegation , err := getDelegation(sysparam , address , delegator)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("GetDelegation:%s" , err.Error()))
+	}
+	settle(candidate , delegation)
+	resultBytes , err := getDelegationMethod.PackOutputs(delegation.Amount , delegation.Pending)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("GetDelegation Last Pack Err:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{{Key:nil , Val:resultBytes}} , nil
+}
+
+//GetValidators returns the validators the current stakes elect
+func GetValidators(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	validators , err := Elect(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("GetValidators:%s" , err.Error()))
+	}
+	resultBytes , err := getValidatorsMethod.PackOutputs(validators)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("GetValidators Last Pack Err:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{{Key:nil , Val:resultBytes}} , nil
+}
+
+//accrued returns the rewards amount of stake got on the candidate since it registered
+func accrued(candidate *Candidate , amount *big.Int)*big.Int{
+	reward := new(big.Int).Mul(amount , candidate.RewardPerShare)
+	return reward.Div(reward , rewardScale)
+}
+
+//settle moves the rewards the delegation got since it last changed into its pending rewards
+func settle(candidate *Candidate , delegation *Delegation){
+	reward := accrued(candidate , delegation.Amount)
+	delegation.Pending.Add(delegation.Pending , reward.Sub(reward , delegation.RewardDebt))
+	delegation.RewardDebt = accrued(candidate , delegation.Amount)
+}
+
+//writeStake writes a candidate and the delegation of delegator on it
+func writeStake(sysparam *intertypes.SystemParams , candidate *Candidate , delegator types.Address , delegation *Delegation)([]intertypes.ActionResult , error){
+	results := make([]intertypes.ActionResult , 0 , 2)
+	results , err := appendValue(results , sysparam , CandidateKey(candidate.Address) , candidate)
+	if err != nil {
+		return nil , err
+	}
+	return appendValue(results , sysparam , DelegationKey(candidate.Address , delegator) , delegation)
+}
+
+//pay moves amount of balance by the balancetransfer contract acting for from,that is the staking contract itself
+//or the account the staking contract acts for
+func pay(sysparam *intertypes.SystemParams , from , to types.Address , amount *big.Int)error{
+	input , err := balancetransfer.BalancerAbi.Pack(balancetransfer.TransferBalance_Method , to , amount)
+	if err != nil {
+		return err
+	}
+	_ , err = sdk.Sys_Call(sysparam.SdkHandler , from , balancetransfer.BalanceTransferAddress , input)
+	return err
+}
+
+//sender returns the account the contract acts for,the staking contract never holds stakes itself
+func sender(sysparam *intertypes.SystemParams)(types.Address , error){
+	caller , err := intertypes.Sender(sysparam)
+	if err != nil {
+		return types.Address{} , err
+	}
+	if caller == StakingAddress {
+		return types.Address{} , errors.New("staking contract can not act for itself")
+	}
+	return caller , nil
+}
+
+func blockNumber(sysparam *intertypes.SystemParams)(uint64 , error){
+	number := sdk.Sys_GetBlockNumber(sysparam.SdkHandler)
+	if number == nil {
+		return 0 , errors.New("no block being applied")
+	}
+	return number.Uint64() , nil
+}
+
+//getCandidate returns nil if the address is not registered
+func getCandidate(sysparam *intertypes.SystemParams , address types.Address)(*Candidate , error){
+	data := sdk.Sys_GetValue(sysparam.SdkHandler , StakingAddress , CandidateKey(address))
+	if nil == data {
+		return nil , nil
+	}
+	candidate := new(Candidate)
+	if err := json.Unmarshal(data , candidate);err != nil {
+		return nil , errors.New(fmt.Sprintf("Unmarshal json:%s" , err.Error()))
+	}
+	return candidate , nil
+}
+
+func getExistingCandidate(sysparam *intertypes.SystemParams , address types.Address)(*Candidate , error){
+	candidate , err := getCandidate(sysparam , address)
+	if err != nil {
+		return nil , err
+	}
+	if candidate == nil {
+		return nil , errors.New(fmt.Sprintf("candidate %s not registered" , address.Hex()))
+	}
+	return candidate , nil
+}
+
+//getDelegation returns an empty delegation if delegator never delegated to the candidate
+func getDelegation(sysparam *intertypes.SystemParams , candidate , delegator types.Address)(*Delegation , error){
+	delegation := &Delegation{Amount:new(big.Int) , RewardDebt:new(big.Int) , Pending:new(big.Int)}
+	data := sdk.Sys_GetValue(sysparam.SdkHandler , StakingAddress , DelegationKey(candidate , delegator))
+	if nil == data {
+		return delegation , nil
+	}
+	if err := json.Unmarshal(data , delegation);err != nil {
+		return nil , errors.New(fmt.Sprintf("Unmarshal json:%s" , err.Error()))
+	}
+	return delegation , nil
+}
+
+func getCandidateList(sysparam *intertypes.SystemParams)(*CandidateList , error){
+	list := new(CandidateList)
+	data := sdk.Sys_GetValue(sysparam.SdkHandler , StakingAddress , candidatesKey)
+	if nil == data {
+		return list , nil
+	}
+	if err := json.Unmarshal(data , list);err != nil {
+		return nil , errors.New(fmt.Sprintf("Unmarshal json:%s" , err.Error()))
+	}
+	return list , nil
+}
+
+//index returns the position of address in the list,-1 if it is not registered
+func (list *CandidateList)index(address types.Address)int{
+	for i , registered := range list.Addresses {
+		if registered == address {
+			return i
+		}
+	}
+	return -1
+}
+
+func getUnbondings(sysparam *intertypes.SystemParams , delegator types.Address)(*UnbondingList , error){
+	list := new(UnbondingList)
+	data := sdk.Sys_GetValue(sysparam.SdkHandler , StakingAddress , UnbondingKey(delegator))
+	if nil == data {
+		return list , nil
+	}
+	if err := json.Unmarshal(data , list);err != nil {
+		return nil , errors.New(fmt.Sprintf("Unmarshal json:%s" , err.Error()))
+	}
+	return list , nil
+}
+
+func setValue(sysparam *intertypes.SystemParams , key []byte , value interface{})(intertypes.ActionResult , error){
+	data , err := json.Marshal(value)
+	if err != nil {
+		return intertypes.ActionResult{} , errors.New(fmt.Sprintf("Marshal json:%s" , err.Error()))
+	}
+	if err = sdk.Sys_SetValue(sysparam.SdkHandler , StakingAddress , key , data);err != nil {
+		return intertypes.ActionResult{} , err
+	}
+	return intertypes.ActionResult{Key:key , Val:data} , nil
+}
+
+func appendValue(results []intertypes.ActionResult , sysparam *intertypes.SystemParams , key []byte , value interface{})([]intertypes.ActionResult , error){
+	result , err := setValue(sysparam , key , value)
+	if err != nil {
+		return nil , err
+	}
+	return append(results , result) , nil
+}
+
+//emit packs the event and emits it from the staking contract
+func emit(sysparam *intertypes.SystemParams , event *abi.Event , indexed []interface{} , values ...interface{})error{
+	topics , data , err := event.Pack(indexed , values...)
+	if err != nil {
+		return err
+	}
+	return sdk.Sys_EmitEvent(sysparam.SdkHandler , StakingAddress , event.Name , topics , data)
+}
@@ -0,0 +1,192 @@
+package staking
+
+/*
+staking is a innerContract electing the validators by stake.An account registers itself as a candidate with a
+stake of its own,and any account can delegate more of its balance to a candidate.The staked balances are held by
+the staking contract,an undelegated stake stays locked for params.UnbondingPeriod blocks before it can be withdrawn.
+The rewards given to a validator are split:the candidate takes its commission and the rest is shared by all the
+stake of the candidate,the delegators claim their part whenever they want.
+A candidate caught signing two headers of a height is slashed by the slashing contract:a part of its own stake is
+burned and it is jailed.
+At every params.StakingEpoch blocks the candidates with the largest stake are elected,see Elect.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/abi"
+)
+
+//method names of the staking contract
+const(
+	RegisterCandidate_Method = "registerCandidate"
+	Delegate_Method = "delegate"
+	Undelegate_Method = "undelegate"
+	Withdraw_Method = "withdraw"
+	ClaimRewards_Method = "claimRewards"
+	DistributeReward_Method = "distributeReward"
+	Slash_Method = "slash"
+	GetCandidate_Method = "getCandidate"
+	GetDelegation_Method = "getDelegation"
+	GetValidators_Method = "getValidators"
+)
+
+//event names of the staking contract
+const(
+	Register_Event = "Register"
+	Delegate_Event = "Delegate"
+	Undelegate_Event = "Undelegate"
+	Withdraw_Event = "Withdraw"
+	Claim_Event = "Claim"
+	Reward_Event = "Reward"
+	Slash_Event = "Slash"
+)
+
+var StakingAddress = types.HexToAddress("0x0000000000000000000000000000000000000003")
+
+//SlasherAddress is the slashing contract,the only caller allowed to slash a candidate
+var SlasherAddress = types.HexToAddress("0x0000000000000000000000000000000000000004")
+
+//StakingAbi is the schema of the staking contract
+var StakingAbi = abi.New("staking" ,
+	&abi.Method{
+		Name:RegisterCandidate_Method ,
+		Inputs:abi.Arguments{abi.Arg("commission" , abi.Uint64Ty) , abi.Arg("stake" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:Delegate_Method ,
+		Inputs:abi.Arguments{abi.Arg("candidate" , abi.AddressTy) , abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:Undelegate_Method ,
+		Inputs:abi.Arguments{abi.Arg("candidate" , abi.AddressTy) , abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:Withdraw_Method ,
+	},
+	&abi.Method{
+		Name:ClaimRewards_Method ,
+		Inputs:abi.Arguments{abi.Arg("candidate" , abi.AddressTy)},
+	},
+	&abi.Method{
+		Name:DistributeReward_Method ,
+		Inputs:abi.Arguments{abi.Arg("validator" , abi.AddressTy) , abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:Slash_Method ,
+		Inputs:abi.Arguments{abi.Arg("candidate" , abi.AddressTy)},
+	},
+	&abi.Method{
+		Name:GetCandidate_Method ,
+		Inputs:abi.Arguments{abi.Arg("candidate" , abi.AddressTy)},
+		Outputs:abi.Arguments{abi.Arg("commission" , abi.Uint64Ty) , abi.Arg("totalStake" , abi.BigIntTy) , abi.Arg("jailed" , abi.BoolTy)},
+		Constant:true,
+	},
+	&abi.Method{
+		Name:GetDelegation_Method ,
+		Inputs:abi.Arguments{abi.Arg("candidate" , abi.AddressTy) , abi.Arg("delegator" , abi.AddressTy)},
+		Outputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy) , abi.Arg("reward" , abi.BigIntTy)},
+		Constant:true,
+	},
+	&abi.Method{
+		Name:GetValidators_Method ,
+		Outputs:abi.Arguments{abi.Arg("validators" , abi.AddressSliceTy)},
+		Constant:true,
+	},
+).WithEvents(
+	&abi.Event{
+		Name:Register_Event ,
+		Indexed:abi.Arguments{abi.Arg("candidate" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("commission" , abi.Uint64Ty) , abi.Arg("stake" , abi.BigIntTy)},
+	},
+	&abi.Event{
+		Name:Delegate_Event ,
+		Indexed:abi.Arguments{abi.Arg("candidate" , abi.AddressTy) , abi.Arg("delegator" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Event{
+		Name:Undelegate_Event ,
+		Indexed:abi.Arguments{abi.Arg("candidate" , abi.AddressTy) , abi.Arg("delegator" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy) , abi.Arg("release" , abi.Uint64Ty)},
+	},
+	&abi.Event{
+		Name:Withdraw_Event ,
+		Indexed:abi.Arguments{abi.Arg("delegator" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Event{
+		Name:Claim_Event ,
+		Indexed:abi.Arguments{abi.Arg("candidate" , abi.AddressTy) , abi.Arg("delegator" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Event{
+		Name:Reward_Event ,
+		Indexed:abi.Arguments{abi.Arg("validator" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Event{
+		Name:Slash_Event ,
+		Indexed:abi.Arguments{abi.Arg("candidate" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("penalty" , abi.BigIntTy)},
+	},
+)
+
+var (
+	getCandidateMethod , _ = StakingAbi.Method(GetCandidate_Method)
+	getDelegationMethod , _ = StakingAbi.Method(GetDelegation_Method)
+	getValidatorsMethod , _ = StakingAbi.Method(GetValidators_Method)
+
+	registerEvent , _ = StakingAbi.Event(Register_Event)
+	delegateEvent , _ = StakingAbi.Event(Delegate_Event)
+	undelegateEvent , _ = StakingAbi.Event(Undelegate_Event)
+	withdrawEvent , _ = StakingAbi.Event(Withdraw_Event)
+	claimEvent , _ = StakingAbi.Event(Claim_Event)
+	rewardEvent , _ = StakingAbi.Event(Reward_Event)
+	slashEvent , _ = StakingAbi.Event(Slash_Event)
+)
+
+//DoFunc get the arguments decoded by StakingAbi,so the types of them are checked
+type DoFunc func([]interface{} ,  *intertypes.SystemParams)([]intertypes.ActionResult , error)
+
+type ContractStaking struct {
+	funcMapper map[string]DoFunc
+}
+
+//managed by vm
+func NewContractStaking()*ContractStaking{
+	s := new(ContractStaking)
+	s.init()
+	return s
+}
+
+func (this *ContractStaking)init(){
+	this.funcMapper = make(map[string]DoFunc)
+	this.funcMapper[RegisterCandidate_Method] = RegisterCandidate
+	this.funcMapper[Delegate_Method] = Delegate
+	this.funcMapper[Undelegate_Method] = Undelegate
+	this.funcMapper[Withdraw_Method] = Withdraw
+	this.funcMapper[ClaimRewards_Method] = ClaimRewards
+	this.funcMapper[DistributeReward_Method] = DistributeReward
+	this.funcMapper[Slash_Method] = Slash
+	this.funcMapper[GetCandidate_Method] = GetCandidate
+	this.funcMapper[GetDelegation_Method] = GetDelegation
+	this.funcMapper[GetValidators_Method] = GetValidators
+}
+
+func (this *ContractStaking)Abi()*abi.ABI{
+	return StakingAbi
+}
+
+func (this *ContractStaking)DoFun( params []byte,sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	method , args , err := StakingAbi.Unpack(params)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ContractStaking: %s" , err.Error()))
+	}
+
+	if doFunc,ok := this.funcMapper[method.Name];ok {
+		return doFunc(args , sysparam)
+	}
+	return nil , errors.New(fmt.Sprintf("ContractStaking: no method %s find in map" , method.Name))
+}
@@ -0,0 +1,327 @@
+package staking
+
+import (
+	"testing"
+	"math/big"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/interpreter/intertest"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/params"
+)
+
+func newTestSysParams(t *testing.T , balances map[types.Address]int64)*intertypes.SystemParams{
+	sysparam := intertest.NewSysParams(t , intertest.NewVm(map[types.Address]intertest.Contract{
+		balancetransfer.BalanceTransferAddress:balancetransfer.NewContractBalancer() ,
+		StakingAddress:NewContractStaking() ,
+	}))
+	for address , amount := range balances {
+		if _ , err := balancetransfer.Reward(sysparam , address , big.NewInt(amount));err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sysparam
+}
+
+//as calls the staking contract in a transaction signed by sender in the block number
+func as(sender types.Address , number int64 , sysparam *intertypes.SystemParams , params []byte)([]intertypes.ActionResult , error){
+	return intertest.AsAt(sender , number , sysparam , NewContractStaking() , params)
+}
+
+func balanceOf(t *testing.T , sysparam *intertypes.SystemParams , address types.Address)int64{
+	balance , err := balancetransfer.BalanceOf(sysparam , address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance.Int64()
+}
+
+func delegationOf(t *testing.T , sysparam *intertypes.SystemParams , candidate , delegator types.Address)(int64 , int64){
+	results , err := NewContractStaking().DoFun(MakeGetDelegationParam(candidate , delegator) , sysparam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values , err := getDelegationMethod.UnpackOutputs(results[0].Val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return values[0].(*big.Int).Int64() , values[1].(*big.Int).Int64()
+}
+
+func elect(t *testing.T , sysparam *intertypes.SystemParams)[]types.Address{
+	validators , err := Elect(sysparam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return validators
+}
+
+func TestStakingLifecycle(t *testing.T){
+	alice := types.Address{1}
+	bob := types.Address{2}
+	dave := types.Address{3}
+	rewarder := types.Address{4}
+	sysparam := newTestSysParams(t , map[types.Address]int64{alice:100000 , bob:100000 , dave:100000 , rewarder:100000})
+
+	if _ , err := as(alice , 1 , sysparam , MakeRegisterCandidateParam(10 , big.NewInt(int64(params.MinCandidateStake) - 1)));err == nil {
+		t.Fatal("candidate registered below the minimum stake")
+	}
+	if _ , err := as(alice , 1 , sysparam , MakeRegisterCandidateParam(MaxCommission + 1 , big.NewInt(20000)));err == nil {
+		t.Fatal("candidate registered with a commission above 100%")
+	}
+	if _ , err := as(alice , 1 , sysparam , MakeRegisterCandidateParam(10 , big.NewInt(20000)));err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := as(alice , 1 , sysparam , MakeRegisterCandidateParam(10 , big.NewInt(20000)));err == nil {
+		t.Fatal("candidate registered twice")
+	}
+	if _ , err := as(bob , 1 , sysparam , MakeRegisterCandidateParam(0 , big.NewInt(10000)));err != nil {
+		t.Fatal(err)
+	}
+	if validators := elect(t , sysparam);len(validators) != 2 || validators[0] != alice {
+		t.Fatalf("validators %v, want alice first" , validators)
+	}
+
+	//the stake of dave moves bob ahead
+	if _ , err := as(dave , 2 , sysparam , MakeDelegateParam(bob , big.NewInt(200000)));err == nil {
+		t.Fatal("delegated more than the balance")
+	}
+	if _ , err := as(dave , 2 , sysparam , MakeDelegateParam(rewarder , big.NewInt(100)));err == nil {
+		t.Fatal("delegated to a non candidate")
+	}
+	if _ , err := as(dave , 2 , sysparam , MakeDelegateParam(bob , big.NewInt(30000)));err != nil {
+		t.Fatal(err)
+	}
+	if validators := elect(t , sysparam);len(validators) != 2 || validators[0] != bob {
+		t.Fatalf("validators %v, want bob first" , validators)
+	}
+
+	//bob takes no commission,every unit of its stake gets 0.1
+	if _ , err := as(rewarder , 3 , sysparam , MakeDistributeRewardParam(bob , big.NewInt(4000)));err != nil {
+		t.Fatal(err)
+	}
+	//alice takes 10% and has all of its stake
+	if _ , err := as(rewarder , 3 , sysparam , MakeDistributeRewardParam(alice , big.NewInt(1000)));err != nil {
+		t.Fatal(err)
+	}
+	rewards := []struct{
+		candidate , delegator types.Address
+		amount , reward int64
+	}{
+		{bob , bob , 10000 , 1000},
+		{bob , dave , 30000 , 3000},
+		{alice , alice , 20000 , 1000},
+		{alice , dave , 0 , 0},
+	}
+	for _ , want := range rewards {
+		if amount , reward := delegationOf(t , sysparam , want.candidate , want.delegator);amount != want.amount || reward != want.reward {
+			t.Errorf("delegation of %x on %x: have %d %d, want %d %d" , want.delegator , want.candidate , amount , reward , want.amount , want.reward)
+		}
+	}
+
+	if _ , err := as(dave , 4 , sysparam , MakeClaimRewardsParam(bob));err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := as(dave , 4 , sysparam , MakeClaimRewardsParam(bob));err == nil {
+		t.Fatal("rewards claimed twice")
+	}
+	if have := balanceOf(t , sysparam , dave);have != 73000 {
+		t.Fatalf("balance of dave %d, want 73000" , have)
+	}
+
+	//the undelegated stake is locked for the unbonding period
+	if _ , err := as(dave , 10 , sysparam , MakeUndelegateParam(bob , big.NewInt(30001)));err == nil {
+		t.Fatal("undelegated more than the stake")
+	}
+	if _ , err := as(dave , 10 , sysparam , MakeUndelegateParam(bob , big.NewInt(30000)));err != nil {
+		t.Fatal(err)
+	}
+	if validators := elect(t , sysparam);len(validators) != 2 || validators[0] != alice {
+		t.Fatalf("validators %v, want alice first" , validators)
+	}
+	if _ , err := as(dave , 10 + int64(params.UnbondingPeriod) - 1 , sysparam , MakeWithdrawParam());err == nil {
+		t.Fatal("withdrawn before the unbonding period")
+	}
+	if _ , err := as(dave , 10 + int64(params.UnbondingPeriod) , sysparam , MakeWithdrawParam());err != nil {
+		t.Fatal(err)
+	}
+	if have := balanceOf(t , sysparam , dave);have != 103000 {
+		t.Fatalf("balance of dave %d, want 103000" , have)
+	}
+	//the stakes of the candidates and their unclaimed rewards stay
+	if have := balanceOf(t , sysparam , StakingAddress);have != 32000 {
+		t.Fatalf("balance of the staking contract %d, want 32000" , have)
+	}
+}
+
+func TestElection(t *testing.T){
+	balances := make(map[types.Address]int64)
+	candidates := make([]types.Address , params.MaxElectedValidators + 3)
+	for i := range candidates {
+		candidates[i] = types.Address{byte(i + 1)}
+		balances[candidates[i]] = 100000
+	}
+	sysparam := newTestSysParams(t , balances)
+
+	//every candidate has more stake than the previous one,except the last two which have the same
+	for i , candidate := range candidates {
+		stake := int64(params.MinCandidateStake) + int64(i)
+		if i == len(candidates) - 1 {
+			stake--
+		}
+		if _ , err := as(candidate , 1 , sysparam , MakeRegisterCandidateParam(0 , big.NewInt(stake)));err != nil {
+			t.Fatal(err)
+		}
+	}
+	validators := elect(t , sysparam)
+	if len(validators) != params.MaxElectedValidators {
+		t.Fatalf("%d validators elected, want %d" , len(validators) , params.MaxElectedValidators)
+	}
+	last := len(candidates) - 1
+	if validators[0] != candidates[last - 1] || validators[1] != candidates[last] || validators[2] != candidates[last - 2] {
+		t.Fatalf("validators %v not ordered by stake and address" , validators[:3])
+	}
+
+	//a jailed candidate or an unregistered one is not elected,a candidate can't keep less than the minimum stake
+	candidate , err := getExistingCandidate(sysparam , candidates[last])
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidate.Jailed = true
+	if _ , err := setValue(sysparam , CandidateKey(candidates[last]) , candidate);err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := as(candidates[last - 1] , 2 , sysparam , MakeUndelegateParam(candidates[last - 1] , big.NewInt(int64(last))));err == nil {
+		t.Fatal("own stake left below the minimum")
+	}
+	if _ , err := as(candidates[last - 1] , 2 , sysparam , MakeUndelegateParam(candidates[last - 1] , big.NewInt(int64(params.MinCandidateStake) + int64(last - 1))));err != nil {
+		t.Fatal(err)
+	}
+	for _ , validator := range elect(t , sysparam) {
+		if validator == candidates[last] || validator == candidates[last - 1] {
+			t.Errorf("%x elected" , validator)
+		}
+	}
+}
+
+func TestElectionBlock(t *testing.T){
+	epoch := params.StakingEpoch
+	tests := []struct{
+		number , block uint64
+	}{
+		{0 , 0} , {1 , 0} , {epoch , 0} , {epoch + 1 , epoch} , {2 * epoch , epoch} , {2 * epoch + 1 , 2 * epoch},
+	}
+	for _ , test := range tests {
+		if have := ElectionBlock(test.number);have != test.block {
+			t.Errorf("election block of %d: have %d, want %d" , test.number , have , test.block)
+		}
+	}
+}
+
+func TestSlash(t *testing.T){
+	alice := types.Address{1}
+	dave := types.Address{3}
+	sysparam := newTestSysParams(t , map[types.Address]int64{alice:100000 , dave:100000})
+
+	if _ , err := as(alice , 1 , sysparam , MakeRegisterCandidateParam(0 , big.NewInt(20000)));err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := as(dave , 1 , sysparam , MakeDelegateParam(alice , big.NewInt(80000)));err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := as(alice , 2 , sysparam , MakeSlashParam(alice));err == nil {
+		t.Fatal("slashed by a candidate")
+	}
+	if _ , err := as(SlasherAddress , 2 , sysparam , MakeSlashParam(dave));err == nil {
+		t.Fatal("slashed a non candidate")
+	}
+	if _ , err := as(SlasherAddress , 2 , sysparam , MakeSlashParam(alice));err != nil {
+		t.Fatal(err)
+	}
+
+	//10% of the total stake is taken from the own stake of alice,the delegation of dave is kept
+	candidate , err := getExistingCandidate(sysparam , alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !candidate.Jailed || candidate.TotalStake.Int64() != 90000 {
+		t.Fatalf("candidate jailed %v with stake %d, want jailed with 90000" , candidate.Jailed , candidate.TotalStake.Int64())
+	}
+	if amount , _ := delegationOf(t , sysparam , alice , alice);amount != 10000 {
+		t.Fatalf("own stake of alice %d, want 10000" , amount)
+	}
+	if amount , _ := delegationOf(t , sysparam , alice , dave);amount != 80000 {
+		t.Fatalf("stake of dave %d, want 80000" , amount)
+	}
+	if len(elect(t , sysparam)) != 0 {
+		t.Fatal("jailed candidate elected")
+	}
+	if _ , err := as(dave , 3 , sysparam , MakeDelegateParam(alice , big.NewInt(100)));err == nil {
+		t.Fatal("delegated to a jailed candidate")
+	}
+}
+
+func TestUndelegateOwnStake(t *testing.T){
+	alice := types.Address{1}
+	dave := types.Address{3}
+	sysparam := newTestSysParams(t , map[types.Address]int64{alice:100000 , dave:100000})
+
+	if _ , err := as(alice , 1 , sysparam , MakeRegisterCandidateParam(0 , big.NewInt(20000)));err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := as(dave , 1 , sysparam , MakeDelegateParam(alice , big.NewInt(80000)));err != nil {
+		t.Fatal(err)
+	}
+	//the delegation of dave doesn't count,alice has to keep the minimum stake itself
+	if _ , err := as(alice , 2 , sysparam , MakeUndelegateParam(alice , big.NewInt(15000)));err == nil {
+		t.Fatal("own stake left below the minimum")
+	}
+	if _ , err := as(alice , 2 , sysparam , MakeUndelegateParam(alice , big.NewInt(10000)));err != nil {
+		t.Fatal(err)
+	}
+
+	//the slashing still finds the 10% of the total stake in the own stake of alice
+	if _ , err := as(SlasherAddress , 3 , sysparam , MakeSlashParam(alice));err != nil {
+		t.Fatal(err)
+	}
+	if amount , _ := delegationOf(t , sysparam , alice , alice);amount != 1000 {
+		t.Fatalf("own stake of alice %d, want 1000" , amount)
+	}
+
+	//the rest can only be undelegated all at once,which unregisters alice
+	if _ , err := as(alice , 4 , sysparam , MakeUndelegateParam(alice , big.NewInt(500)));err == nil {
+		t.Fatal("own stake left below the minimum")
+	}
+	if _ , err := as(alice , 4 , sysparam , MakeUndelegateParam(alice , big.NewInt(1000)));err != nil {
+		t.Fatal(err)
+	}
+	list , err := getCandidateList(sysparam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Addresses) != 0 {
+		t.Fatalf("candidates %v, want none" , list.Addresses)
+	}
+	if _ , err := as(dave , 5 , sysparam , MakeUndelegateParam(alice , big.NewInt(80000)));err != nil {
+		t.Fatalf("delegator can't leave an unregistered candidate: %v" , err)
+	}
+}
+
+func TestDelegateUnregistered(t *testing.T){
+	alice := types.Address{1}
+	dave := types.Address{3}
+	sysparam := newTestSysParams(t , map[types.Address]int64{alice:100000 , dave:100000})
+
+	if _ , err := as(alice , 1 , sysparam , MakeRegisterCandidateParam(0 , big.NewInt(20000)));err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := as(alice , 2 , sysparam , MakeUndelegateParam(alice , big.NewInt(20000)));err != nil {
+		t.Fatal(err)
+	}
+	if len(elect(t , sysparam)) != 0 {
+		t.Fatal("unregistered candidate elected")
+	}
+	if _ , err := as(dave , 3 , sysparam , MakeDelegateParam(alice , big.NewInt(100)));err == nil {
+		t.Fatal("delegated to an unregistered candidate")
+	}
+}
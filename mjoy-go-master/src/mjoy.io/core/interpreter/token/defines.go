@@ -0,0 +1,141 @@
+package token
+
+import (
+	"mjoy.io/common/types"
+	"math/big"
+)
+
+const (
+	MaxNameLength = 64
+	MaxSymbolLength = 12
+	MaxDecimals = 18
+)
+
+//storage key prefixes,the token symbol and the addresses follow them
+var (
+	infoPrefix = []byte("token/info/")
+	balancePrefix = []byte("token/balance/")
+	allowancePrefix = []byte("token/allowance/")
+)
+
+//TokenInfo is stored under the info key of the symbol
+type TokenInfo struct {
+	Name        string        `json:"name"`
+	Symbol      string        `json:"symbol"`
+	Decimals    uint64        `json:"decimals"`
+	Issuer      types.Address `json:"issuer"`
+	MaxSupply   *big.Int      `json:"maxSupply"`
+	TotalSupply *big.Int      `json:"totalSupply"`
+}
+
+//TokenAmount is the stored value of a balance or an allowance
+type TokenAmount struct {
+	Amount *big.Int `json:"amount"`
+}
+
+func InfoKey(symbol string)[]byte{
+	key := append([]byte{} , infoPrefix...)
+	return append(key , symbol...)
+}
+
+func BalanceKey(symbol string , owner types.Address)[]byte{
+	key := append([]byte{} , balancePrefix...)
+	key = append(key , symbol...)
+	key = append(key , '/')
+	return append(key , owner[:]...)
+}
+
+func AllowanceKey(symbol string , owner , spender types.Address)[]byte{
+	key := append([]byte{} , allowancePrefix...)
+	key = append(key , symbol...)
+	key = append(key , '/')
+	key = append(key , owner[:]...)
+	return append(key , spender[:]...)
+}
+
+//validSymbol accepts upper case letters and digits only,so a symbol can not contain the key separator
+func validSymbol(symbol string)bool{
+	if len(symbol) == 0 || len(symbol) > MaxSymbolLength {
+		return false
+	}
+	for _ , c := range symbol {
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+//the Make*Param functions of the writing methods act for the sender of the transaction
+
+func MakeIssueParam(name , symbol string , decimals uint64 , maxSupply *big.Int)[]byte{
+	r , err := TokenAbi.Pack(Issue_Method , name , symbol , decimals , maxSupply)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeMintParam(symbol string , to types.Address , amount *big.Int)[]byte{
+	r , err := TokenAbi.Pack(Mint_Method , symbol , to , amount)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeBurnParam(symbol string , amount *big.Int)[]byte{
+	r , err := TokenAbi.Pack(Burn_Method , symbol , amount)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeTransferParam(symbol string , to types.Address , amount *big.Int)[]byte{
+	r , err := TokenAbi.Pack(Transfer_Method , symbol , to , amount)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeApproveParam(symbol string , spender types.Address , amount *big.Int)[]byte{
+	r , err := TokenAbi.Pack(Approve_Method , symbol , spender , amount)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeTransferFromParam(symbol string , from , to types.Address , amount *big.Int)[]byte{
+	r , err := TokenAbi.Pack(TransferFrom_Method , symbol , from , to , amount)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeBalanceOfParam(symbol string , owner types.Address)[]byte{
+	r , err := TokenAbi.Pack(BalanceOf_Method , symbol , owner)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeAllowanceParam(symbol string , owner , spender types.Address)[]byte{
+	r , err := TokenAbi.Pack(Allowance_Method , symbol , owner , spender)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+func MakeTokenInfoParam(symbol string)[]byte{
+	r , err := TokenAbi.Pack(TokenInfo_Method , symbol)
+	if err != nil {
+		return nil
+	}
+	return r
+}
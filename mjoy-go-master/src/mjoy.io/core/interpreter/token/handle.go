@@ -0,0 +1,387 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"mjoy.io/common/types"
+	"mjoy.io/core/sdk"
+	"encoding/json"
+	"mjoy.io/core/interpreter/intertypes"
+	"math/big"
+	"mjoy.io/core/interpreter/abi"
+)
+
+func Issue(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	issuer , err := intertypes.Sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Issue:%s" , err.Error()))
+	}
+	name := args[0].(string)
+	symbol := args[1].(string)
+	decimals := args[2].(uint64)
+	maxSupply := args[3].(*big.Int)
+
+	logger.Tracef("Start: Issue %s by %s" , symbol , issuer.Hex())
+	if !validSymbol(symbol) {
+		return nil , errors.New(fmt.Sprintf("Issue:invalid symbol %q" , symbol))
+	}
+	if len(name) == 0 || len(name) > MaxNameLength {
+		return nil , errors.New(fmt.Sprintf("Issue:invalid name length %d" , len(name)))
+	}
+	if decimals > MaxDecimals {
+		return nil , errors.New(fmt.Sprintf("Issue:decimals %d above %d" , decimals , MaxDecimals))
+	}
+	if maxSupply.Sign() <= 0 {
+		return nil , errors.New(fmt.Sprintf("Issue:max supply %s is not positive" , maxSupply.String()))
+	}
+
+	info , err := getInfo(sysparam , symbol)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Issue:%s" , err.Error()))
+	}
+	if info != nil {
+		return nil , errors.New(fmt.Sprintf("Issue:token %s already issued" , symbol))
+	}
+
+	info = &TokenInfo{
+		Name:name ,
+		Symbol:symbol ,
+		Decimals:decimals ,
+		Issuer:issuer ,
+		MaxSupply:maxSupply ,
+		TotalSupply:new(big.Int) ,
+	}
+	result , err := setValue(sysparam , InfoKey(symbol) , info)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Issue:%s" , err.Error()))
+	}
+	if err = emit(sysparam , issueEvent , []interface{}{symbol , issuer} , maxSupply);err != nil {
+		return nil , errors.New(fmt.Sprintf("Issue:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{result} , nil
+}
+
+func Mint(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	issuer , err := intertypes.Sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Mint:%s" , err.Error()))
+	}
+	symbol := args[0].(string)
+	to := args[1].(types.Address)
+	amount := args[2].(*big.Int)
+
+	info , err := getIssuedInfo(sysparam , symbol , issuer)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Mint:%s" , err.Error()))
+	}
+	if amount.Sign() <= 0 {
+		return nil , errors.New(fmt.Sprintf("Mint:amount %s is not positive" , amount.String()))
+	}
+	supply := new(big.Int).Add(info.TotalSupply , amount)
+	if supply.Cmp(info.MaxSupply) > 0 {
+		return nil , errors.New(fmt.Sprintf("Mint:supply %s would exceed max supply %s" , supply.String() , info.MaxSupply.String()))
+	}
+
+	balance , err := getAmount(sysparam , BalanceKey(symbol , to))
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Mint:%s" , err.Error()))
+	}
+	info.TotalSupply = supply
+
+	results := make([]intertypes.ActionResult , 0 , 2)
+	results , err = appendValue(results , sysparam , InfoKey(symbol) , info)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Mint:%s" , err.Error()))
+	}
+	results , err = appendValue(results , sysparam , BalanceKey(symbol , to) , &TokenAmount{balance.Add(balance , amount)})
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Mint:%s" , err.Error()))
+	}
+	if err = emit(sysparam , transferEvent , []interface{}{symbol , types.Address{} , to} , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("Mint:%s" , err.Error()))
+	}
+	return results , nil
+}
+
+//Burn destroys tokens held by the issuer
+func Burn(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	issuer , err := intertypes.Sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Burn:%s" , err.Error()))
+	}
+	symbol := args[0].(string)
+	amount := args[1].(*big.Int)
+
+	info , err := getIssuedInfo(sysparam , symbol , issuer)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Burn:%s" , err.Error()))
+	}
+	if amount.Sign() <= 0 {
+		return nil , errors.New(fmt.Sprintf("Burn:amount %s is not positive" , amount.String()))
+	}
+	balance , err := getAmount(sysparam , BalanceKey(symbol , issuer))
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Burn:%s" , err.Error()))
+	}
+	if balance.Cmp(amount) < 0 {
+		return nil , errors.New(fmt.Sprintf("Burn:has %s , but want %s" , balance.String() , amount.String()))
+	}
+	info.TotalSupply = new(big.Int).Sub(info.TotalSupply , amount)
+
+	results := make([]intertypes.ActionResult , 0 , 2)
+	results , err = appendValue(results , sysparam , InfoKey(symbol) , info)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Burn:%s" , err.Error()))
+	}
+	results , err = appendValue(results , sysparam , BalanceKey(symbol , issuer) , &TokenAmount{balance.Sub(balance , amount)})
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Burn:%s" , err.Error()))
+	}
+	if err = emit(sysparam , transferEvent , []interface{}{symbol , issuer , types.Address{}} , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("Burn:%s" , err.Error()))
+	}
+	return results , nil
+}
+
+func Transfer(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	from , err := intertypes.Sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Transfer:%s" , err.Error()))
+	}
+	symbol := args[0].(string)
+	to := args[1].(types.Address)
+	amount := args[2].(*big.Int)
+
+	results , err := transfer(sysparam , symbol , from , to , amount)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Transfer:%s" , err.Error()))
+	}
+	return results , nil
+}
+
+func Approve(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	owner , err := intertypes.Sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Approve:%s" , err.Error()))
+	}
+	symbol := args[0].(string)
+	spender := args[1].(types.Address)
+	amount := args[2].(*big.Int)
+
+	//a zero amount revokes the allowance
+	if amount.Sign() < 0 {
+		return nil , errors.New(fmt.Sprintf("Approve:amount %s is negative" , amount.String()))
+	}
+	if _ , err := getExistingInfo(sysparam , symbol);err != nil {
+		return nil , errors.New(fmt.Sprintf("Approve:%s" , err.Error()))
+	}
+	result , err := setValue(sysparam , AllowanceKey(symbol , owner , spender) , &TokenAmount{amount})
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Approve:%s" , err.Error()))
+	}
+	if err = emit(sysparam , approvalEvent , []interface{}{symbol , owner , spender} , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("Approve:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{result} , nil
+}
+
+func TransferFrom(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	spender , err := intertypes.Sender(sysparam)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("TransferFrom:%s" , err.Error()))
+	}
+	symbol := args[0].(string)
+	from := args[1].(types.Address)
+	to := args[2].(types.Address)
+	amount := args[3].(*big.Int)
+
+	allowanceKey := AllowanceKey(symbol , from , spender)
+	allowance , err := getAmount(sysparam , allowanceKey)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("TransferFrom:%s" , err.Error()))
+	}
+	if allowance.Cmp(amount) < 0 {
+		return nil , errors.New(fmt.Sprintf("TransferFrom:allowance %s , but want %s" , allowance.String() , amount.String()))
+	}
+
+	results , err := transfer(sysparam , symbol , from , to , amount)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("TransferFrom:%s" , err.Error()))
+	}
+	results , err = appendValue(results , sysparam , allowanceKey , &TokenAmount{allowance.Sub(allowance , amount)})
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("TransferFrom:%s" , err.Error()))
+	}
+	return results , nil
+}
+
+func BalanceOf(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	symbol := args[0].(string)
+	owner := args[1].(types.Address)
+
+	if _ , err := getExistingInfo(sysparam , symbol);err != nil {
+		return nil , errors.New(fmt.Sprintf("BalanceOf:%s" , err.Error()))
+	}
+	balance , err := getAmount(sysparam , BalanceKey(symbol , owner))
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("BalanceOf:%s" , err.Error()))
+	}
+	resultBytes , err := balanceOfMethod.PackOutputs(balance)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("BalanceOf Last Pack Err:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{{Key:nil , Val:resultBytes}} , nil
+}
+
+func Allowance(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	symbol := args[0].(string)
+	owner := args[1].(types.Address)
+	spender := args[2].(types.Address)
+
+	if _ , err := getExistingInfo(sysparam , symbol);err != nil {
+		return nil , errors.New(fmt.Sprintf("Allowance:%s" , err.Error()))
+	}
+	allowance , err := getAmount(sysparam , AllowanceKey(symbol , owner , spender))
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Allowance:%s" , err.Error()))
+	}
+	resultBytes , err := allowanceMethod.PackOutputs(allowance)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("Allowance Last Pack Err:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{{Key:nil , Val:resultBytes}} , nil
+}
+
+func GetTokenInfo(args []interface{} , sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	symbol := args[0].(string)
+
+	info , err := getExistingInfo(sysparam , symbol)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("GetTokenInfo:%s" , err.Error()))
+	}
+	resultBytes , err := tokenInfoMethod.PackOutputs(info.Name , info.Symbol , info.Decimals , info.Issuer , info.MaxSupply , info.TotalSupply)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("GetTokenInfo Last Pack Err:%s" , err.Error()))
+	}
+	return []intertypes.ActionResult{{Key:nil , Val:resultBytes}} , nil
+}
+
+//transfer moves amount of the token from one account to another
+func transfer(sysparam *intertypes.SystemParams , symbol string , from , to types.Address , amount *big.Int)([]intertypes.ActionResult , error){
+	if amount.Sign() <= 0 {
+		return nil , errors.New(fmt.Sprintf("amount %s is not positive" , amount.String()))
+	}
+	if _ , err := getExistingInfo(sysparam , symbol);err != nil {
+		return nil , err
+	}
+	balanceFrom , err := getAmount(sysparam , BalanceKey(symbol , from))
+	if err != nil {
+		return nil , err
+	}
+	if balanceFrom.Cmp(amount) < 0 {
+		return nil , errors.New(fmt.Sprintf("has %s , but want %s" , balanceFrom.String() , amount.String()))
+	}
+	if from == to {
+		logger.Tracef("token %s sender address is equal to receipt address %s" , symbol , from.Hex())
+		return nil , emit(sysparam , transferEvent , []interface{}{symbol , from , to} , amount)
+	}
+	balanceTo , err := getAmount(sysparam , BalanceKey(symbol , to))
+	if err != nil {
+		return nil , err
+	}
+
+	results := make([]intertypes.ActionResult , 0 , 3)
+	if results , err = appendValue(results , sysparam , BalanceKey(symbol , from) , &TokenAmount{balanceFrom.Sub(balanceFrom , amount)});err != nil {
+		return nil , err
+	}
+	if results , err = appendValue(results , sysparam , BalanceKey(symbol , to) , &TokenAmount{balanceTo.Add(balanceTo , amount)});err != nil {
+		return nil , err
+	}
+	if err = emit(sysparam , transferEvent , []interface{}{symbol , from , to} , amount);err != nil {
+		return nil , err
+	}
+	return results , nil
+}
+
+//getInfo returns nil if the token is not issued
+func getInfo(sysparam *intertypes.SystemParams , symbol string)(*TokenInfo , error){
+	if !validSymbol(symbol) {
+		return nil , errors.New(fmt.Sprintf("invalid symbol %q" , symbol))
+	}
+	data := sdk.Sys_GetValue(sysparam.SdkHandler , TokenAddress , InfoKey(symbol))
+	if nil == data {
+		return nil , nil
+	}
+	info := new(TokenInfo)
+	if err := json.Unmarshal(data , info);err != nil {
+		return nil , errors.New(fmt.Sprintf("Unmarshal json:%s" , err.Error()))
+	}
+	return info , nil
+}
+
+func getExistingInfo(sysparam *intertypes.SystemParams , symbol string)(*TokenInfo , error){
+	info , err := getInfo(sysparam , symbol)
+	if err != nil {
+		return nil , err
+	}
+	if info == nil {
+		return nil , errors.New(fmt.Sprintf("token %s not issued" , symbol))
+	}
+	return info , nil
+}
+
+//getIssuedInfo returns the info of the token only if issuer issued it
+func getIssuedInfo(sysparam *intertypes.SystemParams , symbol string , issuer types.Address)(*TokenInfo , error){
+	info , err := getExistingInfo(sysparam , symbol)
+	if err != nil {
+		return nil , err
+	}
+	if info.Issuer != issuer {
+		return nil , errors.New(fmt.Sprintf("%s is not the issuer of %s" , issuer.Hex() , symbol))
+	}
+	return info , nil
+}
+
+//getAmount returns zero for a key never written
+func getAmount(sysparam *intertypes.SystemParams , key []byte)(*big.Int , error){
+	data := sdk.Sys_GetValue(sysparam.SdkHandler , TokenAddress , key)
+	if nil == data {
+		return new(big.Int) , nil
+	}
+	amount := new(TokenAmount)
+	if err := json.Unmarshal(data , amount);err != nil {
+		return nil , errors.New(fmt.Sprintf("Unmarshal json:%s" , err.Error()))
+	}
+	if amount.Amount == nil {
+		return new(big.Int) , nil
+	}
+	return amount.Amount , nil
+}
+
+func setValue(sysparam *intertypes.SystemParams , key []byte , value interface{})(intertypes.ActionResult , error){
+	data , err := json.Marshal(value)
+	if err != nil {
+		return intertypes.ActionResult{} , errors.New(fmt.Sprintf("Marshal json:%s" , err.Error()))
+	}
+	if err = sdk.Sys_SetValue(sysparam.SdkHandler , TokenAddress , key , data);err != nil {
+		return intertypes.ActionResult{} , err
+	}
+	return intertypes.ActionResult{Key:key , Val:data} , nil
+}
+
+func appendValue(results []intertypes.ActionResult , sysparam *intertypes.SystemParams , key []byte , value interface{})([]intertypes.ActionResult , error){
+	result , err := setValue(sysparam , key , value)
+	if err != nil {
+		return nil , err
+	}
+	return append(results , result) , nil
+}
+
+//emit packs the event and emits it from the token contract
+func emit(sysparam *intertypes.SystemParams , event *abi.Event , indexed []interface{} , values ...interface{})error{
+	topics , data , err := event.Pack(indexed , values...)
+	if err != nil {
+		return err
+	}
+	return sdk.Sys_EmitEvent(sysparam.SdkHandler , TokenAddress , event.Name , topics , data)
+}
@@ -0,0 +1,157 @@
+package token
+
+/*
+token is a innerContract managing any number of fungible tokens.A token is issued once by its issuer,who is the only
+one allowed to mint and burn it,and is identified by its symbol.The account a method acts for is always the signed
+sender of the transaction,or the contract calling the token contract.Every write is returned as an ActionResult keyed by the
+storage key,so the receipt logs of a transaction show which token info,balances and allowances it changed,and
+the Issue,Transfer and Approval events let clients filter the logs by token and account.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/abi"
+)
+
+//method names of the token contract
+const(
+	Issue_Method = "issue"
+	Mint_Method = "mint"
+	Burn_Method = "burn"
+	Transfer_Method = "transfer"
+	Approve_Method = "approve"
+	TransferFrom_Method = "transferFrom"
+	BalanceOf_Method = "balanceOf"
+	Allowance_Method = "allowance"
+	TokenInfo_Method = "tokenInfo"
+)
+
+//event names of the token contract,minting is a transfer from the zero address and burning one to it
+const(
+	Issue_Event = "Issue"
+	Transfer_Event = "Transfer"
+	Approval_Event = "Approval"
+)
+
+var TokenAddress = types.HexToAddress("0x0000000000000000000000000000000000000002")
+
+//TokenAbi is the schema of the token contract
+var TokenAbi = abi.New("token" ,
+	&abi.Method{
+		Name:Issue_Method ,
+		Inputs:abi.Arguments{abi.Arg("name" , abi.StringTy) , abi.Arg("symbol" , abi.StringTy) , abi.Arg("decimals" , abi.Uint64Ty) ,
+			abi.Arg("maxSupply" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:Mint_Method ,
+		Inputs:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("to" , abi.AddressTy) , abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:Burn_Method ,
+		Inputs:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:Transfer_Method ,
+		Inputs:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("to" , abi.AddressTy) , abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:Approve_Method ,
+		Inputs:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("spender" , abi.AddressTy) , abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:TransferFrom_Method ,
+		Inputs:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("from" , abi.AddressTy) , abi.Arg("to" , abi.AddressTy) ,
+			abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Method{
+		Name:BalanceOf_Method ,
+		Inputs:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("owner" , abi.AddressTy)},
+		Outputs:abi.Arguments{abi.Arg("balance" , abi.BigIntTy)},
+		Constant:true,
+	},
+	&abi.Method{
+		Name:Allowance_Method ,
+		Inputs:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("owner" , abi.AddressTy) , abi.Arg("spender" , abi.AddressTy)},
+		Outputs:abi.Arguments{abi.Arg("allowance" , abi.BigIntTy)},
+		Constant:true,
+	},
+	&abi.Method{
+		Name:TokenInfo_Method ,
+		Inputs:abi.Arguments{abi.Arg("symbol" , abi.StringTy)},
+		Outputs:abi.Arguments{abi.Arg("name" , abi.StringTy) , abi.Arg("symbol" , abi.StringTy) , abi.Arg("decimals" , abi.Uint64Ty) ,
+			abi.Arg("issuer" , abi.AddressTy) , abi.Arg("maxSupply" , abi.BigIntTy) , abi.Arg("totalSupply" , abi.BigIntTy)},
+		Constant:true,
+	},
+).WithEvents(
+	&abi.Event{
+		Name:Issue_Event ,
+		Indexed:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("issuer" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("maxSupply" , abi.BigIntTy)},
+	},
+	&abi.Event{
+		Name:Transfer_Event ,
+		Indexed:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("from" , abi.AddressTy) , abi.Arg("to" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy)},
+	},
+	&abi.Event{
+		Name:Approval_Event ,
+		Indexed:abi.Arguments{abi.Arg("symbol" , abi.StringTy) , abi.Arg("owner" , abi.AddressTy) , abi.Arg("spender" , abi.AddressTy)},
+		Inputs:abi.Arguments{abi.Arg("amount" , abi.BigIntTy)},
+	},
+)
+
+var (
+	balanceOfMethod , _ = TokenAbi.Method(BalanceOf_Method)
+	allowanceMethod , _ = TokenAbi.Method(Allowance_Method)
+	tokenInfoMethod , _ = TokenAbi.Method(TokenInfo_Method)
+
+	issueEvent , _ = TokenAbi.Event(Issue_Event)
+	transferEvent , _ = TokenAbi.Event(Transfer_Event)
+	approvalEvent , _ = TokenAbi.Event(Approval_Event)
+)
+
+//DoFunc get the arguments decoded by TokenAbi,so the types of them are checked
+type DoFunc func([]interface{} ,  *intertypes.SystemParams)([]intertypes.ActionResult , error)
+
+type ContractToken struct {
+	funcMapper map[string]DoFunc
+}
+
+//managed by vm
+func NewContractToken()*ContractToken{
+	t := new(ContractToken)
+	t.init()
+	return t
+}
+
+func (this *ContractToken)init(){
+	this.funcMapper = make(map[string]DoFunc)
+	this.funcMapper[Issue_Method] = Issue
+	this.funcMapper[Mint_Method] = Mint
+	this.funcMapper[Burn_Method] = Burn
+	this.funcMapper[Transfer_Method] = Transfer
+	this.funcMapper[Approve_Method] = Approve
+	this.funcMapper[TransferFrom_Method] = TransferFrom
+	this.funcMapper[BalanceOf_Method] = BalanceOf
+	this.funcMapper[Allowance_Method] = Allowance
+	this.funcMapper[TokenInfo_Method] = GetTokenInfo
+}
+
+func (this *ContractToken)Abi()*abi.ABI{
+	return TokenAbi
+}
+
+func (this *ContractToken)DoFun( params []byte,sysparam *intertypes.SystemParams)([]intertypes.ActionResult , error){
+	method , args , err := TokenAbi.Unpack(params)
+	if err != nil {
+		return nil , errors.New(fmt.Sprintf("ContractToken: %s" , err.Error()))
+	}
+
+	if doFunc,ok := this.funcMapper[method.Name];ok {
+		return doFunc(args , sysparam)
+	}
+	return nil , errors.New(fmt.Sprintf("ContractToken: no method %s find in map" , method.Name))
+}
@@ -0,0 +1,143 @@
+package token
+
+import (
+	"testing"
+	"math/big"
+	"mjoy.io/common/types"
+	"mjoy.io/core/interpreter/intertest"
+	"mjoy.io/core/interpreter/intertypes"
+)
+
+func call(contract *ContractToken , sysparam *intertypes.SystemParams , params []byte)([]intertypes.ActionResult , error){
+	return contract.DoFun(params , sysparam)
+}
+
+//as calls the contract in a transaction signed by sender
+func queryBigInt(t *testing.T , contract *ContractToken , sysparam *intertypes.SystemParams , params []byte)*big.Int{
+	results , err := call(contract , sysparam , params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method , _ , _ := TokenAbi.Unpack(params)
+	values , err := method.UnpackOutputs(results[0].Val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return values[0].(*big.Int)
+}
+
+func TestTokenLifecycle(t *testing.T){
+	sysparam := intertest.NewSysParams(t , nil)
+	contract := NewContractToken()
+
+	issuer := types.Address{1}
+	alice := types.Address{2}
+	bob := types.Address{3}
+
+	results , err := intertest.As(issuer , sysparam , contract , MakeIssueParam("Test Token" , "TT" , 8 , big.NewInt(1000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || string(results[0].Key) != string(InfoKey("TT")) {
+		t.Fatalf("issue results mismatch: %v" , results)
+	}
+	if _ , err := intertest.As(alice , sysparam , contract , MakeIssueParam("Other" , "TT" , 8 , big.NewInt(1))); err == nil {
+		t.Fatal("token issued twice")
+	}
+
+	if _ , err := intertest.As(alice , sysparam , contract , MakeMintParam("TT" , alice , big.NewInt(1))); err == nil {
+		t.Fatal("mint by non issuer accepted")
+	}
+	if _ , err := intertest.As(issuer , sysparam , contract , MakeMintParam("TT" , alice , big.NewInt(1001))); err == nil {
+		t.Fatal("mint above max supply accepted")
+	}
+	if _ , err := intertest.As(issuer , sysparam , contract , MakeMintParam("TT" , alice , big.NewInt(600))); err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := intertest.As(issuer , sysparam , contract , MakeMintParam("TT" , issuer , big.NewInt(400))); err != nil {
+		t.Fatal(err)
+	}
+
+	//transfer
+	if _ , err := intertest.As(alice , sysparam , contract , MakeTransferParam("TT" , bob , big.NewInt(601))); err == nil {
+		t.Fatal("transfer above balance accepted")
+	}
+	if _ , err := intertest.As(alice , sysparam , contract , MakeTransferParam("TT" , bob , big.NewInt(0))); err == nil {
+		t.Fatal("zero transfer accepted")
+	}
+	results , err = intertest.As(alice , sysparam , contract , MakeTransferParam("TT" , bob , big.NewInt(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("transfer results mismatch: %v" , results)
+	}
+
+	//allowance
+	if _ , err := intertest.As(alice , sysparam , contract , MakeApproveParam("TT" , bob , big.NewInt(50))); err != nil {
+		t.Fatal(err)
+	}
+	if _ , err := intertest.As(bob , sysparam , contract , MakeTransferFromParam("TT" , alice , bob , big.NewInt(51))); err == nil {
+		t.Fatal("transfer above allowance accepted")
+	}
+	if _ , err := intertest.As(bob , sysparam , contract , MakeTransferFromParam("TT" , alice , bob , big.NewInt(30))); err != nil {
+		t.Fatal(err)
+	}
+	if a := queryBigInt(t , contract , sysparam , MakeAllowanceParam("TT" , alice , bob)); a.Int64() != 20 {
+		t.Fatalf("allowance mismatch: have %v, want 20" , a)
+	}
+
+	//burn
+	if _ , err := intertest.As(issuer , sysparam , contract , MakeBurnParam("TT" , big.NewInt(401))); err == nil {
+		t.Fatal("burn above balance accepted")
+	}
+	if _ , err := intertest.As(issuer , sysparam , contract , MakeBurnParam("TT" , big.NewInt(150))); err != nil {
+		t.Fatal(err)
+	}
+
+	balances := map[types.Address]int64{issuer:250 , alice:470 , bob:130}
+	for addr , want := range balances {
+		if have := queryBigInt(t , contract , sysparam , MakeBalanceOfParam("TT" , addr)); have.Int64() != want {
+			t.Errorf("balance of %x mismatch: have %v, want %d" , addr , have , want)
+		}
+	}
+
+	results , err = call(contract , sysparam , MakeTokenInfoParam("TT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values , err := tokenInfoMethod.UnpackOutputs(results[0].Val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].(string) != "Test Token" || values[2].(uint64) != 8 || values[3].(types.Address) != issuer ||
+		values[5].(*big.Int).Int64() != 850 {
+		t.Fatalf("token info mismatch: %v" , values)
+	}
+}
+
+func TestTokenInvalidIssue(t *testing.T){
+	sysparam := intertest.NewSysParams(t , nil)
+	contract := NewContractToken()
+	issuer := types.Address{1}
+
+	invalid := [][]byte{
+		MakeIssueParam("Token" , "tt" , 8 , big.NewInt(1)),
+		MakeIssueParam("Token" , "T/T" , 8 , big.NewInt(1)),
+		MakeIssueParam("Token" , "TOOLONGSYMBOL" , 8 , big.NewInt(1)),
+		MakeIssueParam("" , "TT" , 8 , big.NewInt(1)),
+		MakeIssueParam("Token" , "TT" , 19 , big.NewInt(1)),
+		MakeIssueParam("Token" , "TT" , 8 , big.NewInt(0)),
+	}
+	for i , params := range invalid {
+		if _ , err := intertest.As(issuer , sysparam , contract , params); err == nil {
+			t.Errorf("issue %d: invalid token accepted" , i)
+		}
+	}
+	if _ , err := call(contract , sysparam , MakeIssueParam("Token" , "TT" , 8 , big.NewInt(1))); err == nil {
+		t.Error("issue without sender accepted")
+	}
+	if _ , err := intertest.As(issuer , sysparam , contract , MakeTransferParam("TT" , types.Address{2} , big.NewInt(1))); err == nil {
+		t.Error("transfer of a token never issued accepted")
+	}
+}
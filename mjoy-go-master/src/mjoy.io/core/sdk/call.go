@@ -0,0 +1,140 @@
+package sdk
+
+import (
+	"errors"
+	"mjoy.io/common/types"
+	"mjoy.io/common/types/util"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+)
+
+var (
+	ErrCallDepth = errors.New("max call depth exceeded")
+	ErrReentrantCall = errors.New("contract is already running")
+	ErrCallNotSupported = errors.New("contract calls are not supported")
+)
+
+//Caller runs a contract called by another one,the vm of the transaction sets it by SetCaller.
+//ret is the data returned by the called contract,writes are its storage writes
+type Caller interface {
+	Call(contract types.Address , params []byte)(ret []byte , writes []CallWrite , err error)
+}
+
+//CallWrite is a storage write made by a called contract,it goes to the trie with the results of the action
+type CallWrite struct {
+	Address types.Address
+	Key []byte
+	Val []byte
+}
+
+type callFrame struct {
+	caller types.Address
+	contract types.Address
+}
+
+func (this *TmpStatusManager)SetCaller(caller Caller){
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.caller = caller
+}
+
+//Call runs params on contract for caller.The writes of the called contract are reverted if it fails,the caller
+//decides whether the whole action fails by returning the error or not
+func (this *TmpStatusManager)Call(caller , contract types.Address , input []byte)([]byte , error){
+	this.mu.Lock()
+	runner := this.caller
+	trace := &transaction.CallTrace{
+		Depth:uint64(len(this.calls) + 1) ,
+		Caller:caller ,
+		Contract:contract ,
+		Input:util.CopyBytes(input) ,
+	}
+	this.traces = append(this.traces , trace)
+	err := this.checkCall(caller , contract)
+	if err == nil && runner == nil {
+		err = ErrCallNotSupported
+	}
+	if err == nil && this.meter != nil {
+		err = this.meter.Charge(params.CallResourceCost)
+	}
+	if err == nil {
+		this.calls = append(this.calls , callFrame{caller:caller , contract:contract})
+	}
+	this.mu.Unlock()
+
+	if err != nil {
+		trace.Error = err.Error()
+		return nil , err
+	}
+
+	snapshot := this.Snapshot()
+	ret , writes , err := runner.Call(contract , input)
+
+	this.mu.Lock()
+	this.calls = this.calls[:len(this.calls) - 1]
+	this.mu.Unlock()
+
+	if err != nil {
+		logger.Debugf("call of %s by %s failed: %v" , contract.Hex() , caller.Hex() , err)
+		this.RevertToSnapshot(snapshot)
+		trace.Error = err.Error()
+		return nil , err
+	}
+
+	this.mu.Lock()
+	this.callWrites = append(this.callWrites , writes...)
+	this.mu.Unlock()
+	trace.Output = util.CopyBytes(ret)
+	return ret , nil
+}
+
+//checkCall limits the depth of calls,and a contract can not be called again while it is running
+func (this *TmpStatusManager)checkCall(caller , contract types.Address)error{
+	if len(this.calls) >= params.CallDepthLimit {
+		return ErrCallDepth
+	}
+	if contract == caller {
+		return ErrReentrantCall
+	}
+	for _ , frame := range this.calls {
+		if frame.caller == contract {
+			return ErrReentrantCall
+		}
+	}
+	return nil
+}
+
+//Caller returns the address the running contract acts for:the contract calling it,or the sender of the
+//transaction for an action.ok is false if neither is known,as in a query
+func (this *TmpStatusManager)Caller()(caller types.Address , ok bool){
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	if len(this.calls) > 0 {
+		return this.calls[len(this.calls) - 1].caller , true
+	}
+	if this.tx != nil {
+		return this.tx.Sender , true
+	}
+	return types.Address{} , false
+}
+
+//TakeCallWrites returns the storage writes of the calls made since the last take
+func (this *TmpStatusManager)TakeCallWrites()[]CallWrite{
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	writes := this.callWrites
+	this.callWrites = nil
+	return writes
+}
+
+//TakeCallTraces returns the traces of the calls made since the last take,failed ones included
+func (this *TmpStatusManager)TakeCallTraces()[]*transaction.CallTrace{
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	traces := this.traces
+	this.traces = nil
+	return traces
+}
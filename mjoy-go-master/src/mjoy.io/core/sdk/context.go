@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"math/big"
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/params"
+)
+
+//BlockContext is the block the transactions are applied in
+type BlockContext struct {
+	Number *big.Int
+	Time *big.Int
+	ParentHash types.Hash
+	ChainId *big.Int
+	Rules params.Rules    //the fork rules active in the block
+}
+
+//TxContext is the transaction being applied,Sender is the signer of it
+type TxContext struct {
+	Hash types.Hash
+	Sender types.Address
+}
+
+func NewBlockContext(config *params.ChainConfig , header *block.Header)*BlockContext{
+	ctx := &BlockContext{
+		Number:new(big.Int) ,
+		Time:new(big.Int) ,
+		ParentHash:header.ParentHash ,
+		ChainId:new(big.Int) ,
+	}
+	if header.Number != nil {
+		ctx.Number.Set(&header.Number.IntVal)
+	}
+	if header.Time != nil {
+		ctx.Time.Set(&header.Time.IntVal)
+	}
+	if config != nil && config.ChainId != nil {
+		ctx.ChainId.Set(config.ChainId)
+	}
+	if config != nil {
+		ctx.Rules = config.Rules(ctx.Number)
+	}
+	return ctx
+}
+
+//SetBlockContext set the block being produced or processed,nil means no block
+func (this *TmpStatusManager)SetBlockContext(ctx *BlockContext){
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.block = ctx
+}
+
+//SetTxContext set the transaction being applied,nil means no transaction
+func (this *TmpStatusManager)SetTxContext(ctx *TxContext){
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.tx = ctx
+	this.actionIndex = 0
+}
+
+//SetActionIndex set the index of the running action inside the transaction
+func (this *TmpStatusManager)SetActionIndex(index int){
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.actionIndex = index
+}
+
+func (this *TmpStatusManager)BlockContext()*BlockContext{
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.block
+}
+
+func (this *TmpStatusManager)TxContext()*TxContext{
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.tx
+}
+
+func (this *TmpStatusManager)ActionIndex()int{
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.actionIndex
+}
@@ -0,0 +1,42 @@
+package sdk
+
+import (
+	"errors"
+	"mjoy.io/common/types"
+	"mjoy.io/common/types/util"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+)
+
+var ErrTooManyTopics = errors.New("too many event topics")
+
+//EmitEvent adds a log of the event to the transaction being applied.The first topic of the log is the id of the
+//event name,the indexed topics follow it.The log is dropped if the snapshot it was emitted in is reverted
+func (this *TmpStatusManager)EmitEvent(contractAddress types.Address , name string , topics []types.Hash , data []byte)error{
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if len(topics) > abi.MaxIndexed {
+		return ErrTooManyTopics
+	}
+	if this.meter != nil {
+		cost := params.EventResourceCost + uint64(len(topics)) * params.EventTopicResourceCost + uint64(len(data)) * params.EventByteResourceCost
+		if err := this.meter.Charge(cost);err != nil {
+			return err
+		}
+	}
+	if this.state == nil {
+		return errors.New("no state to emit event")
+	}
+
+	logTopics := make([]types.Hash , 0 , len(topics) + 1)
+	logTopics = append(logTopics , abi.EventID(name))
+	logTopics = append(logTopics , topics...)
+	this.state.AddLog(&transaction.Log{
+		Address:contractAddress ,
+		Topics:logTopics ,
+		Data:[][]byte{util.CopyBytes(data)} ,
+	})
+	return nil
+}
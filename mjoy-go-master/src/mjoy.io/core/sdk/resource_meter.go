@@ -0,0 +1,51 @@
+package sdk
+
+import (
+	"errors"
+)
+
+var ErrResourceExhausted = errors.New("resource limit exhausted")
+
+//ResourceMeter counts the resource units a transaction consumes against the limit declared by the sender.
+//Once the limit is exceeded the meter stays exhausted,and the used units equal the limit
+type ResourceMeter struct {
+	limit uint64
+	used uint64
+	exhausted bool
+}
+
+func NewResourceMeter(limit uint64)*ResourceMeter{
+	m := new(ResourceMeter)
+	m.limit = limit
+	return m
+}
+
+//Charge consumes units,it returns ErrResourceExhausted if the limit is exceeded
+func (this *ResourceMeter)Charge(units uint64)error{
+	if this.exhausted {
+		return ErrResourceExhausted
+	}
+	if this.used + units < this.used || this.used + units > this.limit {
+		this.used = this.limit
+		this.exhausted = true
+		return ErrResourceExhausted
+	}
+	this.used += units
+	return nil
+}
+
+func (this *ResourceMeter)Limit()uint64{
+	return this.limit
+}
+
+func (this *ResourceMeter)Used()uint64{
+	return this.used
+}
+
+func (this *ResourceMeter)Remaining()uint64{
+	return this.limit - this.used
+}
+
+func (this *ResourceMeter)Exhausted()bool{
+	return this.exhausted
+}
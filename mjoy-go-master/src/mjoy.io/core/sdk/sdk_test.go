@@ -5,6 +5,11 @@ import (
 	"mjoy.io/utils/database"
 	"mjoy.io/common/types"
 	"fmt"
+	"mjoy.io/core/state"
+	"bytes"
+	"mjoy.io/params"
+	"math/big"
+	"mjoy.io/core/blockchain/block"
 )
 
 
@@ -18,7 +23,12 @@ func TestDataStore(t *testing.T){
 		panic(err)
 	}
 
-	sdkHandler := NewTmpStatusManager(types.Hash{} , db)
+	stateDb , err := state.New(types.Hash{} , state.NewDatabase(db))
+	if err != nil {
+		panic(err)
+	}
+
+	sdkHandler := NewTmpStatusManager(db , stateDb , types.Address{})
 	contractAddr := types.Address{}
 	contractAddr[0] = 1
 
@@ -26,9 +36,147 @@ func TestDataStore(t *testing.T){
 	Sys_SetValue(sdkHandler , contractAddr , accountAddr[:] , []byte{1,2,3,4,5})
 	r := Sys_GetValue(sdkHandler , contractAddr , accountAddr[:] )
 	fmt.Println("r:" , r)
+	if !bytes.Equal(r , []byte{1,2,3,4,5}){
+		t.Fatalf("value mismatch: have %x" , r)
+	}
+
+	//keys longer than an address must not collide
+	long1 := bytes.Repeat([]byte{7} , 32)
+	long2 := append(bytes.Repeat([]byte{7} , 20) , bytes.Repeat([]byte{8} , 12)...)
+	Sys_SetValue(sdkHandler , contractAddr , long1 , []byte{1})
+	Sys_SetValue(sdkHandler , contractAddr , long2 , []byte{2})
+	if v := Sys_GetValue(sdkHandler , contractAddr , long1); !bytes.Equal(v , []byte{1}){
+		t.Fatalf("long key value mismatch: have %x" , v)
+	}
+}
+
+
+
+
+
+
+func TestResourceMeter(t *testing.T){
+	db , _ := database.OpenMemDB()
+	stateDb , _ := state.New(types.Hash{} , state.NewDatabase(db))
+	sdkHandler := NewTmpStatusManager(db , stateDb , types.Address{})
+
+	limit := params.StorageWriteResourceCost + 2 * params.StorageByteResourceCost + params.StorageReadResourceCost
+	meter := NewResourceMeter(limit)
+	sdkHandler.SetMeter(meter)
+
+	contractAddr := types.Address{}
+	if err := Sys_SetValue(sdkHandler , contractAddr , []byte{1} , []byte{2});err != nil {
+		t.Fatal(err)
+	}
+	if v := Sys_GetValue(sdkHandler , contractAddr , []byte{1});!bytes.Equal(v , []byte{2}){
+		t.Fatalf("value mismatch: have %x" , v)
+	}
+	if meter.Used() != limit || meter.Exhausted() {
+		t.Fatalf("meter mismatch: used %d of %d" , meter.Used() , limit)
+	}
+
+	if err := Sys_SetValue(sdkHandler , contractAddr , []byte{1} , []byte{3});err != ErrResourceExhausted {
+		t.Fatalf("error mismatch: have %v, want %v" , err , ErrResourceExhausted)
+	}
+	if !meter.Exhausted() || meter.Used() != limit {
+		t.Fatalf("meter should be exhausted: used %d of %d" , meter.Used() , limit)
+	}
+
+	//without meter nothing is charged
+	sdkHandler.SetMeter(nil)
+	if err := Sys_SetValue(sdkHandler , contractAddr , []byte{1} , []byte{3});err != nil {
+		t.Fatal(err)
+	}
 }
 
+func TestSnapshotRevert(t *testing.T){
+	db , _ := database.OpenMemDB()
+	stateDb , _ := state.New(types.Hash{} , state.NewDatabase(db))
+	sdkHandler := NewTmpStatusManager(db , stateDb , types.Address{})
 
+	contractAddr := types.Address{1}
+	Sys_SetValue(sdkHandler , contractAddr , []byte{1} , []byte{1})
 
+	outer := sdkHandler.Snapshot()
+	Sys_SetValue(sdkHandler , contractAddr , []byte{1} , []byte{2})
+	stateDb.SetNonce(contractAddr , 5)
 
+	inner := sdkHandler.Snapshot()
+	Sys_SetValue(sdkHandler , contractAddr , []byte{1} , []byte{3})
+	Sys_SetValue(sdkHandler , types.Address{2} , []byte{2} , []byte{4})
 
+	sdkHandler.RevertToSnapshot(inner)
+	if v := Sys_GetValue(sdkHandler , contractAddr , []byte{1});!bytes.Equal(v , []byte{2}){
+		t.Fatalf("value after inner revert mismatch: have %x, want 02" , v)
+	}
+	if v := Sys_GetValue(sdkHandler , types.Address{2} , []byte{2});v != nil {
+		t.Fatalf("value set after the snapshot survived: %x" , v)
+	}
+	if stateDb.GetNonce(contractAddr) != 5 {
+		t.Fatal("state changed before the inner snapshot reverted")
+	}
+
+	sdkHandler.RevertToSnapshot(outer)
+	if v := Sys_GetValue(sdkHandler , contractAddr , []byte{1});!bytes.Equal(v , []byte{1}){
+		t.Fatalf("value after outer revert mismatch: have %x, want 01" , v)
+	}
+	if stateDb.GetNonce(contractAddr) != 0 {
+		t.Fatal("state not reverted with the outer snapshot")
+	}
+}
+
+func TestExecutionContext(t *testing.T){
+	db , _ := database.OpenMemDB()
+	stateDb , _ := state.New(types.Hash{} , state.NewDatabase(db))
+	sdkHandler := NewTmpStatusManager(db , stateDb , types.Address{})
+
+	if Sys_GetBlockNumber(sdkHandler) != nil || Sys_GetTimestamp(sdkHandler) != nil {
+		t.Fatal("block context out of a block")
+	}
+	if _ , ok := Sys_GetTxSender(sdkHandler);ok {
+		t.Fatal("sender out of a transaction")
+	}
+	if _ , ok := Sys_GetCaller(sdkHandler);ok {
+		t.Fatal("caller out of a transaction")
+	}
+
+	header := &block.Header{
+		ParentHash:types.Hash{9} ,
+		Number:types.NewBigInt(*big.NewInt(12)) ,
+		Time:types.NewBigInt(*big.NewInt(1530000000)) ,
+	}
+	sdkHandler.SetBlockContext(NewBlockContext(&params.ChainConfig{ChainId:big.NewInt(3)} , header))
+	sender := types.Address{5}
+	sdkHandler.SetTxContext(&TxContext{Hash:types.Hash{7} , Sender:sender})
+	sdkHandler.SetActionIndex(2)
+
+	if n := Sys_GetBlockNumber(sdkHandler);n.Int64() != 12 {
+		t.Errorf("block number mismatch: have %v" , n)
+	}
+	//the returned numbers are copies
+	Sys_GetBlockNumber(sdkHandler).SetInt64(0)
+	if n := Sys_GetBlockNumber(sdkHandler);n.Int64() != 12 {
+		t.Errorf("block number changed through the returned value: %v" , n)
+	}
+	if ts := Sys_GetTimestamp(sdkHandler);ts.Int64() != 1530000000 {
+		t.Errorf("timestamp mismatch: have %v" , ts)
+	}
+	if h := Sys_GetParentHash(sdkHandler);h != header.ParentHash {
+		t.Errorf("parent hash mismatch: have %x" , h)
+	}
+	if id := Sys_GetChainId(sdkHandler);id.Int64() != 3 {
+		t.Errorf("chain id mismatch: have %v" , id)
+	}
+	if h , ok := Sys_GetTxHash(sdkHandler);!ok || h != (types.Hash{7}) {
+		t.Errorf("tx hash mismatch: have %x" , h)
+	}
+	if s , ok := Sys_GetTxSender(sdkHandler);!ok || s != sender {
+		t.Errorf("tx sender mismatch: have %x" , s)
+	}
+	if c , ok := Sys_GetCaller(sdkHandler);!ok || c != sender {
+		t.Errorf("caller of an action mismatch: have %x" , c)
+	}
+	if i := Sys_GetActionIndex(sdkHandler);i != 2 {
+		t.Errorf("action index mismatch: have %d" , i)
+	}
+}
@@ -3,6 +3,8 @@ package sdk
 import (
 	"mjoy.io/common/types"
 	"errors"
+	"math/big"
+	"mjoy.io/params"
 )
 
 func Sys_GetValue(handlePtr *TmpStatusManager , contractAddress types.Address , key []byte)[]byte{
@@ -30,3 +32,155 @@ func Sys_GetCoinbase(handlePtr *TmpStatusManager)*types.Address{
 	}
 	return &handlePtr.coinBase
 }
+
+func Sys_GetCode(handlePtr *TmpStatusManager , contractAddress types.Address)[]byte{
+	//nil check
+	if nil == handlePtr {
+		return nil
+	}
+	return handlePtr.GetCode(contractAddress)
+}
+
+//Sys_ChargeResource charge units to the resource meter of the running transaction
+func Sys_ChargeResource(handlePtr *TmpStatusManager , units uint64)error{
+	//nil check
+	if nil == handlePtr {
+		return errors.New("ptr")
+	}
+	if meter := handlePtr.Meter();meter != nil {
+		return meter.Charge(units)
+	}
+	return nil
+}
+
+//Sys_GetResourceRemaining returns the units left to the running transaction,ok is false if it is not metered
+func Sys_GetResourceRemaining(handlePtr *TmpStatusManager)(remaining uint64 , ok bool){
+	//nil check
+	if nil == handlePtr {
+		return 0 , false
+	}
+	if meter := handlePtr.Meter();meter != nil {
+		return meter.Remaining() , true
+	}
+	return 0 , false
+}
+
+//Sys_Call calls contract with params for the caller contract and returns the data of the called contract.
+//If the call fails only the writes of the called contract are reverted
+func Sys_Call(handlePtr *TmpStatusManager , caller , contract types.Address , params []byte)([]byte , error){
+	//nil check
+	if nil == handlePtr {
+		return nil , errors.New("ptr")
+	}
+	return handlePtr.Call(caller , contract , params)
+}
+
+//Sys_GetCaller returns the contract which called the running contract,or the signed sender of the transaction
+//if the contract runs an action.ok is false if there is no caller,contracts must not act for anyone then
+func Sys_GetCaller(handlePtr *TmpStatusManager)(caller types.Address , ok bool){
+	//nil check
+	if nil == handlePtr {
+		return types.Address{} , false
+	}
+	return handlePtr.Caller()
+}
+
+//Sys_EmitEvent emits the event name of the contract with indexed topics and opaque data
+func Sys_EmitEvent(handlePtr *TmpStatusManager , contractAddress types.Address , name string , topics []types.Hash , data []byte)error{
+	//nil check
+	if nil == handlePtr {
+		return errors.New("ptr")
+	}
+	return handlePtr.EmitEvent(contractAddress , name , topics , data)
+}
+
+//Sys_GetBlockNumber returns the number of the block being applied,nil out of a block
+func Sys_GetBlockNumber(handlePtr *TmpStatusManager)*big.Int{
+	//nil check
+	if nil == handlePtr {
+		return nil
+	}
+	if ctx := handlePtr.BlockContext();ctx != nil {
+		return new(big.Int).Set(ctx.Number)
+	}
+	return nil
+}
+
+//Sys_GetTimestamp returns the time of the block being applied,nil out of a block
+func Sys_GetTimestamp(handlePtr *TmpStatusManager)*big.Int{
+	//nil check
+	if nil == handlePtr {
+		return nil
+	}
+	if ctx := handlePtr.BlockContext();ctx != nil {
+		return new(big.Int).Set(ctx.Time)
+	}
+	return nil
+}
+
+func Sys_GetParentHash(handlePtr *TmpStatusManager)types.Hash{
+	//nil check
+	if nil == handlePtr {
+		return types.Hash{}
+	}
+	if ctx := handlePtr.BlockContext();ctx != nil {
+		return ctx.ParentHash
+	}
+	return types.Hash{}
+}
+
+//Sys_GetRules returns the fork rules of the block being applied,ok is false out of a block
+func Sys_GetRules(handlePtr *TmpStatusManager)(rules params.Rules , ok bool){
+	//nil check
+	if nil == handlePtr {
+		return params.Rules{} , false
+	}
+	if ctx := handlePtr.BlockContext();ctx != nil {
+		return ctx.Rules , true
+	}
+	return params.Rules{} , false
+}
+
+func Sys_GetChainId(handlePtr *TmpStatusManager)*big.Int{
+	//nil check
+	if nil == handlePtr {
+		return nil
+	}
+	if ctx := handlePtr.BlockContext();ctx != nil {
+		return new(big.Int).Set(ctx.ChainId)
+	}
+	return nil
+}
+
+//Sys_GetTxHash returns the hash of the transaction being applied,ok is false out of a transaction
+func Sys_GetTxHash(handlePtr *TmpStatusManager)(hash types.Hash , ok bool){
+	//nil check
+	if nil == handlePtr {
+		return types.Hash{} , false
+	}
+	if ctx := handlePtr.TxContext();ctx != nil {
+		return ctx.Hash , true
+	}
+	return types.Hash{} , false
+}
+
+//Sys_GetTxSender returns the signer of the transaction being applied,ok is false out of a transaction
+func Sys_GetTxSender(handlePtr *TmpStatusManager)(sender types.Address , ok bool){
+	//nil check
+	if nil == handlePtr {
+		return types.Address{} , false
+	}
+	if ctx := handlePtr.TxContext();ctx != nil {
+		return ctx.Sender , true
+	}
+	return types.Address{} , false
+}
+
+//Sys_GetActionIndex returns the index of the running action inside the transaction
+func Sys_GetActionIndex(handlePtr *TmpStatusManager)int{
+	//nil check
+	if nil == handlePtr {
+		return 0
+	}
+	return handlePtr.ActionIndex()
+}
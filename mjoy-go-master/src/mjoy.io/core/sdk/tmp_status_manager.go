@@ -6,6 +6,9 @@ import (
 	"mjoy.io/utils/database"
 	"mjoy.io/core/state"
 	"mjoy.io/utils/crypto"
+	"mjoy.io/params"
+	"fmt"
+	"mjoy.io/core/transaction"
 )
 
 /*
@@ -24,7 +27,33 @@ type TmpStatusManager struct {
 	db database.IDatabaseGetter
 	state *state.StateDB
 	coinBase types.Address
+	meter *ResourceMeter    //meter of the running transaction,nil means no metering
+	block *BlockContext    //block being applied,nil for queries out of a block
+	tx *TxContext    //transaction being applied,nil out of a transaction
+	actionIndex int
 	TmpConTracts map[types.Address]*TmpStatusNode
+
+	journal []tmpChange    //every SetValue,so a failed transaction can be undone
+	validRevisions []tmpRevision
+
+	caller Caller    //runs the contracts called by Sys_Call,nil means calls are not supported
+	calls []callFrame    //the running calls,the innermost is the last
+	callWrites []CallWrite    //storage writes of the called contracts
+	traces []*transaction.CallTrace
+}
+
+//tmpChange is the value a key had before a SetValue
+type tmpChange struct {
+	node *TmpStatusNode
+	key TmpKey
+	prev []byte
+	existed bool
+}
+
+type tmpRevision struct {
+	id int    //the StateDB snapshot id
+	journalIndex int
+	callWritesIndex int
 }
 
 func NewTmpStatusManager(db database.IDatabaseGetter, state *state.StateDB , coinbase types.Address)*TmpStatusManager{
@@ -37,11 +66,31 @@ func NewTmpStatusManager(db database.IDatabaseGetter, state *state.StateDB , coi
 	return t
 }
 
+//SetMeter set the resource meter of the transaction being applied,storage access is charged to it
+func (this *TmpStatusManager)SetMeter(meter *ResourceMeter){
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.meter = meter
+}
+
+func (this *TmpStatusManager)Meter()*ResourceMeter{
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.meter
+}
+
 //SetValue always set into memery
 func (this *TmpStatusManager)SetValue(contractAddress types.Address , key []byte , value []byte)error{
 	this.mu.Lock()
 	defer this.mu.Unlock()
 
+	if this.meter != nil {
+		cost := params.StorageWriteResourceCost + uint64(len(key) + len(value)) * params.StorageByteResourceCost
+		if err := this.meter.Charge(cost);err != nil {
+			return err
+		}
+	}
+
 	//step 1: get a statusNode from manager
 	statusNode := this.ExistContract(contractAddress)
 	if statusNode == nil {
@@ -50,19 +99,68 @@ func (this *TmpStatusManager)SetValue(contractAddress types.Address , key []byte
 	}
 
 	//step 2: make TmpKey
-	tmpKey := TmpKey{contractAddress:contractAddress , key:types.BytesToAddress(key)}
+	tmpKey := TmpKey{contractAddress:contractAddress , key:string(key)}
 
-	//step 3:set value
+	//step 3:journal the old value and set value
+	prev , existed := statusNode.Modified[tmpKey]
+	this.journal = append(this.journal , tmpChange{node:statusNode , key:tmpKey , prev:prev , existed:existed})
 	statusNode.SetValue(tmpKey , value)
 	return nil
 }
 
+//Snapshot takes a snapshot of the StateDB and of the values set since,the returned id is the StateDB's
+//snapshot id,so both are reverted together by RevertToSnapshot
+func (this *TmpStatusManager)Snapshot()int{
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	id := this.state.Snapshot()
+	this.validRevisions = append(this.validRevisions , tmpRevision{id:id , journalIndex:len(this.journal) , callWritesIndex:len(this.callWrites)})
+	return id
+}
+
+//RevertToSnapshot reverts the StateDB and drops all values set after the snapshot was taken
+func (this *TmpStatusManager)RevertToSnapshot(id int){
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	idx := len(this.validRevisions) - 1
+	for idx >= 0 && this.validRevisions[idx].id != id {
+		idx--
+	}
+	if idx < 0 {
+		panic(fmt.Errorf("revision id %v cannot be reverted" , id))
+	}
+	this.state.RevertToSnapshot(id)
+
+	journalIndex := this.validRevisions[idx].journalIndex
+	for i := len(this.journal) - 1;i >= journalIndex;i-- {
+		change := this.journal[i]
+		if change.existed {
+			change.node.Modified[change.key] = change.prev
+		}else{
+			delete(change.node.Modified , change.key)
+		}
+	}
+	this.journal = this.journal[:journalIndex]
+	if callWritesIndex := this.validRevisions[idx].callWritesIndex;callWritesIndex < len(this.callWrites){
+		this.callWrites = this.callWrites[:callWritesIndex]
+	}
+	this.validRevisions = this.validRevisions[:idx]
+}
+
 
 func (this *TmpStatusManager)GetValue(contractAddress types.Address , key []byte)[]byte{
 	this.mu.RLock()
 	defer this.mu.RUnlock()
 
-	tmpKey := TmpKey{contractAddress:contractAddress , key:types.BytesToAddress(key)}
+	if this.meter != nil {
+		if err := this.meter.Charge(params.StorageReadResourceCost);err != nil {
+			return nil
+		}
+	}
+
+	tmpKey := TmpKey{contractAddress:contractAddress , key:string(key)}
 
 	tmpNode := this.ExistContract(contractAddress)
 	if tmpNode != nil {
@@ -85,6 +183,18 @@ func (this *TmpStatusManager)GetValue(contractAddress types.Address , key []byte
 }
 
 
+//GetCode returns the code deployed at the contract address
+func (this *TmpStatusManager)GetCode(contractAddress types.Address)[]byte{
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	if this.state == nil {
+		return nil
+	}
+	return this.state.GetCode(contractAddress)
+}
+
+
 //TmpStatusManager basic functions,should not control the mu(lock), the lock should hold by Upper caller
 
 
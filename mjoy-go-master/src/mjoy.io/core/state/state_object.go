@@ -32,6 +32,9 @@ import (
 
 var emptyCodeHash = crypto.Keccak256(nil)
 
+// emptyRoot is the root of a storage trie without entries
+var emptyRoot = types.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
 type Code []byte
 
 func (self Code) String() string {
@@ -93,9 +96,18 @@ type stateObject struct {
 	onDirty   func(addr types.Address) // Callback method to mark a state object newly dirty
 }
 
-// empty returns whether the account is considered empty.
+// empty returns whether the account is considered empty. An account holding
+// storage, like the one of an inner contract, is never empty.
 func (s *stateObject) empty() bool {
-	return s.data.Nonce == 0 && bytes.Equal(s.data.CodeHash, emptyCodeHash)
+	return s.data.Nonce == 0 && bytes.Equal(s.data.CodeHash, emptyCodeHash) && !s.hasStorage()
+}
+
+// hasStorage returns whether the account has storage in its trie or pending writes.
+func (s *stateObject) hasStorage() bool {
+	if len(s.dirtyStorage) > 0 {
+		return true
+	}
+	return s.data.Root != (types.Hash{}) && s.data.Root != emptyRoot
 }
 
 //go:generate msgp
@@ -393,12 +393,16 @@ func (db *StateDB) ForEachStorage(addr types.Address, cb func(key, value types.H
 		cb(h, value)
 	}
 
-	it := trie.NewIterator(so.getTrie(db.db).NodeIterator(nil))
+	tr := so.getTrie(db.db)
+	it := trie.NewIterator(tr.NodeIterator(nil))
 	for it.Next() {
 		// ignore cached values
-		key := types.BytesToHash(db.trie.GetKey(it.Key))
+		key := types.BytesToHash(tr.GetKey(it.Key))
 		if _, ok := so.cachedStorage[key]; !ok {
-			cb(key, types.BytesToHash(it.Value))
+			// the values are msgp encoded by updateTrie
+			var value types.Hash
+			msgp.Decode(bytes.NewReader(it.Value), &value)
+			cb(key, value)
 		}
 	}
 }
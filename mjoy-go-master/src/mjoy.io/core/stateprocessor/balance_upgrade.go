@@ -0,0 +1,96 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: balance_upgrade.go
+// @Date: 2018/07/02 10:21:35
+////////////////////////////////////////////////////////////////////////////////
+
+package stateprocessor
+
+import (
+	"fmt"
+
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/interpreter"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/state"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/database"
+)
+
+// the cache key prefix of the upgraded balance values, the storage keys of the
+// contract are only known hashed
+const balanceUpgradePrefix = "balanceUpgrade-"
+
+// UpgradeBalances rewrites every balance record of the balancetransfer contract as an
+// arbitrary precision number and marks the contract so that later writes use that format.
+// It only acts at the end of the BigBalance fork block, after all of its transactions, and
+// must be run by both the processor and the producer so they reach the same state root.
+func UpgradeBalances(config *params.ChainConfig, header *block.Header, statedb *state.StateDB, db database.IDatabaseGetter, cache *DbCache) error {
+	if !config.IsBigBalanceFork(&header.Number.IntVal) {
+		return nil
+	}
+	address := balancetransfer.BalanceTransferAddress
+	formatKey := append(address.Bytes(), balancetransfer.BalanceFormatKey...)
+	formatKeyHash := crypto.Keccak256Hash(formatKey)
+
+	// values written by this block are not in the database yet
+	pending := make(map[types.Hash][]byte)
+	for _, result := range cache.Cache {
+		if result.Address == address {
+			pending[types.BytesToHash(result.Key)] = result.Val
+		}
+	}
+
+	records := make(map[types.Hash]types.Hash)
+	statedb.ForEachStorage(address, func(key, value types.Hash) bool {
+		if key != formatKeyHash && (value != types.Hash{}) {
+			records[key] = value
+		}
+		return true
+	})
+
+	upgraded := 0
+	for key, valueHash := range records {
+		data, ok := pending[valueHash]
+		if !ok {
+			var err error
+			if data, err = db.Get(valueHash[:]); err != nil {
+				return fmt.Errorf("balance upgrade: missing value %x of key %x: %v", valueHash, key, err)
+			}
+		}
+		newData, changed, err := balancetransfer.UpgradeBalanceRecord(data)
+		if err != nil {
+			return fmt.Errorf("balance upgrade: key %x: %v", key, err)
+		}
+		if !changed {
+			continue
+		}
+		newHash := crypto.Keccak256Hash(newData)
+		statedb.SetState(address, key, newHash)
+		cache.Cache[balanceUpgradePrefix+string(key[:])] = interpreter.MemDatabase{Address: address, Key: newHash.Bytes(), Val: newData}
+		upgraded++
+	}
+
+	formatHash := crypto.Keccak256Hash(balancetransfer.BalanceFormatBig)
+	statedb.SetState(address, formatKeyHash, formatHash)
+	cache.Cache[string(formatKey)] = interpreter.MemDatabase{Address: address, Key: formatHash.Bytes(), Val: balancetransfer.BalanceFormatBig}
+
+	logger.Info("Upgraded balance records", "number", header.Number.IntVal.String(), "upgraded", upgraded)
+	return nil
+}
@@ -0,0 +1,73 @@
+package stateprocessor
+
+import (
+	"math/big"
+	"testing"
+
+	"mjoy.io/common/types"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/interpreter"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/state"
+	"mjoy.io/params"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/database"
+)
+
+func balanceKeyHash(owner types.Address) types.Hash {
+	return crypto.Keccak256Hash(append(balancetransfer.BalanceTransferAddress.Bytes(), owner[:]...))
+}
+
+func TestUpgradeBalances(t *testing.T) {
+	db, _ := database.OpenMemDB()
+	statedb, _ := state.New(types.Hash{}, state.NewDatabase(db))
+	address := balancetransfer.BalanceTransferAddress
+	committed, pending := types.Address{1}, types.Address{2}
+
+	// a balance of an earlier block, in the trie and the database
+	oldVal := []byte(`{"amount":1000}`)
+	oldHash := crypto.Keccak256Hash(oldVal)
+	db.Put(oldHash[:], oldVal)
+	statedb.SetState(address, balanceKeyHash(committed), oldHash)
+	root, err := statedb.CommitTo(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb, _ = state.New(root, state.NewDatabase(db))
+
+	// a balance written by the fork block itself, only in the cache
+	cache := &DbCache{Cache: make(map[string]interpreter.MemDatabase)}
+	newVal := []byte(`{"amount":7}`)
+	newHash := crypto.Keccak256Hash(newVal)
+	statedb.SetState(address, balanceKeyHash(pending), newHash)
+	cache.Cache[string(append(address.Bytes(), pending[:]...))] = interpreter.MemDatabase{Address: address, Key: newHash.Bytes(), Val: newVal}
+
+	config := &params.ChainConfig{ChainId: big.NewInt(1), BigBalanceBlock: big.NewInt(5)}
+	header := &block.Header{Number: types.NewBigInt(*big.NewInt(4))}
+	if err := UpgradeBalances(config, header, statedb, db, cache); err != nil {
+		t.Fatal(err)
+	}
+	if statedb.GetState(address, balanceKeyHash(committed)) != oldHash {
+		t.Fatal("balances upgraded before the fork block")
+	}
+
+	header.Number = types.NewBigInt(*big.NewInt(5))
+	if err := UpgradeBalances(config, header, statedb, db, cache); err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[types.Hash][]byte)
+	for _, result := range cache.Cache {
+		values[types.BytesToHash(result.Key)] = result.Val
+	}
+	want := map[types.Address]string{committed: `{"amount":"1000"}`, pending: `{"amount":"7"}`}
+	for owner, data := range want {
+		hash := statedb.GetState(address, balanceKeyHash(owner))
+		if string(values[hash]) != data {
+			t.Errorf("balance of %x mismatch: have %s, want %s", owner, values[hash], data)
+		}
+	}
+	formatKey := crypto.Keccak256Hash(append(address.Bytes(), balancetransfer.BalanceFormatKey...))
+	if statedb.GetState(address, formatKey) != crypto.Keccak256Hash(balancetransfer.BalanceFormatBig) {
+		t.Error("balance format not marked")
+	}
+}
@@ -100,7 +100,7 @@ func (p *StateProcessor) Process(blk *block.Block, statedb *state.StateDB, db da
 		statedb.Prepare(tx.Hash(), blk.Hash(), i)
 		receipt, err := ApplyTransaction(p.config, nil, statedb, header, tx, dbcache , sysparam)
 		if err != nil {
-			logger.Errorf("ApplyTransacton Wrong.....:",err.Error())
+			logger.Errorf("ApplyTransacton Wrong.....:%s",err.Error())
 
 			return  nil, nil, nil, err
 		}
@@ -108,16 +108,32 @@ func (p *StateProcessor) Process(blk *block.Block, statedb *state.StateDB, db da
 		allLogs = append(allLogs, receipt.Logs...)
 	}
 
+	if err := UpgradeBalances(p.config, header, statedb, db, dbcache); err != nil {
+		logger.Error("Process: balance upgrade failed", err)
+		return nil, nil, nil, err
+	}
+
 
-	// TODO: need to be compeleted, now skip this step
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
 	if p.engine != nil {
-		p.engine.Finalize(p.cs, header, statedb, blk.Transactions(), receipts, false)
+		rewards := BlockRewards(p.config, header, sysparam, dbcache)
+		if _, err := p.engine.Finalize(p.cs, header, statedb, blk.Transactions(), receipts, rewards, false); err != nil {
+			logger.Error("Process: finalize failed", err)
+			return nil, nil, nil, err
+		}
 	}
 
 	return  dbcache, receipts, allLogs, nil
 }
 
+// BlockRewards returns the state the engine pays the rewards of the block with, nil
+// before the Reward fork, where no rewards are paid.
+func BlockRewards(config *params.ChainConfig, header *block.Header, sysparam *intertypes.SystemParams, cache *DbCache) *consensus.RewardState {
+	if !config.IsReward(&header.Number.IntVal) {
+		return nil
+	}
+	return &consensus.RewardState{SysParams: sysparam, Cache: cache.Cache}
+}
 
 // ApplyTransaction attempts to apply a transaction to the given state database
 // and uses the input parameters for its environment. It returns the receipt
@@ -133,7 +149,12 @@ func ApplyTransaction(config *params.ChainConfig, author *types.Address, statedb
 	if author == nil {
 		author = &header.BlockProducer
 	}
-	_, failed, err := ApplyMessage(statedb, msg, *author, cache, header,sysparam)
+	// the contracts read the block and the signed sender from the sdk
+	sysparam.SdkHandler.SetBlockContext(sdk.NewBlockContext(config, header))
+	sysparam.SdkHandler.SetTxContext(&sdk.TxContext{Hash: tx.Hash(), Sender: msg.From()})
+	_, resourceUsed, failed, err := ApplyMessage(statedb, msg, *author, cache, header,sysparam)
+	sysparam.SdkHandler.SetTxContext(nil)
+	calls := sysparam.SdkHandler.TakeCallTraces()
 	if err != nil {
 		return nil, err
 	}
@@ -144,12 +165,18 @@ func ApplyTransaction(config *params.ChainConfig, author *types.Address, statedb
 	// based on the mip phase, we're passing wether the root touch-delete accounts.
 	receipt := transaction.NewReceipt(failed)
 	receipt.TxHash = tx.Hash()
+	receipt.ResourceUsed = resourceUsed
+	receipt.Calls = calls
 	// if the transaction created a contract, store the creation address in the receipt.
 	if len(tx.Data.Actions) == 2 && tx.Data.Actions[1].Address == nil {
 		receipt.ContractAddress = crypto.CreateAddress(msg.From(), tx.Nonce())
 	}
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = statedb.GetLogs(tx.Hash())
+	// the events emitted by contracts do not know the block
+	for _, log := range receipt.Logs {
+		log.BlockNumber = header.Number.IntVal.Uint64()
+	}
 
 	topics := []bloom.BloomByte{}
 	for _, log := range receipt.Logs {
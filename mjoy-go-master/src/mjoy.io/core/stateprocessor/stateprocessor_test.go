@@ -0,0 +1,175 @@
+package stateprocessor
+
+import (
+	"math/big"
+	"testing"
+
+	"mjoy.io/common/types"
+	"mjoy.io/core"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/interpreter"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/sdk"
+	"mjoy.io/core/state"
+	"mjoy.io/core/transaction"
+	"mjoy.io/params"
+	"mjoy.io/utils/bloom"
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/database"
+)
+
+// TestMixedFailures applies a block whose second transaction fails half way, the
+// transfer its first action made must be reverted while the fee and the nonce stay.
+func TestMixedFailures(t *testing.T) {
+	db, _ := database.OpenMemDB()
+	statedb, _ := state.New(types.Hash{}, state.NewDatabase(db))
+	config := &params.ChainConfig{ChainId: big.NewInt(1)}
+	header := &block.Header{Number: types.NewBigInt(*big.NewInt(1)), Time: types.NewBigInt(*big.NewInt(1000)), BlockProducer: types.Address{9}}
+
+	key, _ := crypto.GenerateKey()
+	alice := crypto.PubkeyToAddress(key.PublicKey)
+	bob, carol := types.Address{2}, types.Address{3}
+
+	sdkHandler := sdk.NewTmpStatusManager(db, statedb, header.BlockProducer)
+	sysparam := intertypes.MakeSystemParams(sdkHandler, interpreter.NewVm())
+	funds, _ := balancetransfer.EncodeBalance(big.NewInt(10000000), true)
+	sdkHandler.SetValue(balancetransfer.BalanceTransferAddress, alice[:], funds)
+
+	transfer := func(to types.Address, amount int) transaction.Action {
+		return transaction.MakeAction(balancetransfer.BalanceTransferAddress, balancetransfer.MakaBalanceTransferParam(to, amount))
+	}
+	signer := transaction.MakeSigner(config, &header.Number.IntVal)
+	txs := []transaction.ActionSlice{
+		{transfer(bob, 100)},
+		{transfer(bob, 50), transfer(carol, 100000000)},
+		{transfer(carol, 10)},
+	}
+	cache := &DbCache{Cache: make(map[string]interpreter.MemDatabase)}
+	var receipts transaction.Receipts
+	for i, actions := range txs {
+		tx, err := transaction.SignTx(transaction.NewTransaction(uint64(i), actions), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		statedb.Prepare(tx.Hash(), types.Hash{}, i)
+		receipt, err := ApplyTransaction(config, nil, statedb, header, tx, cache, sysparam)
+		if err != nil {
+			t.Fatalf("tx %d: %v", i, err)
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	for i, want := range []uint{transaction.ReceiptStatusSuccessful, transaction.ReceiptStatusFailed, transaction.ReceiptStatusSuccessful} {
+		if receipts[i].Status != want {
+			t.Errorf("tx %d: status mismatch: have %d, want %d", i, receipts[i].Status, want)
+		}
+	}
+	if len(receipts[1].Logs) != 0 {
+		t.Errorf("failed tx left %d logs", len(receipts[1].Logs))
+	}
+
+	// the transfer event is logged with the storage writes and can be found by the bloom
+	transferID := abi.EventID(balancetransfer.Transfer_Event)
+	for _, i := range []int{0, 2} {
+		var events []*transaction.Log
+		for _, log := range receipts[i].Logs {
+			if log.Topics[0] == transferID {
+				events = append(events, log)
+			}
+		}
+		if len(events) != 1 || events[0].Address != balancetransfer.BalanceTransferAddress ||
+			events[0].Topics[1] != types.BytesToHash(alice[:]) || events[0].BlockNumber != 1 {
+			t.Fatalf("tx %d: transfer events mismatch: %v", i, events)
+		}
+		if !bloom.BloomLookup(receipts[i].Bloom, transferID) || !bloom.BloomLookup(receipts[i].Bloom, events[0].Topics[2]) {
+			t.Errorf("tx %d: transfer event not in bloom", i)
+		}
+	}
+	writeID := abi.EventID(StorageWriteEvent)
+	for _, log := range receipts[0].Logs {
+		if log.Topics[0] == writeID && (len(log.Data) != 2 || log.Topics[1] != crypto.Keccak256Hash(log.Data[0])) {
+			t.Errorf("storage write log mismatch: %v", log)
+		}
+	}
+	if nonce := statedb.GetNonce(alice); nonce != 3 {
+		t.Errorf("nonce mismatch: have %d, want 3", nonce)
+	}
+
+	for owner, want := range map[types.Address]int64{bob: 100, carol: 10} {
+		have, err := balancetransfer.BalanceOf(sysparam, owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have.Int64() != want {
+			t.Errorf("balance of %x mismatch: have %v, want %d", owner, have, want)
+		}
+	}
+	paid := new(big.Int).SetInt64(10000000 - 110)
+	for _, receipt := range receipts {
+		paid.Sub(paid, balancetransfer.ResourceFee(receipt.ResourceUsed))
+	}
+	if have, _ := balancetransfer.BalanceOf(sysparam, alice); have.Cmp(paid) != 0 {
+		t.Errorf("balance of alice mismatch: have %v, want %v", have, paid)
+	}
+
+	// the trie and the value cache agree with the contract values
+	for _, owner := range []types.Address{alice, bob, carol} {
+		storageKey := append(balancetransfer.BalanceTransferAddress.Bytes(), owner[:]...)
+		cached, ok := cache.Cache[string(storageKey)]
+		if !ok {
+			t.Fatalf("balance of %x not cached", owner)
+		}
+		value := sdkHandler.GetValue(balancetransfer.BalanceTransferAddress, owner[:])
+		if string(cached.Val) != string(value) {
+			t.Errorf("cached balance of %x mismatch: have %s, want %s", owner, cached.Val, value)
+		}
+		if statedb.GetState(balancetransfer.BalanceTransferAddress, crypto.Keccak256Hash(storageKey)) != crypto.Keccak256Hash(value) {
+			t.Errorf("trie balance of %x mismatch", owner)
+		}
+	}
+}
+
+// TestValidityBounds applies transactions against the block number and time bounds
+// they declare, a time bound is never dropped for a header without time.
+func TestValidityBounds(t *testing.T) {
+	db, _ := database.OpenMemDB()
+	statedb, _ := state.New(types.Hash{}, state.NewDatabase(db))
+	config := &params.ChainConfig{ChainId: big.NewInt(1)}
+	now := params.TxBoundTimeThreshold + 1000
+	timed := &block.Header{Number: types.NewBigInt(*big.NewInt(10)), Time: types.NewBigInt(*new(big.Int).SetUint64(now))}
+	untimed := &block.Header{Number: types.NewBigInt(*big.NewInt(10))}
+
+	key, _ := crypto.GenerateKey()
+	sdkHandler := sdk.NewTmpStatusManager(db, statedb, types.Address{})
+	sysparam := intertypes.MakeSystemParams(sdkHandler, interpreter.NewVm())
+	signer := transaction.MakeSigner(config, big.NewInt(10))
+	action := transaction.MakeAction(balancetransfer.BalanceTransferAddress, balancetransfer.MakaBalanceTransferParam(types.Address{2}, 1))
+
+	tests := []struct {
+		header                 *block.Header
+		validAfter, validUntil uint64
+		err                    error
+	}{
+		{header: timed, validAfter: 11, err: core.ErrTxNotYetValid},
+		{header: timed, validUntil: 9, err: core.ErrTxExpired},
+		{header: timed, validAfter: now + 1, err: core.ErrTxNotYetValid},
+		{header: timed, validUntil: now - 1, err: core.ErrTxExpired},
+		{header: untimed, validAfter: 11, err: core.ErrTxNotYetValid},
+		{header: untimed, validUntil: 9, err: core.ErrTxExpired},
+		{header: untimed, validAfter: now - 1, err: core.ErrTxTimeBoundUnchecked},
+		{header: untimed, validUntil: now - 1, err: core.ErrTxTimeBoundUnchecked},
+	}
+	for i, test := range tests {
+		tx := transaction.NewBoundedTransaction(0, params.TxResourceLimit, test.validAfter, test.validUntil, transaction.ActionSlice{action})
+		tx, err := transaction.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache := &DbCache{Cache: make(map[string]interpreter.MemDatabase)}
+		if _, err := ApplyTransaction(config, nil, statedb, test.header, tx, cache, sysparam); err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
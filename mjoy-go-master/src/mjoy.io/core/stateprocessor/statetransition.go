@@ -29,6 +29,10 @@ import (
 	"mjoy.io/utils/crypto"
 	"mjoy.io/core/blockchain/block"
 	"mjoy.io/core/interpreter/intertypes"
+	"mjoy.io/core/interpreter/abi"
+	"mjoy.io/core/interpreter/balancetransfer"
+	"mjoy.io/core/sdk"
+	"mjoy.io/params"
 )
 
 /*
@@ -61,6 +65,9 @@ type Message interface {
 	From() types.Address
 	Actions()[]transaction.Action
 	Nonce() uint64
+	ResourceLimit() uint64
+	ValidAfter() uint64
+	ValidUntil() uint64
 	CheckNonce() bool
 }
 
@@ -78,7 +85,7 @@ func NewStateTransition(statedb *state.StateDB, msg Message, coinBase types.Addr
 
 // ApplyMessage computes the new state by applying the given message
 // against the old state within the environment.
-func ApplyMessage(statedb *state.StateDB, msg Message, coinBase types.Address, cache *DbCache,header *block.Header , sysparam *intertypes.SystemParams) ([]byte, bool, error) {
+func ApplyMessage(statedb *state.StateDB, msg Message, coinBase types.Address, cache *DbCache,header *block.Header , sysparam *intertypes.SystemParams) ([]byte, uint64, bool, error) {
 	return NewStateTransition(statedb, msg, coinBase, cache, header).TransitionDb(sysparam)
 }
 
@@ -94,6 +101,28 @@ func (st *StateTransition) preCheck() error {
 	msg := st.msg
 	sender := st.from()
 
+	if msg.ResourceLimit() > params.MaxTxResourceLimit {
+		return core.ErrResourceLimitTooHigh
+	}
+
+	// Make sure the block is within the validity bounds of this transaction, a bound
+	// in block time can't be checked against a header without time
+	if st.header != nil {
+		validAfter, validUntil := msg.ValidAfter(), msg.ValidUntil()
+		number, time := st.header.Number.IntVal.Uint64(), uint64(0)
+		if st.header.Time != nil {
+			time = st.header.Time.IntVal.Uint64()
+		} else if validAfter >= params.TxBoundTimeThreshold || validUntil >= params.TxBoundTimeThreshold {
+			return core.ErrTxTimeBoundUnchecked
+		}
+		if transaction.Premature(validAfter, number, time) {
+			return core.ErrTxNotYetValid
+		}
+		if transaction.Expired(validUntil, number, time) {
+			return core.ErrTxExpired
+		}
+	}
+
 	// Make sure this transaction's nonce is correct
 	if msg.CheckNonce() {
 		nonce := st.statedb.GetNonce(sender)
@@ -107,94 +136,166 @@ func (st *StateTransition) preCheck() error {
 }
 
 
-// make log  function
-func MakeLog(address types.Address, results interpreter.ActionResults, blockNumber uint64) *transaction.Log {
-	topics := []types.Hash{}
-	data := [][]byte{}
+// StorageWriteEvent is the event logged for every storage write of an action, the
+// topic after the event id is the hash of the key, the data holds the key and the value.
+const StorageWriteEvent = "StorageWrite"
 
+// MakeLogs makes a log of StorageWriteEvent for every storage write in results, the
+// data returned by an action has no key and is not logged.
+func MakeLogs(address types.Address, results interpreter.ActionResults, blockNumber uint64) []*transaction.Log {
+	logs := make([]*transaction.Log, 0, len(results))
 	for _, result := range results {
-		topics = append(topics,types.BytesToHash(result.Key))
-		data = append(data, result.Key)
-	}
-
-	return &transaction.Log{
-		Address:     address,
-		Topics:      topics,
-		Data:        data,
-		BlockNumber:   blockNumber,
+		if result.Key == nil {
+			continue
+		}
+		logs = append(logs, &transaction.Log{
+			Address:     address,
+			Topics:      []types.Hash{abi.EventID(StorageWriteEvent), crypto.Keccak256Hash(result.Key)},
+			Data:        [][]byte{result.Key, result.Val},
+			BlockNumber: blockNumber,
+		})
 	}
+	return logs
 }
 
 // TransitionDb will transition the state by applying the current message and
-// returning the result. It returns an error if it
+// returning the result and the resource units consumed. It returns an error if it
 // failed. An error indicates a consensus issue.
-func (st *StateTransition) TransitionDb(sysparam *intertypes.SystemParams) (ret []byte, failed bool, err error) {
+func (st *StateTransition) TransitionDb(sysparam *intertypes.SystemParams) (ret []byte, resourceUsed uint64, failed bool, err error) {
 	if err = st.preCheck(); err != nil {
 		return
 	}
 
 	sender := st.from() // err checked in preCheck
 
+	resultMem := []*interpreter.MemDatabase{}
+
+	// pay for the whole resource limit first, the unused part is refunded at the end
+	results, err := balancetransfer.ChargeFee(sysparam, sender, balancetransfer.ResourceFee(st.msg.ResourceLimit()))
+	if err != nil {
+		logger.Debugf("charge fee fail: %v", err)
+		return nil, 0, false, core.ErrInsufficientFundsForFee
+	}
+	resultMem = appendResultMem(resultMem, balancetransfer.BalanceTransferAddress, results)
+
+	meter := sdk.NewResourceMeter(st.msg.ResourceLimit())
+	sysparam.SdkHandler.SetMeter(meter)
+	failed = st.applyActions(sender, sysparam, meter, &resultMem)
+	sysparam.SdkHandler.SetMeter(nil)
+	resourceUsed = meter.Used()
+
+	results, err = balancetransfer.RefundFee(sysparam, sender, balancetransfer.ResourceFee(meter.Limit()-resourceUsed))
+	if err != nil {
+		logger.Error("refund fee fail.", err)
+		return nil, 0, false, err
+	}
+	resultMem = appendResultMem(resultMem, balancetransfer.BalanceTransferAddress, results)
+
+	for _, result := range resultMem {
+		storgageKey := append(result.Address.Bytes(), result.Key...)
+
+		//1, change statedb storage
+		storageKeyHash := crypto.Keccak256Hash(storgageKey)
+		storageValHash := crypto.Keccak256Hash(result.Val)
+		st.statedb.SetState(result.Address, storageKeyHash, storageValHash)
+
+		//2, collect results for block producer future write level db
+		st.Cache.Cache[string(storgageKey)] = interpreter.MemDatabase{
+			result.Address,
+			storageValHash.Bytes(),
+			result.Val}
+	}
+
+	return ret, resourceUsed, failed, nil
+}
+
+// applyActions runs the actions of the message, the results of the actions are appended to
+// resultMem only if all of them succeed. It reports whether the actions failed.
+func (st *StateTransition) applyActions(sender types.Address, sysparam *intertypes.SystemParams, meter *sdk.ResourceMeter, resultMem *[]*interpreter.MemDatabase) bool {
 	contractCreation := false
 
-	if len(st.actions) == 2 && st.actions[1].Address == nil{
+	if len(st.actions) == 2 && st.actions[1].Address == nil {
 		contractCreation = true
 	}
-	// Snapshot !!!!!!!!!!!!!!!!!
-	snapshot := st.statedb.Snapshot()
 
-	resultMem := []*interpreter.MemDatabase{}
+	// the snapshot covers the statedb and the contract values set by the actions
+	snapshot := sysparam.SdkHandler.Snapshot()
+	nonce := st.statedb.GetNonce(sender)
+	// the nonce is used even if the actions fail
+	fail := func() bool {
+		sysparam.SdkHandler.RevertToSnapshot(snapshot)
+		st.statedb.SetNonce(sender, nonce+1)
+		return true
+	}
+
+	// charge the params of all actions before any of them runs
+	if meter != nil {
+		for _, action := range st.actions {
+			if err := meter.Charge(params.ActionResourceCost + uint64(len(action.Params))*params.ParamsByteResourceCost); err != nil {
+				logger.Debugf("transaction out of resource before running actions")
+				return fail()
+			}
+		}
+	}
+
+	actionMem := []*interpreter.MemDatabase{}
 
 	if contractCreation {
-		results, _, err := interpreter.Create(sender, st.statedb, st.actions)
+		results, _, err := interpreter.Create(sender, st.statedb, st.actions, sysparam)
+		if err == nil && meter != nil && meter.Exhausted() {
+			err = sdk.ErrResourceExhausted
+		}
 		if err != nil {
-			st.statedb.RevertToSnapshot(snapshot)
-			return nil, true, err
+			logger.Error("contract creation fail.", err)
+			return fail()
 		}
-		for _, res := range results {
-			resM := &interpreter.MemDatabase{*st.actions[0].Address, res.Key, res.Val}
-			resultMem = append(resultMem, resM)
+		actionMem = appendCallWrites(actionMem, sysparam.SdkHandler.TakeCallWrites())
+		actionMem = appendResultMem(actionMem, *st.actions[0].Address, results)
+		// make logs for receipt
+		for _, log := range MakeLogs(*st.actions[0].Address, results, st.header.Number.IntVal.Uint64()) {
+			st.statedb.AddLog(log)
 		}
-		// make log for receipt
-		log := MakeLog(*st.actions[0].Address, results, st.header.Number.IntVal.Uint64())
-		st.statedb.AddLog(log)
 	} else {
 		logger.Debugf("Just process actions transaction.")
 		st.statedb.SetNonce(sender, st.statedb.GetNonce(sender) +1 )
-		for _,action := range st.actions {
+		for i, action := range st.actions {
+			sysparam.SdkHandler.SetActionIndex(i)
 			//resulst := make(chan interpreter.WorkResult)
 			resulstChan :=sysparam.VmHandler.SendWork(sender,action,sysparam)
 
 			result := <-resulstChan
+			if result.Err == nil && meter != nil && meter.Exhausted() {
+				result.Err = sdk.ErrResourceExhausted
+			}
 			if result.Err != nil {
 				logger.Error("action fail.", result.Err)
-				st.statedb.RevertToSnapshot(snapshot)
-				return nil, true, err
+				return fail()
 			}
-			for _, res := range result.Results {
-				resM := &interpreter.MemDatabase{*action.Address, res.Key, res.Val}
-				resultMem = append(resultMem, resM)
+			// the writes of the contracts called by the action go first, a running contract
+			// can not be called again so its own results are always the latest
+			actionMem = appendCallWrites(actionMem, sysparam.SdkHandler.TakeCallWrites())
+			actionMem = appendResultMem(actionMem, *action.Address, result.Results)
+			// make logs for receipt
+			for _, log := range MakeLogs(*action.Address, result.Results, st.header.Number.IntVal.Uint64()) {
+				st.statedb.AddLog(log)
 			}
-			// make log for receipt
-			log := MakeLog(*action.Address, result.Results, st.header.Number.IntVal.Uint64())
-			st.statedb.AddLog(log)
 		}
 	}
 
-	for _, result := range resultMem {
-		storgageKey := append(result.Address.Bytes(), result.Key...)
-
-		//1, change statedb storage
-		storageKeyHash := crypto.Keccak256Hash(storgageKey)
-		storageValHash := crypto.Keccak256Hash(result.Val)
-		st.statedb.SetState(result.Address, storageKeyHash, storageValHash)
+	*resultMem = append(*resultMem, actionMem...)
+	return false
+}
 
-		//2, collect results for block producer future write level db
-		st.Cache.Cache[string(storgageKey)] = interpreter.MemDatabase{
-			result.Address,
-			storageValHash.Bytes(),
-			result.Val}
+func appendCallWrites(resultMem []*interpreter.MemDatabase, writes []sdk.CallWrite) []*interpreter.MemDatabase {
+	for _, write := range writes {
+		resultMem = append(resultMem, &interpreter.MemDatabase{Address: write.Address, Key: write.Key, Val: write.Val})
 	}
+	return resultMem
+}
 
-	return ret, false, err
+func appendResultMem(resultMem []*interpreter.MemDatabase, address types.Address, results []intertypes.ActionResult) []*interpreter.MemDatabase {
+	for _, res := range results {
+		resultMem = append(resultMem, &interpreter.MemDatabase{Address: address, Key: res.Key, Val: res.Val})
+	}
+	return resultMem
 }
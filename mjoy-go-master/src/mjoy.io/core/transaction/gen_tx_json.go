@@ -12,6 +12,9 @@ func (t Txdata) MarshalJSON() ([]byte, error) {
 	type Txdata struct {
 		AccountNonce	uint64		`json:"nonce"   gencodec:"required"`
 		Actions		ActionSlice	`json:"actions" gencodec:"required"`
+		ResourceLimit	uint64		`json:"resourceLimit" gencodec:"required"`
+		ValidAfter	uint64		`json:"validAfter"`
+		ValidUntil	uint64		`json:"validUntil"`
 		V		*types.BigInt	`json:"v"       gencodec:"required"`
 		R		*types.BigInt	`json:"r"       gencodec:"required"`
 		S		*types.BigInt	`json:"s"       gencodec:"required"`
@@ -20,6 +23,9 @@ func (t Txdata) MarshalJSON() ([]byte, error) {
 	var enc Txdata
 	enc.AccountNonce = t.AccountNonce
 	enc.Actions = t.Actions
+	enc.ResourceLimit = t.ResourceLimit
+	enc.ValidAfter = t.ValidAfter
+	enc.ValidUntil = t.ValidUntil
 	enc.V = t.V
 	enc.R = t.R
 	enc.S = t.S
@@ -31,6 +37,9 @@ func (t *Txdata) UnmarshalJSON(input []byte) error {
 	type Txdata struct {
 		AccountNonce	*uint64		`json:"nonce"   gencodec:"required"`
 		Actions		ActionSlice	`json:"actions" gencodec:"required"`
+		ResourceLimit	*uint64		`json:"resourceLimit" gencodec:"required"`
+		ValidAfter	*uint64		`json:"validAfter"`
+		ValidUntil	*uint64		`json:"validUntil"`
 		V		*types.BigInt	`json:"v"       gencodec:"required"`
 		R		*types.BigInt	`json:"r"       gencodec:"required"`
 		S		*types.BigInt	`json:"s"       gencodec:"required"`
@@ -48,6 +57,16 @@ func (t *Txdata) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'actions' for Txdata")
 	}
 	t.Actions = dec.Actions
+	if dec.ResourceLimit == nil {
+		return errors.New("missing required field 'resourceLimit' for Txdata")
+	}
+	t.ResourceLimit = *dec.ResourceLimit
+	if dec.ValidAfter != nil {
+		t.ValidAfter = *dec.ValidAfter
+	}
+	if dec.ValidUntil != nil {
+		t.ValidUntil = *dec.ValidUntil
+	}
 	if dec.V == nil {
 		return errors.New("missing required field 'v' for Txdata")
 	}
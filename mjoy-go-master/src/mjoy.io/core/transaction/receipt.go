@@ -44,10 +44,23 @@ type Receipt struct {
 	Status            uint        `json:"status"`
 	Bloom             types.Bloom `json:"logsBloom"         gencodec:"required"`
 	Logs              []*Log      `json:"logs"              gencodec:"required"`
+	ResourceUsed      uint64      `json:"resourceUsed"      gencodec:"required"`
 
 	// Implementation fields (don't reorder!)
 	TxHash          types.Hash    `json:"transactionHash"   gencodec:"required"`
 	ContractAddress types.Address `json:"contractAddress"`
+	Calls           []*CallTrace  `json:"calls"`
+}
+
+// CallTrace records a call of a contract by another contract while the transaction was applied.
+// Calls that failed are recorded too, their Error is not empty.
+type CallTrace struct {
+	Depth    uint64        `json:"depth"`
+	Caller   types.Address `json:"caller"`
+	Contract types.Address `json:"contract"`
+	Input    []byte        `json:"input"`
+	Output   []byte        `json:"output"`
+	Error    string        `json:"error"`
 }
 
 //ReceiptProtocol is the consensus encoding of a receipt
@@ -55,6 +68,7 @@ type ReceiptProtocol struct {
 	Status            uint
 	Bloom             types.Bloom
 	Logs              []*LogProtocol
+	ResourceUsed      uint64
 }
 
 
@@ -71,7 +85,7 @@ func NewReceipt(failed bool) *Receipt {
 
 // String implements the Stringer interface.
 func (r *Receipt) String() string {
-	return fmt.Sprintf("receipt{status=%d   bloom=%x logs=%v}", r.Status,  r.Bloom, r.Logs)
+	return fmt.Sprintf("receipt{status=%d   bloom=%x logs=%v resourceUsed=%d}", r.Status,  r.Bloom, r.Logs, r.ResourceUsed)
 }
 
 // Receipts is a wrapper around a Receipt array to implement DerivableList.
@@ -87,7 +101,7 @@ func (r Receipts)GetMsgp(i int)[]byte{
 		logP := &LogProtocol{log.Address,log.Topics, log.Data}
 		logPs = append(logPs, logP)
 	}
-	input := &ReceiptProtocol{r[i].Status, r[i].Bloom,logPs}
+	input := &ReceiptProtocol{r[i].Status, r[i].Bloom,logPs, r[i].ResourceUsed}
 	err := msgp.Encode(&buf, input)
 	if err != nil{
 		return nil
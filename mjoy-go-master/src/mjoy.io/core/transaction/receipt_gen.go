@@ -8,6 +8,216 @@ import (
 	"github.com/tinylib/msgp/msgp"
 )
 
+// DecodeMsg implements msgp.Decodable
+func (z *CallTrace) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Depth":
+			z.Depth, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		case "Caller":
+			err = z.Caller.DecodeMsg(dc)
+			if err != nil {
+				return
+			}
+		case "Contract":
+			err = z.Contract.DecodeMsg(dc)
+			if err != nil {
+				return
+			}
+		case "Input":
+			z.Input, err = dc.ReadBytes(z.Input)
+			if err != nil {
+				return
+			}
+		case "Output":
+			z.Output, err = dc.ReadBytes(z.Output)
+			if err != nil {
+				return
+			}
+		case "Error":
+			z.Error, err = dc.ReadString()
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *CallTrace) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 6
+	// write "Depth"
+	err = en.Append(0x86, 0xa5, 0x44, 0x65, 0x70, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Depth)
+	if err != nil {
+		return
+	}
+	// write "Caller"
+	err = en.Append(0xa6, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72)
+	if err != nil {
+		return
+	}
+	err = z.Caller.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	// write "Contract"
+	err = en.Append(0xa8, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74)
+	if err != nil {
+		return
+	}
+	err = z.Contract.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	// write "Input"
+	err = en.Append(0xa5, 0x49, 0x6e, 0x70, 0x75, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Input)
+	if err != nil {
+		return
+	}
+	// write "Output"
+	err = en.Append(0xa6, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Output)
+	if err != nil {
+		return
+	}
+	// write "Error"
+	err = en.Append(0xa5, 0x45, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Error)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *CallTrace) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 6
+	// string "Depth"
+	o = append(o, 0x86, 0xa5, 0x44, 0x65, 0x70, 0x74, 0x68)
+	o = msgp.AppendUint64(o, z.Depth)
+	// string "Caller"
+	o = append(o, 0xa6, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72)
+	o, err = z.Caller.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	// string "Contract"
+	o = append(o, 0xa8, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74)
+	o, err = z.Contract.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	// string "Input"
+	o = append(o, 0xa5, 0x49, 0x6e, 0x70, 0x75, 0x74)
+	o = msgp.AppendBytes(o, z.Input)
+	// string "Output"
+	o = append(o, 0xa6, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74)
+	o = msgp.AppendBytes(o, z.Output)
+	// string "Error"
+	o = append(o, 0xa5, 0x45, 0x72, 0x72, 0x6f, 0x72)
+	o = msgp.AppendString(o, z.Error)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *CallTrace) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Depth":
+			z.Depth, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "Caller":
+			bts, err = z.Caller.UnmarshalMsg(bts)
+			if err != nil {
+				return
+			}
+		case "Contract":
+			bts, err = z.Contract.UnmarshalMsg(bts)
+			if err != nil {
+				return
+			}
+		case "Input":
+			z.Input, bts, err = msgp.ReadBytesBytes(bts, z.Input)
+			if err != nil {
+				return
+			}
+		case "Output":
+			z.Output, bts, err = msgp.ReadBytesBytes(bts, z.Output)
+			if err != nil {
+				return
+			}
+		case "Error":
+			z.Error, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *CallTrace) Msgsize() (s int) {
+	s = 1 + 6 + msgp.Uint64Size + 7 + z.Caller.Msgsize() + 9 + z.Contract.Msgsize() + 6 + msgp.BytesPrefixSize + len(z.Input) + 7 + msgp.BytesPrefixSize + len(z.Output) + 6 + msgp.StringPrefixSize + len(z.Error)
+	return
+}
+
 // DecodeMsg implements msgp.Decodable
 func (z *Receipt) DecodeMsg(dc *msgp.Reader) (err error) {
 	var field []byte
@@ -62,6 +272,11 @@ func (z *Receipt) DecodeMsg(dc *msgp.Reader) (err error) {
 					}
 				}
 			}
+		case "ResourceUsed":
+			z.ResourceUsed, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
 		case "TxHash":
 			err = z.TxHash.DecodeMsg(dc)
 			if err != nil {
@@ -72,6 +287,34 @@ func (z *Receipt) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "Calls":
+			var zb0003 uint32
+			zb0003, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Calls) >= int(zb0003) {
+				z.Calls = (z.Calls)[:zb0003]
+			} else {
+				z.Calls = make([]*CallTrace, zb0003)
+			}
+			for za0002 := range z.Calls {
+				if dc.IsNil() {
+					err = dc.ReadNil()
+					if err != nil {
+						return
+					}
+					z.Calls[za0002] = nil
+				} else {
+					if z.Calls[za0002] == nil {
+						z.Calls[za0002] = new(CallTrace)
+					}
+					err = z.Calls[za0002].DecodeMsg(dc)
+					if err != nil {
+						return
+					}
+				}
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -84,9 +327,9 @@ func (z *Receipt) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Receipt) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 5
+	// map header, size 7
 	// write "Status"
-	err = en.Append(0x85, 0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
+	err = en.Append(0x87, 0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
 	if err != nil {
 		return
 	}
@@ -125,6 +368,15 @@ func (z *Receipt) EncodeMsg(en *msgp.Writer) (err error) {
 			}
 		}
 	}
+	// write "ResourceUsed"
+	err = en.Append(0xac, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x73, 0x65, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.ResourceUsed)
+	if err != nil {
+		return
+	}
 	// write "TxHash"
 	err = en.Append(0xa6, 0x54, 0x78, 0x48, 0x61, 0x73, 0x68)
 	if err != nil {
@@ -143,15 +395,37 @@ func (z *Receipt) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "Calls"
+	err = en.Append(0xa5, 0x43, 0x61, 0x6c, 0x6c, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Calls)))
+	if err != nil {
+		return
+	}
+	for za0002 := range z.Calls {
+		if z.Calls[za0002] == nil {
+			err = en.WriteNil()
+			if err != nil {
+				return
+			}
+		} else {
+			err = z.Calls[za0002].EncodeMsg(en)
+			if err != nil {
+				return
+			}
+		}
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *Receipt) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 5
+	// map header, size 7
 	// string "Status"
-	o = append(o, 0x85, 0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
+	o = append(o, 0x87, 0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
 	o = msgp.AppendUint(o, z.Status)
 	// string "Bloom"
 	o = append(o, 0xa5, 0x42, 0x6c, 0x6f, 0x6f, 0x6d)
@@ -172,6 +446,9 @@ func (z *Receipt) MarshalMsg(b []byte) (o []byte, err error) {
 			}
 		}
 	}
+	// string "ResourceUsed"
+	o = append(o, 0xac, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x73, 0x65, 0x64)
+	o = msgp.AppendUint64(o, z.ResourceUsed)
 	// string "TxHash"
 	o = append(o, 0xa6, 0x54, 0x78, 0x48, 0x61, 0x73, 0x68)
 	o, err = z.TxHash.MarshalMsg(o)
@@ -184,6 +461,19 @@ func (z *Receipt) MarshalMsg(b []byte) (o []byte, err error) {
 	if err != nil {
 		return
 	}
+	// string "Calls"
+	o = append(o, 0xa5, 0x43, 0x61, 0x6c, 0x6c, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Calls)))
+	for za0002 := range z.Calls {
+		if z.Calls[za0002] == nil {
+			o = msgp.AppendNil(o)
+		} else {
+			o, err = z.Calls[za0002].MarshalMsg(o)
+			if err != nil {
+				return
+			}
+		}
+	}
 	return
 }
 
@@ -241,6 +531,11 @@ func (z *Receipt) UnmarshalMsg(bts []byte) (o []byte, err error) {
 					}
 				}
 			}
+		case "ResourceUsed":
+			z.ResourceUsed, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
 		case "TxHash":
 			bts, err = z.TxHash.UnmarshalMsg(bts)
 			if err != nil {
@@ -251,6 +546,34 @@ func (z *Receipt) UnmarshalMsg(bts []byte) (o []byte, err error) {
 			if err != nil {
 				return
 			}
+		case "Calls":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Calls) >= int(zb0003) {
+				z.Calls = (z.Calls)[:zb0003]
+			} else {
+				z.Calls = make([]*CallTrace, zb0003)
+			}
+			for za0002 := range z.Calls {
+				if msgp.IsNil(bts) {
+					bts, err = msgp.ReadNilBytes(bts)
+					if err != nil {
+						return
+					}
+					z.Calls[za0002] = nil
+				} else {
+					if z.Calls[za0002] == nil {
+						z.Calls[za0002] = new(CallTrace)
+					}
+					bts, err = z.Calls[za0002].UnmarshalMsg(bts)
+					if err != nil {
+						return
+					}
+				}
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -272,7 +595,14 @@ func (z *Receipt) Msgsize() (s int) {
 			s += z.Logs[za0001].Msgsize()
 		}
 	}
-	s += 7 + z.TxHash.Msgsize() + 16 + z.ContractAddress.Msgsize()
+	s += 13 + msgp.Uint64Size + 7 + z.TxHash.Msgsize() + 16 + z.ContractAddress.Msgsize() + 6 + msgp.ArrayHeaderSize
+	for za0002 := range z.Calls {
+		if z.Calls[za0002] == nil {
+			s += msgp.NilSize
+		} else {
+			s += z.Calls[za0002].Msgsize()
+		}
+	}
 	return
 }
 
@@ -330,6 +660,11 @@ func (z *ReceiptProtocol) DecodeMsg(dc *msgp.Reader) (err error) {
 					}
 				}
 			}
+		case "ResourceUsed":
+			z.ResourceUsed, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -342,9 +677,9 @@ func (z *ReceiptProtocol) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *ReceiptProtocol) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 3
+	// map header, size 4
 	// write "Status"
-	err = en.Append(0x83, 0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
+	err = en.Append(0x84, 0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
 	if err != nil {
 		return
 	}
@@ -383,15 +718,24 @@ func (z *ReceiptProtocol) EncodeMsg(en *msgp.Writer) (err error) {
 			}
 		}
 	}
+	// write "ResourceUsed"
+	err = en.Append(0xac, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x73, 0x65, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.ResourceUsed)
+	if err != nil {
+		return
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *ReceiptProtocol) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 3
+	// map header, size 4
 	// string "Status"
-	o = append(o, 0x83, 0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
+	o = append(o, 0x84, 0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
 	o = msgp.AppendUint(o, z.Status)
 	// string "Bloom"
 	o = append(o, 0xa5, 0x42, 0x6c, 0x6f, 0x6f, 0x6d)
@@ -412,6 +756,9 @@ func (z *ReceiptProtocol) MarshalMsg(b []byte) (o []byte, err error) {
 			}
 		}
 	}
+	// string "ResourceUsed"
+	o = append(o, 0xac, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x73, 0x65, 0x64)
+	o = msgp.AppendUint64(o, z.ResourceUsed)
 	return
 }
 
@@ -469,6 +816,11 @@ func (z *ReceiptProtocol) UnmarshalMsg(bts []byte) (o []byte, err error) {
 					}
 				}
 			}
+		case "ResourceUsed":
+			z.ResourceUsed, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -490,6 +842,7 @@ func (z *ReceiptProtocol) Msgsize() (s int) {
 			s += z.Logs[za0001].Msgsize()
 		}
 	}
+	s += 13 + msgp.Uint64Size
 	return
 }
 
@@ -33,6 +33,7 @@ import (
 	"github.com/tinylib/msgp/msgp"
 	"container/heap"
 	"mjoy.io/common/types/util/hex"
+	"mjoy.io/params"
 )
 
 //go:generate msgp
@@ -96,6 +97,11 @@ type ActionSlice []Action
 type Txdata struct {
 	AccountNonce 	uint64         	`json:"nonce"   gencodec:"required"`
 	Actions     	ActionSlice     `json:"actions" gencodec:"required"`
+	ResourceLimit	uint64          `json:"resourceLimit" gencodec:"required"`
+	// Validity bounds, zero means unbounded. A bound below params.TxBoundTimeThreshold
+	// is a block number, otherwise it is a unix timestamp. Both bounds are inclusive.
+	ValidAfter	uint64          `json:"validAfter"`
+	ValidUntil	uint64          `json:"validUntil"`
 	// Signature values
 	V *types.BigInt                 `json:"v"       gencodec:"required"`
 	R *types.BigInt                 `json:"r"       gencodec:"required"`
@@ -105,16 +111,27 @@ type Txdata struct {
 	Hash *types.Hash                `json:"hash"    msg:"-"`
 }
 
-//All actions is made by interpreter
+//All actions is made by interpreter,the resource limit is params.TxResourceLimit
 func NewTransaction(nonce uint64, actions ActionSlice) *Transaction {
-	return newTransaction(nonce, actions)
+	return newTransaction(nonce, params.TxResourceLimit, actions)
+}
+//NewTransactionWithLimit makes a transaction which may consume at most resourceLimit resource units
+func NewTransactionWithLimit(nonce uint64, resourceLimit uint64, actions ActionSlice) *Transaction {
+	return newTransaction(nonce, resourceLimit, actions)
+}
+//NewBoundedTransaction makes a transaction which is only valid within [validAfter, validUntil]
+func NewBoundedTransaction(nonce uint64, resourceLimit uint64, validAfter uint64, validUntil uint64, actions ActionSlice) *Transaction {
+	tx := newTransaction(nonce, resourceLimit, actions)
+	tx.Data.ValidAfter = validAfter
+	tx.Data.ValidUntil = validUntil
+	return tx
 }
 //All acions is made by interpreter
 func NewContractCreation(nonce uint64, actions ActionSlice) *Transaction {
-	return newTransaction(nonce, actions)
+	return newTransaction(nonce, params.TxResourceLimit, actions)
 }
 //the actions is right or not ,should be judged by interpreter,we have no right to do this
-func newTransaction(nonce uint64, actions ActionSlice) *Transaction {
+func newTransaction(nonce uint64, resourceLimit uint64, actions ActionSlice) *Transaction {
 	if len(actions) < 0 {
 		return nil
 	}
@@ -122,6 +139,7 @@ func newTransaction(nonce uint64, actions ActionSlice) *Transaction {
 	d := Txdata{
 		AccountNonce: nonce,
 		Actions:	actions,
+		ResourceLimit: resourceLimit,
 		V:            new(types.BigInt),
 		R:            new(types.BigInt),
 		S:            new(types.BigInt),
@@ -179,7 +197,43 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 }
 
 func (tx *Transaction) Nonce() uint64      { return tx.Data.AccountNonce }
+func (tx *Transaction) ResourceLimit() uint64 { return tx.Data.ResourceLimit }
 func (tx *Transaction) CheckNonce() bool   { return true }
+func (tx *Transaction) ValidAfter() uint64 { return tx.Data.ValidAfter }
+func (tx *Transaction) ValidUntil() uint64 { return tx.Data.ValidUntil }
+
+// Premature reports whether the transaction may not yet be included in a block
+// with the given number and time.
+func (tx *Transaction) Premature(number, time uint64) bool {
+	return Premature(tx.Data.ValidAfter, number, time)
+}
+
+// Expired reports whether the transaction may no longer be included in a block
+// with the given number and time.
+func (tx *Transaction) Expired(number, time uint64) bool {
+	return Expired(tx.Data.ValidUntil, number, time)
+}
+
+// boundValue picks the block number or the block time to compare the bound
+// against, depending on which one the bound was declared in.
+func boundValue(bound, number, time uint64) uint64 {
+	if bound < params.TxBoundTimeThreshold {
+		return number
+	}
+	return time
+}
+
+// Premature reports whether a validAfter bound is not yet reached at the given
+// block number and time.
+func Premature(validAfter, number, time uint64) bool {
+	return validAfter != 0 && boundValue(validAfter, number, time) < validAfter
+}
+
+// Expired reports whether a validUntil bound is already passed at the given
+// block number and time.
+func Expired(validUntil, number, time uint64) bool {
+	return validUntil != 0 && boundValue(validUntil, number, time) > validUntil
+}
 
 
 // Hash hashes the Msgp encoding of tx.
@@ -232,6 +286,9 @@ func (tx *Transaction) AsMessage(s Signer) (Message, error) {
 	newActions = append(newActions , tx.Data.Actions...)
 	msg := Message{
 		nonce:      tx.Data.AccountNonce,
+		resourceLimit: tx.Data.ResourceLimit,
+		validAfter: tx.Data.ValidAfter,
+		validUntil: tx.Data.ValidUntil,
 		actions:    newActions,
 		checkNonce: true,
 	}
@@ -259,8 +316,12 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 //	total.Add(total, &tx.Data.Amount.IntVal)
 //	return total
 //}
+//GetPriority returns a copy of the priority set by the txpool, zero if none is set
 func (tx *Transaction)GetPriority()*big.Int{
-	return big.NewInt(tx.Priority.Int64())
+	if tx.Priority == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(tx.Priority)
 }
 
 func (tx *Transaction)SetPriority(priority int){
@@ -390,7 +451,7 @@ func (t *TransactionForProducing)Shift(){
 type TxByPriority Transactions
 
 func (s TxByPriority)Len()	int 			{return len(s)}
-func (s TxByPriority)Less(i , j int)bool 	{return s[i].Priority.Cmp(s[j].Priority) > 0}
+func (s TxByPriority)Less(i , j int)bool 	{return s[i].GetPriority().Cmp(s[j].GetPriority()) > 0}
 func (s TxByPriority) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
 func (s *TxByPriority) Push(x interface{}) {
@@ -412,10 +473,9 @@ type TransactionsByPriorityAndNonce struct {
 
 }
 
-func NewTransactionsByPriorityAndNonce(signer Signer , txs map[types.Address]Transactions, txReword *Transaction)*TransactionsByPriorityAndNonce {
+func NewTransactionsByPriorityAndNonce(signer Signer , txs map[types.Address]Transactions)*TransactionsByPriorityAndNonce {
 	// Initialize a price based heap with the head transactions
-	heads := make(TxByPriority, 0, len(txs) + 1)
-	heads = append(heads, txReword)
+	heads := make(TxByPriority, 0, len(txs))
 	for _, accTxs := range txs {
 		heads = append(heads, accTxs[0])
 		// Ensure the sender address is from the signer
@@ -474,14 +534,20 @@ func (s TxByNonce) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 type Message struct {
 	from       types.Address
 	nonce      uint64
+	resourceLimit uint64
+	validAfter uint64
+	validUntil uint64
 	actions    []Action
 	checkNonce bool
 }
 
-func NewMessage(from types.Address, nonce uint64, actions ActionSlice, checkNonce bool) Message {
+func NewMessage(from types.Address, nonce uint64, resourceLimit uint64, validAfter uint64, validUntil uint64, actions ActionSlice, checkNonce bool) Message {
 	return Message{
 		from:       from,
 		nonce:      nonce,
+		resourceLimit: resourceLimit,
+		validAfter: validAfter,
+		validUntil: validUntil,
 		actions:    actions,
 		checkNonce: checkNonce,
 	}
@@ -489,5 +555,8 @@ func NewMessage(from types.Address, nonce uint64, actions ActionSlice, checkNonc
 
 func (m Message) From() types.Address { return m.from }
 func (m Message) Nonce() uint64        { return m.nonce }
+func (m Message) ResourceLimit() uint64 { return m.resourceLimit }
+func (m Message) ValidAfter() uint64   { return m.validAfter }
+func (m Message) ValidUntil() uint64   { return m.validUntil }
 func (m Message) Actions()[]Action      {return m.actions}
 func (m Message) CheckNonce() bool     { return m.checkNonce }
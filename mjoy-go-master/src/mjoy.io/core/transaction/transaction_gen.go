@@ -1196,6 +1196,21 @@ func (z *Txdata) DecodeMsg(dc *msgp.Reader) (err error) {
 					}
 				}
 			}
+		case "ResourceLimit":
+			z.ResourceLimit, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		case "ValidAfter":
+			z.ValidAfter, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
+		case "ValidUntil":
+			z.ValidUntil, err = dc.ReadUint64()
+			if err != nil {
+				return
+			}
 		case "V":
 			if dc.IsNil() {
 				err = dc.ReadNil()
@@ -1256,9 +1271,9 @@ func (z *Txdata) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Txdata) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 5
+	// map header, size 8
 	// write "AccountNonce"
-	err = en.Append(0x85, 0xac, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x4e, 0x6f, 0x6e, 0x63, 0x65)
+	err = en.Append(0x88, 0xac, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x4e, 0x6f, 0x6e, 0x63, 0x65)
 	if err != nil {
 		return
 	}
@@ -1303,6 +1318,33 @@ func (z *Txdata) EncodeMsg(en *msgp.Writer) (err error) {
 			return
 		}
 	}
+	// write "ResourceLimit"
+	err = en.Append(0xad, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.ResourceLimit)
+	if err != nil {
+		return
+	}
+	// write "ValidAfter"
+	err = en.Append(0xaa, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x66, 0x74, 0x65, 0x72)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.ValidAfter)
+	if err != nil {
+		return
+	}
+	// write "ValidUntil"
+	err = en.Append(0xaa, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69, 0x6c)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.ValidUntil)
+	if err != nil {
+		return
+	}
 	// write "V"
 	err = en.Append(0xa1, 0x56)
 	if err != nil {
@@ -1357,9 +1399,9 @@ func (z *Txdata) EncodeMsg(en *msgp.Writer) (err error) {
 // MarshalMsg implements msgp.Marshaler
 func (z *Txdata) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 5
+	// map header, size 8
 	// string "AccountNonce"
-	o = append(o, 0x85, 0xac, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x4e, 0x6f, 0x6e, 0x63, 0x65)
+	o = append(o, 0x88, 0xac, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x4e, 0x6f, 0x6e, 0x63, 0x65)
 	o = msgp.AppendUint64(o, z.AccountNonce)
 	// string "Actions"
 	o = append(o, 0xa7, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73)
@@ -1380,6 +1422,15 @@ func (z *Txdata) MarshalMsg(b []byte) (o []byte, err error) {
 		o = append(o, 0xa6, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73)
 		o = msgp.AppendBytes(o, z.Actions[za0001].Params)
 	}
+	// string "ResourceLimit"
+	o = append(o, 0xad, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74)
+	o = msgp.AppendUint64(o, z.ResourceLimit)
+	// string "ValidAfter"
+	o = append(o, 0xaa, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x66, 0x74, 0x65, 0x72)
+	o = msgp.AppendUint64(o, z.ValidAfter)
+	// string "ValidUntil"
+	o = append(o, 0xaa, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69, 0x6c)
+	o = msgp.AppendUint64(o, z.ValidUntil)
 	// string "V"
 	o = append(o, 0xa1, 0x56)
 	if z.V == nil {
@@ -1487,6 +1538,21 @@ func (z *Txdata) UnmarshalMsg(bts []byte) (o []byte, err error) {
 					}
 				}
 			}
+		case "ResourceLimit":
+			z.ResourceLimit, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "ValidAfter":
+			z.ValidAfter, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "ValidUntil":
+			z.ValidUntil, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				return
+			}
 		case "V":
 			if msgp.IsNil(bts) {
 				bts, err = msgp.ReadNilBytes(bts)
@@ -1558,7 +1624,7 @@ func (z *Txdata) Msgsize() (s int) {
 		}
 		s += 7 + msgp.BytesPrefixSize + len(z.Actions[za0001].Params)
 	}
-	s += 2
+	s += 14 + msgp.Uint64Size + 11 + msgp.Uint64Size + 11 + msgp.Uint64Size + 2
 	if z.V == nil {
 		s += msgp.NilSize
 	} else {
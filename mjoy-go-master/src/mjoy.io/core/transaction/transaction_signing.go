@@ -169,6 +169,9 @@ func (s MSigner) Hash(tx *Transaction) types.Hash {
 	h, err := common.MsgpHash([]interface{}{
 		tx.Data.AccountNonce,
 		tx.Data.Actions,
+		tx.Data.ResourceLimit,
+		tx.Data.ValidAfter,
+		tx.Data.ValidUntil,
 		types.BigInt{*s.chainId}, uint(0), uint(0),
 	})
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 
 	"math/big"
 	"reflect"
+	"sort"
 	"mjoy.io/utils/crypto"
 	"mjoy.io/common/types"
 	"fmt"
@@ -26,7 +27,7 @@ func TestTransactionCreate(t *testing.T){
 		Params:data,
 	},}
 
-	tx := newTransaction(nonce  , actions)
+	tx := NewTransaction(nonce  , actions)
 	_ = tx
 }
 
@@ -40,7 +41,7 @@ func TestTransactionNew(t *testing.T){
 		Params:data,
 	},}
 
-	tx := newTransaction(nonce  , actions)
+	tx := NewTransaction(nonce  , actions)
 
 	sig := NewMSigner(big.NewInt(1))
 
@@ -75,7 +76,7 @@ func TestAsMessageGenerate(t *testing.T){
 		Params:data,
 	},}
 	//new transaction
-	tx := newTransaction(nonce  , actions)
+	tx := NewTransaction(nonce  , actions)
 	//create key
 	key , _ := crypto.GenerateKey()
 	//Sign tx
@@ -88,3 +89,96 @@ func TestAsMessageGenerate(t *testing.T){
 	}
 	fmt.Println("msg:" , msg)
 }
+
+func TestResourceLimitSigned(t *testing.T){
+	actions := []Action{{
+		Address: &testAddress,
+		Params:[]byte{1, 4, 5},
+	},}
+	tx := NewTransactionWithLimit(10 , 5000 , actions)
+	txSigned , err := SignTx(tx , mSigner , testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txSigned.ResourceLimit() != 5000 {
+		t.Fatalf("resource limit mismatch: have %d, want 5000" , txSigned.ResourceLimit())
+	}
+
+	//changing the limit after signing changes the sender
+	forged := &Transaction{Data: txSigned.Data}
+	forged.Data.ResourceLimit = 1
+	if addr , err := Sender(mSigner , forged);err == nil && addr == testAddress {
+		t.Fatal("resource limit is not covered by the signature")
+	}
+}
+
+func TestValidityBoundsSigned(t *testing.T){
+	actions := []Action{{
+		Address: &testAddress,
+		Params:[]byte{1, 4, 5},
+	},}
+	tx := NewBoundedTransaction(10 , 5000 , 100 , 200 , actions)
+	txSigned , err := SignTx(tx , mSigner , testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txSigned.ValidAfter() != 100 || txSigned.ValidUntil() != 200 {
+		t.Fatalf("bounds mismatch: have [%d, %d], want [100, 200]" , txSigned.ValidAfter() , txSigned.ValidUntil())
+	}
+
+	//extending the bounds after signing changes the sender
+	forged := &Transaction{Data: txSigned.Data}
+	forged.Data.ValidUntil = 0
+	if addr , err := Sender(mSigner , forged);err == nil && addr == testAddress {
+		t.Fatal("valid until is not covered by the signature")
+	}
+	forged = &Transaction{Data: txSigned.Data}
+	forged.Data.ValidAfter = 0
+	if addr , err := Sender(mSigner , forged);err == nil && addr == testAddress {
+		t.Fatal("valid after is not covered by the signature")
+	}
+}
+
+func TestValidityBounds(t *testing.T){
+	var timestamp uint64 = 1533200000
+	tests := []struct{
+		after, until  uint64
+		number, time  uint64
+		premature     bool
+		expired       bool
+	}{
+		{0, 0, 1, timestamp, false, false},
+		{10, 20, 9, timestamp, true, false},
+		{10, 20, 10, timestamp, false, false},
+		{10, 20, 20, timestamp, false, false},
+		{10, 20, 21, timestamp, false, true},
+		{timestamp, timestamp + 60, 1, timestamp - 1, true, false},
+		{timestamp, timestamp + 60, 1, timestamp, false, false},
+		{timestamp, timestamp + 60, 1000, timestamp + 61, false, true},
+		{10, timestamp + 60, 11, timestamp + 60, false, false},
+	}
+	for i, test := range tests {
+		tx := NewBoundedTransaction(0 , 0 , test.after , test.until , nil)
+		if have := tx.Premature(test.number , test.time);have != test.premature {
+			t.Errorf("test %d: premature mismatch: have %v, want %v" , i , have , test.premature)
+		}
+		if have := tx.Expired(test.number , test.time);have != test.expired {
+			t.Errorf("test %d: expired mismatch: have %v, want %v" , i , have , test.expired)
+		}
+	}
+}
+
+//A transaction without priority sorts as a zero priority one
+func TestTxByPriorityUnset(t *testing.T){
+	txs := TxByPriority{NewTransaction(0 , nil) , NewTransaction(1 , nil) , NewTransaction(2 , nil)}
+	txs[1].SetPriority(5)
+	sort.Sort(txs)
+	if txs[0].Nonce() != 1 {
+		t.Errorf("highest priority mismatch: have nonce %d, want 1" , txs[0].Nonce())
+	}
+	for _ , tx := range txs[1:] {
+		if tx.Priority != nil {
+			t.Errorf("priority of nonce %d set by sorting" , tx.Nonce())
+		}
+	}
+}
@@ -3,6 +3,9 @@ package txprocessor
 import (
 	"mjoy.io/core/transaction"
 	"math/big"
+	"mjoy.io/common/types"
+	"mjoy.io/core/state"
+	"mjoy.io/core/interpreter"
 )
 
 type Interpreter interface {
@@ -14,3 +17,21 @@ type Interpreter interface {
 	SetPriorityForTransaction(tx *transaction.Transaction)
 
 }
+
+// feeInterpreter rates a transaction by the fee it declares, see
+// interpreter.GetPriority.
+type feeInterpreter struct{}
+
+func (feeInterpreter) GetPriorityFromTransaction(tx *transaction.Transaction) *big.Int {
+	return interpreter.GetPriority(tx)
+}
+
+func (feeInterpreter) SetPriorityForTransaction(tx *transaction.Transaction) {
+	tx.Priority = interpreter.GetPriority(tx)
+}
+
+// FeeChecker checks the sender of a transaction can pay the fee the transaction
+// declares out of its balance on statedb.
+type FeeChecker interface {
+	CheckFee(statedb *state.StateDB, from types.Address, tx *transaction.Transaction) error
+}
@@ -174,20 +174,21 @@ func (m *txSortedMap) Remove(nonce uint64) bool {
 }
 
 // Ready retrieves a sequentially increasing list of transaction.Transactions starting at the
-// provided nonce that is ready for processing. The returned transaction.Transactions will be
-// removed from the list.
+// provided nonce that is ready for processing in the block with the given number and
+// time. The returned transaction.Transactions will be removed from the list.
 //
 // Note, all transaction.Transactions with nonces lower than start will also be returned to
 // prevent getting into and invalid state. This is not something that should ever
 // happen but better to be self correcting than failing!
-func (m *txSortedMap) Ready(start uint64) transaction.Transactions {
+func (m *txSortedMap) Ready(start uint64, number uint64, time uint64) transaction.Transactions {
 	// Short circuit if no transaction.Transactions are available
 	if m.index.Len() == 0 || (*m.index)[0] > start {
 		return nil
 	}
-	// Otherwise start accumulating incremental transaction.Transactions
+	// Otherwise start accumulating incremental transaction.Transactions, stopping at
+	// the first one not yet valid in the given block
 	var ready transaction.Transactions
-	for next := (*m.index)[0]; m.index.Len() > 0 && (*m.index)[0] == next; next++ {
+	for next := (*m.index)[0]; m.index.Len() > 0 && (*m.index)[0] == next && !m.items[next].Premature(number, time); next++ {
 		ready = append(ready, m.items[next])
 		delete(m.items, next)
 		heap.Pop(m.index)
@@ -250,16 +251,18 @@ func (l *txList) Overlaps(tx *transaction.Transaction) bool {
 // Add tries to insert a new transaction.Transaction into the list, returning whether the
 // transaction.Transaction was accepted, and if yes, any previous transaction.Transaction it replaced.
 //
-
-func (l *txList) Add(tx *transaction.Transaction, rev uint64) (bool, *transaction.Transaction) {
+// If the new transaction.Transaction is accepted into the list, the lists' priority
+// threshold is also potentially updated. A transaction.Transaction replacing one of the
+// same nonce must be priorityBump percent better than it.
+func (l *txList) Add(tx *transaction.Transaction, priorityBump uint64) (bool, *transaction.Transaction) {
 	// If there's an older better transaction.Transaction, abort
-	//already has
 	old := l.txs.Get(tx.Nonce())
 	if old != nil {
-		//check priority
-		if old.Priority.Int64() >= tx.Priority.Int64() {
-			//Add false , because the old transaction's priority is higher than new
-			return false , nil
+		threshold := new(big.Int).Div(new(big.Int).Mul(old.GetPriority(), big.NewInt(100+int64(priorityBump))), big.NewInt(100))
+		// Have to ensure that the new priority is higher than the old one as well as
+		// checking the percentage threshold, for the zero and tiny priorities
+		if old.GetPriority().Cmp(tx.GetPriority()) >= 0 || threshold.Cmp(tx.GetPriority()) > 0 {
+			return false, nil
 		}
 	}
 	// Otherwise overwrite the old transaction.Transaction with the current one
@@ -337,14 +340,14 @@ func (l *txList) Remove(tx *transaction.Transaction) (bool, transaction.Transact
 }
 
 // Ready retrieves a sequentially increasing list of transaction.Transactions starting at the
-// provided nonce that is ready for processing. The returned transaction.Transactions will be
-// removed from the list.
+// provided nonce that is ready for processing in the block with the given number and
+// time. The returned transaction.Transactions will be removed from the list.
 //
 // Note, all transaction.Transactions with nonces lower than start will also be returned to
 // prevent getting into and invalid state. This is not something that should ever
 // happen but better to be self correcting than failing!
-func (l *txList) Ready(start uint64) transaction.Transactions {
-	return l.txs.Ready(start)
+func (l *txList) Ready(start uint64, number uint64, time uint64) transaction.Transactions {
+	return l.txs.Ready(start, number, time)
 }
 
 // Len returns the length of the transaction.Transaction list.
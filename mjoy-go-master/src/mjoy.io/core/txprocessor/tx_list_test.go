@@ -20,7 +20,12 @@
 
 package txprocessor
 
-import "testing"
+import (
+	"math/big"
+	"testing"
+
+	"mjoy.io/core/transaction"
+)
 
 // Tests that transactions can be added to strict lists and list contents and
 // nonce boundaries are correctly maintained.
@@ -28,3 +33,50 @@ func TestStrictTxListAdd(t *testing.T) {
 	test := newTxList(true)
 	_ = test
 }
+
+func priorityTransaction(nonce uint64, priority int64) *transaction.Transaction {
+	tx := transaction.NewTransaction(nonce, nil)
+	if priority >= 0 {
+		tx.Priority = big.NewInt(priority)
+	}
+	return tx
+}
+
+// Tests that a transaction replaces the one of the same nonce only with a priority
+// bumped by the configured percentage, a transaction without priority counts as zero.
+func TestTxListPriorityBump(t *testing.T) {
+	tests := []struct {
+		old, new int64 // -1 is no priority
+		bump     uint64
+		replaced bool
+	}{
+		{100, 109, 10, false},
+		{100, 110, 10, true},
+		{100, 100, 10, false},
+		{100, 101, 1, true},
+		{5, 5, 10, false},
+		{5, 6, 10, true},
+		{0, 0, 10, false},
+		{0, 1, 10, true},
+		{-1, 0, 10, false},
+		{-1, 1, 10, true},
+	}
+	for i, test := range tests {
+		list := newTxList(true)
+		old := priorityTransaction(0, test.old)
+		if inserted, _ := list.Add(old, test.bump); !inserted {
+			t.Fatalf("test %d: first transaction rejected", i)
+		}
+		tx := priorityTransaction(0, test.new)
+		inserted, replaced := list.Add(tx, test.bump)
+		if inserted != test.replaced {
+			t.Errorf("test %d: replacement of priority %d by %d with bump %d: have %v, want %v", i, test.old, test.new, test.bump, inserted, test.replaced)
+		}
+		if test.replaced && replaced != old {
+			t.Errorf("test %d: replaced transaction mismatch", i)
+		}
+		if !test.replaced && list.txs.Get(0) != old {
+			t.Errorf("test %d: old transaction dropped", i)
+		}
+	}
+}
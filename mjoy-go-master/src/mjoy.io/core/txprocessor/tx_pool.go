@@ -37,7 +37,6 @@ import (
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 	"mjoy.io/core/transaction"
 	"math/big"
-	"mjoy.io/core/interpreter"
 )
 
 const (
@@ -60,15 +59,24 @@ var (
 	ErrWrongTransactionAmount = errors.New("transaction's Amount is wrong")
 
 	//ErrUnderpriority is returned if a transaction's priority is below the minimum
-	//configured for the transaction pool
+	//configured for the transaction pool, or below all the ones of a full pool
 	ErrUnderPriority = errors.New("transaction underpriority")
 
-
+	// ErrReplaceUnderpriority is returned if a transaction is attempted to be replaced
+	// with a different one without the required priority bump.
 	ErrReplaceUnderpriority = errors.New("replacement transaction underpriority")
 
-	// ErrInsufficientFunds is returned if the total cost of executing a transaction
-	// is higher than the balance of the user's account.
-	ErrInsufficientFunds = errors.New("insufficient funds for value")
+	// ErrInsufficientFunds is returned if the fee a transaction declares is higher
+	// than the balance of the user's account.
+	ErrInsufficientFunds = errors.New("insufficient funds for fee")
+
+	// ErrInvalidChainId is returned if a transaction is not signed for the chain of
+	// the pool, so that it can not be replayed from another chain.
+	ErrInvalidChainId = errors.New("invalid chain id")
+
+	// ErrTooManyActions is returned if a transaction carries more actions than the
+	// pool accepts. This is not a consensus error, rather a DOS protection.
+	ErrTooManyActions = errors.New("too many actions")
 
 
 	// ErrNegativeValue is a sanity error to ensure noone is able to specify a
@@ -99,9 +107,24 @@ var (
 	queuedReplaceCounter   = metrics.NewRegisteredCounter("txpool/queued/replace",nil)
 	queuedRateLimitCounter = metrics.NewRegisteredCounter("txpool/queued/ratelimit",nil) // Dropped due to rate limiting
 	queuedNofundsCounter   = metrics.NewRegisteredCounter("txpool/queued/nofunds",nil)   // Dropped due to out-of-funds
+	queuedPrematureCounter = metrics.NewRegisteredCounter("txpool/queued/premature",nil) // Held back until valid
 
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid",nil)
+
+	// Metrics for the transactions failing validation, one per reason
+	oversizedTxCounter       = metrics.NewRegisteredCounter("txpool/invalid/oversized", nil)
+	tooManyActionsCounter    = metrics.NewRegisteredCounter("txpool/invalid/actions", nil)
+	resourceLimitCounter     = metrics.NewRegisteredCounter("txpool/invalid/resourcelimit", nil)
+	invalidChainIdCounter    = metrics.NewRegisteredCounter("txpool/invalid/chainid", nil)
+	invalidSenderCounter     = metrics.NewRegisteredCounter("txpool/invalid/sender", nil)
+	nonceTooLowCounter       = metrics.NewRegisteredCounter("txpool/invalid/nonce", nil)
+	insufficientFundCounter  = metrics.NewRegisteredCounter("txpool/invalid/funds", nil)
+	underpriorityCounter     = metrics.NewRegisteredCounter("txpool/invalid/underpriority", nil)
+	underpriorityFullCounter = metrics.NewRegisteredCounter("txpool/full/underpriority", nil) // Rejected by a full pool
+	evictedCounter           = metrics.NewRegisteredCounter("txpool/full/evicted", nil)       // Evicted by better transactions
+	expiredCounter           = metrics.NewRegisteredCounter("txpool/invalid/expired", nil)
+	expiredPurgeCounter      = metrics.NewRegisteredCounter("txpool/expired", nil) // Purged on new heads
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -136,6 +159,12 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	MaxTxSize    uint64 // Maximum encoded size of a transaction in bytes
+	MaxTxActions uint64 // Maximum number of actions of a transaction
+
+	MinFee       uint64 // Minimum fee a remote transaction must declare to be accepted into the pool
+	PriorityBump uint64 // Minimum fee bump percentage to replace an already existing transaction (nonce)
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -150,6 +179,12 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	MaxTxSize:    32 * 1024,
+	MaxTxActions: 64,
+
+	MinFee:       0,
+	PriorityBump: 10,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -160,6 +195,18 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		logger.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.MaxTxSize == 0 {
+		logger.Warn("Sanitizing invalid txpool max transaction size", "provided", conf.MaxTxSize, "updated", DefaultTxPoolConfig.MaxTxSize)
+		conf.MaxTxSize = DefaultTxPoolConfig.MaxTxSize
+	}
+	if conf.MaxTxActions == 0 {
+		logger.Warn("Sanitizing invalid txpool max transaction actions", "provided", conf.MaxTxActions, "updated", DefaultTxPoolConfig.MaxTxActions)
+		conf.MaxTxActions = DefaultTxPoolConfig.MaxTxActions
+	}
+	if conf.PriorityBump < 1 {
+		logger.Warn("Sanitizing invalid txpool priority bump", "provided", conf.PriorityBump, "updated", DefaultTxPoolConfig.PriorityBump)
+		conf.PriorityBump = DefaultTxPoolConfig.PriorityBump
+	}
 
 	return conf
 }
@@ -182,11 +229,13 @@ type TxPool struct {
 	signer       transaction.Signer
 	mu           sync.RWMutex
 
+	currentHead   *block.Header       // Current head of the blockchain
 	currentState  *state.StateDB      // Current state in the blockchain head
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 
 	priority 	*big.Int
 	inter		Interpreter
+	feeChecker	FeeChecker // Checks the senders of new transactions can pay their fees, nil means no check
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
@@ -219,8 +268,8 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		all:         make(map[types.Hash]*transaction.Transaction),
 		chainHeadCh: make(chan core.ChainHeadEvent, chainHeadChanSize),
 	}
-	//set test interpreter for test
-	pool.inter = new(testInterpreter)
+	pool.priority = new(big.Int).SetUint64(config.MinFee)
+	pool.inter = feeInterpreter{}
 	pool.locals = newAccountSet(pool.signer)
 	pool.priorited = newTxPriorityList(&pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
@@ -393,9 +442,12 @@ func (pool *TxPool) reset(oldHead, newHead *block.Header) {
 		logger.Error("Failed to reset txpool state", "err", err)
 		return
 	}
+	pool.currentHead = newHead
 	pool.currentState = statedb
 	pool.pendingState = state.ManageState(statedb)
 
+	// Drop the transactions which can not be included in any later block
+	pool.purgeExpired()
 
 	// Inject any transactions discarded due to reorgs
 	logger.Debug("Reinjecting stale transactions", "count", len(reinject))
@@ -462,6 +514,15 @@ func (pool *TxPool)SetPriority(priority *big.Int){
 
 
 
+// SetFeeChecker sets the hook checking the senders of new transactions can pay
+// their fees, nil disables the check.
+func (pool *TxPool) SetFeeChecker(checker FeeChecker) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.feeChecker = checker
+}
+
 // State returns the virtual managed state of the transaction pool.
 func (pool *TxPool) State() *state.ManagedState {
 	pool.mu.RLock()
@@ -540,51 +601,83 @@ func (pool *TxPool) local() map[types.Address]transaction.Transactions {
 	return txs
 }
 
-//validateTx return nil,we should check the tx validation at interpreter
+// validateTx checks whether a transaction is valid according to the consensus
+// rules and adheres to some heuristic limits of the local node (size, actions).
 func (pool *TxPool) validateTx(tx *transaction.Transaction, local bool) error {
-	//// Heuristic limit, reject transactions over 32KB to prevent DOS attacks
-	//if tx.Size() > 32*1024 {
-	//	return ErrOversizedData
-	//}
-	////get basic info from interpreter
-	//a := pool.inter.GetBasicInfo(tx)
-	//txInfo := a.(transaction.TxBasicInfo)
-	//
-	////Some transaction judgement operations below
-	//
-	//// Transactions can't be negative. This may never happen using MSGP decoded
-	//// transactions but may occur if you create a transaction using the RPC.
-	//
-	//
-	//if txInfo.Amount == nil {
-	//	logger.Errorf("!!!!!!!!!Tx.Amount == nil")
-	//	return ErrWrongTransactionAmount
-	//}
-	//
-	//if txInfo.Amount.Sign() < 0 {
-	//	return ErrNegativeValue
-	//}
-	//// Make sure the transaction is signed properly
-	//from, err := transaction.Sender(pool.signer, tx)
-	//if err != nil {
-	//	logger.Error("Why invalidSender :",err)
-	//	return ErrInvalidSender
-	//}
-	//
-	//// Ensure the transaction adheres to nonce ordering
-	//if pool.currentState.GetNonce(from) > tx.Nonce() {
-	//	logger.Errorf("Account :%x , stateNonce:%d   tx.Nonce:%d" , from , pool.currentState.GetNonce(from) , tx.Nonce())
-	//	return ErrNonceTooLow
-	//}
-	//// Transactor should have enough funds to cover the costs
-	//if pool.currentState.GetBalance(from).Cmp(txInfo.Cost()) < 0 {
-	//	logger.Error("[validateTx] insufficient funds Cost")
-	//	return ErrInsufficientFunds
-	//}
-
+	// Heuristic limits, reject oversized transactions to prevent DOS attacks
+	if uint64(tx.Size()) > pool.config.MaxTxSize {
+		oversizedTxCounter.Inc(1)
+		return ErrOversizedData
+	}
+	if uint64(len(tx.Data.Actions)) > pool.config.MaxTxActions {
+		tooManyActionsCounter.Inc(1)
+		return ErrTooManyActions
+	}
+	if tx.ResourceLimit() > params.MaxTxResourceLimit {
+		resourceLimitCounter.Inc(1)
+		return core.ErrResourceLimitTooHigh
+	}
+	// Make sure the transaction is signed properly for this chain
+	from, err := transaction.Sender(pool.signer, tx)
+	if err == transaction.ErrInvalidChainId {
+		invalidChainIdCounter.Inc(1)
+		return ErrInvalidChainId
+	}
+	if err != nil {
+		invalidSenderCounter.Inc(1)
+		return ErrInvalidSender
+	}
+	// Make sure the transaction didn't expire yet, premature ones are queued until
+	// the next block is within their validity bounds
+	number, now := pool.nextBlock()
+	if tx.Expired(number, now) {
+		expiredCounter.Inc(1)
+		return core.ErrTxExpired
+	}
+	// Drop non-local transactions under our own minimal accepted fee
+	if !local && pool.priority.Cmp(tx.GetPriority()) > 0 {
+		underpriorityCounter.Inc(1)
+		return ErrUnderPriority
+	}
+	// Ensure the transaction adheres to nonce ordering
+	if pool.currentState.GetNonce(from) > tx.Nonce() {
+		nonceTooLowCounter.Inc(1)
+		return ErrNonceTooLow
+	}
+	// Transactor should have enough funds to cover the fee
+	if pool.feeChecker != nil {
+		if err := pool.feeChecker.CheckFee(pool.currentState, from, tx); err != nil {
+			logger.Trace("Transaction fee not covered", "from", from, "err", err)
+			insufficientFundCounter.Inc(1)
+			return ErrInsufficientFunds
+		}
+	}
 	return nil
 }
 
+// nextBlock returns the number of the block following the current head and the
+// earliest time it may be produced at.
+func (pool *TxPool) nextBlock() (uint64, uint64) {
+	number, now := pool.currentHead.Number.IntVal.Uint64()+1, uint64(time.Now().Unix())
+	if earliest := pool.currentHead.Time.IntVal.Uint64() + 1; now < earliest {
+		now = earliest
+	}
+	return number, now
+}
+
+// purgeExpired removes all the transactions whose valid until bound has passed
+// for the block following the current head, locals included.
+func (pool *TxPool) purgeExpired() {
+	number, now := pool.nextBlock()
+	for hash, tx := range pool.all {
+		if tx.Expired(number, now) {
+			logger.Trace("Removing expired transaction", "hash", hash)
+			pool.removeTx(hash)
+			expiredPurgeCounter.Inc(1)
+		}
+	}
+}
+
 // add validates a transaction and inserts it into the non-executable queue for
 // later pending promotion and execution. If the transaction is a replacement for
 // an already pending or queued one, it overwrites the previous and returns this
@@ -593,78 +686,74 @@ func (pool *TxPool) validateTx(tx *transaction.Transaction, local bool) error {
 // If a newly added transaction is marked as local, its sending account will be
 // whitelisted
 func (pool *TxPool) add(tx *transaction.Transaction, local bool) (bool, error) {
-	//!!!!!!!!!!!!!!!!!!!!!!!!!!!!
-
-	//get Priority
-	priority := interpreter.GetPriority(types.Address{} , tx.Data.Actions)
-	tx.SetPriority(priority)
-
 	// If the transaction is already known, discard it
 	hash := tx.Hash()
 	if pool.all[hash] != nil {
 		logger.Tracef("Discarding already known transaction hash:0x%x",  hash)
 		return false, fmt.Errorf("known transaction: 0x%x", hash)
 	}
-	// If the transaction fails basic validation, discard it
-
-	////here we do not check validation by pool
-	//if err := pool.validateTx(tx, local); err != nil {
-	//	logger.Trace("Discarding invalid transaction hash:0x%x , err:%s",  hash, err.Error())
-	//	invalidTxCounter.Inc(1)
-	//	return false, err
-	//}
+	// The priority of a transaction is the fee it declares
+	pool.inter.SetPriorityForTransaction(tx)
 
+	// If the transaction fails basic validation, discard it
+	if err := pool.validateTx(tx, local); err != nil {
+		logger.Tracef("Discarding invalid transaction hash:0x%x , err:%s",  hash, err.Error())
+		invalidTxCounter.Inc(1)
+		return false, err
+	}
+	// If the transaction pool is full, discard the lowest priority transactions
 	if uint64(len(pool.all)) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
-		//do not add more transactions
+		// If the new transaction is not better than the worst one, discard it
 		if pool.priorited.Underpriority(tx , pool.locals){
+			logger.Tracef("Discarding underpriority transaction hash:0x%x priority:%v", hash, tx.GetPriority())
+			underpriorityFullCounter.Inc(1)
 			return false , ErrUnderPriority
 		}
-
-		//New transaction is better than our worse ones , make room for it
+		// New transaction is better than our worse ones, make room for it
 		drop := pool.priorited.Discard(len(pool.all) - int(pool.config.GlobalSlots + pool.config.GlobalQueue -1) , pool.locals)
 		for _ , tx := range drop {
+			logger.Tracef("Discarding freshly underpriority transaction hash:0x%x priority:%v", tx.Hash(), tx.GetPriority())
+			evictedCounter.Inc(1)
 			pool.removeTx(tx.Hash())
 		}
-
-		return false,fmt.Errorf("pool.all > config.GlobalQueue")
 	}
-	// If the transaction is replacing an already pending one, do directly
+	// If the transaction is replacing an already pending one, do directly. A premature
+	// transaction is not executable yet, so it can only wait in the queue
 	from, _ := transaction.Sender(pool.signer, tx) // already validated
-	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
-
-		inserted, old := list.Add(tx, 0)
-		if !inserted{
-			//have same nonce ,but priority is lower than before
-			return false  , ErrReplaceUnderpriority
+	number, now := pool.nextBlock()
+	premature := tx.Premature(number, now)
+	if list := pool.pending[from]; list != nil && list.Overlaps(tx) && !premature {
+		// Nonce already pending, check if required priority bump is met
+		inserted, old := list.Add(tx, pool.config.PriorityBump)
+		if !inserted {
+			pendingDiscardCounter.Inc(1)
+			return false, ErrReplaceUnderpriority
 		}
-
-
-
-		// if old != nil,the tx has been here before
+		// New transaction is better, replace old one
 		if old != nil {
-			//delete the old transaction
-			delete(pool.all , old.Hash())
+			delete(pool.all, old.Hash())
 			pool.priorited.Removed()
-		}else{
-		//old == nil,mean here is no the transaction in the pool before
-			pool.all[tx.Hash()] = tx
-			pool.journalTx(from, tx)
-
-			logger.Trace("Pooled new executable transaction hash:0x%x , from:0x%x", hash,  from)
+			pendingReplaceCounter.Inc(1)
+		}
+		pool.all[tx.Hash()] = tx
+		pool.priorited.Put(tx)
+		pool.journalTx(from, tx)
 
-			// We've directly injected a replacement transaction, notify subsystems
-			logger.Debugf("!!!!!!!!!!!add  From:%x  Nonce:%d" , from,tx.Data.AccountNonce)
-			go pool.txFeed.Send(core.TxPreEvent{tx})
+		logger.Tracef("Pooled new executable transaction hash:0x%x , from:0x%x", hash,  from)
 
-		}
+		// We've directly injected a replacement transaction, notify subsystems
+		go pool.txFeed.Send(core.TxPreEvent{tx})
 
-		return true, nil
+		return old != nil, nil
 	}
 	// New transaction isn't replacing a pending one, push into queue
 	replace, err := pool.enqueueTx(hash, tx)
 	if err != nil {
 		return false, err
 	}
+	if premature {
+		queuedPrematureCounter.Inc(1)
+	}
 	// Mark local addresses and journal local transactions
 	if local {
 		pool.locals.add(from)
@@ -692,9 +781,10 @@ func (pool *TxPool) enqueueTx(hash types.Hash, tx *transaction.Transaction) (boo
 	if pool.queue[from] == nil {
 		pool.queue[from] = newTxList(false)
 	}
-	inserted, old := pool.queue[from].Add(tx, 0)
+	inserted, old := pool.queue[from].Add(tx, pool.config.PriorityBump)
 	if !inserted {
-		//new transaction's priority is lower than the old one
+		// An older transaction was better, discard this
+		queuedDiscardCounter.Inc(1)
 		return false , ErrReplaceUnderpriority
 	}
 
@@ -704,6 +794,7 @@ func (pool *TxPool) enqueueTx(hash types.Hash, tx *transaction.Transaction) (boo
 
 		delete(pool.all , old.Hash())
 		pool.priorited.Removed()
+		queuedReplaceCounter.Inc(1)
 	}
 	//notice , if no the same tx before ,we should not return a true boolean,
 	//should false,because not replace
@@ -736,7 +827,7 @@ func (pool *TxPool) promoteTx(addr types.Address, hash types.Hash, tx *transacti
 	}
 	list := pool.pending[addr]
 
-	inserted, old := list.Add(tx, 0)
+	inserted, old := list.Add(tx, pool.config.PriorityBump)
 	if !inserted {
 		// An older transaction was better, discard this
 		delete(pool.all, hash)
@@ -927,6 +1018,7 @@ func (pool *TxPool) promoteExecutables(accounts []types.Address) {
 		}
 	}
 	// Iterate over all accounts and promote any executable transactions
+	number, now := pool.nextBlock()
 	for _, addr := range accounts {
 		list := pool.queue[addr]
 		if list == nil {
@@ -959,8 +1051,8 @@ func (pool *TxPool) promoteExecutables(accounts []types.Address) {
 
 
 		//fmt.Println("[promoteExecutables]List Len Before:Ready:" , len(list.txs.items))
-		// Gather all executable transactions and promote them
-		for _, tx := range list.Ready(pool.pendingState.GetNonce(addr)) {
+		// Gather all executable transactions within their validity bounds and promote them
+		for _, tx := range list.Ready(pool.pendingState.GetNonce(addr), number, now) {
 			hash := tx.Hash()
 			logger.Trace("Promoting queued transaction hash:", hash.String())
 
@@ -1025,7 +1117,7 @@ func (pool *TxPool) promoteExecutables(accounts []types.Address) {
 							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i]) > nonce {
 								pool.pendingState.SetNonce(offenders[i], nonce)
 							}
-							logger.Trace("Removed fairness-exceeding pending transaction hash:0x%x", hash)
+							logger.Tracef("Removed fairness-exceeding pending transaction hash:0x%x", hash)
 						}
 						pending--
 					}
@@ -1046,7 +1138,7 @@ func (pool *TxPool) promoteExecutables(accounts []types.Address) {
 						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
 							pool.pendingState.SetNonce(addr, nonce)
 						}
-						logger.Trace("Removed fairness-exceeding pending transaction hash:0x%x", hash)
+						logger.Tracef("Removed fairness-exceeding pending transaction hash:0x%x", hash)
 					}
 					pending--
 				}
@@ -36,6 +36,9 @@ import (
 	"time"
 	"testing"
 	"math/rand"
+	"errors"
+	"mjoy.io/utils/metrics"
+	"mjoy.io/core/interpreter/balancetransfer"
 )
 
 // Tests that transactions can be added to strict lists and list contents and
@@ -94,13 +97,13 @@ func xtransaction(nonce uint64 , key *ecdsa.PrivateKey ,pool *TxPool)*transactio
 }
 
 func AsignedTransaction(nonce uint64 , key *ecdsa.PrivateKey,pool *TxPool)*transaction.Transaction{
-	tx,_ := transaction.SignTx(transaction.NewTransaction(nonce,types.Address{},randomActions()),mSigner,key)
+	tx,_ := transaction.SignTx(transaction.NewTransaction(nonce,randomActions()),mSigner,key)
 	pool.inter.SetPriorityForTransaction(tx)
 	return tx
 }
 
 func newxtransaction(nonce uint64  ,key *ecdsa.PrivateKey,pool *TxPool)*transaction.Transaction{
-	tx,_ := transaction.SignTx(transaction.NewTransaction(nonce,types.Address{},randomActions()),mSigner,key)
+	tx,_ := transaction.SignTx(transaction.NewTransaction(nonce,randomActions()),mSigner,key)
 	pool.inter.SetPriorityForTransaction(tx)
 	return tx
 }
@@ -108,7 +111,7 @@ func newxtransaction(nonce uint64  ,key *ecdsa.PrivateKey,pool *TxPool)*transact
 
 
 var (
-	TestChainConfig  = &params.ChainConfig{big.NewInt(1)}
+	TestChainConfig  = &params.ChainConfig{ChainId: big.NewInt(1)}
 
 )
 func setupTxPool()(*TxPool , *ecdsa.PrivateKey){
@@ -183,7 +186,6 @@ func (c *testChain)State()(*state.StateDB , error){
 		db,_:= database.OpenMemDB()
 		c.statedb ,_ = state.New(types.Hash{} , state.NewDatabase(db))
 		c.statedb.SetNonce(c.address , 2)
-		c.statedb.SetBalance(c.address , new(big.Int).SetUint64(100000))
 		*c.trigger = false
 	}
 
@@ -202,7 +204,6 @@ func TestStateChangeDuringTransactionPoolReset(t *testing.T){
 		trigger = false
 	)
 
-	statedb.SetBalance(address , new(big.Int).SetUint64(100000000))
 	blockchain := &testChain{&testBlockChain{statedb,new(event.Feed)},address,&trigger}
 
 	pool := NewTxPool(testTxPoolConfig,TestChainConfig,blockchain)
@@ -259,17 +260,13 @@ func TestInvalidTransactions(t *testing.T){
 	tx := xtransaction(0,key,pool)
 
 	from,_ := deriveSender(tx)
-	fmt.Println("Before AddBalance:",pool.currentState.GetBalance(from).Int64())
-	pool.currentState.AddBalance(from,big.NewInt(1))
 	//pool.currentState.AddBalance(from,big.NewInt(1000))
-	fmt.Println("After AddBalance:",pool.currentState.GetBalance(from).Int64())
 
 	if err := pool.AddRemote(tx);err != nil{
 		fmt.Println("test addremote Err:",err)
 	}
 
 	pool.currentState.SetNonce(from,1)
-	pool.currentState.AddBalance(from,big.NewInt(111111111))
 	tx = xtransaction(0,key,pool)
 
 	if err := pool.AddRemote(tx);err != nil{
@@ -287,7 +284,6 @@ func TestTransactionQueue(t *testing.T){
 
 	from,_ := deriveSender(tx)
 
-	pool.currentState.AddBalance(from,big.NewInt(1000))
 	pool.lockedReset(nil,nil)
 	pool.enqueueTx(tx.Hash() , tx)
 
@@ -328,7 +324,6 @@ func TestTransactionQueue(t *testing.T){
 
 	from , _ = deriveSender(tx1)
 
-	pool.currentState.AddBalance(from , big.NewInt(1000))
 	pool.lockedReset(nil, nil)
 
 	fmt.Println("QueueLen before = " , len(pool.queue))
@@ -351,10 +346,8 @@ func TestTransactionNegativeValue(t *testing.T){
 	pool , key := setupTxPool()
 	defer pool.Stop()
 
-	tx ,_ := transaction.SignTx(transaction.NewTransaction(0 , types.Address{} , randomActions()),mSigner,key )
+	tx ,_ := transaction.SignTx(transaction.NewTransaction(0 , randomActions()),mSigner,key )
 	pool.inter.SetPriorityForTransaction(tx)
-	from ,_ := deriveSender(tx)
-	pool.currentState.AddBalance(from , big.NewInt(1))
 
 	if err := pool.AddRemote(tx);err != nil{
 		fmt.Println("Get A err:" , err)
@@ -369,11 +362,9 @@ func TestTransactionChainFork(t *testing.T){
 	pool,key := setupTxPool()
 	defer pool.Stop()
 
-	addr := crypto.PubkeyToAddress(key.PublicKey)
 	resetState := func(){
 		db , _ := database.OpenMemDB()
 		statedb,_ := state.New(types.Hash{} , state.NewDatabase(db))
-		statedb.AddBalance(addr , big.NewInt(100000000000000))
 
 		pool.chain = &testBlockChain{statedb,new(event.Feed)}
 		pool.lockedReset(nil,nil)
@@ -417,7 +408,6 @@ func TestTransactionDoubleNonce(t *testing.T) {
 
 		statedb , _ := state.New(types.Hash{},state.NewDatabase(db))
 
-		statedb.AddBalance(addr , big.NewInt(100000000000000))
 
 		pool.chain = &testBlockChain{statedb  , new(event.Feed)}
 		pool.lockedReset(nil,nil)
@@ -425,11 +415,11 @@ func TestTransactionDoubleNonce(t *testing.T) {
 
 	resetState()
 	fmt.Println("Notice:If All Transaction's nonce are same,the txpool just exist one ")
-	tx1,_:=transaction.SignTx(transaction.NewTransaction(0,types.Address{},randomActions()),mSigner,key)
+	tx1,_:=transaction.SignTx(transaction.NewTransaction(0,randomActions()),mSigner,key)
 	pool.inter.SetPriorityForTransaction(tx1)
-	tx2,_:=transaction.SignTx(transaction.NewTransaction(0,types.Address{},randomActions()),mSigner,key)
+	tx2,_:=transaction.SignTx(transaction.NewTransaction(0,randomActions()),mSigner,key)
 	pool.inter.SetPriorityForTransaction(tx2)
-	tx3,_:=transaction.SignTx(transaction.NewTransaction(0,types.Address{},randomActions()),mSigner,key)
+	tx3,_:=transaction.SignTx(transaction.NewTransaction(0,randomActions()),mSigner,key)
 	pool.inter.SetPriorityForTransaction(tx3)
 
 	fmt.Println("tx1..1:" , tx1.Priority.Int64() , "  tx..2:" , tx2.Priority.Int64() , "   tx3..:",tx3.Priority.Int64())
@@ -457,7 +447,7 @@ func TestTransactionDoubleNonce(t *testing.T) {
 	pool.promoteExecutables([]types.Address{addr})
 
 	if pool.pending[addr].Len() != 1{
-		t.Errorf("Error:expected 1 pending trasactions , got\n",pool.pending[addr].Len())
+		t.Errorf("Error:expected 1 pending trasactions , got %d\n",pool.pending[addr].Len())
 	}
 
 	if tx := pool.pending[addr].txs.items[0];tx.Hash()!= tx2.Hash() {
@@ -481,7 +471,6 @@ func TestTransactionMissingNonce(t *testing.T){
 
 
 	addr := crypto.PubkeyToAddress(key.PublicKey)
-	pool.currentState.AddBalance(addr , big.NewInt(100000000000000))
 
 	tx := xtransaction(1 ,key,pool)
 	if _,err := pool.add(tx , false);err != nil{
@@ -489,7 +478,7 @@ func TestTransactionMissingNonce(t *testing.T){
 	}
 
 	if len(pool.pending) != 0 {
-		fmt.Errorf("expected 0 pending transactions,got %d" , len(pool.pending))
+		t.Errorf("expected 0 pending transactions,got %d" , len(pool.pending))
 	}
 
 	if pool.queue[addr].Len() != 1 {
@@ -510,7 +499,6 @@ func TestTransactionDroppng(t *testing.T){
 	defer pool .Stop()
 
 	account , _ := deriveSender(xtransaction(0 , key,pool))
-	pool.currentState.AddBalance(account , big.NewInt(1000))
 
 	var(
 		tx0 = newxtransaction(0 , key,pool)
@@ -558,14 +546,11 @@ func TestTransactionDroppng(t *testing.T){
 	if len(pool.all)!= 6{
 		t.Errorf("total transaction mismatch :have %d ,want %d",len(pool.all) , 6)
 	}
-	fmt.Println("1now Balance:",pool.currentState.GetBalance(account))
 	fmt.Println("1len pending:",len(pool.pending[account].txs.items))
 	fmt.Println("1len queue:",len(pool.queue[account].txs.items))
 
-	pool.currentState.AddBalance(account,big.NewInt(-500))
 	pool.lockedReset(nil,nil)
 
-	fmt.Println("2now Balance:",pool.currentState.GetBalance(account))
 	fmt.Println("2len pending:",len(pool.pending[account].txs.items))
 	fmt.Println("2len queue:",len(pool.queue[account].txs.items))
 	if _, ok := pool.pending[account].txs.items[tx0.Nonce()]; !ok {
@@ -606,7 +591,6 @@ func TestPendingState(t *testing.T){
 	defer pool .Stop()
 
 	account , _ := deriveSender(xtransaction(0 , key,pool))
-	pool.currentState.AddBalance(account , big.NewInt(1000))
 
 	var(
 		tx0 = newxtransaction(0 ,  key,pool)
@@ -638,7 +622,6 @@ func TestTransactionPostponing(t *testing.T){
 	defer pool.Stop()
 
 	account, _ := deriveSender(newxtransaction(0,  key,pool))
-	pool.currentState.AddBalance(account, big.NewInt(1000))
 
 	txns := []*transaction.Transaction{}
 
@@ -679,7 +662,6 @@ func TestTransactionPostponing(t *testing.T){
 
 
 	// Reduce the balance of the account, and check that transactions are reorganised
-	pool.currentState.AddBalance(account, big.NewInt(-750))
 	pool.lockedReset(nil, nil)
 
 	if _, ok := pool.pending[account].txs.items[txns[0].Nonce()]; !ok {
@@ -702,9 +684,6 @@ func TestTransactionGapFilling(t *testing.T){
 	defer pool.Stop()
 
 
-	account ,_ := deriveSender(newxtransaction(0 ,  key,pool))
-	pool.currentState.AddBalance(account , big.NewInt(1000000))
-
 	events := make(chan core.TxPreEvent , 70)
 	sub := pool.txFeed.Subscribe(events)
 	defer sub.Unsubscribe()
@@ -767,7 +746,6 @@ func TestTransactionQueueAccountLimiting(t *testing.T){
 	defer pool.Stop()
 
 	account ,_ := deriveSender(newxtransaction(0 , key,pool))
-	pool.currentState.AddBalance(account , big.NewInt(1000000))
 
 	for i:= uint64(1);i<=testTxPoolConfig.AccountQueue + 5;i++{
 		if err := pool.AddRemote(newxtransaction(i , key,pool));err != nil{
@@ -807,7 +785,6 @@ func TestBefore(t *testing.T){
 		trigger = false
 	)
 
-	statedb.SetBalance(address , new(big.Int).SetUint64(100000000))
 	blockchain := &testChain{&testBlockChain{statedb,new(event.Feed)},address,&trigger}
 	pool := NewTxPool(testTxPoolConfig,TestChainConfig,blockchain)
 	defer pool.Stop()
@@ -850,7 +827,6 @@ func TestTransactionPendingLimiting(t *testing.T ) {
 	for i:=0;i<len(keys);i++{
 		keys[i] , _ = crypto.GenerateKey()
 		address[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
-		pool.currentState.AddBalance(address[i] , big.NewInt(1000000))
 
 	}
 
@@ -925,7 +901,6 @@ func testTransactionQueueGlobalLimiting(t *testing.T , nolocals bool) {
 	for i:=0;i<len(keys);i++{
 		keys[i] , _ = crypto.GenerateKey()
 		address[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
-		pool.currentState.AddBalance(address[i] , big.NewInt(1000000))
 
 	}
 
@@ -1048,3 +1023,260 @@ func testTransactionQueueGlobalLimiting(t *testing.T , nolocals bool) {
 
 
 
+
+// rejectingFeeChecker finds no sender able to pay its fees
+type rejectingFeeChecker struct{}
+
+func (rejectingFeeChecker) CheckFee(statedb *state.StateDB, from types.Address, tx *transaction.Transaction) error {
+	return errors.New("no funds for the fee")
+}
+
+// Tests that validateTx rejects every kind of invalid transaction with its own
+// error, counted by its own metric.
+func TestValidateTxErrors(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	sign := func(tx *transaction.Transaction, signer transaction.Signer) *transaction.Transaction {
+		signed, err := transaction.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	actions := func(n int, size int) transaction.ActionSlice {
+		slice := make(transaction.ActionSlice, n)
+		for i := range slice {
+			slice[i] = transaction.MakeAction(types.Address{}, make([]byte, size))
+		}
+		return slice
+	}
+	unsigned := sign(transaction.NewTransaction(1, actions(1, 1)), mSigner)
+	unsigned.Data.S = &types.BigInt{}
+
+	tests := []struct {
+		name    string
+		tx      *transaction.Transaction
+		fees    FeeChecker
+		err     error
+		counter *metrics.Counter
+	}{
+		{"size", sign(transaction.NewTransaction(1, actions(1, int(DefaultTxPoolConfig.MaxTxSize))), mSigner), nil, ErrOversizedData, &oversizedTxCounter},
+		{"actions", sign(transaction.NewTransaction(1, actions(int(DefaultTxPoolConfig.MaxTxActions)+1, 1)), mSigner), nil, ErrTooManyActions, &tooManyActionsCounter},
+		{"resource limit", sign(transaction.NewTransactionWithLimit(1, params.MaxTxResourceLimit+1, actions(1, 1)), mSigner), nil, core.ErrResourceLimitTooHigh, &resourceLimitCounter},
+		{"chain id", sign(transaction.NewTransaction(1, actions(1, 1)), transaction.NewMSigner(big.NewInt(2))), nil, ErrInvalidChainId, &invalidChainIdCounter},
+		{"sender", unsigned, nil, ErrInvalidSender, &invalidSenderCounter},
+		{"nonce", sign(transaction.NewTransaction(0, actions(1, 1)), mSigner), nil, ErrNonceTooLow, &nonceTooLowCounter},
+		{"fee hook", sign(transaction.NewTransaction(1, actions(1, 1)), mSigner), rejectingFeeChecker{}, ErrInsufficientFunds, &insufficientFundCounter},
+	}
+	for _, test := range tests {
+		pool, _ := setupTxPool()
+		pool.currentState.SetNonce(from, 1)
+		pool.SetFeeChecker(test.fees)
+
+		counter := &metrics.StandardCounter{}
+		saved := *test.counter
+		*test.counter = counter
+
+		pool.inter.SetPriorityForTransaction(test.tx)
+		err := pool.validateTx(test.tx, false)
+		*test.counter = saved
+		pool.Stop()
+
+		if err != test.err {
+			t.Errorf("%s: error mismatch: have %v, want %v", test.name, err, test.err)
+		}
+		if counter.Count() != 1 {
+			t.Errorf("%s: counter mismatch: have %d, want 1", test.name, counter.Count())
+		}
+	}
+
+	// The same transaction is valid once the fee hook accepts it
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+	pool.currentState.SetNonce(from, 1)
+	if err := pool.validateTx(tests[len(tests)-1].tx, false); err != nil {
+		t.Errorf("valid transaction rejected: %v", err)
+	}
+}
+
+// feeTransaction makes a transaction declaring fee, its priority in the pool
+func feeTransaction(nonce uint64, fee int, key *ecdsa.PrivateKey) *transaction.Transaction {
+	action := transaction.MakeAction(balancetransfer.BalanceTransferAddress, balancetransfer.MakeTransferFeeParam(fee))
+	tx, _ := transaction.SignTx(transaction.NewTransaction(nonce, transaction.ActionSlice{action}), mSigner, key)
+	return tx
+}
+
+// Tests that remote transactions declaring less than MinFee are rejected, and local
+// ones are not.
+func TestTransactionMinFee(t *testing.T) {
+	config := testTxPoolConfig
+	config.MinFee = 100
+
+	db, _ := database.OpenMemDB()
+	statedb, _ := state.New(types.Hash{}, state.NewDatabase(db))
+	pool := NewTxPool(config, TestChainConfig, &testBlockChain{statedb, new(event.Feed)})
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	if err := pool.AddRemote(feeTransaction(0, 99, key)); err != ErrUnderPriority {
+		t.Errorf("remote transaction under the minimal fee: have %v, want %v", err, ErrUnderPriority)
+	}
+	if err := pool.AddRemote(feeTransaction(0, 100, key)); err != nil {
+		t.Errorf("remote transaction of the minimal fee rejected: %v", err)
+	}
+	if err := pool.AddLocal(feeTransaction(1, 1, key)); err != nil {
+		t.Errorf("local transaction under the minimal fee rejected: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Errorf("pending transactions mismatch: have %d, want 2", pending)
+	}
+}
+
+// Tests that a full pool evicts its lowest priority transactions for better ones,
+// and rejects the ones not better than all of them.
+func TestTransactionEvictionOrder(t *testing.T) {
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+
+	db, _ := database.OpenMemDB()
+	statedb, _ := state.New(types.Hash{}, state.NewDatabase(db))
+	pool := NewTxPool(config, TestChainConfig, &testBlockChain{statedb, new(event.Feed)})
+	defer pool.Stop()
+
+	add := func(fee int) (*transaction.Transaction, error) {
+		key, _ := crypto.GenerateKey()
+		tx := feeTransaction(0, fee, key)
+		return tx, pool.AddRemote(tx)
+	}
+	txs := make(map[int]*transaction.Transaction)
+	for _, fee := range []int{30, 10, 40, 20} {
+		tx, err := add(fee)
+		if err != nil {
+			t.Fatalf("failed to add transaction of fee %d: %v", fee, err)
+		}
+		txs[fee] = tx
+	}
+	if _, err := add(10); err != ErrUnderPriority {
+		t.Errorf("transaction not better than the pool: have %v, want %v", err, ErrUnderPriority)
+	}
+	// Every new transaction evicts the cheapest one left
+	for _, test := range []struct{ fee, evicted int }{{15, 10}, {25, 15}, {50, 20}} {
+		tx, err := add(test.fee)
+		if err != nil {
+			t.Fatalf("failed to add transaction of fee %d: %v", test.fee, err)
+		}
+		txs[test.fee] = tx
+		if pool.Get(txs[test.evicted].Hash()) != nil {
+			t.Errorf("transaction of fee %d not evicted by fee %d", test.evicted, test.fee)
+		}
+		if pending, queued := pool.Stats(); pending+queued != 4 {
+			t.Errorf("pool size mismatch after fee %d: have %d, want 4", test.fee, pending+queued)
+		}
+	}
+	for _, fee := range []int{25, 30, 40, 50} {
+		if pool.Get(txs[fee].Hash()) == nil {
+			t.Errorf("transaction of fee %d evicted", fee)
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Errorf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that a pending transaction is only replaced by one bumping its fee by the
+// configured percentage, a free transaction by any paying one.
+func TestTransactionReplacementBump(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	free, _ := transaction.SignTx(transaction.NewTransaction(1, nil), mSigner, key)
+	tests := []struct {
+		nonce    uint64
+		old, new int // -1 is a transaction declaring no fee
+		err      error
+	}{
+		{0, 100, 109, ErrReplaceUnderpriority},
+		{0, 100, 110, nil},
+		{1, -1, 0, ErrReplaceUnderpriority},
+		{1, -1, 1, nil},
+	}
+	for i, test := range tests {
+		old := free
+		if test.old >= 0 {
+			old = feeTransaction(test.nonce, test.old, key)
+		}
+		if pool.Get(old.Hash()) == nil {
+			if err := pool.AddRemote(old); err != nil {
+				t.Fatalf("test %d: failed to add transaction: %v", i, err)
+			}
+		}
+		tx := feeTransaction(test.nonce, test.new, key)
+		if err := pool.AddRemote(tx); err != test.err {
+			t.Errorf("test %d: replacement of fee %d by %d: have %v, want %v", i, test.old, test.new, err, test.err)
+		}
+		if replaced := pool.Get(old.Hash()) == nil; replaced != (test.err == nil) {
+			t.Errorf("test %d: replaced mismatch: have %v, want %v", i, replaced, test.err == nil)
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Errorf("pool internal state corrupted: %v", err)
+	}
+}
+
+func boundedTransaction(nonce, validAfter, validUntil uint64, key *ecdsa.PrivateKey) *transaction.Transaction {
+	action := transaction.MakeAction(balancetransfer.BalanceTransferAddress, balancetransfer.MakeTransferFeeParam(1))
+	tx, _ := transaction.SignTx(transaction.NewBoundedTransaction(nonce, params.TxResourceLimit, validAfter, validUntil, transaction.ActionSlice{action}), mSigner, key)
+	return tx
+}
+
+// Tests that expired transactions are rejected, while premature ones are queued
+// and only promoted once the next block is within their validity bounds, in
+// block numbers as well as in block times.
+func TestTransactionValidityWindow(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	now := uint64(time.Now().Unix())
+	head := func(number, time uint64) {
+		pool.lockedReset(nil, &block.Header{Number: types.NewBigInt(*new(big.Int).SetUint64(number)), Time: types.NewBigInt(*new(big.Int).SetUint64(time))})
+	}
+	head(1, now)
+
+	// Transactions past their bounds are rejected
+	if err := pool.AddRemote(boundedTransaction(0, 0, 1, key)); err != core.ErrTxExpired {
+		t.Errorf("expired block bound: have %v, want %v", err, core.ErrTxExpired)
+	}
+	if err := pool.AddRemote(boundedTransaction(0, 0, now-1, key)); err != core.ErrTxExpired {
+		t.Errorf("expired time bound: have %v, want %v", err, core.ErrTxExpired)
+	}
+	// A transaction valid from a later block waits in the queue, holding back its successors
+	if err := pool.AddRemote(boundedTransaction(0, 4, 0, key)); err != nil {
+		t.Fatalf("failed to add premature transaction: %v", err)
+	}
+	if err := pool.AddRemote(boundedTransaction(1, 0, 0, key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 2 {
+		t.Errorf("before the block bound: have %d pending %d queued, want 0 pending 2 queued", pending, queued)
+	}
+	head(3, now)
+	if pending, queued := pool.Stats(); pending != 2 || queued != 0 {
+		t.Errorf("after the block bound: have %d pending %d queued, want 2 pending 0 queued", pending, queued)
+	}
+	// A transaction valid from a later time waits in the queue too
+	other, _ := crypto.GenerateKey()
+	if err := pool.AddRemote(boundedTransaction(0, now+3600, 0, other)); err != nil {
+		t.Fatalf("failed to add premature transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Errorf("before the time bound: have %d pending %d queued, want 2 pending 1 queued", pending, queued)
+	}
+	head(4, now+3600)
+	if pending, queued := pool.Stats(); pending != 3 || queued != 0 {
+		t.Errorf("after the time bound: have %d pending %d queued, want 3 pending 0 queued", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
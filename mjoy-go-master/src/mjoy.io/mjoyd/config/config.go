@@ -44,6 +44,7 @@ type Iconfig interface {
 type Config struct{
 	path string
 	configs map[string] Iconfig
+	overrides map[string] func(Iconfig) error
 }
 
 var c *Config
@@ -54,6 +55,7 @@ func GetConfigInstance() *Config {
 		c =&Config{
 			path: defaults.DefaultTOMLConfigPath,
 			configs: make(map[string]Iconfig),
+			overrides: make(map[string]func(Iconfig) error),
 		}
 	})
 	return c
@@ -75,11 +77,23 @@ func (c *Config) Register(name string, config Iconfig) error {
 	if err := loadConfig(path, config); err != nil{
 		return err
 	}
+	if override, ok := c.overrides[name]; ok {
+		if err := override(config); err != nil {
+			return err
+		}
+	}
 	c.configs[name] = config
 	//c.dumpAllconfig()
 	return nil
 }
 
+//Override sets a function changing the config of module name after it is loaded, so that
+//command line flags take precedence over the config file. It must be set before the module
+//registers its config
+func (c *Config) Override(name string, override func(Iconfig) error) {
+	c.overrides[name] = override
+}
+
 func (c *Config) Unregister(name string) error {
 	if _, ok := c.configs[name]; !ok {
 		logger.Error("module",name,"is not registered")
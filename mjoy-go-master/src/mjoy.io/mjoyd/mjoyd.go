@@ -51,6 +51,8 @@ var (
 		utils.MetricsEnabledFlag,
 		utils.WorkingNetFlag,
 		utils.ResyncBlockFlag,
+		utils.DevModeFlag,
+		utils.DevPeriodFlag,
 	}
 
 	logTag = "mjoyd.main"
@@ -143,6 +145,10 @@ func mjoyd(ctx *cli.Context) error {
 		return err
 	}
 
+	if ctx.GlobalBool(utils.DevModeFlag.Name) {
+		setupDevMode(ctx)
+	}
+
 	node := createMjoyNode(ctx)
 	if node == nil {
 		logger.Critical("Create node failed.")
@@ -116,6 +116,31 @@ func createMjoydCfg(ctx *cli.Context)*mjoy.MjoydConfig{
 	}
 }
 
+// setupDevMode makes the node run an ephemeral development chain: the databases and the
+// keystore are in memory, the node has no peers and produces the blocks itself
+func setupDevMode(ctx *cli.Context){
+	period := ctx.GlobalUint64(utils.DevPeriodFlag.Name)
+	logger.Info("Running a development chain" , "period" , period)
+
+	c := config.GetConfigInstance()
+	c.Override("node" , func(conf config.Iconfig)error{
+		nodeConfig := conf.(*node.Config)
+		nodeConfig.DataDir = ""
+		nodeConfig.KeyStoreDir = ""
+		nodeConfig.P2P.MaxPeers = 0
+		nodeConfig.P2P.ListenAddr = ""
+		nodeConfig.P2P.NoDiscovery = true
+		nodeConfig.P2P.DiscoveryV5 = false
+		return nil
+	})
+	c.Override("mjoy" , func(conf config.Iconfig)error{
+		mjoyConfig := conf.(*mjoy.Config)
+		mjoyConfig.Dev = true
+		mjoyConfig.DevPeriod = period
+		return nil
+	})
+}
+
 func createMjoyNode(ctx *cli.Context)(*node.Node){
 	c := config.GetConfigInstance()
 	c.SetPath(ctx.GlobalString(utils.ConfigFileFlag.Name))
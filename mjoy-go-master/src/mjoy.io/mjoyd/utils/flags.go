@@ -177,4 +177,14 @@ var (
 		Name: 	"resync-block",
 		Usage:	"Clear chain database and rebuild blockchain.",
 	}
+
+	DevModeFlag = cli.BoolFlag{
+		Name:  "dev",
+		Usage: "Ephemeral development chain with a pre-funded developer account, producing blocks on transactions",
+	}
+
+	DevPeriodFlag = cli.Uint64Flag{
+		Name:  "dev.period",
+		Usage: "Block period of the development chain in seconds (0 = produce a block for pending transactions)",
+	}
 )
@@ -27,6 +27,11 @@ import (
 	"sync"
 	"sync/atomic"
 	"mjoy.io/consensus"
+	"mjoy.io/consensus/poa"
+	"mjoy.io/consensus/bft"
+	"mjoy.io/consensus/dev"
+	"mjoy.io/consensus/lottery"
+	"mjoy.io/core"
 
 	"mjoy.io/node/services/mjoy/downloader"
 	"mjoy.io/utils/event"
@@ -92,6 +97,7 @@ type Mjoy struct {
 	blockproducer     *blockproducer.Blockproducer
 	interVm             *interpreter.Vms
 	coinbase types.Address
+	signKey  *ecdsa.PrivateKey // Key the engine signs with, also signs the double sign reports
 
 	networkId     uint64
 	//netRPCService *mjoyapi.PublicNetAPI
@@ -128,6 +134,11 @@ func New(ctx *node.ServiceContext) (*Mjoy, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	if config.Dev {
+		if err := setupDevChain(ctx.AccountManager, config); err != nil {
+			return nil, err
+		}
+	}
 	chainDb, err := CreateDB(ctx, config, "chaindata")
 	if err != nil {
 		return nil, err
@@ -167,6 +178,12 @@ func New(ctx *node.ServiceContext) (*Mjoy, error) {
 	if err != nil {
 		return nil, err
 	}
+	forkChoice, err := CreateForkChoice(config, mjoy.engine)
+	if err != nil {
+		return nil, err
+	}
+	mjoy.blockchain.SetForkChoice(forkChoice)
+	mjoy.blockchain.SetMaxReorgDepth(config.MaxReorgDepth)
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		logger.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -174,12 +191,16 @@ func New(ctx *node.ServiceContext) (*Mjoy, error) {
 		blockchain.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	mjoy.bloomIndexer.Start(mjoy.blockchain)
+	if engine, ok := mjoy.engine.(*bft.Bft); ok {
+		engine.Start(mjoy.blockchain, mjoy.validateBftBlock, mjoy.commitBftBlock)
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
 
 	mjoy.txPool = txprocessor.NewTxPool(config.TxPool, mjoy.chainConfig, mjoy.blockchain)
+	mjoy.txPool.SetFeeChecker(interpreter.NewFeeChecker(chainDb))
 
 	if mjoy.protocolManager, err = NewProtocolManager(mjoy.chainConfig, config.SyncMode, config.NetworkId, mjoy.eventMux, mjoy.txPool, mjoy.engine, mjoy.blockchain, chainDb); err != nil {
 		return nil, err
@@ -214,23 +235,100 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (database.I
 
 // CreateConsensusEngine creates the required type of consensus engine instance for an Mjoy service
 func CreateConsensusEngine(mjoy *Mjoy) consensus.Engine {
+	// If a development chain is requested, set it up
+	if mjoy.chainConfig.Dev != nil {
+		return dev.New(mjoy.chainConfig.Dev, nil)
+	}
+	// If proof-of-authority is requested, set it up
+	if mjoy.chainConfig.Poa != nil {
+		return poa.New(mjoy.chainConfig.Poa, nil)
+	}
+	// If bft finality is requested, set it up
+	if mjoy.chainConfig.Bft != nil {
+		return bft.New(mjoy.chainConfig.Bft, mjoy.chainDb)
+	}
+	// If the VRF leader lottery is requested, set it up
+	if mjoy.chainConfig.Lottery != nil {
+		return lottery.New(mjoy.chainConfig.Lottery, nil)
+	}
 	engine := consensus.NewBasicEngine(nil)
 	return engine
 }
 
+// CreateForkChoice creates the fork choice rule named by the config, it never drops
+// a block finalized by the engine.
+func CreateForkChoice(config *Config, engine consensus.Engine) (blockchain.ForkChoice, error) {
+	var base blockchain.ForkChoice
+	switch config.ForkChoice {
+	case "", "longest":
+		base = blockchain.NewLongestChain()
+	case "heaviest":
+		base = blockchain.NewHeaviestChain(engine)
+	default:
+		return nil, fmt.Errorf("unknown fork choice %q", config.ForkChoice)
+	}
+	return blockchain.NewFinalizedChain(engine, base), nil
+}
+
 func (s *Mjoy) SetEngineKey(pri *ecdsa.PrivateKey) {
+	s.lock.Lock()
+	s.signKey = pri
+	s.lock.Unlock()
+
 	switch v := s.engine.(type) {
 	case *consensus.Engine_basic:
 		v.SetKey(pri)
+	case *poa.Poa:
+		v.SetKey(pri)
+	case *bft.Bft:
+		v.SetKey(pri)
+	case *dev.Dev:
+		v.SetKey(pri)
+	case *lottery.Lottery:
+		v.SetKey(pri)
 	}
 }
 
+// validateBftBlock runs the transactions of a proposed block on the state of its
+// parent before the validator prevotes for it.
+func (s *Mjoy) validateBftBlock(blk *block.Block) error {
+	bc := s.blockchain
+	if err := bc.Validator().ValidateBody(blk); err != nil && err != core.ErrKnownBlock {
+		return err
+	}
+	parent := bc.GetBlock(blk.ParentHash(), blk.NumberU64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	statedb, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return err
+	}
+	_, receipts, _, err := bc.Processor().Process(blk, statedb, bc.GetDb(), bc.Config())
+	if err != nil {
+		return err
+	}
+	return bc.Validator().ValidateState(blk, parent, statedb, receipts)
+}
+
+// commitBftBlock inserts a block committed by the validators and announces it.
+func (s *Mjoy) commitBftBlock(blk *block.Block) error {
+	if _, err := s.blockchain.InsertChain(block.Blocks{blk}); err != nil {
+		return err
+	}
+	s.eventMux.Post(core.NewProducedBlockEvent{Block: blk})
+	return nil
+}
+
 // APIs returns the collection of RPC services the mjoy package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 
 func (s *Mjoy) APIs() []rpc.API {
 	apis := mjoyapi.GetAPIs(s.ApiBackend)
 
+	// Append any APIs exposed explicitly by the consensus engine
+	apis = append(apis, s.engine.APIs(s.BlockChain())...)
+
 	//create New
 	//apis := make([]rpc.API , 0)
 
@@ -325,10 +423,14 @@ func (s *Mjoy) Downloader() *downloader.Downloader { return s.protocolManager.do
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Mjoy) Protocols() []p2p.Protocol {
+	protocols := s.protocolManager.SubProtocols
+	if engine, ok := s.engine.(*bft.Bft); ok {
+		protocols = append(protocols, engine.Protocol())
+	}
 	if s.lesServer == nil {
-		return s.protocolManager.SubProtocols
+		return protocols
 	}
-	return append(s.protocolManager.SubProtocols, s.lesServer.Protocols()...)
+	return append(protocols, s.lesServer.Protocols()...)
 }
 
 // Start implements node.Service, starting all internal goroutines needed by the
@@ -350,11 +452,19 @@ func (s *Mjoy) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	// Report the double signs seen by the chain until it stops
+	go s.evidenceLoop()
 
 	_ , err := s.Coinbase()
 	if err != nil{
 		fmt.Println("[Warn]No CoinBase Do Not Start Producing Block!!!!!!!!!!!!!!!!!s")
 	}
+	// A development chain produces its blocks from the start
+	if s.config.Dev {
+		if err := s.StartProducing(true, devPassphrase); err != nil {
+			return err
+		}
+	}
 	//when start mjoy service,not start blockproducer,except the cmd order we should start it
 	if s.config.StartBlockproducerAtStart{
 		fmt.Println("Start Blockproducer At Service Start.......................")
@@ -374,6 +484,9 @@ func (s *Mjoy) Stop() error {
 		s.stopDbUpgrade()
 	}
 	s.bloomIndexer.Close()
+	if engine, ok := s.engine.(*bft.Bft); ok {
+		engine.Stop()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
@@ -82,6 +82,10 @@ type Config struct {
 	ExtraData    []byte       `toml:",omitempty"`
 
 
+	// Fork choice options
+	ForkChoice    string `toml:",omitempty"` // "longest" (default) or "heaviest" by validator weight
+	MaxReorgDepth uint64 `toml:",omitempty"` // Max number of canonical blocks a reorg drops, 0 for no limit
+
 	// Transaction pool options
 	TxPool txprocessor.TxPoolConfig
 
@@ -95,6 +99,11 @@ type Config struct {
 
 	//should we start blockproducer at first
 	StartBlockproducerAtStart bool
+
+	// Development chain options, the node runs a local chain producing its blocks with
+	// a funded and unlocked account
+	Dev       bool   `toml:",omitempty"`
+	DevPeriod uint64 `toml:",omitempty"` // Seconds between the blocks, 0 seals on transactions
 }
 
 func (c *Config) SetDefaultConfig() error{
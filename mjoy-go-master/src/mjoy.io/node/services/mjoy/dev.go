@@ -0,0 +1,64 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: dev.go
+// @Date: 2018/07/26 16:22:05
+////////////////////////////////////////////////////////////////////////////////
+
+package mjoy
+
+import (
+	"errors"
+
+	"mjoy.io/accounts"
+	"mjoy.io/accounts/keystore"
+	"mjoy.io/core/genesis"
+	"mjoy.io/params"
+)
+
+// devPassphrase is the passphrase of the account of a development chain
+const devPassphrase = ""
+
+var errNoKeystore = errors.New("no keystore to create the dev account in")
+
+// setupDevChain makes config run a development chain: the first account of the keystore,
+// created if there is none, is unlocked, funded by the genesis and signs every block.
+func setupDevChain(am *accounts.Manager, config *Config) error {
+	backends := am.Backends(keystore.KeyStoreType)
+	if len(backends) == 0 {
+		return errNoKeystore
+	}
+	ks := backends[0].(*keystore.KeyStore)
+
+	var (
+		account accounts.Account
+		err     error
+	)
+	if all := ks.Accounts(); len(all) > 0 {
+		account = all[0]
+	} else if account, err = ks.NewAccount(devPassphrase); err != nil {
+		return err
+	}
+	if err := ks.Unlock(account, devPassphrase); err != nil {
+		return err
+	}
+	logger.Info("Using developer account", "address", account.Address.Hex())
+
+	config.Genesis = genesis.DeveloperGenesisBlock(config.DevPeriod, account.Address)
+	config.NetworkId = uint64(params.DevChainId)
+	config.Coinbase = account.Address
+	return nil
+}
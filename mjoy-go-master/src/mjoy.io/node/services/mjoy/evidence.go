@@ -0,0 +1,102 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: evidence.go
+// @Date: 2018/07/23 11:06:52
+////////////////////////////////////////////////////////////////////////////////
+
+package mjoy
+
+import (
+	"errors"
+
+	"github.com/hashicorp/golang-lru"
+	"mjoy.io/common/types"
+	"mjoy.io/core"
+	"mjoy.io/core/blockchain/block"
+	"mjoy.io/core/interpreter/slashing"
+	"mjoy.io/core/transaction"
+	"mjoy.io/utils/crypto"
+)
+
+const (
+	// evidenceChanSize is the size of channel listening to DoubleSignEvent.
+	evidenceChanSize = 16
+	// reportedEvidenceLimit is the number of double signs remembered as reported.
+	reportedEvidenceLimit = 256
+)
+
+var errNoSignKey = errors.New("no key to sign the report with, the node is not producing")
+
+// reportedEvidence identifies a double sign reported by the node.
+type reportedEvidence struct {
+	signer types.Address
+	number uint64
+}
+
+// evidenceLoop reports every double sign seen by the block chain to the slashing
+// contract, in a transaction signed by the key the node produces with. It returns
+// when the block chain stops.
+func (s *Mjoy) evidenceLoop() {
+	ch := make(chan core.DoubleSignEvent, evidenceChanSize)
+	sub := s.blockchain.SubscribeDoubleSignEvent(ch)
+	defer sub.Unsubscribe()
+
+	reported, _ := lru.New(reportedEvidenceLimit)
+	signer := block.NewBlockSigner(s.chainConfig.ChainId)
+	for {
+		select {
+		case ev := <-ch:
+			offender, err := slashing.VerifyDoubleSign(signer, ev.First, ev.Second)
+			if err != nil {
+				logger.Warn("Invalid double sign evidence", "err", err)
+				continue
+			}
+			key := reportedEvidence{signer: offender, number: ev.First.Number.IntVal.Uint64()}
+			if reported.Contains(key) {
+				continue
+			}
+			if err := s.reportDoubleSign(ev); err != nil {
+				logger.Warn("Failed to report double sign", "signer", offender.Hex(), "number", key.number, "err", err)
+				continue
+			}
+			reported.Add(key, struct{}{})
+			logger.Info("Reported double sign", "signer", offender.Hex(), "number", key.number)
+
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
+// reportDoubleSign adds the transaction reporting the evidence to the pool.
+func (s *Mjoy) reportDoubleSign(ev core.DoubleSignEvent) error {
+	s.lock.RLock()
+	key := s.signKey
+	s.lock.RUnlock()
+	if key == nil {
+		return errNoSignKey
+	}
+	reporter := crypto.PubkeyToAddress(key.PublicKey)
+
+	action := transaction.MakeAction(slashing.SlashingAddress, slashing.MakeReportDoubleSignParam(ev.First, ev.Second))
+	tx := transaction.NewTransaction(s.txPool.State().GetNonce(reporter), transaction.ActionSlice{action})
+	signed, err := transaction.SignTx(tx, transaction.NewMSigner(s.chainConfig.ChainId), key)
+	if err != nil {
+		return err
+	}
+	return s.txPool.AddLocal(signed)
+}
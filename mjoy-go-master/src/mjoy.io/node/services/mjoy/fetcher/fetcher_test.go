@@ -47,7 +47,7 @@ var (
 	unknownBlock = block.NewBlock(&block.Header{}, nil, nil)
 )
 
-var defaultChainConfig = &params.ChainConfig{big.NewInt(100)}
+var defaultChainConfig = &params.ChainConfig{ChainId: big.NewInt(100)}
 
 // makeChain creates a chain of n blocks starting at and including parent.
 // the returned hash chain is ordered head->parent. In addition, every 3rd block
@@ -24,10 +24,14 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Coinbase			types.Address	`toml:",omitempty"`
 		BlockproducerThreads		int		`toml:",omitempty"`
 		ExtraData			hex.Bytes	`toml:",omitempty"`
+		ForkChoice			string	`toml:",omitempty"`
+		MaxReorgDepth			uint64	`toml:",omitempty"`
 		TxPool				txprocessor.TxPoolConfig
 		EnablePreimageRecording		bool
 		DocRoot				string	`toml:"-"`
 		StartBlockproducerAtStart	bool
+		Dev				bool	`toml:",omitempty"`
+		DevPeriod			uint64	`toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -41,10 +45,14 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Coinbase = c.Coinbase
 	enc.BlockproducerThreads = c.BlockproducerThreads
 	enc.ExtraData = c.ExtraData
+	enc.ForkChoice = c.ForkChoice
+	enc.MaxReorgDepth = c.MaxReorgDepth
 	enc.TxPool = c.TxPool
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
 	enc.DocRoot = c.DocRoot
 	enc.StartBlockproducerAtStart = c.StartBlockproducerAtStart
+	enc.Dev = c.Dev
+	enc.DevPeriod = c.DevPeriod
 	return &enc, nil
 }
 
@@ -61,10 +69,14 @@ func (c *Config) UnmarshalTOML(unmarshal func (interface{}) error) error {
 		Coinbase			*types.Address	`toml:",omitempty"`
 		BlockproducerThreads		*int		`toml:",omitempty"`
 		ExtraData			hex.Bytes	`toml:",omitempty"`
+		ForkChoice			*string	`toml:",omitempty"`
+		MaxReorgDepth			*uint64	`toml:",omitempty"`
 		TxPool				*txprocessor.TxPoolConfig
 		EnablePreimageRecording		*bool
 		DocRoot				*string	`toml:"-"`
 		StartBlockproducerAtStart	*bool
+		Dev				*bool	`toml:",omitempty"`
+		DevPeriod			*uint64	`toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -103,6 +115,12 @@ func (c *Config) UnmarshalTOML(unmarshal func (interface{}) error) error {
 	if dec.ExtraData != nil {
 		c.ExtraData = dec.ExtraData
 	}
+	if dec.ForkChoice != nil {
+		c.ForkChoice = *dec.ForkChoice
+	}
+	if dec.MaxReorgDepth != nil {
+		c.MaxReorgDepth = *dec.MaxReorgDepth
+	}
 	if dec.TxPool != nil {
 		c.TxPool = *dec.TxPool
 	}
@@ -115,6 +133,12 @@ func (c *Config) UnmarshalTOML(unmarshal func (interface{}) error) error {
 	if dec.StartBlockproducerAtStart != nil {
 		c.StartBlockproducerAtStart = *dec.StartBlockproducerAtStart
 	}
+	if dec.Dev != nil {
+		c.Dev = *dec.Dev
+	}
+	if dec.DevPeriod != nil {
+		c.DevPeriod = *dec.DevPeriod
+	}
 	return nil
 }
 
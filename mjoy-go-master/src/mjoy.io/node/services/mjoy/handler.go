@@ -514,7 +514,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 					logP := &transaction.LogProtocol{log.Address,log.Topics,log.Data}
 					logPs = append(logPs, logP)
 				}
-				loreceiptP := &transaction.ReceiptProtocol{receipt.Status,receipt.Bloom, logPs}
+				loreceiptP := &transaction.ReceiptProtocol{receipt.Status,receipt.Bloom, logPs, receipt.ResourceUsed}
 				receiptPs = append(receiptPs, loreceiptP)
 			}
 			// If known, encode and queue for response packet
@@ -51,9 +51,9 @@ var (
 	testBankKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 	testBank       = crypto.PubkeyToAddress(testBankKey.PublicKey)
 )
-var defaultChainConfig = &params.ChainConfig{big.NewInt(100)}
+var defaultChainConfig = &params.ChainConfig{ChainId: big.NewInt(100)}
 
-var testChainConfig = &params.ChainConfig{big.NewInt(200)}
+var testChainConfig = &params.ChainConfig{ChainId: big.NewInt(200)}
 // newTestProtocolManager creates a new protocol manager for testing purposes,
 // with the given number of blocks already known, and potential notification
 // channels for different events.
@@ -23,26 +23,237 @@ package params
 import (
 	"math/big"
 	"fmt"
-	"mjoy.io/utils/crypto"
+	"mjoy.io/common/types"
+	"mjoy.io/common/types/util/hex"
 )
 
 type ChainConfig struct {
 	ChainId *big.Int `json:"chainId"` // Chain id identifies the current chain and is used for replay protection
+
+	BigBalanceBlock *big.Int `json:"bigBalanceBlock,omitempty"` // BigBalance switch block (nil = no fork), balances are rewritten as arbitrary precision numbers at the end of it
+	StakingBlock    *big.Int `json:"stakingBlock,omitempty"`    // Staking switch block (nil = no fork), the staking and slashing contracts are callable from it on
+	RewardBlock     *big.Int `json:"rewardBlock,omitempty"`     // Reward switch block (nil = no fork), the engine pays the block rewards from it on
+
+	Poa     *PoaConfig     `json:"poa,omitempty"`     // Proof-of-Authority engine settings (nil = basic engine)
+	Bft     *BftConfig     `json:"bft,omitempty"`     // BFT finality engine settings (nil = basic engine)
+	Dev     *DevConfig     `json:"dev,omitempty"`     // Development engine settings (nil = basic engine)
+	Lottery *LotteryConfig `json:"lottery,omitempty"` // VRF leader lottery engine settings (nil = basic engine)
+
+	Slot *SlotConfig `json:"slot,omitempty"` // Block slot schedule (nil = a block follows its parent by a second at least)
+
+	Reward *RewardConfig `json:"reward,omitempty"` // Block reward schedule (nil = DefaultRewardConfig)
 }
 
-var(
-	RewordPrikey , _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f292")
-	Address = crypto.PubkeyToAddress(RewordPrikey.PublicKey)
-)
+// PoaConfig is the consensus engine configs for proof-of-authority based sealing.
+type PoaConfig struct {
+	Epoch      uint64         `json:"epoch"`      // Number of blocks after which the pending votes are dropped
+	Validators []PoaValidator `json:"validators"` // Validators of the genesis block, in proposer order
+}
+
+// PoaValidator is a block signer, it gets Weight proposer slots of every round
+type PoaValidator struct {
+	Address types.Address `json:"address"`
+	Weight  uint64        `json:"weight,omitempty"` // 0 means 1
+}
+
+// BftConfig is the consensus engine configs for the BFT finality engine, the timeouts are
+// in milliseconds and grow by TimeoutDelta every round. With BlsKeys set the commits are
+// aggregated into a single bls signature, every validator needs a key then.
+type BftConfig struct {
+	Validators       []types.Address `json:"validators"` // Validators in proposer order
+	TimeoutPropose   uint64          `json:"timeoutPropose,omitempty"`
+	TimeoutPrevote   uint64          `json:"timeoutPrevote,omitempty"`
+	TimeoutPrecommit uint64          `json:"timeoutPrecommit,omitempty"`
+	TimeoutDelta     uint64          `json:"timeoutDelta,omitempty"`
+	BlsKeys          []BftBlsKey     `json:"blsKeys,omitempty"` // Bls keys of the validators (nil = no aggregation)
+}
+
+// BftBlsKey is the bls public key of a bft validator, Proof is its proof of possession:
+// the signature of the key by itself, without which a key could be made to cancel the
+// ones of other validators in an aggregate.
+type BftBlsKey struct {
+	Address   types.Address `json:"address"`
+	PublicKey hex.Bytes     `json:"publicKey"`
+	Proof     hex.Bytes     `json:"proof"`
+}
+
+// DevConfig is the consensus engine configs of a local development chain, Signer seals
+// every block. With a zero Period a block is sealed as soon as there are transactions.
+type DevConfig struct {
+	Period uint64        `json:"period,omitempty"` // Seconds between the blocks, 0 seals on transactions
+	Signer types.Address `json:"signer"`
+}
+
+// LotteryConfig is the consensus engine configs of the VRF leader lottery: time is cut in
+// slots of Period seconds, and a validator is eligible to produce the block of a slot if
+// its VRF output over the parent hash and the slot falls below the threshold giving
+// Leaders eligible validators per slot on average.
+type LotteryConfig struct {
+	Period     uint64          `json:"period,omitempty"`  // Seconds of a slot, 0 means 1
+	Leaders    uint64          `json:"leaders,omitempty"` // Average number of eligible validators of a slot, 0 means 1
+	Validators []types.Address `json:"validators"`        // Validators as long as no candidate is elected by stake
+}
+
+// SlotConfig is the block slot schedule: a block follows its parent by Interval seconds at
+// least. If SkipEmpty is set a block is only made for pending transactions, or as an empty
+// heartbeat block once MaxIdle seconds passed since its parent. MaxTxs and MaxBytes limit
+// the transactions of a block.
+type SlotConfig struct {
+	Interval  uint64 `json:"interval,omitempty"`  // Target seconds between two blocks
+	SkipEmpty bool   `json:"skipEmpty,omitempty"` // Only make blocks for pending transactions
+	MaxIdle   uint64 `json:"maxIdle,omitempty"`   // Seconds before a heartbeat block, 0 means none is made
+	MaxTxs    uint64 `json:"maxTxs,omitempty"`    // 0 means no limit
+	MaxBytes  uint64 `json:"maxBytes,omitempty"`  // 0 means no limit
+}
+
+// RewardConfig is the block reward schedule, the engines pay it in Finalize. The reward
+// starts at InitialReward and loses DecayPercent of itself every HalvingInterval blocks.
+// TreasuryShare percent of the reward and of the resource fees of a block go to Treasury,
+// the rest to the producer of the block.
+type RewardConfig struct {
+	InitialReward   *big.Int      `json:"initialReward"`
+	HalvingInterval uint64        `json:"halvingInterval,omitempty"` // 0 means the reward never decreases
+	DecayPercent    uint64        `json:"decayPercent,omitempty"`    // 0 means 50, the reward halves
+	TreasuryShare   uint64        `json:"treasuryShare,omitempty"`   // Percent, not taken if Treasury is not set
+	Treasury        types.Address `json:"treasury,omitempty"`
+}
+
+// DefaultRewardConfig rewards every block with the same amount and has no treasury
+var DefaultRewardConfig = &RewardConfig{InitialReward: big.NewInt(5e+5)}
+
+// RewardSchedule returns the reward schedule of the chain
+func (c *ChainConfig) RewardSchedule() *RewardConfig {
+	if c.Reward == nil {
+		return DefaultRewardConfig
+	}
+	return c.Reward
+}
+
+// BlockReward returns the reward of the block num
+func (r *RewardConfig) BlockReward(num uint64) *big.Int {
+	reward := new(big.Int)
+	if r.InitialReward == nil {
+		return reward
+	}
+	reward.Set(r.InitialReward)
+	if r.HalvingInterval == 0 {
+		return reward
+	}
+	decay := r.DecayPercent
+	if decay == 0 {
+		decay = 50
+	}
+	if decay >= 100 {
+		if num >= r.HalvingInterval {
+			reward.SetUint64(0)
+		}
+		return reward
+	}
+	keep := new(big.Int).SetUint64(100 - decay)
+	for periods := num / r.HalvingInterval; periods > 0 && reward.Sign() > 0; periods-- {
+		reward.Mul(reward, keep)
+		reward.Div(reward, big.NewInt(100))
+	}
+	return reward
+}
+
+// TreasuryPart returns the part of amount that goes to the treasury
+func (r *RewardConfig) TreasuryPart(amount *big.Int) *big.Int {
+	if (r.Treasury == types.Address{}) || r.TreasuryShare == 0 {
+		return new(big.Int)
+	}
+	share := r.TreasuryShare
+	if share > 100 {
+		share = 100
+	}
+	part := new(big.Int).Mul(amount, new(big.Int).SetUint64(share))
+	return part.Div(part, big.NewInt(100))
+}
+
+// IsBigBalanceFork returns whether num is the block whose end rewrites the balances
+func (c *ChainConfig) IsBigBalanceFork(num *big.Int) bool {
+	return c.BigBalanceBlock != nil && num != nil && c.BigBalanceBlock.Cmp(num) == 0
+}
+
+// IsBigBalance returns whether the balances are arbitrary precision numbers after the block num
+func (c *ChainConfig) IsBigBalance(num *big.Int) bool {
+	return isForked(c.BigBalanceBlock, num)
+}
+
+// IsStaking returns whether num is either equal to the Staking fork block or greater.
+func (c *ChainConfig) IsStaking(num *big.Int) bool {
+	return isForked(c.StakingBlock, num)
+}
+
+// IsReward returns whether num is either equal to the Reward fork block or greater.
+func (c *ChainConfig) IsReward(num *big.Int) bool {
+	return isForked(c.RewardBlock, num)
+}
 
 var (
 
 	DefaultChainId = 1
 	WorkingChainId = 1
-	DefaultChainConfig = &ChainConfig{big.NewInt(1)}
-	TestChainConfig = &ChainConfig{ChainId:big.NewInt(101)}
+	DevChainId = 1337
+	DefaultChainConfig = &ChainConfig{ChainId:big.NewInt(1), StakingBlock:big.NewInt(0), RewardBlock:big.NewInt(0)}
+	TestChainConfig = &ChainConfig{ChainId:big.NewInt(101), StakingBlock:big.NewInt(0), RewardBlock:big.NewInt(0)}
 )
 
+// CheckCompatible checks whether scheduled fork transitions have been imported
+// with a mismatching chain configuration.
+func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
+	bhead := new(big.Int).SetUint64(height)
+
+	// Iterate checkCompatible to find the lowest conflict.
+	var lasterr *ConfigCompatError
+	for {
+		err := c.checkCompatible(newcfg, bhead)
+		if err == nil || (lasterr != nil && err.RewindTo == lasterr.RewindTo) {
+			break
+		}
+		lasterr = err
+		bhead.SetUint64(err.RewindTo)
+	}
+	return lasterr
+}
+
+func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
+	if isForkIncompatible(c.BigBalanceBlock, newcfg.BigBalanceBlock, head) {
+		return newCompatError("BigBalance fork block", c.BigBalanceBlock, newcfg.BigBalanceBlock)
+	}
+	if isForkIncompatible(c.StakingBlock, newcfg.StakingBlock, head) {
+		return newCompatError("Staking fork block", c.StakingBlock, newcfg.StakingBlock)
+	}
+	if isForkIncompatible(c.RewardBlock, newcfg.RewardBlock, head) {
+		return newCompatError("Reward fork block", c.RewardBlock, newcfg.RewardBlock)
+	}
+	return nil
+}
+
+// isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
+// block s2 because head is already past the fork.
+func isForkIncompatible(s1, s2, head *big.Int) bool {
+	return (isForked(s1, head) || isForked(s2, head)) && !configNumEqual(s1, s2)
+}
+
+// isForked returns whether a fork scheduled at block s is active at the given head block.
+func isForked(s, head *big.Int) bool {
+	if s == nil || head == nil {
+		return false
+	}
+	return s.Cmp(head) <= 0
+}
+
+func configNumEqual(x, y *big.Int) bool {
+	if x == nil {
+		return y == nil
+	}
+	if y == nil {
+		return x == nil
+	}
+	return x.Cmp(y) == 0
+}
+
 // ConfigCompatError is raised if the locally-stored blockchain is initialised with a
 // ChainConfig that would alter the past.
 type ConfigCompatError struct {
@@ -57,3 +268,43 @@ func (err *ConfigCompatError) Error() string {
 	return fmt.Sprintf("mismatching %s in database (have %d, want %d, rewindto %d)", err.What, err.StoredConfig, err.NewConfig, err.RewindTo)
 }
 
+func newCompatError(what string, storedblock, newblock *big.Int) *ConfigCompatError {
+	var rew *big.Int
+	switch {
+	case storedblock == nil:
+		rew = newblock
+	case newblock == nil || storedblock.Cmp(newblock) < 0:
+		rew = storedblock
+	default:
+		rew = newblock
+	}
+	err := &ConfigCompatError{what, storedblock, newblock, 0}
+	if rew != nil && rew.Sign() > 0 {
+		err.RewindTo = rew.Uint64() - 1
+	}
+	return err
+}
+
+// Rules wraps ChainConfig and is merely syntactic sugar or can be used for functions
+// that do not have or require information about the block.
+//
+// Rules is a one time interface meaning that it shouldn't be used in between transition
+// phases.
+type Rules struct {
+	ChainId                           *big.Int
+	IsBigBalance, IsStaking, IsReward bool
+}
+
+// Rules returns the rule set active at the block num
+func (c *ChainConfig) Rules(num *big.Int) Rules {
+	chainId := c.ChainId
+	if chainId == nil {
+		chainId = new(big.Int)
+	}
+	return Rules{
+		ChainId:      new(big.Int).Set(chainId),
+		IsBigBalance: c.IsBigBalance(num),
+		IsStaking:    c.IsStaking(num),
+		IsReward:     c.IsReward(num),
+	}
+}
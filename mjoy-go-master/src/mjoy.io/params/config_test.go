@@ -0,0 +1,102 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: config_test.go
+// @Date: 2018/07/26 10:12:41
+////////////////////////////////////////////////////////////////////////////////
+
+package params
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestCheckCompatible(t *testing.T) {
+	type test struct {
+		stored, new *ChainConfig
+		head        uint64
+		wantErr     *ConfigCompatError
+	}
+	tests := []test{
+		{stored: DefaultChainConfig, new: DefaultChainConfig, head: 0, wantErr: nil},
+		{stored: DefaultChainConfig, new: DefaultChainConfig, head: 100, wantErr: nil},
+		{
+			stored:  &ChainConfig{StakingBlock: big.NewInt(10)},
+			new:     &ChainConfig{StakingBlock: big.NewInt(20)},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: DefaultChainConfig,
+			new:    &ChainConfig{StakingBlock: nil, RewardBlock: big.NewInt(0)},
+			head:   3,
+			wantErr: &ConfigCompatError{
+				What:         "Staking fork block",
+				StoredConfig: big.NewInt(0),
+				NewConfig:    nil,
+				RewindTo:     0,
+			},
+		},
+		{
+			stored: &ChainConfig{StakingBlock: big.NewInt(10)},
+			new:    &ChainConfig{StakingBlock: big.NewInt(20)},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "Staking fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			// the lowest conflicting fork decides the rewind height
+			stored: &ChainConfig{BigBalanceBlock: big.NewInt(30), RewardBlock: big.NewInt(10)},
+			new:    &ChainConfig{BigBalanceBlock: big.NewInt(25), RewardBlock: big.NewInt(20)},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Reward fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := test.stored.CheckCompatible(test.new, test.head)
+		if !reflect.DeepEqual(err, test.wantErr) {
+			t.Errorf("error mismatch:\nstored: %v\nnew: %v\nhead: %v\nerr: %v\nwant: %v", test.stored, test.new, test.head, err, test.wantErr)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	config := &ChainConfig{ChainId: big.NewInt(7), BigBalanceBlock: big.NewInt(5), StakingBlock: big.NewInt(10)}
+
+	rules := config.Rules(big.NewInt(4))
+	if rules.IsBigBalance || rules.IsStaking || rules.IsReward {
+		t.Fatalf("rules active before the forks: %+v", rules)
+	}
+	rules = config.Rules(big.NewInt(10))
+	if !rules.IsBigBalance || !rules.IsStaking || rules.IsReward {
+		t.Fatalf("rules mismatch at block 10: %+v", rules)
+	}
+	if rules.ChainId.Cmp(config.ChainId) != 0 {
+		t.Fatalf("chain id mismatch: have %v, want %v", rules.ChainId, config.ChainId)
+	}
+}
@@ -24,4 +24,30 @@ const (
 	MaximumExtraDataSize  uint64 = 32    // Maximum size extra data may be after Genesis.
 	EpochDuration    uint64 = 30000 	 // Duration between proof-of-stack epochs
 	MaxCodeSize 	= 32768 			 // Maximum bytecode to permit for a contract
+	CallDepthLimit  = 8                  // Maximum depth of contracts calling contracts
+
+	TxResourceLimit    uint64 = 100000       // Resource limit of a transaction when the sender declares none
+	MaxTxResourceLimit uint64 = 100000000    // Maximum resource limit a transaction may declare
+	ResourcePrice      uint64 = 1            // Balance charged for every resource unit consumed
+
+	TxBoundTimeThreshold uint64 = 500000000  // Validity bounds below this are block numbers, otherwise unix timestamps
+
+	ActionResourceCost       uint64 = 100    // Resource units charged for every action of a transaction
+	ParamsByteResourceCost   uint64 = 1      // Resource units charged for every byte of action params
+	StepResourceCost         uint64 = 1      // Resource units charged for every compute step of contract code
+	StorageReadResourceCost  uint64 = 10     // Resource units charged for every storage read
+	StorageWriteResourceCost uint64 = 50     // Resource units charged for every storage write
+	StorageByteResourceCost  uint64 = 1      // Resource units charged for every byte written to storage
+	CallResourceCost         uint64 = 100    // Resource units charged for every call of a contract by a contract
+	EventResourceCost        uint64 = 50     // Resource units charged for every event emitted
+	EventTopicResourceCost   uint64 = 20     // Resource units charged for every indexed topic of an event
+	EventByteResourceCost    uint64 = 1      // Resource units charged for every byte of event data
+
+	StakingEpoch           uint64 = 200    // Blocks between two validator elections of the staking contract
+	MaxElectedValidators          = 21     // Maximum number of validators elected by the staking contract
+	UnbondingPeriod        uint64 = 1000   // Blocks an undelegated stake stays locked before it can be withdrawn
+	MinCandidateStake      uint64 = 10000  // Minimum stake a candidate needs to register and to be elected
+	DoubleSignSlashPercent uint64 = 10     // Percent of the stake of a candidate taken for signing two headers of a height
+
+	AllowedFutureBlockTime uint64 = 15     // Seconds a block of a chain with a slot schedule may be ahead of the local time
 )
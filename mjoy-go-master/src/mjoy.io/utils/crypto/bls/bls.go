@@ -0,0 +1,249 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: bls.go
+// @Date: 2018/07/30 09:21:46
+////////////////////////////////////////////////////////////////////////////////
+
+// Package bls implements BLS signatures on the bn256 curve. Signatures are points of
+// G1 and public keys points of G2, so the signatures of many signers of one message
+// aggregate into a single signature verified by the sum of their public keys.
+//
+// Aggregating public keys is only safe with keys proven to be owned by their signers,
+// a key is to be accepted with a valid proof of possession only.
+package bls
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+
+	"mjoy.io/utils/crypto"
+	"mjoy.io/utils/crypto/bn256"
+)
+
+// Sizes of the encoded keys and signatures
+const (
+	PrivateKeyLength = 32
+	PublicKeyLength  = 128
+	SignatureLength  = 64
+)
+
+// Domain tags hashing messages and public keys to different points, a proof of
+// possession can not be used as a signature of a message.
+var (
+	signTag       = []byte("mjoy-bls-sign")
+	possessionTag = []byte("mjoy-bls-pop")
+)
+
+var (
+	errInvalidPrivateKey = errors.New("invalid bls private key")
+	errInvalidPublicKey  = errors.New("invalid bls public key")
+	errInvalidSignature  = errors.New("invalid bls signature")
+	errNoSignatures      = errors.New("no bls signatures to aggregate")
+	errNoPublicKeys      = errors.New("no bls public keys to aggregate")
+
+	g2 = new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+
+	// (p+1)/4, the square root of a square mod p is its (p+1)/4th power as p = 3 mod 4
+	sqrtExp = new(big.Int).Rsh(new(big.Int).Add(bn256.P, big.NewInt(1)), 2)
+	curveB  = big.NewInt(3)
+)
+
+// PrivateKey is a bls private key, a scalar of the groups.
+type PrivateKey struct {
+	x   *big.Int
+	pub *PublicKey
+}
+
+// PublicKey is a bls public key, a point of G2.
+type PublicKey struct {
+	p *bn256.G2
+}
+
+// Signature is a bls signature or an aggregate of signatures, a point of G1.
+type Signature struct {
+	s *bn256.G1
+}
+
+// GenerateKey generates a private key reading randomness from r, crypto/rand if nil.
+func GenerateKey(r io.Reader) (*PrivateKey, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	x, p, err := bn256.RandomG2(r)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{x: x, pub: &PublicKey{p}}, nil
+}
+
+// ToPrivateKey decodes a private key.
+func ToPrivateKey(b []byte) (*PrivateKey, error) {
+	if len(b) != PrivateKeyLength {
+		return nil, errInvalidPrivateKey
+	}
+	x := new(big.Int).SetBytes(b)
+	if x.Sign() == 0 || x.Cmp(bn256.Order) >= 0 {
+		return nil, errInvalidPrivateKey
+	}
+	return &PrivateKey{x: x, pub: &PublicKey{new(bn256.G2).ScalarBaseMult(x)}}, nil
+}
+
+// Marshal encodes the key.
+func (k *PrivateKey) Marshal() []byte {
+	b := make([]byte, PrivateKeyLength)
+	xBytes := k.x.Bytes()
+	copy(b[PrivateKeyLength-len(xBytes):], xBytes)
+	return b
+}
+
+// Public returns the public key of the key.
+func (k *PrivateKey) Public() *PublicKey {
+	return k.pub
+}
+
+// Sign signs msg.
+func (k *PrivateKey) Sign(msg []byte) *Signature {
+	return &Signature{new(bn256.G1).ScalarMult(hashToG1(signTag, msg), k.x)}
+}
+
+// ProvePossession signs the public key of the key, the proof shows the signer owns
+// the key so it is safe to aggregate.
+func (k *PrivateKey) ProvePossession() *Signature {
+	return &Signature{new(bn256.G1).ScalarMult(hashToG1(possessionTag, k.pub.Marshal()), k.x)}
+}
+
+// ToPublicKey decodes a public key, an error is returned if it is not a point of G2
+// or is the point at infinity. Unlike G1, the twist G2 lies on has points out of the
+// group, a key needs to be checked to be in it.
+func ToPublicKey(b []byte) (*PublicKey, error) {
+	p, ok := new(bn256.G2).Unmarshal(b)
+	if !ok || isInfinity(b) {
+		return nil, errInvalidPublicKey
+	}
+	if !isInfinity(new(bn256.G2).ScalarMult(p, bn256.Order).Marshal()) {
+		return nil, errInvalidPublicKey
+	}
+	return &PublicKey{p}, nil
+}
+
+// Marshal encodes the key.
+func (k *PublicKey) Marshal() []byte {
+	return k.p.Marshal()
+}
+
+// Verify reports whether sig is a signature of msg by the key or, for an aggregate
+// key, the aggregate of the signatures of msg by the aggregated keys.
+func (k *PublicKey) Verify(msg []byte, sig *Signature) bool {
+	return verify(k, hashToG1(signTag, msg), sig)
+}
+
+// VerifyPossession reports whether proof shows the signer owns the key.
+func (k *PublicKey) VerifyPossession(proof *Signature) bool {
+	return verify(k, hashToG1(possessionTag, k.Marshal()), proof)
+}
+
+// ToSignature decodes a signature, an error is returned if it is not a point of G1.
+func ToSignature(b []byte) (*Signature, error) {
+	s, ok := new(bn256.G1).Unmarshal(b)
+	if !ok {
+		return nil, errInvalidSignature
+	}
+	return &Signature{s}, nil
+}
+
+// Marshal encodes the signature.
+func (s *Signature) Marshal() []byte {
+	return s.s.Marshal()
+}
+
+// AggregateSignatures aggregates signatures into one signature.
+func AggregateSignatures(sigs []*Signature) (*Signature, error) {
+	if len(sigs) == 0 {
+		return nil, errNoSignatures
+	}
+	agg := new(bn256.G1).ScalarMult(sigs[0].s, big.NewInt(1))
+	for _, sig := range sigs[1:] {
+		agg.Add(agg, sig.s)
+	}
+	return &Signature{agg}, nil
+}
+
+// AggregatePublicKeys aggregates public keys into the key verifying the aggregate of
+// their signatures of a message. The keys need to have their possession proven.
+func AggregatePublicKeys(keys []*PublicKey) (*PublicKey, error) {
+	if len(keys) == 0 {
+		return nil, errNoPublicKeys
+	}
+	agg := new(bn256.G2).ScalarMult(keys[0].p, big.NewInt(1))
+	for _, key := range keys[1:] {
+		agg.Add(agg, key.p)
+	}
+	return &PublicKey{agg}, nil
+}
+
+// VerifyAggregate reports whether sig is the aggregate of the signatures of msg by keys.
+func VerifyAggregate(keys []*PublicKey, msg []byte, sig *Signature) bool {
+	agg, err := AggregatePublicKeys(keys)
+	if err != nil {
+		return false
+	}
+	return agg.Verify(msg, sig)
+}
+
+// verify checks e(sig, g2) = e(h, key)
+func verify(key *PublicKey, h *bn256.G1, sig *Signature) bool {
+	if sig == nil || sig.s == nil || key == nil || key.p == nil {
+		return false
+	}
+	return bn256.PairingCheck([]*bn256.G1{sig.s, new(bn256.G1).Neg(h)}, []*bn256.G2{g2, key.p})
+}
+
+// hashToG1 maps a message to a point of G1 by try and increment: x is the hash of the
+// message and a counter, the first x on the curve y² = x³ + 3 gives the point. The
+// cofactor of G1 is 1, every point of the curve is in the group.
+func hashToG1(tag, msg []byte) *bn256.G1 {
+	for ctr := byte(0); ; ctr++ {
+		x := new(big.Int).SetBytes(crypto.Keccak256(tag, msg, []byte{ctr}))
+		x.Mod(x, bn256.P)
+
+		y2 := new(big.Int).Exp(x, big.NewInt(3), bn256.P)
+		y2.Add(y2, curveB).Mod(y2, bn256.P)
+
+		y := new(big.Int).Exp(y2, sqrtExp, bn256.P)
+		if new(big.Int).Exp(y, big.NewInt(2), bn256.P).Cmp(y2) != 0 {
+			continue
+		}
+		b := make([]byte, 64)
+		xBytes, yBytes := x.Bytes(), y.Bytes()
+		copy(b[32-len(xBytes):], xBytes)
+		copy(b[64-len(yBytes):], yBytes)
+		if p, ok := new(bn256.G1).Unmarshal(b); ok {
+			return p
+		}
+	}
+}
+
+func isInfinity(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: bls_test.go
+// @Date: 2018/07/30 10:37:05
+////////////////////////////////////////////////////////////////////////////////
+
+package bls
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newKeys(t *testing.T, n int) []*PrivateKey {
+	keys := make([]*PrivateKey, n)
+	for i := range keys {
+		key, err := GenerateKey(nil)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestSignVerify(t *testing.T) {
+	key := newKeys(t, 1)[0]
+	msg := []byte("block")
+
+	sig := key.Sign(msg)
+	if !key.Public().Verify(msg, sig) {
+		t.Fatalf("valid signature rejected")
+	}
+	if key.Public().Verify([]byte("other block"), sig) {
+		t.Fatalf("signature of another message accepted")
+	}
+	if newKeys(t, 1)[0].Public().Verify(msg, sig) {
+		t.Fatalf("signature of another key accepted")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	key := newKeys(t, 1)[0]
+	sig := key.Sign([]byte("block"))
+
+	prv, err := ToPrivateKey(key.Marshal())
+	if err != nil {
+		t.Fatalf("failed to decode private key: %v", err)
+	}
+	if !bytes.Equal(prv.Public().Marshal(), key.Public().Marshal()) {
+		t.Fatalf("public key mismatch after decoding")
+	}
+	pub, err := ToPublicKey(key.Public().Marshal())
+	if err != nil || len(key.Public().Marshal()) != PublicKeyLength {
+		t.Fatalf("failed to decode public key: %v", err)
+	}
+	dec, err := ToSignature(sig.Marshal())
+	if err != nil || len(sig.Marshal()) != SignatureLength {
+		t.Fatalf("failed to decode signature: %v", err)
+	}
+	if !pub.Verify([]byte("block"), dec) {
+		t.Fatalf("decoded signature rejected")
+	}
+
+	if _, err := ToPublicKey(make([]byte, PublicKeyLength)); err == nil {
+		t.Errorf("point at infinity accepted as public key")
+	}
+	bad := key.Public().Marshal()
+	bad[10] ^= 1
+	if _, err := ToPublicKey(bad); err == nil {
+		t.Errorf("point off the curve accepted as public key")
+	}
+	if _, err := ToSignature(make([]byte, SignatureLength-1)); err == nil {
+		t.Errorf("short signature accepted")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	keys := newKeys(t, 4)
+	msg := []byte("block")
+
+	pubs := make([]*PublicKey, len(keys))
+	sigs := make([]*Signature, len(keys))
+	for i, key := range keys {
+		pubs[i], sigs[i] = key.Public(), key.Sign(msg)
+	}
+	agg, err := AggregateSignatures(sigs)
+	if err != nil {
+		t.Fatalf("failed to aggregate signatures: %v", err)
+	}
+	if !VerifyAggregate(pubs, msg, agg) {
+		t.Fatalf("valid aggregate signature rejected")
+	}
+	if VerifyAggregate(pubs[:3], msg, agg) {
+		t.Fatalf("aggregate signature accepted without a signer")
+	}
+	part, _ := AggregateSignatures(sigs[:3])
+	if VerifyAggregate(pubs, msg, part) {
+		t.Fatalf("aggregate signature missing a signer accepted")
+	}
+	if _, err := AggregateSignatures(nil); err == nil {
+		t.Errorf("empty signatures aggregated")
+	}
+}
+
+func TestPossession(t *testing.T) {
+	keys := newKeys(t, 2)
+
+	proof := keys[0].ProvePossession()
+	if !keys[0].Public().VerifyPossession(proof) {
+		t.Fatalf("valid proof of possession rejected")
+	}
+	if keys[1].Public().VerifyPossession(proof) {
+		t.Fatalf("proof of possession of another key accepted")
+	}
+	// A proof is not a signature of the key as a message
+	if keys[0].Public().Verify(keys[0].Public().Marshal(), proof) {
+		t.Fatalf("proof of possession accepted as a signature")
+	}
+}
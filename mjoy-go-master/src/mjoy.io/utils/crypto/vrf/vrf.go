@@ -0,0 +1,212 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: vrf.go
+// @Date: 2018/08/02 09:14:38
+////////////////////////////////////////////////////////////////////////////////
+
+// Package vrf implements a verifiable random function on secp256k1, after the
+// ECVRF construction with try and increment hashing to the curve. The output of a
+// key for an input is unpredictable without the private key, and the proof shows
+// anyone holding the public key that the output is the one of the key.
+package vrf
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"mjoy.io/common/math"
+	"mjoy.io/common/types"
+	"mjoy.io/utils/crypto"
+)
+
+// Sizes of a proof: the compressed point Gamma, the challenge c and the response s
+const (
+	pointLength  = 33
+	scalarLength = 32
+	ProofLength  = pointLength + 2*scalarLength
+)
+
+// suite is the first byte of the hashes, it tells the construction apart from others
+const suite = 0xfe
+
+var (
+	// ErrInvalidProof is returned if a proof is malformed or not made by the key
+	// for the input.
+	ErrInvalidProof = errors.New("invalid vrf proof")
+
+	errInvalidKey = errors.New("invalid vrf key")
+)
+
+// Prove returns the output of the key for alpha and the proof of it.
+func Prove(prv *ecdsa.PrivateKey, alpha []byte) (types.Hash, []byte, error) {
+	curve := crypto.S256()
+	n := curve.Params().N
+	if prv == nil || prv.D == nil || prv.D.Sign() <= 0 || prv.D.Cmp(n) >= 0 {
+		return types.Hash{}, nil, errInvalidKey
+	}
+	d := math.PaddedBigBytes(prv.D, scalarLength)
+
+	hx, hy, err := hashToCurve(&prv.PublicKey, alpha)
+	if err != nil {
+		return types.Hash{}, nil, err
+	}
+	gx, gy := curve.ScalarMult(hx, hy, d)
+
+	// The nonce is derived from the key and the point, a key never reuses a nonce
+	// for two inputs.
+	k := new(big.Int)
+	for ctr := byte(0); k.Sign() == 0; ctr++ {
+		k.SetBytes(hashBytes(0x04, d, compress(hx, hy), []byte{ctr}))
+		k.Mod(k, n)
+	}
+	kb := math.PaddedBigBytes(k, scalarLength)
+	ux, uy := curve.ScalarBaseMult(kb)
+	vx, vy := curve.ScalarMult(hx, hy, kb)
+
+	c := hashPoints(hx, hy, gx, gy, ux, uy, vx, vy)
+	s := new(big.Int).Mul(c, prv.D)
+	s.Add(s, k).Mod(s, n)
+
+	proof := make([]byte, 0, ProofLength)
+	proof = append(proof, compress(gx, gy)...)
+	proof = append(proof, math.PaddedBigBytes(c, scalarLength)...)
+	proof = append(proof, math.PaddedBigBytes(s, scalarLength)...)
+	return output(gx, gy), proof, nil
+}
+
+// Evaluate returns the output of the key for alpha without the proof.
+func Evaluate(prv *ecdsa.PrivateKey, alpha []byte) (types.Hash, error) {
+	hx, hy, err := hashToCurve(&prv.PublicKey, alpha)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	gx, gy := crypto.S256().ScalarMult(hx, hy, math.PaddedBigBytes(prv.D, scalarLength))
+	if isInfinity(gx, gy) {
+		return types.Hash{}, errInvalidKey
+	}
+	return output(gx, gy), nil
+}
+
+// Verify checks that proof is the proof of the output of the key for alpha, and
+// returns the output.
+func Verify(pub *ecdsa.PublicKey, alpha, proof []byte) (types.Hash, error) {
+	curve := crypto.S256()
+	n := curve.Params().N
+	if len(proof) != ProofLength {
+		return types.Hash{}, ErrInvalidProof
+	}
+	gamma, err := crypto.DecompressPubkey(proof[:pointLength])
+	if err != nil {
+		return types.Hash{}, ErrInvalidProof
+	}
+	c := new(big.Int).SetBytes(proof[pointLength : pointLength+scalarLength])
+	s := new(big.Int).SetBytes(proof[pointLength+scalarLength:])
+	if c.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return types.Hash{}, ErrInvalidProof
+	}
+	hx, hy, err := hashToCurve(pub, alpha)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	// U = s*G - c*Y and V = s*H - c*Gamma, the nonce points if the proof is right
+	negC := new(big.Int).Sub(n, c)
+	negCb := math.PaddedBigBytes(negC.Mod(negC, n), scalarLength)
+	sb := math.PaddedBigBytes(s, scalarLength)
+
+	sgx, sgy := curve.ScalarBaseMult(sb)
+	cyx, cyy := curve.ScalarMult(pub.X, pub.Y, negCb)
+	ux, uy := add(sgx, sgy, cyx, cyy)
+
+	shx, shy := curve.ScalarMult(hx, hy, sb)
+	cgx, cgy := curve.ScalarMult(gamma.X, gamma.Y, negCb)
+	vx, vy := add(shx, shy, cgx, cgy)
+
+	if isInfinity(ux, uy) || isInfinity(vx, vy) {
+		return types.Hash{}, ErrInvalidProof
+	}
+	if hashPoints(hx, hy, gamma.X, gamma.Y, ux, uy, vx, vy).Cmp(c) != 0 {
+		return types.Hash{}, ErrInvalidProof
+	}
+	return output(gamma.X, gamma.Y), nil
+}
+
+// hashToCurve maps the key and alpha to a point by try and increment: the first hash
+// of them and a counter which is the x of a point with an even y gives the point.
+func hashToCurve(pub *ecdsa.PublicKey, alpha []byte) (*big.Int, *big.Int, error) {
+	if pub == nil || pub.X == nil || !crypto.S256().IsOnCurve(pub.X, pub.Y) {
+		return nil, nil, errInvalidKey
+	}
+	pk := compress(pub.X, pub.Y)
+	for ctr := 0; ctr < 256; ctr++ {
+		x := hashBytes(0x01, pk, alpha, []byte{byte(ctr)})
+		point, err := crypto.DecompressPubkey(append([]byte{0x02}, x...))
+		if err == nil {
+			return point.X, point.Y, nil
+		}
+	}
+	// The chance of 256 misses is 2^-256
+	return nil, nil, errInvalidKey
+}
+
+// hashPoints is the challenge of a proof
+func hashPoints(coords ...*big.Int) *big.Int {
+	data := make([][]byte, 0, len(coords)/2)
+	for i := 0; i < len(coords); i += 2 {
+		data = append(data, compress(coords[i], coords[i+1]))
+	}
+	c := new(big.Int).SetBytes(hashBytes(0x02, data...))
+	return c.Mod(c, crypto.S256().Params().N)
+}
+
+func output(gx, gy *big.Int) types.Hash {
+	return types.BytesToHash(hashBytes(0x03, compress(gx, gy)))
+}
+
+func hashBytes(domain byte, data ...[]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{suite, domain})
+	for _, b := range data {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+func compress(x, y *big.Int) []byte {
+	return crypto.CompressPubkey(&ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y})
+}
+
+// add returns the sum of two points, the curve addition does not handle a point added
+// to itself or to its opposite.
+func add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	switch {
+	case isInfinity(x1, y1):
+		return x2, y2
+	case isInfinity(x2, y2):
+		return x1, y1
+	case x1.Cmp(x2) == 0 && y1.Cmp(y2) == 0:
+		return crypto.S256().Double(x1, y1)
+	case x1.Cmp(x2) == 0:
+		return nil, nil
+	}
+	return crypto.S256().Add(x1, y1, x2, y2)
+}
+
+func isInfinity(x, y *big.Int) bool {
+	return x == nil || y == nil || (x.Sign() == 0 && y.Sign() == 0)
+}
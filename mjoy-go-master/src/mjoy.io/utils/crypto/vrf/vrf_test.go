@@ -0,0 +1,84 @@
+////////////////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 The mjoy-go Authors.
+//
+// The mjoy-go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// @File: vrf_test.go
+// @Date: 2018/08/02 10:51:09
+////////////////////////////////////////////////////////////////////////////////
+
+package vrf
+
+import (
+	"testing"
+
+	"mjoy.io/utils/crypto"
+)
+
+func TestProveVerify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	alpha := []byte("parent hash and slot")
+
+	out, proof, err := Prove(key, alpha)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	if len(proof) != ProofLength {
+		t.Fatalf("proof length %d, want %d", len(proof), ProofLength)
+	}
+	verified, err := Verify(&key.PublicKey, alpha, proof)
+	if err != nil {
+		t.Fatalf("valid proof rejected: %v", err)
+	}
+	if verified != out {
+		t.Fatalf("verified output %x, want %x", verified, out)
+	}
+	if evaluated, _ := Evaluate(key, alpha); evaluated != out {
+		t.Fatalf("evaluated output %x, want %x", evaluated, out)
+	}
+
+	// The output is deterministic and differs by input
+	again, _, _ := Prove(key, alpha)
+	if again != out {
+		t.Errorf("output changed: %x, want %x", again, out)
+	}
+	other, _, _ := Prove(key, []byte("another slot"))
+	if other == out {
+		t.Errorf("same output for two inputs")
+	}
+}
+
+func TestInvalidProof(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	alpha := []byte("parent hash and slot")
+	_, proof, _ := Prove(key, alpha)
+
+	if _, err := Verify(&other.PublicKey, alpha, proof); err != ErrInvalidProof {
+		t.Errorf("proof of another key: %v, want %v", err, ErrInvalidProof)
+	}
+	if _, err := Verify(&key.PublicKey, []byte("another slot"), proof); err != ErrInvalidProof {
+		t.Errorf("proof of another input: %v, want %v", err, ErrInvalidProof)
+	}
+	for i := range proof {
+		forged := append([]byte{}, proof...)
+		forged[i] ^= 0x01
+		if _, err := Verify(&key.PublicKey, alpha, forged); err == nil {
+			t.Fatalf("proof with byte %d flipped accepted", i)
+		}
+	}
+	if _, err := Verify(&key.PublicKey, alpha, proof[1:]); err != ErrInvalidProof {
+		t.Errorf("short proof: %v, want %v", err, ErrInvalidProof)
+	}
+}